@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// compactCommand handles `alaala compact`
+func compactCommand(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	revisionDays := fs.Int("revision-days", 0, "Delete revisions older than this many days (0 keeps all revisions)")
+	_ = fs.Parse(args)
+
+	if *revisionDays < 0 {
+		fmt.Fprintln(os.Stderr, "--revision-days must not be negative")
+		os.Exit(1)
+	}
+
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	var revisionsBefore time.Time
+	if *revisionDays > 0 {
+		revisionsBefore = time.Now().AddDate(0, 0, -*revisionDays)
+	}
+
+	report, err := sqlStore.Compact(ctx, revisionsBefore)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Compact failed: %v\n", err)
+		fmt.Fprintln(os.Stderr, "If the database is locked, stop `alaala serve` and try again")
+		os.Exit(1)
+	}
+
+	fmt.Printf("Deleted %d revisions and %d orphaned rows (%d tags, %d triggers, %d relationships, %d vectors)\n",
+		report.Revisions, report.Tags+report.Triggers+report.Relationships+report.Vectors,
+		report.Tags, report.Triggers, report.Relationships, report.Vectors)
+	fmt.Printf("Database shrank from %s to %s (%s reclaimed)\n",
+		formatBytes(report.SizeBefore), formatBytes(report.SizeAfter), formatBytes(report.Reclaimed()))
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
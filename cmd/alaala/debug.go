@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/logging"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// debugCommand handles `alaala debug <subcommand>`
+func debugCommand(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: alaala debug slow-queries [--log <file>] [--since 24h] [--top 20]")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "slow-queries":
+		slowQueries(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown debug subcommand: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// slowQueryShape aggregates the slow queries of one statement shape
+type slowQueryShape struct {
+	shape     string
+	durations []float64
+	plan      []string // Most recent captured plan
+	last      time.Time
+}
+
+// slowQueries summarizes the slow query log by statement shape
+func slowQueries(args []string) {
+	fs := flag.NewFlagSet("debug slow-queries", flag.ExitOnError)
+	logFile := fs.String("log", "", "Slow query log (default: logging.slow_query_log)")
+	since := fs.Duration("since", 0, "Only include queries from this long ago, e.g. 24h (default: all)")
+	top := fs.Int("top", 20, "Number of statement shapes to show")
+	_ = fs.Parse(args)
+
+	path := *logFile
+	if path == "" {
+		path = loadConfigOrExit().Logging.SlowQueryLog
+	}
+	path, err := logging.ExpandHome(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve %s: %v\n", *logFile, err)
+		os.Exit(1)
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("No slow queries recorded (%s does not exist).\n", path)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open slow query log: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	var cutoff time.Time
+	if *since > 0 {
+		cutoff = time.Now().Add(-*since)
+	}
+
+	shapes := make(map[string]*slowQueryShape)
+	total, skipped := 0, 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry storage.SlowQuery
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			skipped++
+			continue
+		}
+		if entry.Time.Before(cutoff) {
+			continue
+		}
+
+		shape := storage.StatementShape(entry.Statement)
+		agg, ok := shapes[shape]
+		if !ok {
+			agg = &slowQueryShape{shape: shape}
+			shapes[shape] = agg
+		}
+		agg.durations = append(agg.durations, entry.DurationMS)
+		if entry.Time.After(agg.last) {
+			agg.last = entry.Time
+			if len(entry.Plan) > 0 {
+				agg.plan = entry.Plan
+			}
+		}
+		total++
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read slow query log: %v\n", err)
+		os.Exit(1)
+	}
+
+	if total == 0 {
+		fmt.Println("No slow queries recorded.")
+		return
+	}
+
+	sorted := make([]*slowQueryShape, 0, len(shapes))
+	for _, agg := range shapes {
+		sort.Float64s(agg.durations)
+		sorted = append(sorted, agg)
+	}
+	// Worst offenders first: total time spent, then shape for stable output
+	sort.Slice(sorted, func(i, j int) bool {
+		ti, tj := sum(sorted[i].durations), sum(sorted[j].durations)
+		if ti != tj {
+			return ti > tj
+		}
+		return sorted[i].shape < sorted[j].shape
+	})
+	if *top > 0 && len(sorted) > *top {
+		sorted = sorted[:*top]
+	}
+
+	fmt.Printf("%d slow queries in %d statement shapes (%s)\n", total, len(shapes), path)
+	if skipped > 0 {
+		fmt.Printf("Skipped %d unreadable lines\n", skipped)
+	}
+	fmt.Println()
+
+	for i, agg := range sorted {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%d. %s\n", i+1, truncateStatement(agg.shape, 200))
+		fmt.Fprintf(w, "   count %d\tp50 %.1fms\tp95 %.1fms\tmax %.1fms\tlast %s\n",
+			len(agg.durations), percentile(agg.durations, 0.5), percentile(agg.durations, 0.95),
+			agg.durations[len(agg.durations)-1], agg.last.Local().Format("2006-01-02 15:04"))
+		w.Flush()
+		for _, step := range agg.plan {
+			fmt.Printf("   plan: %s\n", step)
+		}
+		fmt.Println()
+	}
+}
+
+// percentile returns the p-th percentile (0-1) of sorted values using the
+// nearest-rank method
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// truncateStatement shortens a statement for display
+func truncateStatement(statement string, max int) string {
+	if len(statement) <= max {
+		return statement
+	}
+	return strings.TrimSpace(statement[:max-3]) + "..."
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/ai"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+	"github.com/0xGurg/alaala/pkg/config"
+)
+
+// webhookTimeout bounds posting a digest to its webhook
+const webhookTimeout = 30 * time.Second
+
+// digestCommand handles `alaala digest`
+func digestCommand(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	sinceFlag := fs.String("since", "7d", "Period to cover, in days (7d) or as a duration (36h)")
+	aiSummary := fs.Bool("ai", false, "Open with a summary written by the AI provider (default: digest.ai_summary)")
+	output := fs.String("output", "", "Write the digest to this file (default: stdout)")
+	webhook := fs.String("webhook", "", "Also POST the digest as JSON {\"text\": ...} to this URL (default: digest.webhook_url)")
+	_ = fs.Parse(args)
+
+	period, err := parsePeriod(*sinceFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --since: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg := loadConfigOrExit()
+
+	summarize := cfg.Digest.AISummary
+	webhookURL := cfg.Digest.WebhookURL
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "ai":
+			summarize = *aiSummary
+		case "webhook":
+			webhookURL = *webhook
+		}
+	})
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	// Digests only read SQLite, so no vector store or embedder is needed
+	engine := memory.NewEngine(sqlStore, nil, nil)
+
+	digest, err := engine.Digest(ctx, time.Now().Add(-period))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate digest: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Without a summary the digest is still complete, so failures only warn
+	if summarize && !digest.Empty() {
+		if err := narrateDigest(ctx, cfg, sqlStore, engine, digest); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: digest is not summarized: %v\n", err)
+		}
+	}
+
+	markdown := digest.Markdown()
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(markdown), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write digest: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote digest of %d projects to %s\n", len(digest.Projects), *output)
+	} else {
+		fmt.Print(markdown)
+	}
+
+	if webhookURL != "" {
+		if err := postDigest(ctx, webhookURL, markdown); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to post digest: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Posted digest to %s\n", webhookURL)
+	}
+}
+
+// narrateDigest has the configured AI provider summarize the digest, under
+// the digest budget like serve would
+func narrateDigest(ctx context.Context, cfg *config.Config, sqlStore *storage.SQLiteStore, engine *memory.Engine, digest *memory.Digest) error {
+	if cfg.Digest.DailyBudget > 0 && !cfg.AI.TrackUsage {
+		return fmt.Errorf("digest.daily_budget needs ai.track_usage: true")
+	}
+
+	aiClient, err := initAIClient(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.AI.TrackUsage {
+		if tracked, ok := aiClient.(interface{ SetUsageRecorder(ai.UsageRecorder) }); ok {
+			tracked.SetUsageRecorder(&usageLedger{store: sqlStore})
+		}
+	}
+
+	curator := memory.NewCurator(engine, aiClient)
+	curator.SetDigestSummary(cfg.Digest.DailyBudget)
+	return curator.NarrateDigest(ctx, digest)
+}
+
+// postDigest sends the digest to a webhook as {"text": <markdown>}, which
+// Slack and compatible incoming webhooks accept
+func postDigest(ctx context.Context, url, markdown string) error {
+	body, err := json.Marshal(map[string]string{"text": markdown})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// parsePeriod parses a number of days such as "7d", or a Go duration such
+// as "36h"
+func parsePeriod(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%q is not a positive number of days", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	period, err := time.ParseDuration(s)
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("%q is neither a number of days (7d) nor a positive duration (36h)", s)
+	}
+	return period, nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/ai"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+	"github.com/0xGurg/alaala/pkg/config"
+)
+
+// doctorTimeout bounds the embeddings check, which may have to load a model
+const doctorTimeout = 30 * time.Second
+
+// doctorCheck is the outcome of one doctor check
+type doctorCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+	Fix    string // How to repair a failed check
+}
+
+// doctorCommand handles `alaala doctor`: unlike status it does not open
+// anything serve would fall back from, but checks each part of the
+// configuration on its own and says how to repair what fails. The config
+// and the SQLite database are needed for everything else, so the remaining
+// checks are skipped when either fails. It exits with 1 when a check fails.
+func doctorCommand(args []string) {
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: alaala doctor\n")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+
+	// Each check reports its own failure; log lines would only interleave
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	failed := 0
+	report := func(check doctorCheck) {
+		state := "OK"
+		if !check.OK {
+			state = "FAIL"
+			failed++
+		}
+		fmt.Printf("%-4s  %-10s  %s\n", state, check.Name, check.Detail)
+		if !check.OK && check.Fix != "" {
+			fmt.Printf("      %-10s  fix: %s\n", "", check.Fix)
+		}
+	}
+
+	cfg, check := checkConfig()
+	report(check)
+	if cfg == nil {
+		os.Exit(1)
+	}
+
+	sqlStore, check := checkSQLite(ctx, cfg)
+	report(check)
+	if sqlStore == nil {
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	report(checkWeaviate(cfg))
+	report(checkEmbeddings(ctx, cfg))
+	report(checkAI(cfg))
+
+	if failed > 0 {
+		fmt.Printf("\n%d of 5 checks failed\n", failed)
+		sqlStore.Close()
+		os.Exit(1)
+	}
+	fmt.Println("\nAll checks passed")
+}
+
+// checkConfig loads and validates the config file
+func checkConfig() (*config.Config, doctorCheck) {
+	path := config.GetConfigPath()
+	check := doctorCheck{Name: "config"}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		check.Detail = err.Error()
+		check.Fix = "correct " + path + ", or move it aside and run `alaala init` to write a fresh one"
+		return nil, check
+	}
+	check.OK = true
+	check.Detail = path
+	if _, err := os.Stat(path); err != nil {
+		check.Detail = path + " (not found, using defaults)"
+	}
+	return cfg, check
+}
+
+// checkSQLite makes sure the database directory and file are writable and
+// opens the database, migrating it if needed
+func checkSQLite(ctx context.Context, cfg *config.Config) (*storage.SQLiteStore, doctorCheck) {
+	path := cfg.Storage.SQLitePath
+	dir := filepath.Dir(path)
+	check := doctorCheck{Name: "sqlite", Fix: "make " + dir + " writable, or set storage.sqlite_path to a writable location"}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		check.Detail = fmt.Sprintf("cannot create %s: %v", dir, err)
+		return nil, check
+	}
+	probe, err := os.CreateTemp(dir, ".alaala-doctor-*")
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s is not writable: %v", dir, err)
+		return nil, check
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	if file, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+		file.Close()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		check.Detail = fmt.Sprintf("%s is not writable: %v", path, err)
+		check.Fix = "make " + path + " writable by this user"
+		return nil, check
+	}
+
+	store, err := initSQLiteStore(cfg)
+	if err != nil {
+		check.Detail = fmt.Sprintf("cannot open %s: %v", path, err)
+		check.Fix = "restore " + path + " from a backup (alaala import --replace), or move it aside to start over"
+		return nil, check
+	}
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		store.Close()
+		check.Detail = fmt.Sprintf("cannot read %s: %v", path, err)
+		check.Fix = "restore " + path + " from a backup (alaala import --replace), or move it aside to start over"
+		return nil, check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s, schema version %d, %d memories", path, stats.SchemaVersion, stats.Memories)
+	return store, check
+}
+
+// checkWeaviate connects to Weaviate unless vectors are kept in SQLite
+func checkWeaviate(cfg *config.Config) doctorCheck {
+	check := doctorCheck{Name: "weaviate"}
+	if cfg.Storage.Mode == config.StorageModeEmbedded {
+		check.OK = true
+		check.Detail = "not used, vectors are kept in SQLite (storage.mode: embedded)"
+		return check
+	}
+
+	if _, err := initWeaviateStore(cfg); err != nil {
+		check.Detail = err.Error()
+		var authErr *storage.WeaviateAuthError
+		if errors.As(err, &authErr) {
+			check.Fix = `export WEAVIATE_API_KEY="..." (or set storage.weaviate_api_key in ` + config.GetConfigPath() + ")"
+		} else {
+			check.Fix = weaviateEnableCommand + " (or set storage.mode: embedded to keep vectors in SQLite)"
+		}
+		return check
+	}
+	check.OK = true
+	check.Detail = "reachable at " + cfg.Storage.WeaviateURL
+	return check
+}
+
+// checkEmbeddings embeds a short text with the configured provider
+func checkEmbeddings(ctx context.Context, cfg *config.Config) doctorCheck {
+	check := doctorCheck{Name: "embeddings", Fix: embeddingsEnableCommand(cfg)}
+
+	embedder, err := initEmbeddings(cfg)
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s: %v", cfg.Embeddings.Provider, err)
+		return check
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := embedder.Ping(ctx); err != nil {
+		check.Detail = fmt.Sprintf("%s/%s: %v", cfg.Embeddings.Provider, cfg.Embeddings.Model, err)
+		return check
+	}
+
+	info := embedder.EmbeddingInfo()
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s/%s, %d dimensions, answered in %s",
+		info.Provider, info.Model, info.Dimension, time.Since(start).Round(time.Millisecond))
+	return check
+}
+
+// checkAI makes sure the AI provider has a key and sends it the tiny ping
+// prompt, which also proves the key is accepted
+func checkAI(cfg *config.Config) doctorCheck {
+	check := doctorCheck{Name: "ai", Fix: aiEnableCommand(cfg)}
+
+	client, err := initAIClient(cfg)
+	if err != nil {
+		check.Detail = fmt.Sprintf("%s: %v", cfg.AI.Provider, strings.SplitN(err.Error(), "\n", 2)[0])
+		return check
+	}
+	tester, ok := client.(memory.ConnectionTester)
+	if !ok {
+		check.OK = true
+		check.Detail = fmt.Sprintf("%s/%s configured (the provider cannot be pinged)", cfg.AI.Provider, cfg.AI.Model)
+		return check
+	}
+
+	start := time.Now()
+	if err := tester.Ping(); err != nil {
+		category := ai.ClassifyError(err)
+		check.Detail = fmt.Sprintf("%s/%s (%s): %v", cfg.AI.Provider, cfg.AI.Model, category, err)
+		switch category {
+		case ai.ErrorCategoryModelUnavailable:
+			check.Fix = "set ai.model to a model the provider offers in " + config.GetConfigPath()
+			if cfg.AI.Provider == "ollama" {
+				check.Fix = "ollama pull " + cfg.AI.Model
+			}
+		case ai.ErrorCategoryNetwork:
+			check.Fix = "check the network connection and the provider URL in " + config.GetConfigPath()
+			if cfg.AI.Provider == "ollama" {
+				check.Fix = "ollama serve"
+			}
+		case ai.ErrorCategoryRateLimited:
+			check.Fix = "wait a moment and run alaala doctor again"
+		}
+		return check
+	}
+
+	check.OK = true
+	check.Detail = fmt.Sprintf("%s/%s, key accepted, answered in %s",
+		cfg.AI.Provider, cfg.AI.Model, time.Since(start).Round(time.Millisecond))
+	return check
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/0xGurg/alaala/internal/eval"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// evalCommand handles `alaala eval <subcommand>`
+func evalCommand(args []string) {
+	ctx := context.Background()
+	if len(args) < 1 || args[0] != "run" {
+		fmt.Fprintln(os.Stderr, "Usage: alaala eval run [--k 5] [--baseline file] [--update-baseline] <dataset>")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("eval run", flag.ExitOnError)
+	k := fs.Int("k", 5, "Ranking depth to compute the metrics at")
+	baselinePath := fs.String("baseline", "", "Baseline file (default: <dataset>.baseline.json)")
+	updateBaseline := fs.Bool("update-baseline", false, "Store this run's metrics as the new baseline")
+	_ = fs.Parse(args[1:])
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: alaala eval run [--k 5] [--baseline file] [--update-baseline] <dataset>")
+		os.Exit(1)
+	}
+	datasetPath := fs.Arg(0)
+	if *baselinePath == "" {
+		*baselinePath = strings.TrimSuffix(datasetPath, filepath.Ext(datasetPath)) + ".baseline.json"
+	}
+
+	dataset, err := eval.LoadDataset(datasetPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	cfg := loadConfigOrExit()
+
+	// Numbers from a fallback embedder would be meaningless, so don't fall back
+	embedder, err := initEmbeddings(cfg)
+	if err == nil && cfg.Embeddings.Provider != "hash" {
+		err = embedder.Ping(ctx)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize embeddings: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Load the dataset into a throwaway database so the real one is untouched
+	dir, err := os.MkdirTemp("", "alaala-eval-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create temporary directory: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	sqlStore, err := storage.NewSQLiteStore(filepath.Join(dir, "eval.db"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	vectorStore := storage.NewLocalVectorStore(sqlStore)
+	vectorStore.SetDimension(embedder.EmbeddingInfo().Dimension)
+
+	engine := memory.NewEngine(sqlStore, vectorStore, embedder)
+	if err := configureEngine(engine, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	project, err := engine.GetOrCreateProject(ctx, "eval", dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create project: %v\n", err)
+		os.Exit(1)
+	}
+
+	report, err := eval.Run(ctx, engine, project.ID, dataset, eval.Options{
+		K:             *k,
+		MinImportance: cfg.Retrieval.MinImportance,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Eval failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	baseline, err := eval.LoadBaseline(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	printEvalReport(datasetPath, dataset, report, baseline)
+
+	if *updateBaseline {
+		if err := eval.SaveBaseline(*baselinePath, report); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nBaseline updated: %s\n", *baselinePath)
+	}
+}
+
+// printEvalReport prints the metrics next to the baseline's, then the
+// queries that missed relevant memories
+func printEvalReport(datasetPath string, dataset *eval.Dataset, report *eval.Report, baseline *eval.Baseline) {
+	fmt.Printf("Dataset %s: %d memories, %d queries, k=%d, embedder %s\n\n",
+		datasetPath, len(dataset.Memories), len(dataset.Queries), report.K, report.Embedder)
+
+	if baseline != nil && !baseline.Comparable(report) {
+		fmt.Printf("Baseline was measured with k=%d and embedder %s; not comparing.\n\n", baseline.K, baseline.Embedder)
+		baseline = nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if baseline == nil {
+		fmt.Fprintln(w, "METRIC\tCURRENT")
+	} else {
+		fmt.Fprintln(w, "METRIC\tCURRENT\tBASELINE\tCHANGE")
+	}
+
+	rows := []struct {
+		name              string
+		current, previous float64
+	}{
+		{fmt.Sprintf("recall@%d", report.K), report.Metrics.RecallAtK, 0},
+		{"MRR", report.Metrics.MRR, 0},
+		{fmt.Sprintf("nDCG@%d", report.K), report.Metrics.NDCG, 0},
+	}
+	if baseline != nil {
+		rows[0].previous = baseline.Metrics.RecallAtK
+		rows[1].previous = baseline.Metrics.MRR
+		rows[2].previous = baseline.Metrics.NDCG
+	}
+	for _, row := range rows {
+		if baseline == nil {
+			fmt.Fprintf(w, "%s\t%.3f\n", row.name, row.current)
+		} else {
+			fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%+.3f\n", row.name, row.current, row.previous, row.current-row.previous)
+		}
+	}
+	w.Flush()
+
+	if baseline == nil {
+		fmt.Println("\nNo baseline to compare against; run with --update-baseline to store one.")
+	}
+
+	var missed []eval.QueryResult
+	for _, q := range report.Queries {
+		if q.Metrics.RecallAtK < 1 {
+			missed = append(missed, q)
+		}
+	}
+	if len(missed) == 0 {
+		return
+	}
+
+	fmt.Printf("\nQueries missing relevant memories in the top %d:\n", report.K)
+	for _, q := range missed {
+		got := strings.Join(q.Ranked, ", ")
+		if got == "" {
+			got = "nothing"
+		}
+		fmt.Printf("  %q: wanted %s, got %s\n", q.Query, strings.Join(q.Relevant, ", "), got)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/export"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// exportPageSize is the number of memories fetched from SQLite at a time
+const exportPageSize = 500
+
+// exportMemories handles `alaala export`
+func exportMemories(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	projectRef := fs.String("project", "", "Project ID, name, or path (required unless --backup)")
+	output := fs.String("output", "", "Output file (default: stdout)")
+	backup := fs.Bool("backup", false, "Export every project, session, memory, relationship and vector as one versioned JSON file")
+	_ = fs.Parse(args)
+
+	if *backup {
+		if *projectRef != "" {
+			fmt.Fprintln(os.Stderr, "--backup exports every project; leave out --project")
+			os.Exit(1)
+		}
+		exportBackup(*output)
+		return
+	}
+	if *projectRef == "" {
+		fmt.Fprintln(os.Stderr, "Usage: alaala export --project <id|name|path> [--output file.jsonl]")
+		fmt.Fprintln(os.Stderr, "       alaala export --backup [--output backup.json]")
+		os.Exit(1)
+	}
+
+	cfg := loadConfigOrExit()
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	project, err := resolveProject(ctx, sqlStore, *projectRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	// Stream page by page so memory use stays flat for large projects
+	writer := export.NewJSONLWriter(w)
+	err = sqlStore.EachMemory(ctx, project.ID, exportPageSize, writer.Write)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export memories: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d memories to %s\n", writer.Count(), *output)
+	}
+}
+
+// exportBackup writes everything in the database, with the memories'
+// vectors, as a single JSON backup
+func exportBackup(output string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	// Vectors saved in minimal mode are in SQLite, the others in Weaviate
+	sources := []memory.VectorFetcher{storage.NewLocalVectorStore(sqlStore)}
+	weaviateStore, err := initWeaviateStore(cfg)
+	if err != nil {
+		reportVectorFallback(err, "exporting only the vectors kept in SQLite")
+	} else {
+		defer weaviateStore.Close()
+		sources = append(sources, weaviateStore)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	writer := export.NewBackupWriter(w, time.Now())
+	if err := writeBackup(ctx, sqlStore, sources, writer); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export backup: %v\n", err)
+		os.Exit(1)
+	}
+
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d projects, %d sessions, %d memories and %d relationships to %s\n",
+			writer.Count(export.SectionProjects), writer.Count(export.SectionSessions),
+			writer.Count(export.SectionMemories), writer.Count(export.SectionRelationships), output)
+	}
+}
+
+// writeBackup writes every section of a backup. Memories are written a page
+// at a time, with their vectors fetched for the whole page from the first
+// source that has them.
+func writeBackup(ctx context.Context, sqlStore *storage.SQLiteStore, sources []memory.VectorFetcher, writer *export.BackupWriter) error {
+	workspaces, err := sqlStore.ListWorkspaces(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writer.Section(export.SectionWorkspaces); err != nil {
+		return err
+	}
+	for i := range workspaces {
+		if err := writer.WriteWorkspace(&workspaces[i]); err != nil {
+			return err
+		}
+	}
+
+	projects, err := sqlStore.ListProjects(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writer.Section(export.SectionProjects); err != nil {
+		return err
+	}
+	for i := range projects {
+		if err := writer.WriteProject(&projects[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Section(export.SectionSessions); err != nil {
+		return err
+	}
+	if err := sqlStore.EachSession(ctx, writer.WriteSession); err != nil {
+		return err
+	}
+
+	if err := writer.Section(export.SectionMemories); err != nil {
+		return err
+	}
+	var page []*storage.Memory
+	flush := func() error {
+		vectors, err := fetchVectors(ctx, sources, page)
+		if err != nil {
+			return err
+		}
+		for _, mem := range page {
+			if err := writer.WriteMemory(mem, vectors[mem.ID]); err != nil {
+				return err
+			}
+		}
+		page = page[:0]
+		return nil
+	}
+	for _, project := range projects {
+		err := sqlStore.EachMemory(ctx, project.ID, exportPageSize, func(mem *storage.Memory) error {
+			page = append(page, mem)
+			if len(page) < exportPageSize {
+				return nil
+			}
+			return flush()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if err := writer.Section(export.SectionRelationships); err != nil {
+		return err
+	}
+	if err := sqlStore.EachRelationship(ctx, writer.WriteRelationship); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// fetchVectors returns the vectors of memories, asking each source in turn
+// for those the previous ones did not have. Archived memories have none.
+func fetchVectors(ctx context.Context, sources []memory.VectorFetcher, mems []*storage.Memory) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(mems))
+	var missing []string
+	for _, mem := range mems {
+		if !mem.Archived {
+			missing = append(missing, mem.ID)
+		}
+	}
+
+	for _, source := range sources {
+		if len(missing) == 0 {
+			break
+		}
+		found, err := source.Vectors(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch vectors: %w", err)
+		}
+		remaining := missing[:0]
+		for _, id := range missing {
+			if vector, ok := found[id]; ok {
+				vectors[id] = vector
+			} else {
+				remaining = append(remaining, id)
+			}
+		}
+		missing = remaining
+	}
+	return vectors, nil
+}
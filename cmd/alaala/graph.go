@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/export"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// exportGraph handles `alaala export-graph`
+func exportGraph(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("export-graph", flag.ExitOnError)
+	projectRef := fs.String("project", "", "Project ID, name, or path (required)")
+	format := fs.String("format", "dot", "Output format: dot or graphml")
+	output := fs.String("output", "", "Output file (default: stdout)")
+	minImportance := fs.Float64("min-importance", 0, "Only include memories with at least this importance")
+	contextTypes := fs.String("context-types", "", "Comma-separated context types to include")
+	createdAfter := fs.String("created-after", "", "Only include memories created after this date (YYYY-MM-DD or RFC3339)")
+	_ = fs.Parse(args)
+
+	if *projectRef == "" {
+		fmt.Fprintln(os.Stderr, "Usage: alaala export-graph --project <id|name|path> [--format dot|graphml]")
+		os.Exit(1)
+	}
+	if *format != "dot" && *format != "graphml" {
+		fmt.Fprintf(os.Stderr, "Unsupported format: %s (use dot or graphml)\n", *format)
+		os.Exit(1)
+	}
+
+	filter := storage.GraphFilter{MinImportance: *minImportance}
+	if *contextTypes != "" {
+		for _, ct := range strings.Split(*contextTypes, ",") {
+			filter.ContextTypes = append(filter.ContextTypes, strings.ToUpper(strings.TrimSpace(ct)))
+		}
+	}
+	if *createdAfter != "" {
+		t, err := parseDate(*createdAfter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --created-after: %v\n", err)
+			os.Exit(1)
+		}
+		filter.CreatedAfter = &t
+	}
+
+	cfg := loadConfigOrExit()
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	project, err := resolveProject(ctx, sqlStore, *projectRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	graph, err := sqlStore.GetProjectGraph(ctx, project.ID, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load graph: %v\n", err)
+		os.Exit(1)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "graphml" {
+		err = export.WriteGraphML(w, project.Name, graph)
+	} else {
+		err = export.WriteDOT(w, project.Name, graph)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write graph: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d memories and %d relationships to %s\n", len(graph.Nodes), len(graph.Edges), *output)
+	}
+}
+
+// resolveProject finds a project by ID, path, or name
+func resolveProject(ctx context.Context, sqlStore *storage.SQLiteStore, ref string) (*storage.Project, error) {
+	if project, err := sqlStore.GetProject(ctx, ref); err != nil {
+		return nil, err
+	} else if project != nil {
+		return project, nil
+	}
+
+	if project, err := sqlStore.GetProjectByPath(ctx, ref); err != nil {
+		return nil, err
+	} else if project != nil {
+		return project, nil
+	}
+
+	projects, err := sqlStore.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *storage.Project
+	for i := range projects {
+		if projects[i].Name == ref {
+			if match != nil {
+				return nil, fmt.Errorf("project name %q is ambiguous, use the project ID or path", ref)
+			}
+			match = &projects[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("project not found: %s", ref)
+	}
+
+	return match, nil
+}
+
+// parseDate parses a date in YYYY-MM-DD or RFC3339 format
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
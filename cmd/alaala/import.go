@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/0xGurg/alaala/internal/export"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// importCounts tallies what happened to the records of one backup section
+type importCounts struct {
+	Imported int // New records
+	Replaced int // Existing records overwritten (--replace)
+	Skipped  int // Existing records kept (--merge), or relationships already there
+	Merged   int // Workspaces and projects matched to an existing one by name or path
+}
+
+// backupImport restores a backup written by `alaala export --backup`. It
+// reads the backup twice: once to find records that already exist, without
+// writing anything, and once to restore it.
+type backupImport struct {
+	sqlStore *storage.SQLiteStore
+	engine   *memory.Engine
+	replace  bool
+	write    bool // False while only looking for existing records
+
+	// Imported workspaces and projects whose name or path is taken by
+	// another one, e.g. a project already initialized on this machine, are
+	// merged into it; these map their IDs to the existing ones
+	workspaceIDs map[string]string
+	projectIDs   map[string]string
+
+	counts      map[string]*importCounts
+	vectors     memory.VectorRestoreReport
+	unembedded  int // Memories restored without a vector
+	memoryBatch []*export.BackupMemory
+}
+
+// importCommand handles `alaala import`
+func importCommand(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	merge := fs.Bool("merge", false, "Keep records that already exist and import only the others")
+	replace := fs.Bool("replace", false, "Overwrite records that already exist with the backup's")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: alaala import [--merge | --replace] <backup.json>")
+		os.Exit(1)
+	}
+	if *merge && *replace {
+		fmt.Fprintln(os.Stderr, "Use either --merge or --replace, not both")
+		os.Exit(1)
+	}
+	path := fs.Arg(0)
+
+	cfg := loadConfigOrExit()
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	// Write vectors to the same store serve would use. They are taken from
+	// the backup, so no embedder is needed.
+	var vectorStore memory.VectorStore
+	weaviateStore, err := initWeaviateStore(cfg)
+	if err != nil {
+		reportVectorFallback(err, "importing vectors into SQLite")
+		vectorStore = storage.NewLocalVectorStore(sqlStore)
+	} else {
+		defer weaviateStore.Close()
+		vectorStore = weaviateStore
+	}
+
+	imp := &backupImport{
+		sqlStore: sqlStore,
+		engine:   memory.NewEngine(sqlStore, vectorStore, nil),
+		replace:  *replace,
+	}
+
+	if err := imp.run(ctx, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read backup: %v\n", err)
+		os.Exit(1)
+	}
+	existing := 0
+	for _, counts := range imp.counts {
+		existing += counts.Skipped
+	}
+	if existing > 0 && !*merge && !*replace {
+		fmt.Fprintf(os.Stderr, "%d records in %s already exist:\n", existing, path)
+		for _, section := range importSections {
+			if counts := imp.counts[section]; counts != nil && counts.Skipped > 0 {
+				fmt.Fprintf(os.Stderr, "  %s: %d\n", section, counts.Skipped)
+			}
+		}
+		fmt.Fprintln(os.Stderr, "Run again with --merge to keep them or --replace to overwrite them with the backup's")
+		os.Exit(1)
+	}
+
+	imp.write = true
+	err = imp.run(ctx, path)
+	printImportReport(imp)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Import stopped: %v\n", err)
+		os.Exit(1)
+	}
+	if imp.vectors.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// importSections are the backup sections in the order they are reported
+var importSections = []string{
+	export.SectionWorkspaces, export.SectionProjects, export.SectionSessions,
+	export.SectionMemories, export.SectionRelationships,
+}
+
+// run reads the backup at path from the start, counting records afresh
+func (imp *backupImport) run(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader, err := export.NewBackupReader(f)
+	if err != nil {
+		return err
+	}
+
+	imp.counts = make(map[string]*importCounts)
+	for _, section := range importSections {
+		imp.counts[section] = &importCounts{}
+	}
+	imp.workspaceIDs = make(map[string]string)
+	imp.projectIDs = make(map[string]string)
+	imp.vectors = memory.VectorRestoreReport{}
+	imp.unembedded = 0
+	imp.memoryBatch = imp.memoryBatch[:0]
+
+	for {
+		section, raw, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// Memories are restored a page at a time; the page is complete once
+		// the next section starts
+		if section != export.SectionMemories {
+			if err := imp.flushMemories(ctx); err != nil {
+				return err
+			}
+		}
+		if err := imp.restore(ctx, section, raw); err != nil {
+			return err
+		}
+	}
+	return imp.flushMemories(ctx)
+}
+
+// restore restores one record of a section
+func (imp *backupImport) restore(ctx context.Context, section string, raw json.RawMessage) error {
+	counts := imp.counts[section]
+	switch section {
+	case export.SectionWorkspaces:
+		var record export.WorkspaceRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("invalid workspace: %w", err)
+		}
+		existing, err := imp.sqlStore.GetWorkspace(ctx, record.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			named, err := imp.sqlStore.GetWorkspaceByName(ctx, record.Name)
+			if err != nil {
+				return err
+			}
+			if named != nil {
+				imp.workspaceIDs[record.ID] = named.ID
+				counts.Merged++
+				return nil
+			}
+		}
+		if !imp.count(counts, existing != nil) {
+			return nil
+		}
+		return imp.sqlStore.RestoreWorkspace(ctx, record.Workspace())
+
+	case export.SectionProjects:
+		var record export.ProjectRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("invalid project: %w", err)
+		}
+		existing, err := imp.sqlStore.GetProject(ctx, record.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			atPath, err := imp.sqlStore.GetProjectByPath(ctx, record.Path)
+			if err != nil {
+				return err
+			}
+			if atPath != nil {
+				imp.projectIDs[record.ID] = atPath.ID
+				counts.Merged++
+				return nil
+			}
+		}
+		if id, ok := imp.workspaceIDs[record.WorkspaceID]; ok {
+			record.WorkspaceID = id
+		}
+		if !imp.count(counts, existing != nil) {
+			return nil
+		}
+		return imp.sqlStore.RestoreProject(ctx, record.Project())
+
+	case export.SectionSessions:
+		var record export.SessionRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("invalid session: %w", err)
+		}
+		existing, err := imp.sqlStore.GetSession(ctx, record.ID)
+		if err != nil {
+			return err
+		}
+		record.ProjectID = imp.projectID(record.ProjectID)
+		if !imp.count(counts, existing != nil) {
+			return nil
+		}
+		return imp.sqlStore.RestoreSession(ctx, record.Session())
+
+	case export.SectionMemories:
+		var record export.BackupMemory
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("invalid memory: %w", err)
+		}
+		record.ProjectID = imp.projectID(record.ProjectID)
+		imp.memoryBatch = append(imp.memoryBatch, &record)
+		if len(imp.memoryBatch) < exportPageSize {
+			return nil
+		}
+		return imp.flushMemories(ctx)
+
+	case export.SectionRelationships:
+		var record export.RelationshipRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("invalid relationship: %w", err)
+		}
+		if !imp.write {
+			return nil
+		}
+		created, err := imp.sqlStore.RestoreRelationship(ctx, record.Relationship())
+		if err != nil {
+			return fmt.Errorf("failed to restore relationship %s -> %s: %w", record.From, record.To, err)
+		}
+		if created {
+			counts.Imported++
+		} else {
+			counts.Skipped++
+		}
+	}
+	return nil
+}
+
+// count counts a record, reporting whether it is to be restored: new
+// records are, existing ones only with --replace
+func (imp *backupImport) count(counts *importCounts, exists bool) bool {
+	switch {
+	case !exists:
+		counts.Imported++
+	case imp.replace:
+		counts.Replaced++
+	default:
+		counts.Skipped++
+		return false
+	}
+	return imp.write
+}
+
+// projectID returns the ID a project from the backup is restored under
+func (imp *backupImport) projectID(id string) string {
+	if mapped, ok := imp.projectIDs[id]; ok {
+		return mapped
+	}
+	return id
+}
+
+// flushMemories restores the pending page of memories in SQLite, then
+// stores their vectors from the backup
+func (imp *backupImport) flushMemories(ctx context.Context) error {
+	batch := imp.memoryBatch
+	if len(batch) == 0 {
+		return nil
+	}
+	imp.memoryBatch = imp.memoryBatch[:0]
+
+	ids := make([]string, len(batch))
+	for i, record := range batch {
+		ids[i] = record.ID
+	}
+	existing, err := imp.sqlStore.ExistingMemoryIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	counts := imp.counts[export.SectionMemories]
+	var mems []*storage.Memory
+	var embeddings [][]float32
+	for _, record := range batch {
+		if !imp.count(counts, existing[record.ID]) {
+			continue
+		}
+		if record.Embedding == nil && !record.Archived {
+			imp.unembedded++
+		}
+		mems = append(mems, record.Memory())
+		embeddings = append(embeddings, record.Embedding)
+	}
+	if len(mems) == 0 {
+		return nil
+	}
+
+	if err := imp.sqlStore.RestoreMemories(ctx, mems); err != nil {
+		return err
+	}
+
+	// New memories without a vector have nothing to store or remove
+	var vectorMems []*storage.Memory
+	var vectors [][]float32
+	for i, mem := range mems {
+		if embeddings[i] != nil || existing[mem.ID] {
+			vectorMems = append(vectorMems, mem)
+			vectors = append(vectors, embeddings[i])
+		}
+	}
+	if len(vectorMems) == 0 {
+		return nil
+	}
+	report, err := imp.engine.RestoreVectors(ctx, vectorMems, vectors)
+	if report != nil {
+		imp.vectors.Stored += report.Stored
+		imp.vectors.Removed += report.Removed
+		imp.vectors.Failed += report.Failed
+	}
+	return err
+}
+
+// printImportReport prints how many records of each section were imported,
+// replaced and skipped, and what happened to the vectors
+func printImportReport(imp *backupImport) {
+	for _, section := range importSections {
+		counts := imp.counts[section]
+		line := fmt.Sprintf("%-14s %d imported", section+":", counts.Imported)
+		if counts.Replaced > 0 {
+			line += fmt.Sprintf(", %d replaced", counts.Replaced)
+		}
+		line += fmt.Sprintf(", %d skipped", counts.Skipped)
+		if counts.Merged > 0 {
+			line += fmt.Sprintf(", %d merged into existing ones", counts.Merged)
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("Restored %d vectors", imp.vectors.Stored)
+	if imp.vectors.Removed > 0 {
+		fmt.Printf(", removed %d of replaced memories", imp.vectors.Removed)
+	}
+	fmt.Println()
+	if imp.vectors.Failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d vectors could not be stored; run `alaala reindex` to embed those memories\n", imp.vectors.Failed)
+	}
+	if imp.unembedded > 0 {
+		fmt.Printf("%d memories had no vector in the backup; run `alaala reindex` to embed them\n", imp.unembedded)
+	}
+}
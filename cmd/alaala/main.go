@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/0xGurg/alaala/internal/ai"
 	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/locale"
+	"github.com/0xGurg/alaala/internal/logging"
 	"github.com/0xGurg/alaala/internal/mcp"
 	"github.com/0xGurg/alaala/internal/memory"
 	"github.com/0xGurg/alaala/internal/storage"
@@ -21,18 +30,64 @@ var (
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	// Global flags come before the command
+	global := flag.NewFlagSet("alaala", flag.ExitOnError)
+	global.Usage = printUsage
+	dataDir := global.String("data-dir", "", "Directory for the config, database, logs and models (default: ~/.alaala)")
+	_ = global.Parse(os.Args[1:])
+	args := global.Args()
+
+	if *dataDir != "" {
+		dir, err := filepath.Abs(*dataDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --data-dir: %v\n", err)
+			os.Exit(1)
+		}
+		config.SetDataDir(dir)
+	}
+
+	if len(args) < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	cmd := os.Args[1]
+	cmd := args[0]
 
 	switch cmd {
 	case "serve":
 		serveMCP()
 	case "init":
 		initProject()
+	case "memories":
+		memoriesCommand(args[1:])
+	case "workspaces":
+		workspacesCommand(args[1:])
+	case "projects":
+		projectsCommand(args[1:])
+	case "export":
+		exportMemories(args[1:])
+	case "import":
+		importCommand(args[1:])
+	case "export-graph":
+		exportGraph(args[1:])
+	case "prune":
+		pruneCommand(args[1:])
+	case "reindex":
+		reindexCommand(args[1:])
+	case "compact":
+		compactCommand(args[1:])
+	case "digest":
+		digestCommand(args[1:])
+	case "eval":
+		evalCommand(args[1:])
+	case "selfcheck":
+		selfcheckCommand()
+	case "status":
+		statusCommand(args[1:])
+	case "doctor":
+		doctorCommand(args[1:])
+	case "debug":
+		debugCommand(args[1:])
 	case "version":
 		printVersion()
 	case "help", "--help", "-h":
@@ -48,13 +103,28 @@ func printUsage() {
 	fmt.Printf(`alaala - Semantic memory system for AI assistants
 
 Usage:
-  alaala <command> [options]
+  alaala [--data-dir dir] <command> [options]
 
 Commands:
-  serve      Start the MCP server (for Cursor/Claude Desktop integration)
-  init       Initialize a new project with .alaala-project.json
-  version    Print version information
-  help       Show this help message
+  serve         Start the MCP server (for Cursor/Claude Desktop integration)
+  init          Initialize a new project with .alaala-project.json
+  memories      Inspect and maintain memories (history <id>, backfill-sessions, stats)
+  workspaces    Group related projects (create, assign, unassign, list, stats)
+  projects      Rename a project or follow its repository to a new directory (rename, move)
+  export        Export a project's memories as JSON lines, or everything as a JSON backup (--backup)
+  import        Restore a backup made with export --backup (--merge or --replace existing records)
+  export-graph  Export a project's memory graph as DOT or GraphML
+  prune         Delete expired temporary and low-importance memories
+  reindex       Re-embed memories whose vectors came from another embedder (decay: age their importance)
+  compact       Delete old revisions and orphaned rows, then shrink the database file
+  digest        Summarize new memories, open action items and conflicts across projects
+  eval          Measure retrieval quality on a dataset (run <dataset>)
+  selfcheck     Save and search a memory in a temporary directory to check the binary works
+  status        Check storage, Weaviate, embeddings and the AI provider, and report problems (--json)
+  doctor        Check each part of the configuration and say how to fix what fails
+  debug         Diagnostics (slow-queries: summarize the slow query log)
+  version       Print version information
+  help          Show this help message
 
 Examples:
   # Start MCP server for Cursor
@@ -63,6 +133,51 @@ Examples:
   # Initialize project
   alaala init
 
+  # Show how a memory changed over time
+  alaala memories history <memory-id>
+
+  # Search one customer's repositories together
+  alaala workspaces create acme && alaala workspaces assign ~/src/acme-api acme
+
+  # Keep a project's memories after moving its repository
+  alaala projects move ~/src/old-name ~/src/new-name
+
+  # Back up a project's memories
+  alaala export --project myapp --output myapp.jsonl
+
+  # Back up every project with its vectors before moving to another machine
+  alaala export --backup --output alaala-backup.json
+
+  # Restore it on the new machine without re-embedding
+  alaala import alaala-backup.json
+
+  # Render the memory graph with Graphviz
+  alaala export-graph --project myapp --format dot | dot -Tsvg > graph.svg
+
+  # Delete temporary memories older than 30 days and anything below 0.2 importance
+  alaala prune --days 30 --min-importance 0.2
+
+  # Re-embed memories after switching embedding models
+  alaala reindex --dry-run && alaala reindex
+
+  # Halve the importance of session and temporary memories every 30 days (crontab)
+  0 3 * * *  alaala reindex decay --half-life-days 30
+
+  # Drop revisions older than a year and reclaim the space
+  alaala compact --revision-days 365
+
+  # Check everything is healthy from a monitoring script (exits 1 on problems)
+  alaala status --json
+
+  # Post last week's digest to a Slack webhook every Monday (crontab)
+  0 9 * * 1  alaala digest --since 7d --webhook https://hooks.slack.com/services/...
+
+  # Check that a scoring change doesn't make retrieval worse
+  alaala eval run internal/eval/testdata/retrieval.json
+
+  # Keep all state in one directory, e.g. on a host without a home directory
+  alaala --data-dir /srv/alaala serve
+
 Installation:
   brew tap 0xGurg/distillery && brew install alaala
 
@@ -74,62 +189,242 @@ For more information, visit: https://github.com/0xGurg/alaala
 }
 
 func serveMCP() {
+	ctx := context.Background()
 	// Load configuration
-	cfg, err := config.Load(config.GetConfigPath())
+	cfg := loadConfigOrExit()
+
+	logger, logFile, err := logging.New(cfg.Logging.Level, cfg.Logging.File)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Invalid logging.level: %v\n", err)
 		os.Exit(1)
 	}
+	defer logFile.Close()
+	// Components log to the default logger until they are handed this one,
+	// e.g. schema migrations run while the store is opened
+	slog.SetDefault(logger)
+
+	// Write the defaults on first run so there is a file to edit
+	cfgPath := config.GetConfigPath()
+	if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
+		if err := cfg.Save(cfgPath); err != nil {
+			logger.Warn("failed to create default config", "path", cfgPath, "error", err)
+		} else {
+			logger.Info("created default config", "path", cfgPath)
+		}
+	}
 
-	fmt.Fprintf(os.Stderr, "Loaded config from: %s\n", config.GetConfigPath())
-	fmt.Fprintf(os.Stderr, "Weaviate URL: %s\n", cfg.Storage.WeaviateURL)
-	fmt.Fprintf(os.Stderr, "AI provider: %s\n", cfg.AI.Provider)
+	logger.Info("loaded config", "path", cfgPath, "storage_mode", cfg.Storage.Mode, "weaviate_url", cfg.Storage.WeaviateURL, "ai_provider", cfg.AI.Provider)
 
-	// Initialize storage
-	sqlStore, err := initSQLiteStore(cfg)
+	svc, err := openServices(ctx, cfg, logger)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		logger.Error("failed to start", "error", err)
 		os.Exit(1)
 	}
-	defer sqlStore.Close()
+	defer svc.Close()
+	engine, curator, setup := svc.engine, svc.curator, svc.setup
+	if setup.Enabled(mcp.FeatureCuration) {
+		go checkAIProvider(curator, cfg, logger)
+	}
 
-	weaviateStore, err := initWeaviateStore(cfg)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize Weaviate: %v\n", err)
+	// Move vectors saved in minimal mode to the configured store
+	dimension := svc.embedder.EmbeddingInfo().Dimension
+	if err := upgradeLocalVectors(ctx, svc.sqlStore, svc.localVectors, svc.vectorStore, svc.embedder, dimension, logger); err != nil {
+		logger.Warn("failed to upgrade local vectors", "error", err)
+	}
+
+	// Start MCP server
+	mcpServer := mcp.NewServer(engine, curator)
+	mcpServer.SetSetupStatus(setup)
+	mcpServer.SetLogger(logger)
+	mcpServer.SetResourceLimit(cfg.Retrieval.ResourceMemoryLimit)
+	mcpServer.SetAutoImportance(cfg.Importance.Default == "auto")
+	mcpServer.SetClientSampling(cfg.AI.ClientSampling)
+	mcpServer.SetPrimerMaxChars(cfg.Retrieval.PrimerMaxChars)
+	mcpServer.SetRequestTimeout(cfg.MCP.RequestTimeout)
+	if !locale.Supported(cfg.Locale) {
+		logger.Warn("unsupported locale, using English", "locale", cfg.Locale, "supported", locale.Locales())
+	}
+	mcpServer.SetLocalizer(locale.New(cfg.Locale))
+
+	logger.Info("MCP server ready", "minimal_mode", setup.Minimal())
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go shutdownOnSignal(cancel, logger)
+
+	if err := mcpServer.RunContext(runCtx); err != nil {
+		logger.Error("MCP server error", "error", err)
+		// os.Exit skips deferred calls
+		svc.Close()
+		logFile.Close()
 		os.Exit(1)
 	}
-	defer weaviateStore.Close()
+}
+
+// shutdownOnSignal cancels serve's context on SIGINT or SIGTERM, so that the
+// request being handled can finish and the stores are closed. A second
+// signal kills the process right away.
+func shutdownOnSignal(cancel context.CancelCauseFunc, logger *slog.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	signal.Stop(signals)
+
+	fmt.Fprintf(os.Stderr, "alaala: received %s, shutting down\n", sig)
+	logger.Info("received signal, shutting down", "signal", sig.String())
+	cancel(fmt.Errorf("received %s", sig))
+}
 
-	// Initialize embeddings
-	embedder, err := initEmbeddings(cfg)
+// services are the stores, engine and curator serve runs with
+type services struct {
+	sqlStore     *storage.SQLiteStore
+	localVectors *storage.LocalVectorStore
+	vectorStore  memory.VectorStore
+	embedder     *embeddings.Client
+	engine       *memory.Engine
+	curator      *memory.Curator
+	setup        *mcp.SetupStatus
+	closers      []func() error
+}
+
+// Close releases the stores in reverse order of opening
+func (svc *services) Close() {
+	for i := len(svc.closers) - 1; i >= 0; i-- {
+		_ = svc.closers[i]()
+	}
+}
+
+// openServices opens the configured stores and builds the engine and
+// curator. Optional features that are unavailable fall back to minimal
+// mode, recorded in setup, instead of failing.
+func openServices(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*services, error) {
+	// Initialize storage
+	sqlStore, err := initSQLiteStore(cfg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize embeddings: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to initialize SQLite: %w", err)
+	}
+	svc := &services{sqlStore: sqlStore, closers: []func() error{sqlStore.Close}}
+	sqlStore.SetLogger(logger)
+	if threshold := cfg.Logging.SlowQueryThreshold; threshold > 0 {
+		slowLog, err := logging.OpenFile(cfg.Logging.SlowQueryLog)
+		if err != nil {
+			logger.Warn("failed to open slow query log, logging slow queries only", "file", cfg.Logging.SlowQueryLog, "error", err)
+			sqlStore.SetSlowQueryLog(threshold, nil)
+		} else {
+			svc.closers = append(svc.closers, slowLog.Close)
+			sqlStore.SetSlowQueryLog(threshold, slowLog)
+		}
+	}
+
+	// Optional features fall back to minimal mode instead of failing
+	setup := &mcp.SetupStatus{}
+	svc.setup = setup
+
+	embedder, embedFeature := initEmbeddingsOrFallback(ctx, cfg, logger)
+	setup.Features = append(setup.Features, embedFeature)
+
+	localVectors := storage.NewLocalVectorStore(sqlStore)
+	var vectorStore memory.VectorStore = localVectors
+	vectorFeature := mcp.Feature{
+		Name:    mcp.FeatureVectorStore,
+		Enabled: true,
+		Detail:  fmt.Sprintf("Weaviate at %s", cfg.Storage.WeaviateURL),
+	}
+
+	weaviateStore, err := initWeaviateStore(cfg)
+	if errors.Is(err, errEmbeddedStorage) {
+		vectorFeature.Detail = "Vectors kept in SQLite and searched in process (storage.mode: embedded)"
+	} else if err != nil {
+		logger.Warn("failed to initialize Weaviate, keeping vectors in SQLite", "error", err)
+		vectorFeature.Enabled = false
+		vectorFeature.Detail = fmt.Sprintf("Weaviate is unreachable at %s; vectors are kept in SQLite and searched by brute force",
+			cfg.Storage.WeaviateURL)
+		vectorFeature.Enable = weaviateEnableCommand
+	} else {
+		svc.closers = append(svc.closers, weaviateStore.Close)
+		if embedFeature.Enabled {
+			vectorStore = weaviateStore
+		} else {
+			// Lexical fallback vectors must never end up in Weaviate
+			vectorFeature.Detail += " (unused until embeddings are enabled)"
+		}
+	}
+	setup.Features = append(setup.Features, vectorFeature)
+
+	// Reject vectors that don't match the embedding model
+	dimension := embedder.EmbeddingInfo().Dimension
+	localVectors.SetDimension(dimension)
+	if weaviateStore != nil {
+		weaviateStore.SetDimension(dimension)
 	}
 
+	svc.embedder = embedder
+	svc.localVectors = localVectors
+	svc.vectorStore = vectorStore
+
 	// Initialize memory engine
-	engine := memory.NewEngine(sqlStore, weaviateStore, embedder)
-	engine.SetGraphDepth(cfg.Retrieval.IncludeGraphDepth)
+	engine := memory.NewEngine(sqlStore, vectorStore, embedder)
+	engine.SetLogger(logger)
+	svc.engine = engine
+	if err := configureEngine(engine, cfg); err != nil {
+		svc.Close()
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	// Initialize AI client
+	curationFeature := mcp.Feature{
+		Name:    mcp.FeatureCuration,
+		Enabled: true,
+		Detail:  fmt.Sprintf("%s (%s)", cfg.AI.Provider, cfg.AI.Model),
+	}
 	aiClient, err := initAIClient(cfg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize AI client: %v\n", err)
-		os.Exit(1)
+		logger.Warn("failed to initialize AI client, curation is disabled", "error", err)
+		curationFeature.Enabled = false
+		curationFeature.Detail = fmt.Sprintf("AI provider %s is not configured (%s); curate_session is unavailable",
+			cfg.AI.Provider, strings.SplitN(err.Error(), "\n", 2)[0])
+		curationFeature.Enable = aiEnableCommand(cfg)
 	}
+	setup.Features = append(setup.Features, curationFeature)
 
-	// Initialize curator
-	curator := memory.NewCurator(engine, aiClient)
-
-	// Start MCP server
-	mcpServer := mcp.NewServer(engine, curator)
+	if aiClient != nil && cfg.AI.TrackUsage {
+		if tracked, ok := aiClient.(interface{ SetUsageRecorder(ai.UsageRecorder) }); ok {
+			tracked.SetUsageRecorder(&usageLedger{store: sqlStore})
+		}
+	}
 
-	fmt.Fprintf(os.Stderr, "MCP server ready\n")
+	if aiClient != nil && cfg.Importance.AIScoring {
+		scorer, ok := aiClient.(memory.ImportanceScorer)
+		switch {
+		case !ok:
+			logger.Warn("AI provider cannot score importance, using heuristics", "provider", cfg.AI.Provider)
+		case cfg.Importance.DailyBudget > 0 && !cfg.AI.TrackUsage:
+			logger.Warn("importance.daily_budget needs ai.track_usage: true, using heuristics")
+		default:
+			engine.SetImportanceScorer(scorer, cfg.Importance.DailyBudget)
+		}
+	}
 
-	if err := mcpServer.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "MCP server error: %v\n", err)
-		os.Exit(1)
+	// Initialize curator
+	curator := memory.NewCurator(engine, aiClient)
+	svc.curator = curator
+	curator.SetLogger(logger)
+	if cfg.Curation.AutoCurateChars > 0 {
+		if cfg.Curation.AutoCurateDailyBudget > 0 && !cfg.AI.TrackUsage {
+			logger.Warn("curation.auto_curate_daily_budget needs ai.track_usage: true, automatic curation is disabled")
+		} else {
+			curator.SetAutoCuration(cfg.Curation.AutoCurateChars, cfg.Curation.AutoCurateDailyBudget)
+		}
+	}
+	if cfg.Digest.AISummary {
+		if cfg.Digest.DailyBudget > 0 && !cfg.AI.TrackUsage {
+			logger.Warn("digest.daily_budget needs ai.track_usage: true, digests are not summarized")
+		} else {
+			curator.SetDigestSummary(cfg.Digest.DailyBudget)
+		}
 	}
+
+	return svc, nil
 }
 
 func initProject() {
@@ -174,6 +469,39 @@ func initProject() {
 
 // Initialization helper functions
 
+// loadConfigOrExit loads the configuration or exits with an error
+func loadConfigOrExit() *config.Config {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// loadConfig loads the config file and registers its custom context types
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(config.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	// Custom context types must be known before memories are read or written
+	custom := make([]memory.CustomContextType, len(cfg.ContextTypes))
+	for i, t := range cfg.ContextTypes {
+		custom[i] = memory.CustomContextType{
+			Name:         memory.ContextType(strings.ToUpper(t.Name)),
+			Description:  t.Description,
+			Importance:   t.Importance,
+			PrimerWeight: t.PrimerWeight,
+		}
+	}
+	if err := memory.RegisterContextTypes(custom); err != nil {
+		return nil, fmt.Errorf("context_types: %w", err)
+	}
+	return cfg, nil
+}
+
 func initSQLiteStore(cfg *config.Config) (*storage.SQLiteStore, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(cfg.Storage.SQLitePath)
@@ -184,7 +512,24 @@ func initSQLiteStore(cfg *config.Config) (*storage.SQLiteStore, error) {
 	return storage.NewSQLiteStore(cfg.Storage.SQLitePath)
 }
 
+// errEmbeddedStorage is returned by initWeaviateStore when storage.mode
+// keeps vectors in SQLite, so that callers use the local vector store
+var errEmbeddedStorage = errors.New("storage.mode is embedded, vectors are kept in SQLite")
+
+// reportVectorFallback tells the user that a command makes do with the
+// vectors in SQLite because Weaviate could not be opened; it stays quiet
+// when storage.mode keeps the vectors there anyway
+func reportVectorFallback(err error, fallback string) {
+	if !errors.Is(err, errEmbeddedStorage) {
+		fmt.Fprintf(os.Stderr, "Weaviate is unavailable, %s: %v\n", fallback, err)
+	}
+}
+
 func initWeaviateStore(cfg *config.Config) (*storage.WeaviateStore, error) {
+	if cfg.Storage.Mode == config.StorageModeEmbedded {
+		return nil, errEmbeddedStorage
+	}
+
 	// Parse Weaviate URL
 	url := cfg.Storage.WeaviateURL
 	if url == "" {
@@ -203,16 +548,72 @@ func initWeaviateStore(cfg *config.Config) (*storage.WeaviateStore, error) {
 		host = url[7:]
 	}
 
+	apiKey := cfg.Storage.WeaviateAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("WEAVIATE_API_KEY")
+	}
+	if apiKey != "" {
+		return storage.NewWeaviateStoreWithAuth(host, scheme, apiKey)
+	}
 	return storage.NewWeaviateStore(host, scheme)
 }
 
 func initEmbeddings(cfg *config.Config) (*embeddings.Client, error) {
+	if cfg.Embeddings.Provider == "local" {
+		return embeddings.NewLocalClient(cfg.Embeddings.Model, cfg.Embeddings.ModelPath)
+	}
 	if cfg.Embeddings.Provider == "ollama" {
 		return embeddings.NewClientWithURL(cfg.Embeddings.Provider, cfg.Embeddings.Model, cfg.Embeddings.OllamaURL)
 	}
 	return embeddings.NewClient(cfg.Embeddings.Provider, cfg.Embeddings.Model)
 }
 
+// configureEngine applies the retrieval and curation settings of cfg to
+// engine
+func configureEngine(engine *memory.Engine, cfg *config.Config) error {
+	engine.SetGraphDepth(cfg.Retrieval.IncludeGraphDepth)
+	engine.SetMinSimilarity(cfg.Retrieval.MinSimilarity)
+	engine.SetPrimerLimit(cfg.Retrieval.MaxMemories)
+	engine.SetExpiryPolicy(memory.ExpiryPolicy{
+		TemporaryAge: time.Duration(cfg.Expiry.TemporaryDays) * 24 * time.Hour,
+		SessionAge:   time.Duration(cfg.Expiry.SessionDays) * 24 * time.Hour,
+	})
+	engine.SetDedupThreshold(cfg.Curation.DedupThreshold)
+	engine.SetDedupSaves(cfg.Curation.DedupSaves)
+	if err := engine.SetDedupAction(memory.DedupAction(cfg.Curation.DedupAction)); err != nil {
+		return fmt.Errorf("invalid curation.dedup_action: %w", err)
+	}
+	engine.SetTriggerFuzzy(cfg.Retrieval.TriggerFuzzy)
+	engine.SetMMRLambda(cfg.Retrieval.MMRLambda)
+	engine.SetUnusedDecay(cfg.Importance.UnusedHalfLifeDays)
+	engine.SetScoringWeights(memory.ScoringWeights{
+		Similarity:   cfg.Retrieval.SimilarityWeight,
+		Importance:   cfg.Retrieval.ImportanceWeight,
+		TriggerBoost: cfg.Retrieval.TriggerBoost,
+		ActionBoost:  cfg.Retrieval.ActionBoost,
+		KeywordBoost: cfg.Retrieval.KeywordBoost,
+	})
+	if err := engine.SetInvalidVectorPolicy(cfg.Embeddings.InvalidVectors); err != nil {
+		return fmt.Errorf("invalid embeddings.invalid_vectors: %w", err)
+	}
+	engine.SetQueryCache(cfg.Embeddings.QueryCacheSize, cfg.Embeddings.QueryCacheTTL)
+	if err := engine.SetContextWeights(cfg.Retrieval.ContextWeights); err != nil {
+		return fmt.Errorf("invalid retrieval.context_weights: %w", err)
+	}
+	decay := cfg.Retrieval.RecencyDecay
+	if err := engine.SetRecencyDecay(memory.RecencyDecay{
+		Curve: decay.Curve,
+		HalfLives: map[memory.TemporalRelevance]time.Duration{
+			memory.TemporalRelevanceTemporary:  decay.TemporaryHalfLife,
+			memory.TemporalRelevanceSession:    decay.SessionHalfLife,
+			memory.TemporalRelevancePersistent: decay.PersistentHalfLife,
+		},
+	}); err != nil {
+		return fmt.Errorf("invalid retrieval.recency_decay: %w", err)
+	}
+	return nil
+}
+
 func initAIClient(cfg *config.Config) (memory.AIClient, error) {
 	switch cfg.AI.Provider {
 	case "anthropic":
@@ -223,7 +624,10 @@ func initAIClient(cfg *config.Config) (memory.AIClient, error) {
 		if apiKey == "" {
 			return nil, fmt.Errorf("ANTHROPIC_API_KEY not set")
 		}
-		return ai.NewClaudeClient(apiKey, cfg.AI.Model), nil
+		client := ai.NewClaudeClient(apiKey, cfg.AI.Model)
+		client.SetMaxOutputTokens(cfg.AI.MaxOutputTokens)
+		client.SetTimeout(cfg.AI.Timeout)
+		return client, nil
 	case "openrouter":
 		apiKey := cfg.AI.APIKey
 		if apiKey == "" {
@@ -232,14 +636,43 @@ func initAIClient(cfg *config.Config) (memory.AIClient, error) {
 		if apiKey == "" {
 			return nil, fmt.Errorf("OPENROUTER_API_KEY not set")
 		}
-		return ai.NewOpenRouterClient(apiKey, cfg.AI.Model, cfg.AI.OpenRouterURL), nil
+		client := ai.NewOpenRouterClient(apiKey, cfg.AI.Model, cfg.AI.OpenRouterURL)
+		client.SetMaxOutputTokens(cfg.AI.MaxOutputTokens)
+		client.SetTimeout(cfg.AI.Timeout)
+		return client, nil
 	case "ollama":
-		return ai.NewOllamaClient(cfg.AI.OllamaURL, cfg.AI.Model), nil
+		// Ollama runs locally and needs no API key
+		if cfg.AI.Model == "" {
+			return nil, fmt.Errorf("ai.model is not set for the ollama provider\n\nSet a model in your config, e.g.:\n  ai:\n    provider: ollama\n    model: llama3.1\n\nand pull it with: ollama pull llama3.1")
+		}
+		ollamaURL := cfg.AI.OllamaURL
+		if ollamaURL == "" {
+			ollamaURL = "http://localhost:11434"
+		}
+		client := ai.NewOllamaClient(ollamaURL, cfg.AI.Model)
+		client.SetMaxOutputTokens(cfg.AI.MaxOutputTokens)
+		client.SetTimeout(cfg.AI.Timeout)
+		return client, nil
 	default:
-		return nil, fmt.Errorf("unsupported AI provider: %s", cfg.AI.Provider)
+		return nil, fmt.Errorf("unsupported AI provider: %s (supported: anthropic, openrouter, ollama)", cfg.AI.Provider)
 	}
 }
 
+// usageLedger records AI usage in the SQLite ai_usage table
+type usageLedger struct {
+	store *storage.SQLiteStore
+}
+
+func (l *usageLedger) RecordUsage(usage ai.Usage) error {
+	return l.store.RecordUsage(context.Background(), &storage.UsageRecord{
+		Provider:         usage.Provider,
+		Model:            usage.Model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             usage.Cost,
+	})
+}
+
 func printVersion() {
 	fmt.Printf("alaala version %s\n", version)
 	if commit != "none" {
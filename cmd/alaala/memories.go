@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+// memoriesCommand handles `alaala memories <subcommand>`
+func memoriesCommand(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: alaala memories history <memory-id>")
+		fmt.Fprintln(os.Stderr, "       alaala memories backfill-sessions [project]")
+		fmt.Fprintln(os.Stderr, "       alaala memories stats [project]")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "history":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: alaala memories history <memory-id>")
+			os.Exit(1)
+		}
+		memoryHistory(args[1])
+	case "backfill-sessions":
+		project := ""
+		if len(args) > 1 {
+			project = args[1]
+		}
+		backfillSessions(project)
+	case "stats":
+		project := ""
+		if len(args) > 1 {
+			project = args[1]
+		}
+		memoryStats(project)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown memories subcommand: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// memoryHistory prints the revision history of a memory
+func memoryHistory(id string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	mem, err := sqlStore.GetMemory(ctx, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get memory: %v\n", err)
+		os.Exit(1)
+	}
+	if mem == nil {
+		fmt.Fprintf(os.Stderr, "Memory not found: %s\n", id)
+		os.Exit(1)
+	}
+
+	revisions, err := sqlStore.GetRevisions(ctx, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get revisions: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Memory %s\n", mem.ID)
+	fmt.Printf("Current content: %s\n\n", mem.Content)
+
+	if len(revisions) == 0 {
+		fmt.Println("No revisions recorded.")
+		return
+	}
+
+	for i, rev := range revisions {
+		fmt.Printf("%d. %s (%s)\n", i+1, rev.CreatedAt.Format("2006-01-02 15:04:05"), rev.Reason)
+		fmt.Printf("   %s\n\n", rev.Diff)
+	}
+}
+
+// backfillSessions links sessionless memories to the session that was running
+// when they were created, for one project or all of them
+func backfillSessions(ref string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	projectID := ""
+	if ref != "" {
+		project, err := resolveProject(ctx, sqlStore, ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		projectID = project.ID
+	}
+
+	linked, err := sqlStore.BackfillSessions(ctx, projectID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to backfill sessions: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Linked %d memories to sessions\n", linked)
+}
+
+// memoryStats prints how importance is distributed, per method that chose
+// it, for one project or all of them
+func memoryStats(ref string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	projectID := ""
+	if ref != "" {
+		project, err := resolveProject(ctx, sqlStore, ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		projectID = project.ID
+	}
+
+	stats, err := sqlStore.ImportanceStats(ctx, projectID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("No memories.")
+		return
+	}
+
+	total := 0
+	for _, stat := range stats {
+		total += stat.Count
+	}
+	fmt.Printf("%d memories\n\n", total)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMPORTANCE METHOD\tCOUNT\tAVERAGE\t0-0.2\t0.2-0.4\t0.4-0.6\t0.6-0.8\t0.8-1")
+	for _, stat := range stats {
+		method := stat.Method
+		if method == "" {
+			method = "unrecorded"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.2f", method, stat.Count, stat.Average)
+		for _, n := range stat.Buckets {
+			fmt.Fprintf(w, "\t%d", n)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+
+	contextStats, err := sqlStore.ContextTypeStats(ctx, projectID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get stats: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTEXT TYPE\tCOUNT\tAVERAGE")
+	for _, stat := range memory.GroupContextTypeStats(contextStats) {
+		contextType := stat.ContextType
+		if contextType == "" {
+			contextType = "none"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.2f\n", contextType, stat.Count, stat.Average)
+	}
+	w.Flush()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// projectsCommand handles `alaala projects <subcommand>`
+func projectsCommand(args []string) {
+	if len(args) < 1 {
+		printProjectsUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "rename":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: alaala projects rename <project> <new-name>")
+			os.Exit(1)
+		}
+		renameProject(args[1], args[2])
+	case "move":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: alaala projects move <old-path> <new-path>")
+			os.Exit(1)
+		}
+		moveProject(args[1], args[2])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown projects subcommand: %s\n", args[0])
+		printProjectsUsage()
+		os.Exit(1)
+	}
+}
+
+func printProjectsUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: alaala projects rename <project> <new-name>")
+	fmt.Fprintln(os.Stderr, "       alaala projects move <old-path> <new-path>")
+}
+
+// renameProject renames a project and its .alaala-project.json
+func renameProject(ref, name string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	project, err := resolveProject(ctx, sqlStore, ref)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if err := sqlStore.RenameProject(ctx, project.ID, name); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to rename project: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Renamed project %s to %s\n", project.Name, name)
+
+	reportProjectFile(project.Path, name)
+}
+
+// moveProject points a project at the directory its repository was moved
+// to, so opening it there finds its memories instead of starting afresh
+func moveProject(oldPath, newPath string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	// Projects are recorded by the absolute path serve was started in
+	newPath, err := filepath.Abs(newPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid new path: %v\n", err)
+		os.Exit(1)
+	}
+	if info, err := os.Stat(newPath); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "%s is not a directory; move the repository first\n", newPath)
+		os.Exit(1)
+	}
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	project, err := resolveProject(ctx, sqlStore, oldPath)
+	if err != nil {
+		if abs, absErr := filepath.Abs(oldPath); absErr == nil && abs != oldPath {
+			project, err = resolveProject(ctx, sqlStore, abs)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if project.Path == newPath {
+		fmt.Printf("Project %s is already at %s\n", project.Name, newPath)
+		return
+	}
+
+	replaced, err := sqlStore.MoveProject(ctx, project.ID, newPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to move project: %v\n", err)
+		os.Exit(1)
+	}
+	if replaced != "" {
+		fmt.Printf("Deleted empty project %s that was created at %s after the move\n", replaced, newPath)
+	}
+	fmt.Printf("Moved project %s from %s to %s\n", project.Name, project.Path, newPath)
+
+	reportProjectFile(newPath, project.Name)
+}
+
+// reportProjectFile updates the name in a directory's .alaala-project.json
+// and says so, or warns if it could not
+func reportProjectFile(dir, name string) {
+	updated, err := updateProjectFile(dir, name)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: failed to update %s: %v\n", filepath.Join(dir, ".alaala-project.json"), err)
+	case updated:
+		fmt.Printf("Updated %s\n", filepath.Join(dir, ".alaala-project.json"))
+	}
+}
+
+// updateProjectFile sets the name in a directory's .alaala-project.json,
+// keeping its other fields. It reports whether the file needed a change;
+// a missing file is left missing.
+func updateProjectFile(dir, name string) (bool, error) {
+	path := filepath.Join(dir, ".alaala-project.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var projectConfig map[string]interface{}
+	if err := json.Unmarshal(data, &projectConfig); err != nil {
+		return false, err
+	}
+	if projectConfig["name"] == name {
+		return false, nil
+	}
+	projectConfig["name"] = name
+
+	data, err = json.MarshalIndent(projectConfig, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// pruneCommand handles `alaala prune`
+func pruneCommand(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	days := fs.Int("days", 30, "Delete temporary memories older than this many days")
+	minImportance := fs.Float64("min-importance", 0, "Also delete memories with importance below this threshold (0 disables)")
+	_ = fs.Parse(args)
+
+	if *days < 0 {
+		fmt.Fprintln(os.Stderr, "--days must not be negative")
+		os.Exit(1)
+	}
+	if *minImportance < 0 || *minImportance > 1 {
+		fmt.Fprintln(os.Stderr, "--min-importance must be between 0 and 1")
+		os.Exit(1)
+	}
+
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	var vectorStore memory.VectorStore
+	vectorsIn := "Weaviate"
+	weaviateStore, err := initWeaviateStore(cfg)
+	if errors.Is(err, errEmbeddedStorage) {
+		vectorStore = storage.NewLocalVectorStore(sqlStore)
+		vectorsIn = "SQLite"
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize Weaviate: %v\n", err)
+		os.Exit(1)
+	} else {
+		defer weaviateStore.Close()
+		vectorStore = weaviateStore
+	}
+
+	// Pruning never embeds anything, so no embedder is needed
+	engine := memory.NewEngine(sqlStore, vectorStore, nil)
+
+	olderThan := time.Now().AddDate(0, 0, -*days)
+	report, err := engine.PruneMemories(ctx, olderThan, *minImportance)
+	if report != nil {
+		fmt.Printf("Deleted %d memories from SQLite and %d vectors from %s\n",
+			report.SQLiteDeleted, report.VectorDeleted, vectorsIn)
+		if report.VectorFailed > 0 {
+			fmt.Printf("Failed to delete %d vectors; the next delete or prune will retry them\n", report.VectorFailed)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Prune failed: %v\n", err)
+		os.Exit(1)
+	}
+}
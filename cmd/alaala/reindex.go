@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// reindexCommand handles `alaala reindex` and `alaala reindex decay`
+func reindexCommand(args []string) {
+	if len(args) > 0 && args[0] == "decay" {
+		decayImportanceCommand(args[1:])
+		return
+	}
+
+	ctx := context.Background()
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	projectRef := fs.String("project", "", "Project ID, name, or path (default: all projects)")
+	all := fs.Bool("all", false, "Re-embed every memory, not just those with stale embeddings")
+	dryRun := fs.Bool("dry-run", false, "Only report what would be re-embedded")
+	_ = fs.Parse(args)
+
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	projectID := ""
+	if *projectRef != "" {
+		project, err := resolveProject(ctx, sqlStore, *projectRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		projectID = project.ID
+	}
+
+	embedder, err := initEmbeddings(cfg)
+	if err == nil {
+		err = embedder.Ping(ctx)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize embeddings: %v\n", err)
+		os.Exit(1)
+	}
+	dimension := embedder.EmbeddingInfo().Dimension
+
+	// Write to the same store serve would use
+	var vectorStore memory.VectorStore
+	weaviateStore, err := initWeaviateStore(cfg)
+	if err != nil {
+		reportVectorFallback(err, "reindexing into SQLite")
+		localVectors := storage.NewLocalVectorStore(sqlStore)
+		localVectors.SetDimension(dimension)
+		vectorStore = localVectors
+	} else {
+		defer weaviateStore.Close()
+		weaviateStore.SetDimension(dimension)
+		vectorStore = weaviateStore
+	}
+
+	engine := memory.NewEngine(sqlStore, vectorStore, embedder)
+	if err := engine.SetInvalidVectorPolicy(cfg.Embeddings.InvalidVectors); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid embeddings.invalid_vectors: %v\n", err)
+		os.Exit(1)
+	}
+
+	var ids []string
+	if *all {
+		ids, err = allMemoryIDs(ctx, sqlStore, projectID)
+	} else {
+		var stale []memory.StaleEmbedding
+		stale, _, err = engine.ListStaleEmbeddings(ctx, projectID, 0)
+		for _, s := range stale {
+			ids = append(ids, s.ID)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list memories: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(ids) == 0 {
+		fmt.Printf("Nothing to reindex: all embeddings come from %s\n", engine.EmbedderID())
+		return
+	}
+	if *dryRun {
+		fmt.Printf("Would re-embed %d memories with %s\n", len(ids), engine.EmbedderID())
+		return
+	}
+
+	report := engine.ReindexMemories(ctx, ids)
+	fmt.Printf("Re-embedded %d of %d memories with %s in %s\n",
+		report.Reindexed, report.Requested, engine.EmbedderID(), report.Duration.Round(1e6))
+	if len(report.Failed) > 0 {
+		fmt.Fprintf(os.Stderr, "%d memories failed and are still stale; run reindex again to retry them:\n", len(report.Failed))
+		for id, err := range report.Failed {
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", id, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// decayImportanceCommand handles `alaala reindex decay`, lowering the
+// importance of aging session and temporary memories
+func decayImportanceCommand(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("reindex decay", flag.ExitOnError)
+	projectRef := fs.String("project", "", "Project ID, name, or path (default: all projects)")
+	halfLifeDays := fs.Float64("half-life-days", 30, "Days over which importance halves")
+	_ = fs.Parse(args)
+
+	if *halfLifeDays <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --half-life-days must be positive")
+		os.Exit(1)
+	}
+
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	projectID := ""
+	if *projectRef != "" {
+		project, err := resolveProject(ctx, sqlStore, *projectRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		projectID = project.ID
+	}
+
+	// SQLite-backed vectors read the importance from the memories table;
+	// only Weaviate keeps its own copy
+	var vectorStore memory.VectorStore = storage.NewLocalVectorStore(sqlStore)
+	weaviateStore, err := initWeaviateStore(cfg)
+	if err != nil {
+		reportVectorFallback(err, "updating SQLite only")
+	} else {
+		defer weaviateStore.Close()
+		vectorStore = weaviateStore
+	}
+
+	engine := memory.NewEngine(sqlStore, vectorStore, nil)
+	report, err := engine.DecayImportance(ctx, projectID, *halfLifeDays)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to decay importance: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Decayed the importance of %d memories (half-life %g days)\n", report.Decayed, *halfLifeDays)
+	if weaviateStore != nil && report.Decayed > 0 {
+		fmt.Printf("Updated %d vectors in Weaviate\n", report.VectorUpdated)
+	}
+	if report.VectorFailed > 0 {
+		fmt.Fprintf(os.Stderr, "%d vectors in Weaviate keep their old importance; run `alaala reindex --all` to rewrite them\n", report.VectorFailed)
+		os.Exit(1)
+	}
+}
+
+// allMemoryIDs returns the IDs of every memory in a project, or in all
+// projects if projectID is empty
+func allMemoryIDs(ctx context.Context, sqlStore *storage.SQLiteStore, projectID string) ([]string, error) {
+	projectIDs := []string{projectID}
+	if projectID == "" {
+		projects, err := sqlStore.ListProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		projectIDs = projectIDs[:0]
+		for _, p := range projects {
+			projectIDs = append(projectIDs, p.ID)
+		}
+	}
+
+	var ids []string
+	for _, id := range projectIDs {
+		err := sqlStore.EachMemory(ctx, id, exportPageSize, func(m *storage.Memory) error {
+			ids = append(ids, m.ID)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
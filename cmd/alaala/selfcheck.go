@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/logging"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+	"github.com/0xGurg/alaala/pkg/config"
+)
+
+// selfcheckMemories are saved and searched by selfcheck; the query matches
+// the first one
+var selfcheckMemories = []string{
+	"The billing service stores invoices in PostgreSQL",
+	"The frontend is written in TypeScript with React",
+	"Deployments go through a canary stage before production",
+}
+
+const selfcheckQuery = "which database stores the invoices"
+
+// selfcheckCommand handles `alaala selfcheck`. It runs a save, search and
+// delete cycle in a temporary directory with the lexical embedder and the
+// SQLite vector store, so it needs nothing but the binary, then checks that
+// the configured paths stay inside the data dir.
+func selfcheckCommand() {
+	dir, err := os.MkdirTemp("", "alaala-selfcheck-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create temporary directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	passed := runSelfcheck(dir)
+	os.RemoveAll(dir)
+	if !passed {
+		os.Exit(1)
+	}
+	fmt.Println("\nSelf-check passed")
+}
+
+// runSelfcheck runs the checks in dir, printing one line per step, and
+// reports whether they all passed
+func runSelfcheck(dir string) bool {
+	ctx := context.Background()
+	var err error
+
+	failed := false
+	step := func(name string, run func() (string, error)) bool {
+		detail, err := run()
+		if err != nil {
+			fmt.Printf("FAIL  %s: %v\n", name, err)
+			failed = true
+			return false
+		}
+		if detail != "" {
+			name += " (" + detail + ")"
+		}
+		fmt.Printf("ok    %s\n", name)
+		return true
+	}
+
+	var sqlStore *storage.SQLiteStore
+	var engine *memory.Engine
+	var projectID string
+	var saved []*memory.Memory
+
+	ok := step("create database", func() (string, error) {
+		sqlStore, err = storage.NewSQLiteStore(filepath.Join(dir, "alaala.db"))
+		if err != nil {
+			return "", err
+		}
+
+		embedder := embeddings.NewHashClient()
+		vectors := storage.NewLocalVectorStore(sqlStore)
+		vectors.SetDimension(embedder.EmbeddingInfo().Dimension)
+		engine = memory.NewEngine(sqlStore, vectors, embedder)
+
+		project, err := engine.GetOrCreateProject(ctx, "selfcheck", dir)
+		if err != nil {
+			return "", err
+		}
+		projectID = project.ID
+		return dir, nil
+	})
+	if sqlStore != nil {
+		defer sqlStore.Close()
+	}
+
+	// Each step needs the previous one
+	ok = ok && step("save memories", func() (string, error) {
+		for _, content := range selfcheckMemories {
+			saved = append(saved, &memory.Memory{
+				ProjectID:   projectID,
+				Content:     content,
+				Importance:  0.8,
+				ContextType: memory.ContextTypeTechnicalImplementation,
+			})
+		}
+		if err := engine.CreateMemories(ctx, saved); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d saved", len(saved)), nil
+	})
+
+	ok = ok && step("search memories", func() (string, error) {
+		results, err := engine.SearchMemories(ctx, &memory.SearchQuery{
+			Query:     selfcheckQuery,
+			ProjectID: projectID,
+			Limit:     1,
+			Mode:      memory.SearchModeSemantic, // Check the vectors, not the full-text index
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(results) == 0 || results[0].Memory.ID != saved[0].ID {
+			return "", fmt.Errorf("%q did not find %q", selfcheckQuery, saved[0].Content)
+		}
+		return fmt.Sprintf("similarity %.2f", results[0].SimilarityScore), nil
+	})
+
+	if ok {
+		step("delete memories", func() (string, error) {
+			ids := make([]string, len(saved))
+			for i, mem := range saved {
+				ids[i] = mem.ID
+			}
+			report, err := engine.DeleteMemories(ctx, ids)
+			if err != nil {
+				return "", err
+			}
+			if report.SQLiteDeleted != len(ids) {
+				return "", fmt.Errorf("deleted %d of %d memories", report.SQLiteDeleted, len(ids))
+			}
+
+			results, err := engine.SearchMemories(ctx, &memory.SearchQuery{
+				Query:     selfcheckQuery,
+				ProjectID: projectID,
+			})
+			if err != nil && !errors.Is(err, memory.ErrNoRelevantMemories) {
+				return "", err
+			}
+			if len(results) > 0 {
+				return "", fmt.Errorf("search still finds %d deleted memories", len(results))
+			}
+			return "", nil
+		})
+	}
+
+	// Independent of the cycle above
+	step("config paths", func() (string, error) {
+		cfg, err := config.Load(config.GetConfigPath())
+		if err != nil {
+			return "", err
+		}
+
+		dataDir := config.DataDir()
+		var outside []string
+		for _, path := range []string{
+			cfg.Storage.SQLitePath,
+			cfg.Logging.File,
+			cfg.Logging.SlowQueryLog,
+			cfg.Embeddings.ModelPath,
+		} {
+			if path == "" {
+				continue
+			}
+			expanded, err := logging.ExpandHome(path)
+			if err != nil {
+				return "", err
+			}
+			if rel, err := filepath.Rel(dataDir, expanded); err != nil || strings.HasPrefix(rel, "..") {
+				outside = append(outside, path)
+			}
+		}
+		if len(outside) > 0 {
+			return "", fmt.Errorf("outside the data dir %s: %s", dataDir, strings.Join(outside, ", "))
+		}
+		return "inside " + dataDir, nil
+	})
+
+	return !failed
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/0xGurg/alaala/internal/ai"
+	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/mcp"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+	"github.com/0xGurg/alaala/pkg/config"
+)
+
+// weaviateEnableCommand starts a local Weaviate container
+const weaviateEnableCommand = "docker run -d --name weaviate -p 8080:8080 " +
+	"-e AUTHENTICATION_ANONYMOUS_ACCESS_ENABLED=true -e PERSISTENCE_DATA_PATH=/var/lib/weaviate weaviate/weaviate:latest"
+
+// initEmbeddingsOrFallback initializes the configured embeddings provider,
+// falling back to the lexical hash embedder when it is unavailable
+func initEmbeddingsOrFallback(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*embeddings.Client, mcp.Feature) {
+	feature := mcp.Feature{
+		Name:    mcp.FeatureEmbeddings,
+		Enabled: true,
+		Detail:  fmt.Sprintf("%s (%s)", cfg.Embeddings.Provider, cfg.Embeddings.Model),
+	}
+
+	embedder, err := initEmbeddings(cfg)
+	if err == nil && cfg.Embeddings.Provider != "hash" {
+		err = embedder.Ping(ctx)
+	}
+	if err == nil {
+		return embedder, feature
+	}
+
+	logger.Warn("failed to initialize embeddings, using lexical matching", "error", err)
+	feature.Enabled = false
+	feature.Detail = fmt.Sprintf("%s embeddings are unavailable; search matches shared words instead of meaning",
+		cfg.Embeddings.Provider)
+	feature.Enable = embeddingsEnableCommand(cfg)
+
+	return embeddings.NewHashClient(), feature
+}
+
+// embeddingsEnableCommand returns a one-line command that makes the
+// configured embeddings provider available
+func embeddingsEnableCommand(cfg *config.Config) string {
+	switch cfg.Embeddings.Provider {
+	case "local":
+		dir := cfg.Embeddings.ModelPath
+		if dir == "" {
+			dir = embeddings.DefaultLocalModelPath(cfg.Embeddings.Model)
+		}
+		model := cfg.Embeddings.Model
+		if model == "" {
+			model = "all-MiniLM-L6-v2"
+		}
+		return fmt.Sprintf("mkdir -p %s && for f in model.safetensors vocab.txt config.json; do "+
+			"curl -L -o %s/$f https://huggingface.co/sentence-transformers/%s/resolve/main/$f; done", dir, dir, model)
+	case "ollama":
+		return fmt.Sprintf("ollama pull %s", cfg.Embeddings.Model)
+	case "openai":
+		return `export OPENAI_API_KEY="sk-..."`
+	default:
+		return "set embeddings.provider to local, ollama or openai in " + config.GetConfigPath()
+	}
+}
+
+// aiEnableCommand returns a one-line command that makes the configured AI
+// provider available
+func aiEnableCommand(cfg *config.Config) string {
+	switch cfg.AI.Provider {
+	case "anthropic":
+		return `export ANTHROPIC_API_KEY="sk-ant-..."`
+	case "openrouter":
+		return `export OPENROUTER_API_KEY="sk-or-v1-..."`
+	case "ollama":
+		model := cfg.AI.Model
+		if model == "" {
+			model = "llama3.1"
+		}
+		return fmt.Sprintf("ollama pull %s (and set ai.model: %s in %s)", model, model, config.GetConfigPath())
+	default:
+		return "set ai.provider to anthropic, openrouter or ollama in " + config.GetConfigPath()
+	}
+}
+
+// checkAIProvider pings the AI provider and logs a warning when it does not
+// answer, so that a bad key or a stopped server shows up at startup rather
+// than at the first curation. A ping is a real, if tiny, request that can
+// take as long as the provider's timeout, so serve runs it in the
+// background.
+func checkAIProvider(curator *memory.Curator, cfg *config.Config, logger *slog.Logger) {
+	status, err := curator.TestConnection()
+	if err != nil {
+		return
+	}
+	if status.Err != nil {
+		category := ai.ClassifyError(status.Err)
+		args := []interface{}{"provider", cfg.AI.Provider, "model", cfg.AI.Model, "problem", category, "error", status.Err}
+		if category == ai.ErrorCategoryAuthentication {
+			args = append(args, "fix", aiEnableCommand(cfg))
+		}
+		logger.Warn("AI provider not reachable, curation fails until it is", args...)
+		return
+	}
+	logger.Debug("AI provider reachable", "provider", status.Provider, "model", status.Model, "latency", status.Latency)
+}
+
+// upgradeLocalVectors brings vectors saved in minimal mode up to date.
+// Vectors from another embedding model are re-embedded from their content,
+// and when target is not the local store they are moved into it. Nothing is
+// removed locally until the target has accepted the vector.
+func upgradeLocalVectors(ctx context.Context, sqlStore *storage.SQLiteStore, local *storage.LocalVectorStore, target memory.VectorStore, embedder *embeddings.Client, dimension int, logger *slog.Logger) error {
+	vectors, err := local.All(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read local vectors: %w", err)
+	}
+
+	moving := target != memory.VectorStore(local)
+	embedderID := embedder.EmbeddingInfo().ID()
+	upgraded := 0
+	var failures []string
+
+	for _, v := range vectors {
+		embedding := v.Embedding
+		reembedded := len(embedding) != dimension
+		if reembedded {
+			embedding, err = embedder.Embed(ctx, v.Content)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", v.ID, err))
+				continue
+			}
+		} else if !moving {
+			continue
+		}
+
+		if err := target.Store(ctx, v.ID, v.Content, embedding, v.Metadata); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", v.ID, err))
+			continue
+		}
+		if reembedded {
+			if err := sqlStore.SetEmbedderID(ctx, v.ID, embedderID); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", v.ID, err))
+				continue
+			}
+		}
+		if moving {
+			if err := local.Delete(ctx, v.ID); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", v.ID, err))
+				continue
+			}
+		}
+		upgraded++
+	}
+
+	if upgraded > 0 {
+		logger.Info("upgraded vectors saved in minimal mode", "count", upgraded)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d vectors failed: %s", len(failures), len(vectors), strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/0xGurg/alaala/internal/mcp"
+	"github.com/0xGurg/alaala/pkg/config"
+)
+
+// statusCommand handles `alaala status`: it opens everything serve would
+// and reports the same health report as the memory://status resource. It
+// exits with 1 when a problem was found.
+func statusCommand(args []string) {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	_ = fs.Parse(args)
+
+	cfg := loadConfigOrExit()
+
+	// Unavailable features show up in the report; their warnings would
+	// only repeat it
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	slog.SetDefault(logger)
+
+	svc, err := openServices(ctx, cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	defer svc.Close()
+
+	report := mcp.NewHealthReport(ctx, svc.setup, svc.engine, svc.curator)
+	report.ConfigPath = config.GetConfigPath()
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(formatHealthReport(report))
+	}
+
+	if !report.OK() {
+		svc.Close()
+		os.Exit(1)
+	}
+}
+
+// formatHealthReport formats a health report for people
+func formatHealthReport(r *mcp.HealthReport) string {
+	var b strings.Builder
+	if r.OK() {
+		b.WriteString("Status: OK\n")
+	} else {
+		fmt.Fprintf(&b, "Status: %d problems\n", len(r.Problems))
+	}
+	if r.ConfigPath != "" {
+		fmt.Fprintf(&b, "Config: %s\n", r.ConfigPath)
+	}
+	mode := "full"
+	if r.Minimal {
+		mode = "minimal"
+	}
+	fmt.Fprintf(&b, "Mode: %s\n", mode)
+
+	st := r.Storage
+	fmt.Fprintf(&b, "\nStorage: SQLite %s, schema version %d of %d; vectors in %s\n",
+		formatBytes(st.SizeBytes), st.SchemaVersion, r.LatestSchemaVersion, r.VectorStore)
+	fmt.Fprintf(&b, "  %d projects, %d memories (%d archived), %d open sessions, %d stale embeddings\n",
+		st.Projects, st.Memories, st.Archived, st.OpenSessions, r.StaleEmbeddings)
+
+	b.WriteString("\nChecks:\n")
+	for _, check := range r.Checks {
+		state := "ok"
+		if !check.OK {
+			state = "FAILED"
+		}
+		fmt.Fprintf(&b, "  %-13s %-6s %8.1fms  %s\n", check.Name, state, check.LatencyMS, check.Detail)
+	}
+
+	fmt.Fprintf(&b, "\nAI spend today: $%.4f\n", r.AISpendToday)
+	for _, budget := range r.Budgets {
+		fmt.Fprintf(&b, "  %s budget: $%.2f/day", budget.Name, budget.Limit)
+		if budget.Reached {
+			b.WriteString(" (reached)")
+		}
+		b.WriteString("\n")
+	}
+	cache := r.QueryCache
+	fmt.Fprintf(&b, "Query cache: %d hits, %d misses, %d of %d entries\n", cache.Hits, cache.Misses, cache.Entries, cache.Size)
+
+	if len(r.Problems) > 0 {
+		b.WriteString("\nProblems:\n")
+		for _, problem := range r.Problems {
+			fmt.Fprintf(&b, "  - %s\n", problem)
+		}
+	}
+	return b.String()
+}
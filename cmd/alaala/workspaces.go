@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/0xGurg/alaala/internal/storage"
+	"github.com/google/uuid"
+)
+
+// workspacesCommand handles `alaala workspaces <subcommand>`
+func workspacesCommand(args []string) {
+	if len(args) < 1 {
+		printWorkspacesUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: alaala workspaces create <name>")
+			os.Exit(1)
+		}
+		createWorkspace(args[1])
+	case "assign":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: alaala workspaces assign <project> <workspace>")
+			os.Exit(1)
+		}
+		assignWorkspace(args[1], args[2])
+	case "unassign":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: alaala workspaces unassign <project>")
+			os.Exit(1)
+		}
+		assignWorkspace(args[1], "")
+	case "list":
+		listWorkspaces()
+	case "stats":
+		workspaceStats()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown workspaces subcommand: %s\n", args[0])
+		printWorkspacesUsage()
+		os.Exit(1)
+	}
+}
+
+func printWorkspacesUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: alaala workspaces create <name>")
+	fmt.Fprintln(os.Stderr, "       alaala workspaces assign <project> <workspace>")
+	fmt.Fprintln(os.Stderr, "       alaala workspaces unassign <project>")
+	fmt.Fprintln(os.Stderr, "       alaala workspaces list")
+	fmt.Fprintln(os.Stderr, "       alaala workspaces stats")
+}
+
+// createWorkspace creates a workspace with the given name
+func createWorkspace(name string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	if existing, err := sqlStore.GetWorkspaceByName(ctx, name); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get workspace: %v\n", err)
+		os.Exit(1)
+	} else if existing != nil {
+		fmt.Fprintf(os.Stderr, "Workspace %s already exists (%s)\n", name, existing.ID)
+		os.Exit(1)
+	}
+
+	workspace := &storage.Workspace{ID: uuid.New().String(), Name: name}
+	if err := sqlStore.CreateWorkspace(ctx, workspace); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create workspace: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Created workspace %s (%s)\n", workspace.Name, workspace.ID)
+}
+
+// assignWorkspace moves a project into a workspace, or out of its workspace
+// when ref is empty, and updates the workspace stored with its vectors
+func assignWorkspace(projectRef, ref string) {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	project, err := resolveProject(ctx, sqlStore, projectRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var workspace *storage.Workspace
+	var workspaceID *string
+	if ref != "" {
+		workspace, err = resolveWorkspace(ctx, sqlStore, ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		workspaceID = &workspace.ID
+	}
+
+	if err := sqlStore.SetProjectWorkspace(ctx, project.ID, workspaceID); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to assign workspace: %v\n", err)
+		os.Exit(1)
+	}
+	if workspace != nil {
+		fmt.Printf("Assigned %s to workspace %s\n", project.Name, workspace.Name)
+	} else {
+		fmt.Printf("Removed %s from its workspace\n", project.Name)
+	}
+
+	// SQLite-backed vectors are filtered through the projects table; only
+	// Weaviate keeps its own copy of the workspace
+	weaviateStore, err := initWeaviateStore(cfg)
+	if errors.Is(err, errEmbeddedStorage) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Weaviate is unavailable, skipping its vectors: %v\n", err)
+		fmt.Fprintf(os.Stderr, "If you use Weaviate, run `alaala reindex --all --project %s` once it is back\n", project.ID)
+		return
+	}
+	defer weaviateStore.Close()
+
+	ids, err := allMemoryIDs(ctx, sqlStore, project.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list memories: %v\n", err)
+		os.Exit(1)
+	}
+
+	properties := map[string]interface{}{"workspaceId": ""}
+	if workspaceID != nil {
+		properties["workspaceId"] = *workspaceID
+	}
+
+	failed := 0
+	for _, id := range ids {
+		if err := weaviateStore.UpdateProperties(ctx, id, properties); err != nil {
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", id, err)
+			failed++
+		}
+	}
+	fmt.Printf("Updated %d of %d vectors in Weaviate\n", len(ids)-failed, len(ids))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// listWorkspaces prints every workspace with its projects
+func listWorkspaces() {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	workspaces, err := sqlStore.ListWorkspaces(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list workspaces: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(workspaces) == 0 {
+		fmt.Println("No workspaces. Create one with `alaala workspaces create <name>`.")
+		return
+	}
+
+	for _, workspace := range workspaces {
+		projects, err := sqlStore.WorkspaceProjects(ctx, workspace.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list projects: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%s (%s)\n", workspace.Name, workspace.ID)
+		if len(projects) == 0 {
+			fmt.Println("  no projects")
+		}
+		for _, project := range projects {
+			fmt.Printf("  %s  %s\n", project.Name, project.Path)
+		}
+	}
+}
+
+// workspaceStats prints memory counts and importance aggregated per workspace
+func workspaceStats() {
+	ctx := context.Background()
+	cfg := loadConfigOrExit()
+
+	sqlStore, err := initSQLiteStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize SQLite: %v\n", err)
+		os.Exit(1)
+	}
+	defer sqlStore.Close()
+
+	stats, err := sqlStore.WorkspaceStats(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("No workspaces.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKSPACE\tPROJECTS\tMEMORIES\tAVERAGE IMPORTANCE\tACTION REQUIRED")
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%d\n", stat.Workspace.Name, stat.Projects, stat.Memories,
+			stat.AverageImportance, stat.ActionRequired)
+	}
+	w.Flush()
+}
+
+// resolveWorkspace finds a workspace by ID or name
+func resolveWorkspace(ctx context.Context, sqlStore *storage.SQLiteStore, ref string) (*storage.Workspace, error) {
+	if workspace, err := sqlStore.GetWorkspace(ctx, ref); err != nil {
+		return nil, err
+	} else if workspace != nil {
+		return workspace, nil
+	}
+
+	workspace, err := sqlStore.GetWorkspaceByName(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, fmt.Errorf("workspace not found: %s", ref)
+	}
+	return workspace, nil
+}
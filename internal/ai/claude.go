@@ -3,14 +3,23 @@ package ai
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 const (
 	claudeAPIURL = "https://api.anthropic.com/v1/messages"
 	apiVersion   = "2023-06-01"
+
+	// defaultClaudeTimeout bounds a single request unless configured
+	defaultClaudeTimeout = 120 * time.Second
+
+	// maxRetryAfter caps how long a Retry-After header can make a retry wait
+	maxRetryAfter = 60 * time.Second
 )
 
 // ClaudeClient handles interactions with Claude API for memory curation
@@ -18,6 +27,9 @@ type ClaudeClient struct {
 	apiKey     string
 	model      string
 	httpClient *http.Client
+	usage      UsageRecorder
+
+	maxOutputTokens int
 }
 
 // NewClaudeClient creates a new Claude API client
@@ -27,18 +39,28 @@ func NewClaudeClient(apiKey string, model string) *ClaudeClient {
 	}
 
 	return &ClaudeClient{
-		apiKey:     apiKey,
-		model:      model,
-		httpClient: &http.Client{},
+		apiKey: apiKey,
+		model:  model,
+		httpClient: &http.Client{
+			Timeout: defaultClaudeTimeout,
+		},
+	}
+}
+
+// SetTimeout sets how long a single request may take; values of 0 or less
+// keep the current timeout
+func (c *ClaudeClient) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.httpClient.Timeout = timeout
 	}
 }
 
 // CurateMemories analyzes a transcript and extracts meaningful memories
 func (c *ClaudeClient) CurateMemories(req *CurationRequest) (*CurationResponse, error) {
-	prompt := c.buildCurationPrompt(req.Transcript)
+	prompt := c.buildCurationPrompt(req)
 
-	// Call Claude API
-	response, err := c.callClaude(prompt)
+	// Call Claude API, retrying with a larger limit if the JSON is cut off
+	response, maxTokens, err := completeUntruncated(c.callClaude, prompt, outputCeiling(c.model, c.maxOutputTokens))
 	if err != nil {
 		return nil, fmt.Errorf("failed to call Claude API: %w", err)
 	}
@@ -48,19 +70,73 @@ func (c *ClaudeClient) CurateMemories(req *CurationRequest) (*CurationResponse,
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse curation response: %w", err)
 	}
+	noteRecovery(curationResp, maxTokens)
 
 	return curationResp, nil
 }
 
+// CurationPrompt returns the fully rendered curation prompt for a request
+func (c *ClaudeClient) CurationPrompt(req *CurationRequest) string {
+	return c.buildCurationPrompt(req)
+}
+
+// Provider returns the provider name used in configuration
+func (c *ClaudeClient) Provider() string {
+	return "anthropic"
+}
+
+// Model returns the model used for curation
+func (c *ClaudeClient) Model() string {
+	return c.model
+}
+
+// SetUsageRecorder sets where token usage is reported after each call
+func (c *ClaudeClient) SetUsageRecorder(recorder UsageRecorder) {
+	c.usage = recorder
+}
+
+// SetMaxOutputTokens sets the ceiling for retrying truncated curation
+// responses with a larger output limit
+func (c *ClaudeClient) SetMaxOutputTokens(ceiling int) {
+	c.maxOutputTokens = ceiling
+}
+
+// ScoreImportance rates how important a memory is (0-1) with one short call
+func (c *ClaudeClient) ScoreImportance(content, contextType string) (float64, error) {
+	return scoreImportance(c.callClaude, content, contextType)
+}
+
+// Summarize writes a short summary of a session transcript
+func (c *ClaudeClient) Summarize(transcript string) (string, error) {
+	return summarize(c.callClaude, transcript)
+}
+
+// Compress shortens text to at most maxChars characters
+func (c *ClaudeClient) Compress(text string, maxChars int) (string, error) {
+	return compress(c.callClaude, text, maxChars)
+}
+
+// NarrateDigest writes a short narrative summary of a Markdown digest
+func (c *ClaudeClient) NarrateDigest(digest string) (string, error) {
+	return narrateDigest(c.callClaude, digest)
+}
+
+// Ping sends a tiny prompt to verify the provider is reachable and the
+// credentials and model work
+func (c *ClaudeClient) Ping() error {
+	_, err := c.callClaude(pingPrompt, pingMaxOutputTokens)
+	return err
+}
+
 // buildCurationPrompt creates the prompt for memory curation
-func (c *ClaudeClient) buildCurationPrompt(transcript string) string {
+func (c *ClaudeClient) buildCurationPrompt(req *CurationRequest) string {
 	return fmt.Sprintf(`You are a memory curator for an AI assistant. Your task is to analyze the following conversation transcript and extract the most important, meaningful memories that should be preserved.
 
 For each memory, provide:
 - content: A clear, concise statement of the memory
 - importance_weight: A float between 0 and 1 indicating importance
 - semantic_tags: Keywords that describe the memory
-- context_type: One of: TECHNICAL_IMPLEMENTATION, ARCHITECTURE, DECISION, BREAKTHROUGH, RELATIONSHIP, UNRESOLVED, MILESTONE, PREFERENCE
+- context_type: %s
 - trigger_phrases: Phrases that should trigger recall of this memory
 - question_types: Types of questions this memory would help answer
 - temporal_relevance: "persistent", "session", or "temporary"
@@ -97,7 +173,7 @@ Respond ONLY with valid JSON in this format:
 TRANSCRIPT:
 %s
 
-Remember: Only extract memories that are genuinely worth preserving. Quality over quantity.`, transcript)
+Remember: Only extract memories that are genuinely worth preserving. Quality over quantity.`, contextTypeField(req.ContextTypes), req.Transcript)
 }
 
 // parseCurationResponse parses the AI's JSON response
@@ -140,13 +216,151 @@ type claudeResponse struct {
 		Text string `json:"text"`
 	} `json:"content"`
 	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// claudeErrorResponse is the body of a failed Claude API request
+type claudeErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// claudeAPIError is a request the Claude API answered with an error
+type claudeAPIError struct {
+	StatusCode int
+	Type       string // e.g. "authentication_error" or "overloaded_error"
+	Message    string
+	RetryAfter time.Duration // From the Retry-After header, 0 if absent
+
+	// From the anthropic-ratelimit-requests-* headers, empty if absent
+	RequestsRemaining string
+	RequestsReset     string
+}
+
+func (e *claudeAPIError) Error() string {
+	baseMsg := fmt.Sprintf("Claude API error (status %d): %s", e.StatusCode, e.Message)
+
+	// Add helpful suggestions based on error type
+	switch {
+	case e.Type == "authentication_error" || e.StatusCode == http.StatusUnauthorized:
+		return fmt.Sprintf("%s\n\nPlease check your ANTHROPIC_API_KEY environment variable", baseMsg)
+
+	case e.Type == "permission_error" || e.StatusCode == http.StatusForbidden:
+		return fmt.Sprintf("%s\n\nYour API key does not have access to this model or workspace", baseMsg)
+
+	case e.Type == "not_found_error" || e.StatusCode == http.StatusNotFound:
+		return fmt.Sprintf("%s\n\nCheck that ai.model names a Claude model available to your account", baseMsg)
+
+	case e.Type == "rate_limit_error" || e.StatusCode == http.StatusTooManyRequests:
+		hint := "You've hit the rate limit. The request was retried automatically"
+		if e.RequestsRemaining != "" {
+			hint += fmt.Sprintf("\n(requests remaining: %s", e.RequestsRemaining)
+			if e.RequestsReset != "" {
+				hint += fmt.Sprintf(", resets at %s", e.RequestsReset)
+			}
+			hint += ")"
+		}
+		return fmt.Sprintf("%s\n\n%s", baseMsg, hint)
+
+	case e.Type == "overloaded_error" || e.StatusCode == 529:
+		return fmt.Sprintf("%s\n\nAnthropic's API is temporarily overloaded. The request was retried automatically; try again later", baseMsg)
+
+	default:
+		return fmt.Sprintf("%s (type: %s)", baseMsg, e.Type)
+	}
+}
+
+// newClaudeAPIError builds the error of a failed response from its body
+func newClaudeAPIError(resp *http.Response, body []byte) *claudeAPIError {
+	apiErr := &claudeAPIError{
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+		RetryAfter: retryAfter(resp.Header),
+
+		RequestsRemaining: resp.Header.Get("anthropic-ratelimit-requests-remaining"),
+		RequestsReset:     resp.Header.Get("anthropic-ratelimit-requests-reset"),
+	}
+
+	var errResp claudeErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
+	}
+	return apiErr
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date, returning 0 if it is absent or invalid
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// callClaude makes an API call to Claude with retry logic
+func (c *ClaudeClient) callClaude(prompt string, maxTokens int) (*completion, error) {
+	var lastErr error
+	maxRetries := 3
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			// Exponential backoff: 1s, 2s, unless the API asked for longer
+			backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+			var apiErr *claudeAPIError
+			if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > backoff {
+				backoff = min(apiErr.RetryAfter, maxRetryAfter)
+			}
+			time.Sleep(backoff)
+		}
+
+		response, err := c.makeRequest(prompt, maxTokens)
+		if err == nil {
+			return response, nil
+		}
+
+		lastErr = err
+
+		// Don't retry on certain errors
+		if !c.shouldRetry(err) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+}
+
+// shouldRetry determines if an error is retryable: rate limits, overload,
+// server errors and timeouts are; other client errors are not
+func (c *ClaudeClient) shouldRetry(err error) bool {
+	var apiErr *claudeAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+
+	errStr := err.Error()
+	return contains(errStr, "timeout") || contains(errStr, "connection")
 }
 
-// callClaude makes an API call to Claude
-func (c *ClaudeClient) callClaude(prompt string) (string, error) {
+// makeRequest performs a single API request
+func (c *ClaudeClient) makeRequest(prompt string, maxTokens int) (*completion, error) {
 	reqBody := claudeRequest{
 		Model:     c.model,
-		MaxTokens: 4096,
+		MaxTokens: maxTokens,
 		Messages: []claudeMessage{
 			{
 				Role:    "user",
@@ -157,12 +371,12 @@ func (c *ClaudeClient) callClaude(prompt string) (string, error) {
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequest("POST", claudeAPIURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -171,30 +385,41 @@ func (c *ClaudeClient) callClaude(prompt string) (string, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, newClaudeAPIError(resp, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var claudeResp claudeResponse
 	if err := json.Unmarshal(body, &claudeResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("empty response from Claude")
+		return nil, fmt.Errorf("empty response from Claude")
 	}
 
-	return claudeResp.Content[0].Text, nil
+	recordUsage(c.usage, Usage{
+		Provider:         c.Provider(),
+		Model:            c.model,
+		PromptTokens:     claudeResp.Usage.InputTokens,
+		CompletionTokens: claudeResp.Usage.OutputTokens,
+		Cost:             EstimateCost(c.model, claudeResp.Usage.InputTokens, claudeResp.Usage.OutputTokens),
+	})
+
+	return &completion{
+		Text:      claudeResp.Content[0].Text,
+		Truncated: claudeResp.StopReason == "max_tokens",
+	}, nil
 }
 
 // Helper functions
@@ -0,0 +1,38 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// builtinContextTypes are the context types every curation prompt offers
+const builtinContextTypes = "TECHNICAL_IMPLEMENTATION, ARCHITECTURE, DECISION, BREAKTHROUGH, RELATIONSHIP, UNRESOLVED, MILESTONE, PREFERENCE"
+
+// ContextType is a context type defined for this deployment that the
+// curator may assign besides the built-in ones
+type ContextType struct {
+	Name        string
+	Description string
+}
+
+// contextTypeField describes the context_type field of a curated memory,
+// explaining custom context types by their descriptions
+func contextTypeField(custom []ContextType) string {
+	var b strings.Builder
+	b.WriteString("One of: " + builtinContextTypes)
+	for _, t := range custom {
+		b.WriteString(", " + t.Name)
+	}
+	if len(custom) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n  Besides the usual types, this deployment uses:")
+	for _, t := range custom {
+		fmt.Fprintf(&b, "\n  - %s", t.Name)
+		if t.Description != "" {
+			fmt.Fprintf(&b, ": %s", t.Description)
+		}
+	}
+	return b.String()
+}
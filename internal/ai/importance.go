@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// importanceMaxOutputTokens is enough for the reply to importancePrompt
+const importanceMaxOutputTokens = 32
+
+// importancePrompt asks for a single importance rating of a memory
+const importancePrompt = `You rate memories kept by an AI coding assistant across sessions.
+
+How important is it to recall the following memory in future sessions? Decisions, gotchas, constraints and user preferences matter most; routine details and transient status matter least.
+
+Context type: %s
+Memory: %s
+
+Reply with only a JSON object of the form {"importance": 0.7}, where importance is between 0 and 1.`
+
+// scoreImportance rates a memory's importance with a single short completion
+func scoreImportance(complete completeFunc, content, contextType string) (float64, error) {
+	if contextType == "" {
+		contextType = "unknown"
+	}
+
+	result, err := complete(fmt.Sprintf(importancePrompt, contextType, content), importanceMaxOutputTokens)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseImportanceScore(result.Text)
+}
+
+// parseImportanceScore extracts the importance from a reply to importancePrompt
+func parseImportanceScore(response string) (float64, error) {
+	start := findJSONStart(response)
+	end := findJSONEnd(response)
+	if start == -1 || end == -1 {
+		return 0, fmt.Errorf("no JSON found in importance response: %q", response)
+	}
+
+	var score struct {
+		Importance *float64 `json:"importance"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &score); err != nil {
+		return 0, fmt.Errorf("failed to parse importance response: %w", err)
+	}
+	if score.Importance == nil {
+		return 0, fmt.Errorf("importance missing from response: %q", response)
+	}
+	if *score.Importance < 0 || *score.Importance > 1 {
+		return 0, fmt.Errorf("importance %v is out of range (0-1)", *score.Importance)
+	}
+
+	return *score.Importance, nil
+}
@@ -18,6 +18,9 @@ type OllamaClient struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+	usage      UsageRecorder
+
+	maxOutputTokens int
 }
 
 // NewOllamaClient creates a new Ollama API client
@@ -38,12 +41,20 @@ func NewOllamaClient(baseURL, model string) *OllamaClient {
 	}
 }
 
+// SetTimeout sets how long a single request may take; values of 0 or less
+// keep the current timeout
+func (c *OllamaClient) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.httpClient.Timeout = timeout
+	}
+}
+
 // CurateMemories analyzes a transcript and extracts meaningful memories
 func (c *OllamaClient) CurateMemories(req *CurationRequest) (*CurationResponse, error) {
-	prompt := c.buildCurationPrompt(req.Transcript)
+	prompt := c.buildCurationPrompt(req)
 
-	// Call Ollama API
-	response, err := c.callOllama(prompt)
+	// Call Ollama API, retrying with a larger limit if the JSON is cut off
+	response, maxTokens, err := completeUntruncated(c.callOllama, prompt, outputCeiling(c.model, c.maxOutputTokens))
 	if err != nil {
 		return nil, fmt.Errorf("failed to call Ollama API: %w", err)
 	}
@@ -53,19 +64,73 @@ func (c *OllamaClient) CurateMemories(req *CurationRequest) (*CurationResponse,
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse curation response: %w", err)
 	}
+	noteRecovery(curationResp, maxTokens)
 
 	return curationResp, nil
 }
 
+// CurationPrompt returns the fully rendered curation prompt for a request
+func (c *OllamaClient) CurationPrompt(req *CurationRequest) string {
+	return c.buildCurationPrompt(req)
+}
+
+// Provider returns the provider name used in configuration
+func (c *OllamaClient) Provider() string {
+	return "ollama"
+}
+
+// Model returns the model used for curation
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// SetUsageRecorder sets where token usage is reported after each call
+func (c *OllamaClient) SetUsageRecorder(recorder UsageRecorder) {
+	c.usage = recorder
+}
+
+// SetMaxOutputTokens sets the ceiling for retrying truncated curation
+// responses with a larger output limit
+func (c *OllamaClient) SetMaxOutputTokens(ceiling int) {
+	c.maxOutputTokens = ceiling
+}
+
+// ScoreImportance rates how important a memory is (0-1) with one short call
+func (c *OllamaClient) ScoreImportance(content, contextType string) (float64, error) {
+	return scoreImportance(c.callOllama, content, contextType)
+}
+
+// Summarize writes a short summary of a session transcript
+func (c *OllamaClient) Summarize(transcript string) (string, error) {
+	return summarize(c.callOllama, transcript)
+}
+
+// Compress shortens text to at most maxChars characters
+func (c *OllamaClient) Compress(text string, maxChars int) (string, error) {
+	return compress(c.callOllama, text, maxChars)
+}
+
+// NarrateDigest writes a short narrative summary of a Markdown digest
+func (c *OllamaClient) NarrateDigest(digest string) (string, error) {
+	return narrateDigest(c.callOllama, digest)
+}
+
+// Ping sends a tiny prompt to verify the provider is reachable and the
+// credentials and model work
+func (c *OllamaClient) Ping() error {
+	_, err := c.callOllama(pingPrompt, pingMaxOutputTokens)
+	return err
+}
+
 // buildCurationPrompt creates the prompt for memory curation
-func (c *OllamaClient) buildCurationPrompt(transcript string) string {
+func (c *OllamaClient) buildCurationPrompt(req *CurationRequest) string {
 	return fmt.Sprintf(`You are a memory curator for an AI assistant. Your task is to analyze the following conversation transcript and extract the most important, meaningful memories that should be preserved.
 
 For each memory, provide:
 - content: A clear, concise statement of the memory
 - importance_weight: A float between 0 and 1 indicating importance
 - semantic_tags: Keywords that describe the memory
-- context_type: One of: TECHNICAL_IMPLEMENTATION, ARCHITECTURE, DECISION, BREAKTHROUGH, RELATIONSHIP, UNRESOLVED, MILESTONE, PREFERENCE
+- context_type: %s
 - trigger_phrases: Phrases that should trigger recall of this memory
 - question_types: Types of questions this memory would help answer
 - temporal_relevance: "persistent", "session", or "temporary"
@@ -102,7 +167,7 @@ Respond ONLY with valid JSON in this format:
 TRANSCRIPT:
 %s
 
-Remember: Only extract memories that are genuinely worth preserving. Quality over quantity.`, transcript)
+Remember: Only extract memories that are genuinely worth preserving. Quality over quantity.`, contextTypeField(req.ContextTypes), req.Transcript)
 }
 
 // parseCurationResponse parses the AI's JSON response
@@ -132,6 +197,13 @@ type ollamaRequest struct {
 	Prompt string `json:"prompt"`
 	Stream bool   `json:"stream"`
 	Format string `json:"format,omitempty"`
+
+	Options ollamaOptions `json:"options"`
+}
+
+// ollamaOptions holds model parameters for a generate request
+type ollamaOptions struct {
+	NumPredict int `json:"num_predict"`
 }
 
 // ollamaResponse represents Ollama's response
@@ -140,55 +212,74 @@ type ollamaResponse struct {
 	CreatedAt string `json:"created_at"`
 	Response  string `json:"response"`
 	Done      bool   `json:"done"`
+
+	DoneReason string `json:"done_reason"`
+
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
 }
 
 // callOllama makes an API call to Ollama
-func (c *OllamaClient) callOllama(prompt string) (string, error) {
+func (c *OllamaClient) callOllama(prompt string, maxTokens int) (*completion, error) {
 	reqBody := ollamaRequest{
 		Model:  c.model,
 		Prompt: prompt,
 		Stream: false,
 		Format: "json", // Request JSON format response
+		Options: ollamaOptions{
+			NumPredict: maxTokens,
+		},
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/api/generate", c.baseURL)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to connect to Ollama (is it running?): %w\n\nStart Ollama with: ollama serve\nPull model with: ollama pull %s", err, c.model)
+		return nil, fmt.Errorf("failed to connect to Ollama (is it running?): %w\n\nStart Ollama with: ollama serve\nPull model with: ollama pull %s", err, c.model)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Ollama returned status %d: %s\n\nMake sure model is pulled: ollama pull %s",
+		return nil, fmt.Errorf("Ollama returned status %d: %s\n\nMake sure model is pulled: ollama pull %s",
 			resp.StatusCode, string(body), c.model)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var ollamaResp ollamaResponse
 	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if ollamaResp.Response == "" {
-		return "", fmt.Errorf("empty response from Ollama")
+		return nil, fmt.Errorf("empty response from Ollama")
 	}
 
-	return ollamaResp.Response, nil
+	// Local models have no per-token cost
+	recordUsage(c.usage, Usage{
+		Provider:         c.Provider(),
+		Model:            c.model,
+		PromptTokens:     ollamaResp.PromptEvalCount,
+		CompletionTokens: ollamaResp.EvalCount,
+	})
+
+	return &completion{
+		Text:      ollamaResp.Response,
+		Truncated: ollamaResp.DoneReason == "length",
+	}, nil
 }
@@ -20,6 +20,9 @@ type OpenRouterClient struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+	usage      UsageRecorder
+
+	maxOutputTokens int
 }
 
 // NewOpenRouterClient creates a new OpenRouter API client
@@ -41,12 +44,20 @@ func NewOpenRouterClient(apiKey string, model string, baseURL string) *OpenRoute
 	}
 }
 
+// SetTimeout sets how long a single request may take; values of 0 or less
+// keep the current timeout
+func (c *OpenRouterClient) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.httpClient.Timeout = timeout
+	}
+}
+
 // CurateMemories analyzes a transcript and extracts meaningful memories
 func (c *OpenRouterClient) CurateMemories(req *CurationRequest) (*CurationResponse, error) {
-	prompt := c.buildCurationPrompt(req.Transcript)
+	prompt := c.buildCurationPrompt(req)
 
-	// Call OpenRouter API
-	response, err := c.callOpenRouter(prompt)
+	// Call OpenRouter API, retrying with a larger limit if the JSON is cut off
+	response, maxTokens, err := completeUntruncated(c.callOpenRouter, prompt, outputCeiling(c.model, c.maxOutputTokens))
 	if err != nil {
 		return nil, fmt.Errorf("failed to call OpenRouter API: %w", err)
 	}
@@ -56,19 +67,73 @@ func (c *OpenRouterClient) CurateMemories(req *CurationRequest) (*CurationRespon
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse curation response: %w", err)
 	}
+	noteRecovery(curationResp, maxTokens)
 
 	return curationResp, nil
 }
 
+// CurationPrompt returns the fully rendered curation prompt for a request
+func (c *OpenRouterClient) CurationPrompt(req *CurationRequest) string {
+	return c.buildCurationPrompt(req)
+}
+
+// Provider returns the provider name used in configuration
+func (c *OpenRouterClient) Provider() string {
+	return "openrouter"
+}
+
+// Model returns the model used for curation
+func (c *OpenRouterClient) Model() string {
+	return c.model
+}
+
+// SetUsageRecorder sets where token usage is reported after each call
+func (c *OpenRouterClient) SetUsageRecorder(recorder UsageRecorder) {
+	c.usage = recorder
+}
+
+// SetMaxOutputTokens sets the ceiling for retrying truncated curation
+// responses with a larger output limit
+func (c *OpenRouterClient) SetMaxOutputTokens(ceiling int) {
+	c.maxOutputTokens = ceiling
+}
+
+// ScoreImportance rates how important a memory is (0-1) with one short call
+func (c *OpenRouterClient) ScoreImportance(content, contextType string) (float64, error) {
+	return scoreImportance(c.callOpenRouter, content, contextType)
+}
+
+// Summarize writes a short summary of a session transcript
+func (c *OpenRouterClient) Summarize(transcript string) (string, error) {
+	return summarize(c.callOpenRouter, transcript)
+}
+
+// Compress shortens text to at most maxChars characters
+func (c *OpenRouterClient) Compress(text string, maxChars int) (string, error) {
+	return compress(c.callOpenRouter, text, maxChars)
+}
+
+// NarrateDigest writes a short narrative summary of a Markdown digest
+func (c *OpenRouterClient) NarrateDigest(digest string) (string, error) {
+	return narrateDigest(c.callOpenRouter, digest)
+}
+
+// Ping sends a tiny prompt to verify the provider is reachable and the
+// credentials and model work
+func (c *OpenRouterClient) Ping() error {
+	_, err := c.makeRequest(pingPrompt, pingMaxOutputTokens)
+	return err
+}
+
 // buildCurationPrompt creates the prompt for memory curation
-func (c *OpenRouterClient) buildCurationPrompt(transcript string) string {
+func (c *OpenRouterClient) buildCurationPrompt(req *CurationRequest) string {
 	return fmt.Sprintf(`You are a memory curator for an AI assistant. Your task is to analyze the following conversation transcript and extract the most important, meaningful memories that should be preserved.
 
 For each memory, provide:
 - content: A clear, concise statement of the memory
 - importance_weight: A float between 0 and 1 indicating importance
 - semantic_tags: Keywords that describe the memory
-- context_type: One of: TECHNICAL_IMPLEMENTATION, ARCHITECTURE, DECISION, BREAKTHROUGH, RELATIONSHIP, UNRESOLVED, MILESTONE, PREFERENCE
+- context_type: %s
 - trigger_phrases: Phrases that should trigger recall of this memory
 - question_types: Types of questions this memory would help answer
 - temporal_relevance: "persistent", "session", or "temporary"
@@ -105,7 +170,7 @@ Respond ONLY with valid JSON in this format:
 TRANSCRIPT:
 %s
 
-Remember: Only extract memories that are genuinely worth preserving. Quality over quantity.`, transcript)
+Remember: Only extract memories that are genuinely worth preserving. Quality over quantity.`, contextTypeField(req.ContextTypes), req.Transcript)
 }
 
 // parseCurationResponse parses the AI's JSON response
@@ -134,6 +199,12 @@ type openRouterRequest struct {
 	Model     string              `json:"model"`
 	Messages  []openRouterMessage `json:"messages"`
 	MaxTokens int                 `json:"max_tokens,omitempty"`
+	Usage     *openRouterUsage    `json:"usage,omitempty"`
+}
+
+// openRouterUsage asks OpenRouter to include the actual cost in the response
+type openRouterUsage struct {
+	Include bool `json:"include"`
 }
 
 // openRouterMessage represents a message in the conversation
@@ -153,9 +224,10 @@ type openRouterResponse struct {
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
+		PromptTokens     int     `json:"prompt_tokens"`
+		CompletionTokens int     `json:"completion_tokens"`
+		TotalTokens      int     `json:"total_tokens"`
+		Cost             float64 `json:"cost"`
 	} `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
@@ -165,7 +237,7 @@ type openRouterResponse struct {
 }
 
 // callOpenRouter makes an API call to OpenRouter with retry logic
-func (c *OpenRouterClient) callOpenRouter(prompt string) (string, error) {
+func (c *OpenRouterClient) callOpenRouter(prompt string, maxTokens int) (*completion, error) {
 	var lastErr error
 	maxRetries := 3
 
@@ -176,7 +248,7 @@ func (c *OpenRouterClient) callOpenRouter(prompt string) (string, error) {
 			time.Sleep(backoff)
 		}
 
-		response, err := c.makeRequest(prompt)
+		response, err := c.makeRequest(prompt, maxTokens)
 		if err == nil {
 			return response, nil
 		}
@@ -185,15 +257,15 @@ func (c *OpenRouterClient) callOpenRouter(prompt string) (string, error) {
 
 		// Don't retry on certain errors
 		if !c.shouldRetry(err) {
-			return "", err
+			return nil, err
 		}
 	}
 
-	return "", fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
 }
 
 // makeRequest performs a single API request
-func (c *OpenRouterClient) makeRequest(prompt string) (string, error) {
+func (c *OpenRouterClient) makeRequest(prompt string, maxTokens int) (*completion, error) {
 	reqBody := openRouterRequest{
 		Model: c.model,
 		Messages: []openRouterMessage{
@@ -202,18 +274,21 @@ func (c *OpenRouterClient) makeRequest(prompt string) (string, error) {
 				Content: prompt,
 			},
 		},
-		MaxTokens: 4096,
+		MaxTokens: maxTokens,
+	}
+	if c.usage != nil {
+		reqBody.Usage = &openRouterUsage{Include: true}
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/chat/completions", c.baseURL)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -223,35 +298,51 @@ func (c *OpenRouterClient) makeRequest(prompt string) (string, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var openRouterResp openRouterResponse
 	if err := json.Unmarshal(body, &openRouterResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for API errors
 	if openRouterResp.Error != nil {
-		return "", c.formatAPIError(openRouterResp.Error, resp.StatusCode)
+		return nil, c.formatAPIError(openRouterResp.Error, resp.StatusCode)
 	}
 
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	if len(openRouterResp.Choices) == 0 {
-		return "", fmt.Errorf("empty response from OpenRouter")
+		return nil, fmt.Errorf("empty response from OpenRouter")
 	}
 
-	return openRouterResp.Choices[0].Message.Content, nil
+	usage := openRouterResp.Usage
+	cost := usage.Cost
+	if cost == 0 {
+		cost = EstimateCost(c.model, usage.PromptTokens, usage.CompletionTokens)
+	}
+	recordUsage(c.usage, Usage{
+		Provider:         c.Provider(),
+		Model:            c.model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             cost,
+	})
+
+	return &completion{
+		Text:      openRouterResp.Choices[0].Message.Content,
+		Truncated: openRouterResp.Choices[0].FinishReason == "length",
+	}, nil
 }
 
 // shouldRetry determines if an error is retryable
@@ -0,0 +1,37 @@
+package ai
+
+// pingPrompt is a minimal prompt used to verify a provider is reachable
+const pingPrompt = `Reply with the JSON object {"ok": true} and nothing else.`
+
+// Error categories reported by ClassifyError
+const (
+	ErrorCategoryAuthentication   = "authentication"
+	ErrorCategoryModelUnavailable = "model_unavailable"
+	ErrorCategoryRateLimited      = "rate_limited"
+	ErrorCategoryNetwork          = "network"
+	ErrorCategoryUnknown          = "unknown"
+)
+
+// ClassifyError maps a provider error to a coarse category so callers can
+// tell a bad key apart from a missing model or an unreachable server
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	msg := err.Error()
+	switch {
+	case contains(msg, "api_key") || contains(msg, "api key") || contains(msg, "authentication") ||
+		contains(msg, "status 401") || contains(msg, "status 403") || contains(msg, "unauthorized"):
+		return ErrorCategoryAuthentication
+	case contains(msg, "model") && (contains(msg, "not found") || contains(msg, "not available") || contains(msg, "status 404")):
+		return ErrorCategoryModelUnavailable
+	case contains(msg, "rate limit") || contains(msg, "status 429"):
+		return ErrorCategoryRateLimited
+	case contains(msg, "connection") || contains(msg, "timeout") || contains(msg, "no such host") ||
+		contains(msg, "is it running") || contains(msg, "failed to make request"):
+		return ErrorCategoryNetwork
+	default:
+		return ErrorCategoryUnknown
+	}
+}
@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SummaryMaxOutputTokens is enough for the reply to SummaryPrompt
+const SummaryMaxOutputTokens = 256
+
+// summaryPrompt asks for a short summary of a session transcript
+const summaryPrompt = `You keep notes for an AI coding assistant across sessions.
+
+Summarize what the following session was about in one to three sentences: the task, what was decided or changed, and anything left open. Reply with only the summary.
+
+Transcript:
+%s`
+
+// compressionPrompt asks for a shorter version of a session primer
+const compressionPrompt = `You keep notes for an AI coding assistant across sessions.
+
+Shorten the following session context to at most %d characters. Keep decisions, open action items, constraints and preferences; drop routine detail and repetition. Keep the Markdown headings. Reply with only the shortened text.
+
+%s`
+
+// DigestMaxOutputTokens is enough for the reply to DigestPrompt
+const DigestMaxOutputTokens = 512
+
+// digestPrompt asks for a narrative summary of a digest
+const digestPrompt = `You keep notes for an AI coding assistant across sessions.
+
+Below is a digest of what was remembered across projects over a period. Write a short narrative summary of it in one or two paragraphs: the main themes and decisions per project, what needs attention (open action items, conflicts), and anything notable across projects. Do not repeat every item. Reply with only the summary.
+
+%s`
+
+// SummaryPrompt renders the prompt that asks a model to summarize a session
+// transcript
+func SummaryPrompt(transcript string) string {
+	return fmt.Sprintf(summaryPrompt, transcript)
+}
+
+// CompressionPrompt renders the prompt that asks a model to shorten text to
+// at most maxChars characters
+func CompressionPrompt(text string, maxChars int) string {
+	return fmt.Sprintf(compressionPrompt, maxChars, text)
+}
+
+// CompressionMaxOutputTokens returns the output limit for shortening text to
+// maxChars characters, at roughly four characters per token plus headroom
+func CompressionMaxOutputTokens(maxChars int) int {
+	return maxChars/3 + 64
+}
+
+// DigestPrompt renders the prompt that asks a model to summarize a digest
+func DigestPrompt(digest string) string {
+	return fmt.Sprintf(digestPrompt, digest)
+}
+
+// summarize asks for a session summary with a single completion
+func summarize(complete completeFunc, transcript string) (string, error) {
+	result, err := complete(SummaryPrompt(transcript), SummaryMaxOutputTokens)
+	if err != nil {
+		return "", err
+	}
+	return nonEmptyReply(result.Text)
+}
+
+// compress asks for a shortened text with a single completion
+func compress(complete completeFunc, text string, maxChars int) (string, error) {
+	result, err := complete(CompressionPrompt(text, maxChars), CompressionMaxOutputTokens(maxChars))
+	if err != nil {
+		return "", err
+	}
+	return nonEmptyReply(result.Text)
+}
+
+// narrateDigest asks for a digest summary with a single completion
+func narrateDigest(complete completeFunc, digest string) (string, error) {
+	result, err := complete(DigestPrompt(digest), DigestMaxOutputTokens)
+	if err != nil {
+		return "", err
+	}
+	return nonEmptyReply(result.Text)
+}
+
+// nonEmptyReply trims a reply and rejects an empty one
+func nonEmptyReply(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("empty reply from provider")
+	}
+	return text, nil
+}
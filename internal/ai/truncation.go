@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// initialMaxOutputTokens is the output limit of the first curation attempt
+	initialMaxOutputTokens = 4096
+
+	// DefaultMaxOutputTokens is the default ceiling when retrying truncated responses
+	DefaultMaxOutputTokens = 16384
+
+	// pingMaxOutputTokens is enough for the short reply to pingPrompt
+	pingMaxOutputTokens = 16
+)
+
+// modelOutputLimits holds the maximum output tokens models declare, matched
+// by prefix after stripping any OpenRouter vendor prefix
+var modelOutputLimits = []struct {
+	prefix string
+	limit  int
+}{
+	{"claude-3-5-", 8192},
+	{"claude-3.5-", 8192},
+	{"claude-3-7-", 64000},
+	{"claude-3.7-", 64000},
+	{"claude-3-", 4096},
+	{"claude-sonnet-4", 64000},
+	{"claude-opus-4", 32000},
+	{"gpt-4o", 16384},
+	{"gpt-4-turbo", 4096},
+}
+
+// completion is the outcome of a single completion call
+type completion struct {
+	Text      string
+	Truncated bool // The provider stopped because the output limit was hit
+}
+
+// completeFunc performs a single completion with the given output limit
+type completeFunc func(prompt string, maxTokens int) (*completion, error)
+
+// outputCeiling returns the largest output limit to request for a model:
+// the configured ceiling, lowered to the model's own limit when known
+func outputCeiling(model string, configured int) int {
+	ceiling := configured
+	if ceiling <= 0 {
+		ceiling = DefaultMaxOutputTokens
+	}
+
+	name := model
+	if i := strings.LastIndex(name, "/"); i != -1 {
+		name = name[i+1:]
+	}
+	for _, l := range modelOutputLimits {
+		if strings.HasPrefix(name, l.prefix) {
+			if l.limit < ceiling {
+				ceiling = l.limit
+			}
+			break
+		}
+	}
+
+	if ceiling < initialMaxOutputTokens {
+		return initialMaxOutputTokens
+	}
+	return ceiling
+}
+
+// completeUntruncated calls complete, doubling the output limit whenever the
+// response is cut off, until it fits or the ceiling is reached. It returns
+// the text and the limit that produced it.
+func completeUntruncated(complete completeFunc, prompt string, ceiling int) (string, int, error) {
+	maxTokens := initialMaxOutputTokens
+	if maxTokens > ceiling {
+		maxTokens = ceiling
+	}
+
+	for {
+		result, err := complete(prompt, maxTokens)
+		if err != nil {
+			return "", maxTokens, err
+		}
+		if !result.Truncated {
+			return result.Text, maxTokens, nil
+		}
+		if maxTokens >= ceiling {
+			return "", maxTokens, fmt.Errorf("curation response was truncated at %d output tokens; "+
+				"curate a shorter transcript or raise ai.max_output_tokens if the model allows it", maxTokens)
+		}
+
+		maxTokens *= 2
+		if maxTokens > ceiling {
+			maxTokens = ceiling
+		}
+	}
+}
+
+// noteRecovery records in the curation summary that the first response was
+// truncated and a retry with a larger limit succeeded
+func noteRecovery(resp *CurationResponse, maxTokens int) {
+	if maxTokens <= initialMaxOutputTokens {
+		return
+	}
+
+	resp.TruncationRecovered = true
+	note := fmt.Sprintf("(Response was truncated at %d output tokens and recovered with a limit of %d.)",
+		initialMaxOutputTokens, maxTokens)
+	if resp.Summary == "" {
+		resp.Summary = note
+	} else {
+		resp.Summary += " " + note
+	}
+}
@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptedOllama answers /api/generate with the given responses in turn,
+// recording the output limit of each request
+type scriptedOllama struct {
+	mu        sync.Mutex
+	responses []ollamaResponse
+	limits    []int
+}
+
+func (s *scriptedOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var req ollamaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.limits = append(s.limits, req.Options.NumPredict)
+
+	if len(s.responses) == 0 {
+		http.Error(w, "no more scripted responses", http.StatusInternalServerError)
+		return
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func newScriptedOllama(t *testing.T, responses ...ollamaResponse) (*scriptedOllama, *OllamaClient) {
+	t.Helper()
+	script := &scriptedOllama{responses: responses}
+	server := httptest.NewServer(script)
+	t.Cleanup(server.Close)
+	return script, NewOllamaClient(server.URL, "llama3.1")
+}
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCurateMemoriesRecoversTruncatedResponse(t *testing.T) {
+	truncated := readFixture(t, "curation_truncated.json")
+	complete := readFixture(t, "curation_complete.json")
+	if json.Valid([]byte(truncated)) {
+		t.Fatal("truncated fixture must not be valid JSON")
+	}
+
+	script, client := newScriptedOllama(t,
+		ollamaResponse{Response: truncated, Done: true, DoneReason: "length"},
+		ollamaResponse{Response: complete, Done: true, DoneReason: "stop"},
+	)
+
+	resp, err := client.CurateMemories(&CurationRequest{Transcript: "we talked about pagination"})
+	if err != nil {
+		t.Fatalf("CurateMemories: %v", err)
+	}
+
+	if want := []int{initialMaxOutputTokens, 2 * initialMaxOutputTokens}; !equalInts(script.limits, want) {
+		t.Errorf("requested output limits %v, want %v", script.limits, want)
+	}
+	if len(resp.Memories) != 2 || len(resp.Relationships) != 1 {
+		t.Errorf("got %d memories and %d relationships, want 2 and 1", len(resp.Memories), len(resp.Relationships))
+	}
+	if !resp.TruncationRecovered {
+		t.Error("TruncationRecovered not set")
+	}
+	if !strings.HasPrefix(resp.Summary, "Settled on cursor pagination (Response was truncated at 4096") {
+		t.Errorf("summary does not note the recovery: %q", resp.Summary)
+	}
+}
+
+func TestCurateMemoriesGivesUpAtCeiling(t *testing.T) {
+	truncated := readFixture(t, "curation_truncated.json")
+	script, client := newScriptedOllama(t,
+		ollamaResponse{Response: truncated, Done: true, DoneReason: "length"},
+		ollamaResponse{Response: truncated, Done: true, DoneReason: "length"},
+		ollamaResponse{Response: truncated, Done: true, DoneReason: "length"},
+	)
+	client.SetMaxOutputTokens(10000)
+
+	_, err := client.CurateMemories(&CurationRequest{Transcript: "a very long session"})
+	if err == nil || !strings.Contains(err.Error(), "truncated at 10000 output tokens") {
+		t.Fatalf("got error %v, want one saying the response was truncated at the ceiling", err)
+	}
+	if want := []int{4096, 8192, 10000}; !equalInts(script.limits, want) {
+		t.Errorf("requested output limits %v, want %v", script.limits, want)
+	}
+}
+
+func TestCurateMemoriesUntruncatedNeedsNoRetry(t *testing.T) {
+	script, client := newScriptedOllama(t,
+		ollamaResponse{Response: readFixture(t, "curation_complete.json"), Done: true, DoneReason: "stop"},
+	)
+
+	resp, err := client.CurateMemories(&CurationRequest{Transcript: "short"})
+	if err != nil {
+		t.Fatalf("CurateMemories: %v", err)
+	}
+	if len(script.limits) != 1 || resp.TruncationRecovered {
+		t.Errorf("made %d requests, recovered = %v; want 1 request and no recovery", len(script.limits), resp.TruncationRecovered)
+	}
+	if resp.Summary != "Settled on cursor pagination" {
+		t.Errorf("summary = %q", resp.Summary)
+	}
+}
+
+func TestCompleteUntruncatedStopsOnError(t *testing.T) {
+	boom := errors.New("connection refused")
+	calls := 0
+	_, _, err := completeUntruncated(func(prompt string, maxTokens int) (*completion, error) {
+		calls++
+		if calls == 1 {
+			return &completion{Text: `{"memories": [`, Truncated: true}, nil
+		}
+		return nil, boom
+	}, "prompt", DefaultMaxOutputTokens)
+
+	if !errors.Is(err, boom) || calls != 2 {
+		t.Errorf("got %v after %d calls, want the call error after 2", err, calls)
+	}
+}
+
+func TestOutputCeiling(t *testing.T) {
+	tests := []struct {
+		model      string
+		configured int
+		want       int
+	}{
+		{"claude-3-5-sonnet-20241022", 0, 8192},
+		{"anthropic/claude-3-5-sonnet", 0, 8192},
+		{"claude-sonnet-4-20250514", 0, DefaultMaxOutputTokens},
+		{"claude-sonnet-4-20250514", 32000, 32000},
+		{"claude-3-haiku-20240307", 0, 4096},
+		{"llama3.1", 0, DefaultMaxOutputTokens},
+		{"llama3.1", 1000, initialMaxOutputTokens}, // Never below the first attempt
+	}
+	for _, tt := range tests {
+		if got := outputCeiling(tt.model, tt.configured); got != tt.want {
+			t.Errorf("outputCeiling(%q, %d) = %d, want %d", tt.model, tt.configured, got, tt.want)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,10 @@ package ai
 
 // CurationRequest represents a request to curate memories
 type CurationRequest struct {
-	Transcript string
-	ProjectID  string
-	SessionID  string
+	Transcript   string
+	ProjectID    string
+	SessionID    string
+	ContextTypes []ContextType // Custom context types to offer besides the built-in ones
 }
 
 // CurationResponse represents the AI's curated memories
@@ -12,6 +13,10 @@ type CurationResponse struct {
 	Memories      []CuratedMemory      `json:"memories"`
 	Relationships []MemoryRelationship `json:"relationships"`
 	Summary       string               `json:"summary"`
+
+	// TruncationRecovered is set when the first response hit the output
+	// token limit and a retry with a larger limit succeeded
+	TruncationRecovered bool `json:"-"`
 }
 
 // CuratedMemory represents a memory extracted by the AI
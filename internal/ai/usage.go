@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Usage describes the tokens consumed by a single AI call
+type Usage struct {
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64 // Estimated cost in USD
+}
+
+// UsageRecorder receives usage after every successful AI call
+type UsageRecorder interface {
+	RecordUsage(usage Usage) error
+}
+
+// modelPrices holds USD prices per million prompt and completion tokens.
+// Models are matched by prefix after stripping any OpenRouter vendor prefix.
+var modelPrices = []struct {
+	prefix     string
+	prompt     float64
+	completion float64
+}{
+	{"claude-3-5-haiku", 0.80, 4},
+	{"claude-3.5-haiku", 0.80, 4},
+	{"claude-3-haiku", 0.25, 1.25},
+	{"claude-3-opus", 15, 75},
+	{"claude-3-5-sonnet", 3, 15},
+	{"claude-3.5-sonnet", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-3.7-sonnet", 3, 15},
+	{"claude-sonnet-4", 3, 15},
+	{"claude-opus-4", 15, 75},
+	{"gpt-4o-mini", 0.15, 0.60},
+	{"gpt-4o", 2.50, 10},
+	{"gpt-4-turbo", 10, 30},
+	{"llama-3.1-70b-instruct", 0.40, 0.40},
+	{"llama-3.1-8b-instruct", 0.05, 0.05},
+	{"gemini-pro-1.5", 1.25, 5},
+}
+
+// EstimateCost estimates the USD cost of a call from its token counts.
+// Unknown models, free OpenRouter models and local models cost nothing.
+func EstimateCost(model string, promptTokens, completionTokens int) float64 {
+	if strings.HasSuffix(model, ":free") {
+		return 0
+	}
+	if i := strings.LastIndex(model, "/"); i != -1 {
+		model = model[i+1:]
+	}
+
+	for _, price := range modelPrices {
+		if strings.HasPrefix(model, price.prefix) {
+			return (float64(promptTokens)*price.prompt + float64(completionTokens)*price.completion) / 1e6
+		}
+	}
+	return 0
+}
+
+// recordUsage passes usage to the recorder, if any. Recording failures are
+// reported but never fail the AI call itself.
+func recordUsage(recorder UsageRecorder, usage Usage) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.RecordUsage(usage); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to record AI usage: %v\n", err)
+	}
+}
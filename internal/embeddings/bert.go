@@ -0,0 +1,397 @@
+package embeddings
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// bertConfig mirrors the fields of a Hugging Face config.json we need
+type bertConfig struct {
+	HiddenSize            int     `json:"hidden_size"`
+	NumHiddenLayers       int     `json:"num_hidden_layers"`
+	NumAttentionHeads     int     `json:"num_attention_heads"`
+	IntermediateSize      int     `json:"intermediate_size"`
+	MaxPositionEmbeddings int     `json:"max_position_embeddings"`
+	LayerNormEps          float64 `json:"layer_norm_eps"`
+}
+
+// defaultBertConfig matches sentence-transformers/all-MiniLM-L6-v2
+func defaultBertConfig() bertConfig {
+	return bertConfig{
+		HiddenSize:            384,
+		NumHiddenLayers:       6,
+		NumAttentionHeads:     12,
+		IntermediateSize:      1536,
+		MaxPositionEmbeddings: 512,
+		LayerNormEps:          1e-12,
+	}
+}
+
+// tensor is a dense row-major float32 tensor
+type tensor struct {
+	shape []int
+	data  []float32
+}
+
+type bertLayer struct {
+	query, queryBias        []float32
+	key, keyBias            []float32
+	value, valueBias        []float32
+	attnOut, attnOutBias    []float32
+	attnNorm, attnNormBias  []float32
+	inter, interBias        []float32
+	output, outputBias      []float32
+	outputNorm, outNormBias []float32
+}
+
+// bertModel is a minimal CPU implementation of the BERT encoder forward pass
+type bertModel struct {
+	cfg bertConfig
+
+	wordEmbeddings     []float32
+	positionEmbeddings []float32
+	typeEmbeddings     []float32
+	embNorm            []float32
+	embNormBias        []float32
+
+	layers []bertLayer
+}
+
+// loadBertModel loads BERT weights from a safetensors file
+func loadBertModel(weightsPath string, cfg bertConfig) (*bertModel, error) {
+	tensors, err := readSafetensors(weightsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	get := func(name string, size int) ([]float32, error) {
+		t, ok := tensors[name]
+		if !ok {
+			t, ok = tensors["bert."+name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("model weights are missing tensor %q", name)
+		}
+		if size > 0 && len(t.data) != size {
+			return nil, fmt.Errorf("tensor %q has %d values, expected %d", name, len(t.data), size)
+		}
+		return t.data, nil
+	}
+
+	h, inter := cfg.HiddenSize, cfg.IntermediateSize
+	m := &bertModel{cfg: cfg}
+
+	if m.wordEmbeddings, err = get("embeddings.word_embeddings.weight", 0); err != nil {
+		return nil, err
+	}
+	if len(m.wordEmbeddings)%h != 0 {
+		return nil, fmt.Errorf("word embeddings do not match hidden size %d", h)
+	}
+	if m.positionEmbeddings, err = get("embeddings.position_embeddings.weight", cfg.MaxPositionEmbeddings*h); err != nil {
+		return nil, err
+	}
+	if m.typeEmbeddings, err = get("embeddings.token_type_embeddings.weight", 0); err != nil {
+		return nil, err
+	}
+	if m.embNorm, err = get("embeddings.LayerNorm.weight", h); err != nil {
+		return nil, err
+	}
+	if m.embNormBias, err = get("embeddings.LayerNorm.bias", h); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < cfg.NumHiddenLayers; i++ {
+		prefix := fmt.Sprintf("encoder.layer.%d.", i)
+		var l bertLayer
+		specs := []struct {
+			dst  *[]float32
+			name string
+			size int
+		}{
+			{&l.query, "attention.self.query.weight", h * h},
+			{&l.queryBias, "attention.self.query.bias", h},
+			{&l.key, "attention.self.key.weight", h * h},
+			{&l.keyBias, "attention.self.key.bias", h},
+			{&l.value, "attention.self.value.weight", h * h},
+			{&l.valueBias, "attention.self.value.bias", h},
+			{&l.attnOut, "attention.output.dense.weight", h * h},
+			{&l.attnOutBias, "attention.output.dense.bias", h},
+			{&l.attnNorm, "attention.output.LayerNorm.weight", h},
+			{&l.attnNormBias, "attention.output.LayerNorm.bias", h},
+			{&l.inter, "intermediate.dense.weight", inter * h},
+			{&l.interBias, "intermediate.dense.bias", inter},
+			{&l.output, "output.dense.weight", h * inter},
+			{&l.outputBias, "output.dense.bias", h},
+			{&l.outputNorm, "output.LayerNorm.weight", h},
+			{&l.outNormBias, "output.LayerNorm.bias", h},
+		}
+		for _, spec := range specs {
+			if *spec.dst, err = get(prefix+spec.name, spec.size); err != nil {
+				return nil, err
+			}
+		}
+		m.layers = append(m.layers, l)
+	}
+
+	return m, nil
+}
+
+// vocabSize returns the number of rows in the word embedding table
+func (m *bertModel) vocabSize() int {
+	return len(m.wordEmbeddings) / m.cfg.HiddenSize
+}
+
+// Forward runs the encoder and returns the L2-normalized mean-pooled embedding
+func (m *bertModel) Forward(ids []int) []float32 {
+	h := m.cfg.HiddenSize
+	seq := len(ids)
+	if seq > m.cfg.MaxPositionEmbeddings {
+		seq = m.cfg.MaxPositionEmbeddings
+		ids = ids[:seq]
+	}
+	eps := float32(m.cfg.LayerNormEps)
+
+	// Embeddings: word + position + token type (always segment 0)
+	x := make([]float32, seq*h)
+	for t, id := range ids {
+		row := x[t*h : (t+1)*h]
+		word := m.wordEmbeddings[id*h : (id+1)*h]
+		pos := m.positionEmbeddings[t*h : (t+1)*h]
+		for i := range row {
+			row[i] = word[i] + pos[i] + m.typeEmbeddings[i]
+		}
+	}
+	layerNorm(x, h, m.embNorm, m.embNormBias, eps)
+
+	heads := m.cfg.NumAttentionHeads
+	headDim := h / heads
+	scale := float32(1.0 / math.Sqrt(float64(headDim)))
+	scores := make([]float32, seq)
+	ctx := make([]float32, seq*h)
+
+	for _, l := range m.layers {
+		q := linear(x, seq, h, h, l.query, l.queryBias)
+		k := linear(x, seq, h, h, l.key, l.keyBias)
+		v := linear(x, seq, h, h, l.value, l.valueBias)
+
+		for i := range ctx {
+			ctx[i] = 0
+		}
+		for hd := 0; hd < heads; hd++ {
+			off := hd * headDim
+			for i := 0; i < seq; i++ {
+				qi := q[i*h+off : i*h+off+headDim]
+				maxScore := float32(math.Inf(-1))
+				for j := 0; j < seq; j++ {
+					kj := k[j*h+off : j*h+off+headDim]
+					var dot float32
+					for d := range qi {
+						dot += qi[d] * kj[d]
+					}
+					scores[j] = dot * scale
+					if scores[j] > maxScore {
+						maxScore = scores[j]
+					}
+				}
+				var sum float32
+				for j := 0; j < seq; j++ {
+					scores[j] = float32(math.Exp(float64(scores[j] - maxScore)))
+					sum += scores[j]
+				}
+				out := ctx[i*h+off : i*h+off+headDim]
+				for j := 0; j < seq; j++ {
+					w := scores[j] / sum
+					vj := v[j*h+off : j*h+off+headDim]
+					for d := range out {
+						out[d] += w * vj[d]
+					}
+				}
+			}
+		}
+
+		attn := linear(ctx, seq, h, h, l.attnOut, l.attnOutBias)
+		for i := range x {
+			x[i] += attn[i]
+		}
+		layerNorm(x, h, l.attnNorm, l.attnNormBias, eps)
+
+		inter := linear(x, seq, h, m.cfg.IntermediateSize, l.inter, l.interBias)
+		for i, val := range inter {
+			inter[i] = gelu(val)
+		}
+		out := linear(inter, seq, m.cfg.IntermediateSize, h, l.output, l.outputBias)
+		for i := range x {
+			x[i] += out[i]
+		}
+		layerNorm(x, h, l.outputNorm, l.outNormBias, eps)
+	}
+
+	// Mean pooling followed by L2 normalization
+	pooled := make([]float32, h)
+	for t := 0; t < seq; t++ {
+		for i := 0; i < h; i++ {
+			pooled[i] += x[t*h+i]
+		}
+	}
+	var norm float64
+	for i := range pooled {
+		pooled[i] /= float32(seq)
+		norm += float64(pooled[i]) * float64(pooled[i])
+	}
+	if norm > 0 {
+		inv := float32(1.0 / math.Sqrt(norm))
+		for i := range pooled {
+			pooled[i] *= inv
+		}
+	}
+
+	return pooled
+}
+
+// linear computes x·Wᵀ + b for a [rows, in] input and [out, in] weight
+func linear(x []float32, rows, in, out int, w, b []float32) []float32 {
+	y := make([]float32, rows*out)
+	for r := 0; r < rows; r++ {
+		xr := x[r*in : (r+1)*in]
+		yr := y[r*out : (r+1)*out]
+		for o := 0; o < out; o++ {
+			wo := w[o*in : (o+1)*in]
+			sum := b[o]
+			for i, xv := range xr {
+				sum += xv * wo[i]
+			}
+			yr[o] = sum
+		}
+	}
+	return y
+}
+
+// layerNorm normalizes each row of x in place
+func layerNorm(x []float32, width int, gamma, beta []float32, eps float32) {
+	for start := 0; start < len(x); start += width {
+		row := x[start : start+width]
+		var mean float32
+		for _, v := range row {
+			mean += v
+		}
+		mean /= float32(width)
+		var variance float32
+		for _, v := range row {
+			d := v - mean
+			variance += d * d
+		}
+		variance /= float32(width)
+		inv := float32(1.0 / math.Sqrt(float64(variance+eps)))
+		for i, v := range row {
+			row[i] = (v-mean)*inv*gamma[i] + beta[i]
+		}
+	}
+}
+
+func gelu(x float32) float32 {
+	return float32(0.5 * float64(x) * (1 + math.Erf(float64(x)/math.Sqrt2)))
+}
+
+// readSafetensors parses a .safetensors file into float32 tensors
+func readSafetensors(path string) (map[string]tensor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open model weights: %w", err)
+	}
+	defer f.Close()
+
+	var headerLen uint64
+	if err := binary.Read(f, binary.LittleEndian, &headerLen); err != nil {
+		return nil, fmt.Errorf("failed to read safetensors header: %w", err)
+	}
+	if headerLen > 100<<20 {
+		return nil, fmt.Errorf("safetensors header too large (%d bytes)", headerLen)
+	}
+
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(f, headerBytes); err != nil {
+		return nil, fmt.Errorf("failed to read safetensors header: %w", err)
+	}
+
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse safetensors header: %w", err)
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model weights: %w", err)
+	}
+
+	tensors := make(map[string]tensor, len(header))
+	for name, raw := range header {
+		if name == "__metadata__" {
+			continue
+		}
+
+		var info struct {
+			DType       string `json:"dtype"`
+			Shape       []int  `json:"shape"`
+			DataOffsets [2]int `json:"data_offsets"`
+		}
+		if err := json.Unmarshal(raw, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse tensor %q: %w", name, err)
+		}
+
+		begin, end := info.DataOffsets[0], info.DataOffsets[1]
+		if begin < 0 || end > len(data) || begin > end {
+			return nil, fmt.Errorf("tensor %q has invalid offsets", name)
+		}
+		buf := data[begin:end]
+
+		var values []float32
+		switch strings.ToUpper(info.DType) {
+		case "F32":
+			values = make([]float32, len(buf)/4)
+			for i := range values {
+				values[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:]))
+			}
+		case "F16":
+			values = make([]float32, len(buf)/2)
+			for i := range values {
+				values[i] = float16ToFloat32(binary.LittleEndian.Uint16(buf[i*2:]))
+			}
+		default:
+			// Non-float tensors (e.g. position_ids buffers) are not needed
+			continue
+		}
+
+		tensors[name] = tensor{shape: info.Shape, data: values}
+	}
+
+	return tensors, nil
+}
+
+func float16ToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+
+	switch {
+	case exp == 0 && frac == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Subnormal: normalize the fraction
+		e := uint32(127 - 15 + 1)
+		for frac&0x400 == 0 {
+			frac <<= 1
+			e--
+		}
+		frac &= 0x3ff
+		return math.Float32frombits(sign | e<<23 | frac<<13)
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+	}
+}
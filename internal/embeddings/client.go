@@ -1,21 +1,75 @@
 package embeddings
 
 import (
+	"context"
 	"fmt"
+	"sync"
 )
 
-// Client handles text embedding generation
+// Client handles text embedding generation. It is safe for concurrent use:
+// its embedder is set up when it is created and never changes, and
+// concurrent calls to Embed with the same text embed it once.
 type Client struct {
 	provider       string
 	model          string
+	localEmbedder  *LocalEmbedder
 	ollamaEmbedder *OllamaEmbedder
+	openAIEmbedder *OpenAIEmbedder
+	hashEmbedder   *HashEmbedder
+	flights        flightGroup
+
+	dimensionMu sync.Mutex
+	dimension   int // Cached by EmbeddingInfo
 }
 
+// supportedProviders lists the embeddings providers understood by Client
+const supportedProviders = `"local", "ollama", "openai", "hash"`
+
 // NewClient creates a new embeddings client
 func NewClient(provider, model string) (*Client, error) {
+	switch provider {
+	case "local":
+		return NewLocalClient(model, "")
+	case "ollama":
+		return NewClientWithURL(provider, model, "")
+	case "openai":
+		return NewClientWithURL(provider, model, "")
+	case "hash":
+		return NewHashClient(), nil
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider: %q (supported: %s)", provider, supportedProviders)
+	}
+}
+
+// NewHashClient creates a client backed by the feature-hashing embedder,
+// which works without model files or services
+func NewHashClient() *Client {
 	return &Client{
-		provider: provider,
-		model:    model,
+		provider:     "hash",
+		model:        hashModelName,
+		hashEmbedder: NewHashEmbedder(),
+	}
+}
+
+// NewLocalClient creates a client backed by the in-process local model.
+// It fails immediately if the model files are missing.
+func NewLocalClient(model, modelPath string) (*Client, error) {
+	if model == "" {
+		model = defaultLocalModel
+	}
+	if modelPath == "" {
+		modelPath = DefaultLocalModelPath(model)
+	}
+
+	localEmbedder, err := NewLocalEmbedder(modelPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		provider:      "local",
+		model:         model,
+		localEmbedder: localEmbedder,
 	}, nil
 }
 
@@ -26,57 +80,77 @@ func NewClientWithURL(provider, model, url string) (*Client, error) {
 		model:    model,
 	}
 
-	// For Ollama, create the actual embedder
-	if provider == "ollama" {
+	switch provider {
+	case "ollama":
 		client.ollamaEmbedder = NewOllamaEmbedder(url, model)
+	case "openai":
+		// The configured model defaults to the local one; OpenAI would
+		// reject that name on every request
+		if !isOpenAIModel(model) {
+			client.model = defaultOpenAIEmbedder
+		}
+		client.openAIEmbedder = NewOpenAIEmbedder("", client.model)
+	case "hash":
+		client.hashEmbedder = NewHashEmbedder()
 	}
 
 	return client, nil
 }
 
-// Embed generates an embedding vector for the given text
-func (c *Client) Embed(text string) ([]float32, error) {
+// Embed generates an embedding vector for the given text. Callers asking
+// for a text that is already being embedded wait for that embedding.
+func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
+	return c.flights.do(ctx, text, func() ([]float32, error) {
+		return c.embed(ctx, text)
+	})
+}
+
+// pingText is embedded by Ping
+const pingText = "alaala startup check"
+
+// Ping embeds a short text to check that the provider is reachable and its
+// model works
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.embed(ctx, pingText)
+	return err
+}
+
+// embed generates an embedding with the provider's embedder
+func (c *Client) embed(ctx context.Context, text string) ([]float32, error) {
 	switch c.provider {
 	case "local":
-		return c.embedLocal(text)
-	case "ollama":
-		if c.ollamaEmbedder == nil {
-			c.ollamaEmbedder = NewOllamaEmbedder("", c.model)
+		if c.localEmbedder == nil {
+			return nil, fmt.Errorf("local embedding model not loaded")
 		}
-		return c.ollamaEmbedder.Embed(text)
+		return c.localEmbedder.Embed(ctx, text)
+	case "ollama":
+		return c.ollamaEmbedder.Embed(ctx, text)
 	case "openai":
-		return nil, fmt.Errorf("OpenAI embeddings not yet implemented")
+		return c.openAIEmbedder.Embed(ctx, text)
+	case "hash":
+		return c.hashEmbedder.Embed(ctx, text)
 	default:
-		return nil, fmt.Errorf("unknown embeddings provider: %s", c.provider)
+		return nil, fmt.Errorf("unknown embeddings provider: %q (supported: %s)", c.provider, supportedProviders)
 	}
 }
 
-// embedLocal generates embeddings using a local model
-func (c *Client) embedLocal(text string) ([]float32, error) {
-	// TODO: Implement actual local embeddings using sentence-transformers
-	// For now, return a dummy embedding vector
-	// This should be replaced with actual model inference
-
-	// Dummy 384-dimensional vector (typical for all-MiniLM-L6-v2)
-	embedding := make([]float32, 384)
-
-	// Simple hash-based fake embedding for development
-	hash := simpleHash(text)
-	for i := 0; i < 384; i++ {
-		embedding[i] = float32((hash+i)%100) / 100.0
+// EmbedBatch generates embeddings for several texts, in order. OpenAI and
+// Ollama embed them in one request; other providers embed one at a time.
+func (c *Client) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	switch c.provider {
+	case "ollama":
+		return c.ollamaEmbedder.EmbedBatch(ctx, texts)
+	case "openai":
+		return c.openAIEmbedder.EmbedBatch(ctx, texts)
 	}
 
-	return embedding, nil
-}
-
-// simpleHash creates a simple hash of a string (for dummy embeddings)
-func simpleHash(s string) int {
-	h := 0
-	for i := 0; i < len(s); i++ {
-		h = 31*h + int(s[i])
-	}
-	if h < 0 {
-		h = -h
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := c.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
 	}
-	return h
+	return embeddings, nil
 }
@@ -0,0 +1,175 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fakeOllama serves /api/embeddings and /api/embed with vectors derived
+// from the text, counting the requests for each text. Requests for a text
+// in block wait until release is closed.
+type fakeOllama struct {
+	mu       sync.Mutex
+	requests map[string]int
+	block    map[string]bool
+	release  chan struct{}
+}
+
+func newFakeOllama(t *testing.T, block ...string) (*fakeOllama, *Client) {
+	f := &fakeOllama{requests: make(map[string]int), block: make(map[string]bool), release: make(chan struct{})}
+	for _, text := range block {
+		f.block[text] = true
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithURL("ollama", "unknown-model", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, client
+}
+
+// fakeVector is the vector fakeOllama returns for text
+func fakeVector(text string) []float32 {
+	return []float32{float32(len(text)), float32(text[0]), 1}
+}
+
+func (f *fakeOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Prompt string   `json:"prompt"`
+		Input  []string `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	texts := req.Input
+	if r.URL.Path == "/api/embeddings" {
+		texts = []string{req.Prompt}
+	}
+
+	f.mu.Lock()
+	blocked := false
+	for _, text := range texts {
+		f.requests[text]++
+		blocked = blocked || f.block[text]
+	}
+	f.mu.Unlock()
+	if blocked {
+		<-f.release
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = fakeVector(text)
+	}
+	switch r.URL.Path {
+	case "/api/embeddings":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"embedding": vectors[0]})
+	case "/api/embed":
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": vectors})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeOllama) count(text string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[text]
+}
+
+func TestClientConcurrentUse(t *testing.T) {
+	fake, client := newFakeOllama(t, "shared")
+
+	errs := make(chan error, 150)
+	wait := startTogether(50, func(i int) {
+		ctx := context.Background()
+		if got, err := client.Embed(ctx, "shared"); err != nil || !reflect.DeepEqual(got, fakeVector("shared")) {
+			errs <- fmt.Errorf("Embed(shared) = %v, %v", got, err)
+		}
+
+		texts := []string{fmt.Sprintf("batch %d", i), "common"}
+		got, err := client.EmbedBatch(ctx, texts)
+		if err != nil || len(got) != 2 || !reflect.DeepEqual(got[0], fakeVector(texts[0])) || !reflect.DeepEqual(got[1], fakeVector(texts[1])) {
+			errs <- fmt.Errorf("EmbedBatch(%v) = %v, %v", texts, got, err)
+		}
+
+		if info := client.EmbeddingInfo(); info.Dimension != 3 {
+			errs <- fmt.Errorf("EmbeddingInfo dimension = %d, want 3", info.Dimension)
+		}
+	})
+	close(fake.release)
+	wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := fake.count("shared"); n != 1 {
+		t.Errorf("Ollama asked to embed the shared text %d times, want 1", n)
+	}
+	if n := fake.count(dimensionProbe); n != 1 {
+		t.Errorf("dimension probed %d times, want 1", n)
+	}
+}
+
+func TestHashClientConcurrentUse(t *testing.T) {
+	client := NewHashClient()
+	ctx := context.Background()
+
+	texts := make([]string, 10)
+	want := make([][]float32, len(texts))
+	for i := range texts {
+		texts[i] = fmt.Sprintf("memory number %d about deploys", i)
+		vector, err := client.Embed(ctx, texts[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[i] = vector
+	}
+
+	errs := make(chan error, 100)
+	startTogether(50, func(i int) {
+		text := texts[i%len(texts)]
+		if got, err := client.Embed(ctx, text); err != nil || !reflect.DeepEqual(got, want[i%len(texts)]) {
+			errs <- fmt.Errorf("Embed(%q) differs under concurrency (%v)", text, err)
+		}
+		if got, err := client.EmbedBatch(ctx, texts); err != nil || !reflect.DeepEqual(got, want) {
+			errs <- fmt.Errorf("EmbedBatch differs under concurrency (%v)", err)
+		}
+	})()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestOpenAIClientFallsBackFromNonOpenAIModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"", defaultOpenAIEmbedder},
+		{defaultLocalModel, defaultOpenAIEmbedder},
+		{"nomic-embed-text", defaultOpenAIEmbedder},
+		{"text-embedding-3-large", "text-embedding-3-large"},
+	}
+	for _, tt := range tests {
+		client, err := NewClient("openai", tt.model)
+		if err != nil {
+			t.Fatalf("NewClient(openai, %q): %v", tt.model, err)
+		}
+		if got := client.EmbeddingInfo().Model; got != tt.want {
+			t.Errorf("model for %q = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// flight is an embedding in progress that other callers can wait for
+type flight struct {
+	done      chan struct{}
+	embedding []float32
+	err       error
+}
+
+// flightGroup makes concurrent requests to embed the same text share one
+// call to the embedder, safe for concurrent use
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// do calls embed for text unless the same text is already being embedded,
+// in which case it waits for that result and returns a copy of it. If the
+// caller that started the embedding gave up, waiters that have not try
+// again themselves.
+func (g *flightGroup) do(ctx context.Context, text string, embed func() ([]float32, error)) ([]float32, error) {
+	for {
+		g.mu.Lock()
+		if g.flights == nil {
+			g.flights = make(map[string]*flight)
+		}
+		f, ok := g.flights[text]
+		if !ok {
+			f = &flight{done: make(chan struct{})}
+			g.flights[text] = f
+			g.mu.Unlock()
+
+			f.embedding, f.err = embed()
+			g.mu.Lock()
+			delete(g.flights, text)
+			g.mu.Unlock()
+			close(f.done)
+			return f.embedding, f.err
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if f.err == nil {
+			return append([]float32(nil), f.embedding...), nil
+		}
+		abandoned := errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)
+		if !abandoned || ctx.Err() != nil {
+			return nil, f.err
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startTogether runs n goroutines calling fn and waits for them. It returns
+// once they have all started, after giving them time to reach fn's first
+// blocking call, and the returned wait func waits for them to finish.
+func startTogether(n int, fn func(i int)) (wait func()) {
+	var ready, done sync.WaitGroup
+	ready.Add(n)
+	done.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer done.Done()
+			ready.Done()
+			fn(i)
+		}(i)
+	}
+	ready.Wait()
+	time.Sleep(50 * time.Millisecond)
+	return done.Wait
+}
+
+func TestFlightGroupSharesConcurrentEmbeds(t *testing.T) {
+	var g flightGroup
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	results := make([][]float32, 50)
+	errs := make([]error, 50)
+	wait := startTogether(50, func(i int) {
+		results[i], errs[i] = g.do(context.Background(), "shared", func() ([]float32, error) {
+			calls.Add(1)
+			<-release
+			return []float32{1, 2, 3}, nil
+		})
+	})
+	close(release)
+	wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("embedder called %d times for one text, want 1", n)
+	}
+	for i := range results {
+		if errs[i] != nil || len(results[i]) != 3 || results[i][0] != 1 {
+			t.Fatalf("caller %d got %v, %v", i, results[i], errs[i])
+		}
+	}
+
+	// Every caller owns its vector
+	results[0][0] = 42
+	for i := 1; i < len(results); i++ {
+		if results[i][0] != 1 {
+			t.Fatalf("changing caller 0's vector changed caller %d's to %v", i, results[i])
+		}
+	}
+}
+
+func TestFlightGroupKeepsTextsApart(t *testing.T) {
+	var g flightGroup
+	var calls atomic.Int32
+
+	errs := make(chan error, 50)
+	startTogether(50, func(i int) {
+		text := fmt.Sprintf("text %d", i%5)
+		got, err := g.do(context.Background(), text, func() ([]float32, error) {
+			calls.Add(1)
+			return []float32{float32(i % 5)}, nil
+		})
+		if err != nil || got[0] != float32(i%5) {
+			errs <- fmt.Errorf("%s embedded as %v (%v)", text, got, err)
+		}
+	})()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := calls.Load(); n < 5 || n > 50 {
+		t.Errorf("embedder called %d times for 5 texts, want 5 to 50", n)
+	}
+}
+
+func TestFlightGroupRetriesAbandonedEmbed(t *testing.T) {
+	var g flightGroup
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The first caller gives up part way
+	go func() {
+		_, _ = g.do(ctx, "shared", func() ([]float32, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	}()
+	<-started
+
+	var got []float32
+	var err error
+	wait := startTogether(1, func(int) {
+		got, err = g.do(context.Background(), "shared", func() ([]float32, error) {
+			return []float32{7}, nil
+		})
+	})
+	cancel()
+	wait()
+
+	if err != nil || len(got) != 1 || got[0] != 7 {
+		t.Errorf("waiter got %v, %v; want it to embed the text itself", got, err)
+	}
+}
+
+func TestFlightGroupWaiterHonorsItsContext(t *testing.T) {
+	var g flightGroup
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+
+	go func() {
+		_, _ = g.do(context.Background(), "shared", func() ([]float32, error) {
+			close(started)
+			<-release
+			return []float32{1}, nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.do(ctx, "shared", nil); err != context.DeadlineExceeded {
+		t.Errorf("waiter error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
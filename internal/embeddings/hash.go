@@ -0,0 +1,76 @@
+package embeddings
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"unicode"
+)
+
+const (
+	// hashEmbeddingDim is deliberately different from every real model so
+	// hash vectors are never mistaken for semantic ones
+	hashEmbeddingDim = 256
+
+	hashModelName = "feature-hash-256"
+)
+
+// HashEmbedder produces lexical embeddings by hashing words and word pairs
+// into a fixed number of buckets. It needs no model files or services and
+// is the fallback when no embedding model is available; similarity reflects
+// shared words rather than meaning.
+type HashEmbedder struct{}
+
+// NewHashEmbedder creates a feature-hashing embedder
+func NewHashEmbedder() *HashEmbedder {
+	return &HashEmbedder{}
+}
+
+// Embed generates a normalized 256-dimensional lexical embedding
+func (e *HashEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vector := make([]float32, hashEmbeddingDim)
+
+	var words []string
+	for _, token := range basicTokenize(text) {
+		if r := []rune(token); len(r) == 1 && !unicode.IsLetter(r[0]) && !unicode.IsDigit(r[0]) {
+			continue // Skip punctuation
+		}
+		words = append(words, token)
+	}
+
+	for i, word := range words {
+		addHashedFeature(vector, word, 1)
+		if i > 0 {
+			addHashedFeature(vector, words[i-1]+" "+word, 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+
+	return vector, nil
+}
+
+// addHashedFeature adds weight to the bucket a feature hashes to, using one
+// hash bit as the sign so that collisions tend to cancel out
+func addHashedFeature(vector []float32, feature string, weight float32) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum32()
+
+	if sum&(1<<31) != 0 {
+		weight = -weight
+	}
+	vector[sum%hashEmbeddingDim] += weight
+}
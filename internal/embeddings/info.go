@@ -0,0 +1,75 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+)
+
+// Info describes the vector space produced by an embeddings client
+type Info struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	Dimension  int    `json:"dimension"`
+	Normalized bool   `json:"normalized"` // Vectors have unit length
+}
+
+// ID identifies the vector space, e.g. "local/all-MiniLM-L6-v2/384". Vectors
+// with different IDs are not comparable.
+func (i Info) ID() string {
+	return fmt.Sprintf("%s/%s/%d", i.Provider, i.Model, i.Dimension)
+}
+
+// knownDimensions maps well-known embedding models to their output size
+var knownDimensions = map[string]int{
+	"all-MiniLM-L6-v2":       localEmbeddingDim,
+	"all-minilm":             384,
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// dimensionProbe is embedded once to measure models of unknown dimension
+const dimensionProbe = "dimension probe"
+
+// EmbeddingInfo describes the vectors this client produces. The dimension
+// is looked up for well-known models and otherwise measured by embedding a
+// probe text once; it is 0 if the provider cannot be reached. Concurrent
+// callers share one probe.
+func (c *Client) EmbeddingInfo() Info {
+	info := Info{Provider: c.provider, Model: c.model}
+
+	switch c.provider {
+	case "local":
+		info.Dimension = localEmbeddingDim
+		info.Normalized = true
+	case "hash":
+		info.Dimension = hashEmbeddingDim
+		info.Normalized = true
+	case "openai":
+		info.Model = c.openAIEmbedder.model
+		info.Normalized = true // OpenAI embeddings are normalized to length 1
+	case "ollama":
+		info.Model = c.ollamaEmbedder.model
+	}
+
+	if info.Dimension != 0 {
+		return info
+	}
+
+	c.dimensionMu.Lock()
+	defer c.dimensionMu.Unlock()
+	info.Dimension = c.dimension
+	if info.Dimension == 0 {
+		info.Dimension = knownDimensions[info.Model]
+	}
+	if info.Dimension == 0 {
+		if vector, err := c.Embed(context.Background(), dimensionProbe); err == nil {
+			info.Dimension = len(vector)
+		}
+	}
+	c.dimension = info.Dimension
+
+	return info
+}
@@ -0,0 +1,103 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	defaultLocalModel = "all-MiniLM-L6-v2"
+
+	// localEmbeddingDim is the output size of all-MiniLM-L6-v2
+	localEmbeddingDim = 384
+
+	// localMaxSeqLength matches the sentence-transformers max_seq_length
+	localMaxSeqLength = 256
+)
+
+// LocalEmbedder generates embeddings in-process from a sentence-transformers
+// model directory containing model.safetensors, vocab.txt and config.json.
+// It is safe for concurrent use, as the model is only read once loaded.
+type LocalEmbedder struct {
+	modelPath string
+	tokenizer *wordPieceTokenizer
+	model     *bertModel
+}
+
+// DefaultLocalModelPath returns where the local model is expected by default
+func DefaultLocalModelPath(model string) string {
+	if model == "" {
+		model = defaultLocalModel
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".alaala", "models", model)
+}
+
+// NewLocalEmbedder loads the model at modelPath, failing if any file is missing
+func NewLocalEmbedder(modelPath string) (*LocalEmbedder, error) {
+	weightsPath := filepath.Join(modelPath, "model.safetensors")
+	vocabPath := filepath.Join(modelPath, "vocab.txt")
+
+	for _, path := range []string{weightsPath, vocabPath} {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("local embedding model file not found: %s\n\n"+
+				"Download all-MiniLM-L6-v2 with:\n"+
+				"  mkdir -p %s\n"+
+				"  for f in model.safetensors vocab.txt config.json; do\n"+
+				"    curl -L -o %s/$f https://huggingface.co/sentence-transformers/all-MiniLM-L6-v2/resolve/main/$f\n"+
+				"  done\n\n"+
+				"Or switch embeddings.provider to \"ollama\" or \"openai\"",
+				path, modelPath, modelPath)
+		}
+	}
+
+	cfg := defaultBertConfig()
+	if data, err := os.ReadFile(filepath.Join(modelPath, "config.json")); err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse model config: %w", err)
+		}
+	}
+
+	if cfg.HiddenSize != localEmbeddingDim {
+		return nil, fmt.Errorf("local model has dimension %d, expected %d", cfg.HiddenSize, localEmbeddingDim)
+	}
+	if cfg.NumAttentionHeads == 0 || cfg.HiddenSize%cfg.NumAttentionHeads != 0 {
+		return nil, fmt.Errorf("invalid attention head count %d for hidden size %d", cfg.NumAttentionHeads, cfg.HiddenSize)
+	}
+
+	model, err := loadBertModel(weightsPath, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load local model: %w", err)
+	}
+
+	maxLen := localMaxSeqLength
+	if cfg.MaxPositionEmbeddings < maxLen {
+		maxLen = cfg.MaxPositionEmbeddings
+	}
+
+	tokenizer, err := loadWordPieceTokenizer(vocabPath, maxLen)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokenizer.vocab) > model.vocabSize() {
+		return nil, fmt.Errorf("vocabulary has %d tokens but model only embeds %d", len(tokenizer.vocab), model.vocabSize())
+	}
+
+	return &LocalEmbedder{
+		modelPath: modelPath,
+		tokenizer: tokenizer,
+		model:     model,
+	}, nil
+}
+
+// Embed generates a normalized 384-dimensional embedding for the given text
+func (e *LocalEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ids := e.tokenizer.Encode(text)
+	return e.model.Forward(ids), nil
+}
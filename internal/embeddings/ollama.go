@@ -2,6 +2,7 @@ package embeddings
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -39,7 +40,7 @@ func NewOllamaEmbedder(baseURL, model string) *OllamaEmbedder {
 }
 
 // Embed generates an embedding for the given text
-func (e *OllamaEmbedder) Embed(text string) ([]float32, error) {
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	reqBody := map[string]interface{}{
 		"model":  e.model,
 		"prompt": text,
@@ -51,7 +52,7 @@ func (e *OllamaEmbedder) Embed(text string) ([]float32, error) {
 	}
 
 	url := fmt.Sprintf("%s/api/embeddings", e.baseURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -95,3 +96,89 @@ func (e *OllamaEmbedder) Embed(text string) ([]float32, error) {
 
 	return embedding, nil
 }
+
+// EmbedBatch generates embeddings for several texts with one call to the
+// /api/embed endpoint. Ollama versions without that endpoint are served one
+// text at a time.
+func (e *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/embed", e.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Ollama (is it running?): %w\n\nStart Ollama with: ollama serve", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Older Ollama without batch support (or an unknown model, which
+		// Embed reports properly)
+		return e.embedEach(ctx, texts)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama returned status %d: %s\n\nMake sure model is pulled: ollama pull %s",
+			resp.StatusCode, string(body), e.model)
+	}
+
+	var ollamaResp struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(ollamaResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("Ollama returned %d embeddings for %d inputs", len(ollamaResp.Embeddings), len(texts))
+	}
+
+	// Convert float64 to float32
+	embeddings := make([][]float32, len(texts))
+	for i, values := range ollamaResp.Embeddings {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("empty embedding returned from Ollama")
+		}
+		embeddings[i] = make([]float32, len(values))
+		for j, v := range values {
+			embeddings[i][j] = float32(v)
+		}
+	}
+
+	return embeddings, nil
+}
+
+// embedEach embeds texts one request at a time
+func (e *OllamaEmbedder) embedEach(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,148 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	openAIEmbeddingsURL   = "https://api.openai.com/v1/embeddings"
+	defaultOpenAIEmbedder = "text-embedding-3-small"
+)
+
+// OpenAIEmbedder generates embeddings using the OpenAI embeddings API
+type OpenAIEmbedder struct {
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIEmbedder creates a new OpenAI embeddings client.
+// If apiKey is empty, OPENAI_API_KEY is used.
+func NewOpenAIEmbedder(apiKey, model string) *OpenAIEmbedder {
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if model == "" {
+		model = defaultOpenAIEmbedder
+	}
+
+	return &OpenAIEmbedder{
+		apiKey: apiKey,
+		model:  model,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// isOpenAIModel reports whether model names an OpenAI embedding model
+func isOpenAIModel(model string) bool {
+	return strings.HasPrefix(model, "text-embedding-")
+}
+
+// Embed generates an embedding for the given text
+func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.request(ctx, text, 1)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for several texts in a single request
+func (e *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	return e.request(ctx, texts, len(texts))
+}
+
+// request calls the embeddings endpoint with a string or an array of
+// strings as input and returns the embeddings in input order
+func (e *OpenAIEmbedder) request(ctx context.Context, input interface{}, count int) ([][]float32, error) {
+	if e.apiKey == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY not set")
+	}
+
+	reqBody := map[string]interface{}{
+		"model": e.model,
+		"input": input,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", openAIEmbeddingsURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", e.apiKey))
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OpenAI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var openAIResp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error,omitempty"`
+	}
+
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if openAIResp.Error != nil {
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("OpenAI API error: %s\n\nPlease check your OPENAI_API_KEY environment variable", openAIResp.Error.Message)
+		}
+		return nil, fmt.Errorf("OpenAI API error: %s (type: %s)", openAIResp.Error.Message, openAIResp.Error.Type)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if len(openAIResp.Data) != count {
+		return nil, fmt.Errorf("OpenAI returned %d embeddings for %d inputs", len(openAIResp.Data), count)
+	}
+
+	// Convert float64 to float32, placing each embedding at its input index
+	embeddings := make([][]float32, count)
+	for _, d := range openAIResp.Data {
+		if d.Index < 0 || d.Index >= count || len(d.Embedding) == 0 {
+			return nil, fmt.Errorf("empty or misplaced embedding returned from OpenAI")
+		}
+		embedding := make([]float32, len(d.Embedding))
+		for i, v := range d.Embedding {
+			embedding[i] = float32(v)
+		}
+		embeddings[d.Index] = embedding
+	}
+
+	return embeddings, nil
+}
@@ -0,0 +1,177 @@
+package embeddings
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	tokenCLS = "[CLS]"
+	tokenSEP = "[SEP]"
+	tokenUNK = "[UNK]"
+
+	maxWordPieceChars = 100
+)
+
+// wordPieceTokenizer implements the uncased BERT tokenizer used by
+// sentence-transformers models such as all-MiniLM-L6-v2
+type wordPieceTokenizer struct {
+	vocab  map[string]int
+	clsID  int
+	sepID  int
+	unkID  int
+	maxLen int
+}
+
+// loadWordPieceTokenizer reads a vocab.txt file (one token per line)
+func loadWordPieceTokenizer(vocabPath string, maxLen int) (*wordPieceTokenizer, error) {
+	f, err := os.Open(vocabPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer f.Close()
+
+	vocab := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for id := 0; scanner.Scan(); id++ {
+		vocab[strings.TrimRight(scanner.Text(), "\r")] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+
+	t := &wordPieceTokenizer{vocab: vocab, maxLen: maxLen}
+	for token, dst := range map[string]*int{tokenCLS: &t.clsID, tokenSEP: &t.sepID, tokenUNK: &t.unkID} {
+		id, ok := vocab[token]
+		if !ok {
+			return nil, fmt.Errorf("vocabulary is missing special token %s", token)
+		}
+		*dst = id
+	}
+
+	return t, nil
+}
+
+// Encode converts text into token IDs wrapped in [CLS] ... [SEP]
+func (t *wordPieceTokenizer) Encode(text string) []int {
+	ids := []int{t.clsID}
+	for _, word := range basicTokenize(text) {
+		ids = append(ids, t.wordPiece(word)...)
+		if len(ids) >= t.maxLen-1 {
+			ids = ids[:t.maxLen-1]
+			break
+		}
+	}
+	return append(ids, t.sepID)
+}
+
+// wordPiece splits a single word into greedy longest-match subword tokens
+func (t *wordPieceTokenizer) wordPiece(word string) []int {
+	runes := []rune(word)
+	if len(runes) > maxWordPieceChars {
+		return []int{t.unkID}
+	}
+
+	var ids []int
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := -1
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := t.vocab[piece]; ok {
+				found = id
+				break
+			}
+		}
+		if found == -1 {
+			return []int{t.unkID}
+		}
+		ids = append(ids, found)
+		start = end
+	}
+
+	return ids
+}
+
+// basicTokenize cleans, lowercases, strips accents and splits on whitespace
+// and punctuation
+func basicTokenize(text string) []string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar:
+			continue
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		case unicode.IsControl(r):
+			continue
+		case isCJK(r):
+			b.WriteRune(' ')
+			b.WriteRune(r)
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	var tokens []string
+	for _, word := range strings.Fields(b.String()) {
+		word = stripAccents(strings.ToLower(word))
+
+		current := []rune{}
+		for _, r := range word {
+			if isPunctuation(r) {
+				if len(current) > 0 {
+					tokens = append(tokens, string(current))
+					current = current[:0]
+				}
+				tokens = append(tokens, string(r))
+				continue
+			}
+			current = append(current, r)
+		}
+		if len(current) > 0 {
+			tokens = append(tokens, string(current))
+		}
+	}
+
+	return tokens
+}
+
+func stripAccents(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isPunctuation(r rune) bool {
+	// BERT treats all non-alphanumeric ASCII as punctuation
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+func isCJK(r rune) bool {
+	return (r >= 0x4E00 && r <= 0x9FFF) ||
+		(r >= 0x3400 && r <= 0x4DBF) ||
+		(r >= 0x20000 && r <= 0x2A6DF) ||
+		(r >= 0x2A700 && r <= 0x2B73F) ||
+		(r >= 0x2B740 && r <= 0x2B81F) ||
+		(r >= 0x2B820 && r <= 0x2CEAF) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0x2F800 && r <= 0x2FA1F)
+}
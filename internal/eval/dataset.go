@@ -0,0 +1,123 @@
+// Package eval measures retrieval quality on a fixed set of memories and
+// queries, so changes to scoring can be compared by their numbers.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+// Dataset is a set of memories and the queries that should retrieve them
+type Dataset struct {
+	Memories []Memory `json:"memories"`
+	Queries  []Query  `json:"queries"`
+}
+
+// Memory is a memory to load before running the queries. Age backdates it
+// (e.g. "72h") so recency decay takes effect.
+type Memory struct {
+	ID                string   `json:"id"` // Referenced by Query.Relevant
+	Content           string   `json:"content"`
+	Importance        float64  `json:"importance"`
+	ContextType       string   `json:"context_type,omitempty"`
+	TemporalRelevance string   `json:"temporal_relevance,omitempty"`
+	ActionRequired    bool     `json:"action_required,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	TriggerPhrases    []string `json:"trigger_phrases,omitempty"`
+	Age               string   `json:"age,omitempty"`
+}
+
+// Query is a search and the IDs of the dataset memories relevant to it
+type Query struct {
+	Query    string   `json:"query"`
+	Relevant []string `json:"relevant"`
+}
+
+// LoadDataset reads and validates a dataset file
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	var dataset Dataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset %s: %w", path, err)
+	}
+	if err := dataset.validate(); err != nil {
+		return nil, fmt.Errorf("invalid dataset %s: %w", path, err)
+	}
+
+	return &dataset, nil
+}
+
+// validate checks that every memory can be created and every relevant ID
+// names one of them
+func (d *Dataset) validate() error {
+	if len(d.Memories) == 0 || len(d.Queries) == 0 {
+		return fmt.Errorf("needs at least one memory and one query")
+	}
+
+	ids := make(map[string]bool, len(d.Memories))
+	for i, mem := range d.Memories {
+		if mem.ID == "" {
+			return fmt.Errorf("memory %d has no id", i)
+		}
+		if ids[mem.ID] {
+			return fmt.Errorf("duplicate memory id %q", mem.ID)
+		}
+		ids[mem.ID] = true
+
+		if strings.TrimSpace(mem.Content) == "" {
+			return fmt.Errorf("memory %q has no content", mem.ID)
+		}
+		if mem.Importance < 0 || mem.Importance > 1 {
+			return fmt.Errorf("memory %q: importance must be between 0 and 1", mem.ID)
+		}
+		if mem.ContextType != "" {
+			if _, err := memory.ParseContextType(mem.ContextType); err != nil {
+				return fmt.Errorf("memory %q: %w", mem.ID, err)
+			}
+		}
+		switch memory.TemporalRelevance(mem.TemporalRelevance) {
+		case "", memory.TemporalRelevancePersistent, memory.TemporalRelevanceSession, memory.TemporalRelevanceTemporary:
+		default:
+			return fmt.Errorf("memory %q: unknown temporal_relevance %q", mem.ID, mem.TemporalRelevance)
+		}
+		if _, err := mem.age(); err != nil {
+			return fmt.Errorf("memory %q: %w", mem.ID, err)
+		}
+	}
+
+	for i, query := range d.Queries {
+		if strings.TrimSpace(query.Query) == "" {
+			return fmt.Errorf("query %d is empty", i)
+		}
+		if len(query.Relevant) == 0 {
+			return fmt.Errorf("query %q lists no relevant memories", query.Query)
+		}
+		for _, id := range query.Relevant {
+			if !ids[id] {
+				return fmt.Errorf("query %q: unknown memory id %q", query.Query, id)
+			}
+		}
+	}
+	return nil
+}
+
+// age parses Age, which defaults to zero
+func (m *Memory) age() (time.Duration, error) {
+	if m.Age == "" {
+		return 0, nil
+	}
+	age, err := time.ParseDuration(m.Age)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", m.Age)
+	}
+	return age, nil
+}
@@ -0,0 +1,153 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+// Options control how the queries are run
+type Options struct {
+	K             int     // Ranking depth the metrics are computed at
+	MinImportance float64 // Passed to every search, as search_memories does
+}
+
+// QueryResult is the ranking one query produced
+type QueryResult struct {
+	Query    string
+	Relevant []string // Dataset IDs of the relevant memories
+	Ranked   []string // Dataset IDs of the top k direct matches, best first
+	Metrics  Metrics
+}
+
+// Report is the outcome of running a dataset
+type Report struct {
+	K        int
+	Embedder string
+	Metrics  Metrics // Averaged over all queries
+	Queries  []QueryResult
+}
+
+// Run loads the dataset's memories into the project, which should be empty,
+// runs every query through engine and scores the rankings. Memories reached
+// only through relationships are left out of the rankings.
+func Run(ctx context.Context, engine *memory.Engine, projectID string, dataset *Dataset, opts Options) (*Report, error) {
+	if opts.K <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", opts.K)
+	}
+
+	now := time.Now()
+	mems := make([]*memory.Memory, len(dataset.Memories))
+	for i, m := range dataset.Memories {
+		age, err := m.age()
+		if err != nil {
+			return nil, err
+		}
+		mems[i] = &memory.Memory{
+			ProjectID:         projectID,
+			Content:           m.Content,
+			Importance:        m.Importance,
+			ContextType:       memory.ContextType(m.ContextType),
+			TemporalRelevance: memory.TemporalRelevance(m.TemporalRelevance),
+			ActionRequired:    m.ActionRequired,
+			SemanticTags:      m.Tags,
+			TriggerPhrases:    m.TriggerPhrases,
+			CreatedAt:         now.Add(-age),
+		}
+	}
+	if err := engine.CreateMemories(ctx, mems); err != nil {
+		return nil, fmt.Errorf("failed to load memories: %w", err)
+	}
+
+	datasetIDs := make(map[string]string, len(mems))
+	for i, mem := range mems {
+		datasetIDs[mem.ID] = dataset.Memories[i].ID
+	}
+
+	report := &Report{K: opts.K, Embedder: engine.EmbedderID()}
+	var all []Metrics
+	for _, q := range dataset.Queries {
+		results, err := engine.SearchMemories(ctx, &memory.SearchQuery{
+			Query:         q.Query,
+			ProjectID:     projectID,
+			Limit:         opts.K,
+			MinImportance: opts.MinImportance,
+		})
+		if err != nil && !errors.Is(err, memory.ErrNoRelevantMemories) {
+			return nil, fmt.Errorf("failed to search for %q: %w", q.Query, err)
+		}
+
+		var ranked []string
+		for _, result := range results {
+			if !result.GraphExpanded {
+				ranked = append(ranked, datasetIDs[result.Memory.ID])
+			}
+		}
+
+		metrics := score(ranked, q.Relevant, opts.K)
+		all = append(all, metrics)
+		report.Queries = append(report.Queries, QueryResult{
+			Query:    q.Query,
+			Relevant: q.Relevant,
+			Ranked:   ranked,
+			Metrics:  metrics,
+		})
+	}
+	report.Metrics = mean(all)
+
+	return report, nil
+}
+
+// Baseline is a stored report that later runs are compared against
+type Baseline struct {
+	K         int       `json:"k"`
+	Embedder  string    `json:"embedder"`
+	Metrics   Metrics   `json:"metrics"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Comparable reports whether the baseline was measured the same way as
+// report; metrics from another k or embedder are not comparable
+func (b *Baseline) Comparable(report *Report) bool {
+	return b.K == report.K && b.Embedder == report.Embedder
+}
+
+// LoadBaseline reads a baseline file. It returns nil if there is none yet.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+// SaveBaseline stores report as the baseline at path
+func SaveBaseline(path string, report *Report) error {
+	data, err := json.MarshalIndent(Baseline{
+		K:         report.K,
+		Embedder:  report.Embedder,
+		Metrics:   report.Metrics,
+		UpdatedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
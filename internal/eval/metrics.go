@@ -0,0 +1,58 @@
+package eval
+
+import "math"
+
+// Metrics are ranking quality measures averaged over queries. Each is
+// between 0 and 1; higher is better.
+type Metrics struct {
+	RecallAtK float64 `json:"recall_at_k"` // Share of the relevant memories in the top k
+	MRR       float64 `json:"mrr"`         // Mean reciprocal rank of the first relevant memory
+	NDCG      float64 `json:"ndcg_at_k"`   // Normalized discounted cumulative gain at k
+}
+
+// score computes the metrics of a single ranking, truncated to k
+func score(ranked []string, relevant []string, k int) Metrics {
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	isRelevant := make(map[string]bool, len(relevant))
+	for _, id := range relevant {
+		isRelevant[id] = true
+	}
+
+	var m Metrics
+	var found int
+	var dcg float64
+	for i, id := range ranked {
+		if !isRelevant[id] {
+			continue
+		}
+		found++
+		if m.MRR == 0 {
+			m.MRR = 1 / float64(i+1)
+		}
+		dcg += 1 / math.Log2(float64(i+2))
+	}
+
+	// The ideal ranking puts every relevant memory first
+	var ideal float64
+	for i := 0; i < len(relevant) && i < k; i++ {
+		ideal += 1 / math.Log2(float64(i+2))
+	}
+
+	m.RecallAtK = float64(found) / float64(len(relevant))
+	m.NDCG = dcg / ideal
+	return m
+}
+
+// mean averages the metrics of several queries
+func mean(all []Metrics) Metrics {
+	var sum Metrics
+	for _, m := range all {
+		sum.RecallAtK += m.RecallAtK
+		sum.MRR += m.MRR
+		sum.NDCG += m.NDCG
+	}
+	n := float64(len(all))
+	return Metrics{RecallAtK: sum.RecallAtK / n, MRR: sum.MRR / n, NDCG: sum.NDCG / n}
+}
@@ -0,0 +1,224 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// A backup is a single JSON object identified by BackupFormat. Bump
+// BackupVersion when a change would make an importer misread older files;
+// adding fields does not need a new version.
+const (
+	BackupFormat  = "alaala-backup"
+	BackupVersion = 1
+)
+
+// Backup sections, in the order they are written. Each refers only to
+// records in the sections before it, so they can be restored in order.
+const (
+	SectionWorkspaces    = "workspaces"
+	SectionProjects      = "projects"
+	SectionSessions      = "sessions"
+	SectionMemories      = "memories"
+	SectionRelationships = "relationships"
+)
+
+// WorkspaceRecord is a workspace in a backup
+type WorkspaceRecord struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectRecord is a project in a backup
+type ProjectRecord struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	WorkspaceID string    `json:"workspace_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SessionRecord is a session in a backup
+type SessionRecord struct {
+	ID              string     `json:"id"`
+	ProjectID       string     `json:"project_id"`
+	StartedAt       time.Time  `json:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	DurationSeconds *int       `json:"duration_seconds,omitempty"`
+	Summary         string     `json:"summary,omitempty"`
+}
+
+// BackupMemory is a memory in a backup: the JSON lines record plus what is
+// needed to restore it as it was, including its vector
+type BackupMemory struct {
+	MemoryRecord
+	Reasoning        string     `json:"reasoning,omitempty"`
+	ImportanceMethod string     `json:"importance_method,omitempty"`
+	AccessCount      int        `json:"access_count,omitempty"`
+	LastAccessedAt   *time.Time `json:"last_accessed_at,omitempty"`
+	Archived         bool       `json:"archived,omitempty"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	EmbedderID       string     `json:"embedder_id,omitempty"` // Embedder that produced Embedding
+	Embedding        []float32  `json:"embedding,omitempty"`   // Missing if the memory had no stored vector
+}
+
+// RelationshipRecord is a relationship between two memories in a backup
+type RelationshipRecord struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupWriter streams a backup record by record, so that only the record
+// being written is held in memory. Start each section with Section, in the
+// order of the Section constants, and call Close when done.
+type BackupWriter struct {
+	buf     *bufio.Writer
+	section string
+	empty   bool // No record written to the current section yet
+	counts  map[string]int
+	err     error
+}
+
+// NewBackupWriter writes the backup's header
+func NewBackupWriter(w io.Writer, exportedAt time.Time) *BackupWriter {
+	b := &BackupWriter{buf: bufio.NewWriter(w), counts: make(map[string]int)}
+	header, err := json.Marshal(struct {
+		Format     string    `json:"format"`
+		Version    int       `json:"version"`
+		ExportedAt time.Time `json:"exported_at"`
+	}{BackupFormat, BackupVersion, exportedAt})
+	if err != nil {
+		b.err = err
+		return b
+	}
+	// Leave the object open for the sections
+	b.write(header[:len(header)-1])
+	return b
+}
+
+// Section ends the current section and starts the named one
+func (b *BackupWriter) Section(name string) error {
+	if b.section != "" {
+		b.write([]byte("\n]"))
+	}
+	b.write([]byte(fmt.Sprintf(",\n%q: [", name)))
+	b.section = name
+	b.empty = true
+	return b.err
+}
+
+// WriteWorkspace adds a workspace to the current section
+func (b *BackupWriter) WriteWorkspace(w *storage.Workspace) error {
+	return b.record(WorkspaceRecord{ID: w.ID, Name: w.Name, CreatedAt: w.CreatedAt})
+}
+
+// WriteProject adds a project to the current section
+func (b *BackupWriter) WriteProject(p *storage.Project) error {
+	return b.record(ProjectRecord{
+		ID:          p.ID,
+		Name:        p.Name,
+		Path:        p.Path,
+		WorkspaceID: deref(p.WorkspaceID),
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	})
+}
+
+// WriteSession adds a session to the current section
+func (b *BackupWriter) WriteSession(s *storage.Session) error {
+	return b.record(SessionRecord{
+		ID:              s.ID,
+		ProjectID:       s.ProjectID,
+		StartedAt:       s.StartedAt,
+		EndedAt:         s.EndedAt,
+		DurationSeconds: s.DurationSeconds,
+		Summary:         s.Summary,
+	})
+}
+
+// WriteMemory adds a memory and its vector, nil if it has none, to the
+// current section
+func (b *BackupWriter) WriteMemory(mem *storage.Memory, embedding []float32) error {
+	record := BackupMemory{
+		MemoryRecord:     newMemoryRecord(mem),
+		Reasoning:        mem.Reasoning,
+		ImportanceMethod: mem.ImportanceMethod,
+		AccessCount:      mem.AccessCount,
+		LastAccessedAt:   mem.LastAccessedAt,
+		Archived:         mem.Archived,
+		ArchivedAt:       mem.ArchivedAt,
+		Embedding:        embedding,
+	}
+	if embedding != nil {
+		record.EmbedderID = mem.EmbedderID
+	}
+	return b.record(record)
+}
+
+// WriteRelationship adds a relationship to the current section
+func (b *BackupWriter) WriteRelationship(rel *storage.MemoryRelationship) error {
+	return b.record(RelationshipRecord{
+		From:      rel.FromMemoryID,
+		To:        rel.ToMemoryID,
+		Type:      rel.RelationshipType,
+		CreatedAt: rel.CreatedAt,
+	})
+}
+
+// Count returns the number of records written to a section
+func (b *BackupWriter) Count(section string) int {
+	return b.counts[section]
+}
+
+// Close ends the backup and flushes it to the underlying writer
+func (b *BackupWriter) Close() error {
+	if b.section != "" {
+		b.write([]byte("\n]"))
+	}
+	b.write([]byte("\n}\n"))
+	if b.err != nil {
+		return b.err
+	}
+	return b.buf.Flush()
+}
+
+// record writes one record of the current section on its own line
+func (b *BackupWriter) record(v interface{}) error {
+	if b.err != nil {
+		return b.err
+	}
+	if b.section == "" {
+		return fmt.Errorf("backup record written before its section")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.err = err
+		return err
+	}
+	if b.empty {
+		b.write([]byte("\n"))
+	} else {
+		b.write([]byte(",\n"))
+	}
+	b.write(data)
+	b.empty = false
+	b.counts[b.section]++
+	return b.err
+}
+
+// write writes raw bytes, remembering the first error
+func (b *BackupWriter) write(p []byte) {
+	if b.err != nil {
+		return
+	}
+	_, b.err = b.buf.Write(p)
+}
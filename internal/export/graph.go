@@ -0,0 +1,147 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+const (
+	// labelLength is the maximum number of characters in a node label
+	labelLength = 60
+)
+
+// contextColors maps context types to node colors
+var contextColors = map[string]string{
+	"TECHNICAL_IMPLEMENTATION": "#4e79a7",
+	"ARCHITECTURE":             "#f28e2b",
+	"DECISION":                 "#e15759",
+	"BREAKTHROUGH":             "#76b7b2",
+	"RELATIONSHIP":             "#59a14f",
+	"UNRESOLVED":               "#edc948",
+	"MILESTONE":                "#b07aa1",
+	"PREFERENCE":               "#ff9da7",
+}
+
+const defaultColor = "#bab0ac"
+
+// WriteDOT writes the graph in Graphviz DOT format
+func WriteDOT(w io.Writer, name string, graph *storage.ProjectGraph) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(name))
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, node := range graph.Nodes {
+		contextType := deref(node.ContextType)
+		fmt.Fprintf(&b, "  %s [label=%s, fillcolor=%s, width=%.2f, tooltip=%s];\n",
+			dotQuote(node.ID),
+			dotQuote(summarize(node.Content)),
+			dotQuote(colorFor(contextType)),
+			nodeSize(node.Importance),
+			dotQuote(contextType))
+	}
+
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n",
+			dotQuote(edge.FromMemoryID),
+			dotQuote(edge.ToMemoryID),
+			dotQuote(edge.RelationshipType))
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteGraphML writes the graph in GraphML format (readable by Gephi)
+func WriteGraphML(w io.Writer, name string, graph *storage.ProjectGraph) error {
+	var b strings.Builder
+
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="context_type" for="node" attr.name="context_type" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="importance" for="node" attr.name="importance" attr.type="double"/>` + "\n")
+	b.WriteString(`  <key id="color" for="node" attr.name="color" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="size" for="node" attr.name="size" attr.type="double"/>` + "\n")
+	b.WriteString(`  <key id="type" for="edge" attr.name="type" attr.type="string"/>` + "\n")
+	fmt.Fprintf(&b, "  <graph id=\"%s\" edgedefault=\"directed\">\n", xmlEscape(name))
+
+	for _, node := range graph.Nodes {
+		contextType := deref(node.ContextType)
+		fmt.Fprintf(&b, "    <node id=\"%s\">\n", xmlEscape(node.ID))
+		fmt.Fprintf(&b, "      <data key=\"label\">%s</data>\n", xmlEscape(summarize(node.Content)))
+		fmt.Fprintf(&b, "      <data key=\"context_type\">%s</data>\n", xmlEscape(contextType))
+		fmt.Fprintf(&b, "      <data key=\"importance\">%.3f</data>\n", node.Importance)
+		fmt.Fprintf(&b, "      <data key=\"color\">%s</data>\n", colorFor(contextType))
+		fmt.Fprintf(&b, "      <data key=\"size\">%.2f</data>\n", nodeSize(node.Importance))
+		b.WriteString("    </node>\n")
+	}
+
+	for i, edge := range graph.Edges {
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n",
+			i, xmlEscape(edge.FromMemoryID), xmlEscape(edge.ToMemoryID))
+		fmt.Fprintf(&b, "      <data key=\"type\">%s</data>\n", xmlEscape(edge.RelationshipType))
+		b.WriteString("    </edge>\n")
+	}
+
+	b.WriteString("  </graph>\n</graphml>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// summarize shortens memory content to a single-line label
+func summarize(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) <= labelLength {
+		return content
+	}
+	return string(runes[:labelLength-3]) + "..."
+}
+
+// nodeSize scales importance (0-1) to a node width
+func nodeSize(importance float64) float64 {
+	return 0.75 + importance*1.5
+}
+
+func colorFor(contextType string) string {
+	if color, ok := contextColors[contextType]; ok {
+		return color
+	}
+	return defaultColor
+}
+
+// dotQuote returns s as a quoted DOT ID with quotes, backslashes and line
+// breaks escaped. DOT has no escape for a backslash before the closing
+// quote, so a trailing backslash is followed by a space.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`)
+	quoted := r.Replace(s)
+	if strings.HasSuffix(quoted, `\`) {
+		quoted += " "
+	}
+	return `"` + quoted + `"`
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
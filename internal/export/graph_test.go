@@ -0,0 +1,315 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// awkwardGraph has content that needs escaping in both formats
+func awkwardGraph() *storage.ProjectGraph {
+	decision := "DECISION"
+	return &storage.ProjectGraph{
+		Nodes: []storage.Memory{
+			{ID: "a", Content: `Use "quoted" names; {braces} and -> arrows`, Importance: 0.9, ContextType: &decision},
+			{ID: "b", Content: "Line one\nline two\r\nwith a trailing backslash \\", Importance: 0.1},
+			{ID: "c\"d", Content: "Ünïcödé 日本語 <tag> & ampersand", Importance: 0.5},
+		},
+		Edges: []storage.MemoryRelationship{
+			{FromMemoryID: "a", ToMemoryID: "b", RelationshipType: "supersedes"},
+			{FromMemoryID: "b", ToMemoryID: "c\"d", RelationshipType: "relates \"to\""},
+		},
+	}
+}
+
+func TestWriteDOTParses(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, `my "project"`, awkwardGraph()); err != nil {
+		t.Fatalf("WriteDOT: %v", err)
+	}
+
+	nodes, edges, err := checkDOT(buf.String())
+	if err != nil {
+		t.Fatalf("output is not valid DOT: %v\n%s", err, buf.String())
+	}
+	if nodes != 3 || edges != 2 {
+		t.Errorf("parsed %d nodes and %d edges, want 3 and 2\n%s", nodes, edges, buf.String())
+	}
+}
+
+func TestWriteDOTEmptyGraph(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, "", &storage.ProjectGraph{}); err != nil {
+		t.Fatalf("WriteDOT: %v", err)
+	}
+	if _, _, err := checkDOT(buf.String()); err != nil {
+		t.Fatalf("output is not valid DOT: %v\n%s", err, buf.String())
+	}
+}
+
+func TestWriteGraphMLParses(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, `my "project" <x>`, awkwardGraph()); err != nil {
+		t.Fatalf("WriteGraphML: %v", err)
+	}
+
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 {
+		t.Fatalf("parsed %d nodes and %d edges, want 3 and 2", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	if got := doc.Graph.Nodes[2].ID; got != `c"d` {
+		t.Errorf("node ID = %q, want %q", got, `c"d`)
+	}
+	if got := doc.Graph.Edges[1].Target; got != `c"d` {
+		t.Errorf("edge target = %q, want %q", got, `c"d`)
+	}
+	for _, data := range doc.Graph.Nodes[2].Data {
+		if data.Key == "label" && data.Value != "Ünïcödé 日本語 <tag> & ampersand" {
+			t.Errorf("label = %q", data.Value)
+		}
+	}
+}
+
+// checkDOT parses src against the DOT grammar
+// (https://graphviz.org/doc/info/lang.html), minus subgraphs and HTML
+// strings, which WriteDOT never produces. It returns the number of node and
+// edge statements.
+func checkDOT(src string) (nodes, edges int, err error) {
+	p := &dotParser{tokens: tokenizeDOT(src)}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	if p.peekKeyword("strict") {
+		p.next()
+	}
+	if !p.peekKeyword("digraph") && !p.peekKeyword("graph") {
+		p.fail("graph or digraph")
+	}
+	p.next()
+	if p.peek().kind == tokID {
+		p.next()
+	}
+	p.expect("{")
+	for p.peek().text != "}" {
+		switch p.statement() {
+		case "node":
+			nodes++
+		case "edge":
+			edges++
+		}
+		if p.peek().text == ";" {
+			p.next()
+		}
+	}
+	p.expect("}")
+	if p.peek().kind != tokEOF {
+		p.fail("end of input")
+	}
+	return nodes, edges, nil
+}
+
+type dotTokenKind int
+
+const (
+	tokEOF dotTokenKind = iota
+	tokID
+	tokPunct
+)
+
+type dotToken struct {
+	kind dotTokenKind
+	text string
+}
+
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+}
+
+func (p *dotParser) peek() dotToken {
+	if p.pos >= len(p.tokens) {
+		return dotToken{kind: tokEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *dotParser) next() dotToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *dotParser) fail(want string) {
+	panic(fmt.Sprintf("token %d: want %s, got %q", p.pos, want, p.peek().text))
+}
+
+func (p *dotParser) expect(punct string) {
+	if tok := p.peek(); tok.kind != tokPunct || tok.text != punct {
+		p.fail(punct)
+	}
+	p.next()
+}
+
+func (p *dotParser) peekKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokID && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *dotParser) id() {
+	if p.peek().kind != tokID {
+		p.fail("an ID")
+	}
+	p.next()
+}
+
+// statement parses one statement and returns its kind
+func (p *dotParser) statement() string {
+	if p.peekKeyword("node") || p.peekKeyword("edge") || p.peekKeyword("graph") {
+		p.next()
+		p.attrLists(true)
+		return "attr"
+	}
+
+	p.id()
+	if p.peek().text == "=" {
+		p.next()
+		p.id()
+		return "assign"
+	}
+
+	kind := "node"
+	for p.peek().text == "->" || p.peek().text == "--" {
+		p.next()
+		p.id()
+		kind = "edge"
+	}
+	p.attrLists(false)
+	return kind
+}
+
+// attrLists parses a sequence of [a=b, ...] lists, at least one if required
+func (p *dotParser) attrLists(required bool) {
+	if required && p.peek().text != "[" {
+		p.fail("[")
+	}
+	for p.peek().text == "[" {
+		p.next()
+		for p.peek().text != "]" {
+			p.id()
+			p.expect("=")
+			p.id()
+			if t := p.peek().text; t == "," || t == ";" {
+				p.next()
+			}
+		}
+		p.expect("]")
+	}
+}
+
+// tokenizeDOT splits DOT source into IDs and punctuation. A string left
+// open makes the rest of the input one invalid token.
+func tokenizeDOT(src string) []dotToken {
+	var tokens []dotToken
+	r := strings.NewReader(src)
+	for {
+		c, _, err := r.ReadRune()
+		if err == io.EOF {
+			return tokens
+		}
+		switch {
+		case unicode.IsSpace(c):
+		case strings.ContainsRune("{}[]=;,", c):
+			tokens = append(tokens, dotToken{tokPunct, string(c)})
+		case c == '-':
+			d, _, _ := r.ReadRune()
+			if d == '>' || d == '-' {
+				tokens = append(tokens, dotToken{tokPunct, string(c) + string(d)})
+				continue
+			}
+			_ = r.UnreadRune()
+			tokens = append(tokens, dotToken{tokID, "-" + readWhile(r, isDOTNumeral)})
+		case c == '"':
+			var b strings.Builder
+			closed := false
+			for !closed {
+				d, _, err := r.ReadRune()
+				if err == io.EOF {
+					return append(tokens, dotToken{tokPunct, "unterminated string"})
+				}
+				switch d {
+				case '\\':
+					// As in Graphviz's lexer, a backslash only escapes a
+					// quote or a line break; otherwise it stands for itself
+					b.WriteRune(d)
+					if e, _, err := r.ReadRune(); err == nil {
+						if e == '"' || e == '\n' {
+							b.WriteRune(e)
+						} else {
+							_ = r.UnreadRune()
+						}
+					}
+				case '"':
+					closed = true
+				default:
+					b.WriteRune(d)
+				}
+			}
+			tokens = append(tokens, dotToken{tokID, b.String()})
+		case c == '_' || unicode.IsLetter(c):
+			_ = r.UnreadRune()
+			tokens = append(tokens, dotToken{tokID, readWhile(r, func(c rune) bool {
+				return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+			})})
+		case isDOTNumeral(c):
+			_ = r.UnreadRune()
+			tokens = append(tokens, dotToken{tokID, readWhile(r, isDOTNumeral)})
+		default:
+			tokens = append(tokens, dotToken{tokPunct, string(c)})
+		}
+	}
+}
+
+func isDOTNumeral(c rune) bool {
+	return c == '.' || c >= '0' && c <= '9'
+}
+
+func readWhile(r *strings.Reader, ok func(rune) bool) string {
+	var b strings.Builder
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return b.String()
+		}
+		if !ok(c) {
+			_ = r.UnreadRune()
+			return b.String()
+		}
+		b.WriteRune(c)
+	}
+}
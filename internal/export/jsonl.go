@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// MemoryRecord is one line of a JSON lines export
+type MemoryRecord struct {
+	ID                string    `json:"id"`
+	ProjectID         string    `json:"project_id"`
+	SessionID         string    `json:"session_id,omitempty"`
+	Content           string    `json:"content"`
+	Importance        float64   `json:"importance"`
+	ContextType       string    `json:"context_type,omitempty"`
+	TemporalRelevance string    `json:"temporal_relevance,omitempty"`
+	ActionRequired    bool      `json:"action_required"`
+	Tags              []string  `json:"tags,omitempty"`
+	TriggerPhrases    []string  `json:"trigger_phrases,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// JSONLWriter writes memories as JSON lines, one memory per line, so an
+// export can be streamed without holding the project in memory
+type JSONLWriter struct {
+	buf   *bufio.Writer
+	enc   *json.Encoder
+	count int
+}
+
+// NewJSONLWriter creates a JSON lines writer. Call Flush when done.
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	buf := bufio.NewWriter(w)
+	return &JSONLWriter{
+		buf: buf,
+		enc: json.NewEncoder(buf),
+	}
+}
+
+// Write writes a single memory
+func (j *JSONLWriter) Write(mem *storage.Memory) error {
+	if err := j.enc.Encode(newMemoryRecord(mem)); err != nil {
+		return err
+	}
+	j.count++
+	return nil
+}
+
+// newMemoryRecord converts a stored memory to its exported form
+func newMemoryRecord(mem *storage.Memory) MemoryRecord {
+	return MemoryRecord{
+		ID:                mem.ID,
+		ProjectID:         mem.ProjectID,
+		SessionID:         deref(mem.SessionID),
+		Content:           mem.Content,
+		Importance:        mem.Importance,
+		ContextType:       deref(mem.ContextType),
+		TemporalRelevance: deref(mem.TemporalRelevance),
+		ActionRequired:    mem.ActionRequired,
+		Tags:              mem.Tags,
+		TriggerPhrases:    mem.TriggerPhrases,
+		CreatedAt:         mem.CreatedAt,
+		UpdatedAt:         mem.UpdatedAt,
+	}
+}
+
+// Count returns the number of memories written
+func (j *JSONLWriter) Count() int {
+	return j.count
+}
+
+// Flush writes any buffered data to the underlying writer
+func (j *JSONLWriter) Flush() error {
+	return j.buf.Flush()
+}
@@ -0,0 +1,219 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// backupSections lists the sections in the order BackupWriter writes them
+var backupSections = []string{SectionWorkspaces, SectionProjects, SectionSessions, SectionMemories, SectionRelationships}
+
+// BackupReader streams the records of a backup written by BackupWriter, so
+// that only the record being read is held in memory
+type BackupReader struct {
+	Version    int
+	ExportedAt time.Time
+
+	dec     *json.Decoder
+	pending string // Key read while reading the header
+	section string // Section whose records are being read, if any
+	last    int    // Index in backupSections of the last section started
+	done    bool
+}
+
+// NewBackupReader reads a backup's header, failing if r does not hold a
+// backup or holds one from a newer version of alaala
+func NewBackupReader(r io.Reader) (*BackupReader, error) {
+	b := &BackupReader{dec: json.NewDecoder(bufio.NewReader(r)), last: -1}
+	if tok, err := b.dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("not an alaala backup: expected a JSON object")
+	}
+
+	var format string
+header:
+	for b.dec.More() {
+		key, err := b.key()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "format":
+			err = b.dec.Decode(&format)
+		case "version":
+			err = b.dec.Decode(&b.Version)
+		case "exported_at":
+			err = b.dec.Decode(&b.ExportedAt)
+		default:
+			b.pending = key
+			break header
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup header: %w", err)
+		}
+	}
+
+	if format != BackupFormat {
+		return nil, fmt.Errorf("not an alaala backup: format is %q, expected %q", format, BackupFormat)
+	}
+	if b.Version < 1 || b.Version > BackupVersion {
+		return nil, fmt.Errorf("backup version %d is not supported (this alaala reads up to version %d)", b.Version, BackupVersion)
+	}
+	return b, nil
+}
+
+// Next returns the section and the JSON of the next record, to be decoded
+// into the section's record type. It returns io.EOF after the last record.
+// Keys it does not know are skipped, so that backups with sections added
+// later can still be read.
+func (b *BackupReader) Next() (string, json.RawMessage, error) {
+	for !b.done {
+		if b.section != "" {
+			if b.dec.More() {
+				var raw json.RawMessage
+				if err := b.dec.Decode(&raw); err != nil {
+					return "", nil, fmt.Errorf("invalid %s record: %w", b.section, err)
+				}
+				return b.section, raw, nil
+			}
+			if _, err := b.dec.Token(); err != nil { // The closing ]
+				return "", nil, err
+			}
+			b.section = ""
+		}
+
+		key := b.pending
+		b.pending = ""
+		if key == "" {
+			if !b.dec.More() {
+				// The closing } tells a complete backup from a truncated one
+				if _, err := b.dec.Token(); err != nil {
+					return "", nil, fmt.Errorf("backup is incomplete: %w", err)
+				}
+				b.done = true
+				break
+			}
+			var err error
+			if key, err = b.key(); err != nil {
+				return "", nil, err
+			}
+		}
+
+		index := sectionIndex(key)
+		if index < 0 {
+			var skipped json.RawMessage
+			if err := b.dec.Decode(&skipped); err != nil {
+				return "", nil, fmt.Errorf("invalid backup field %q: %w", key, err)
+			}
+			continue
+		}
+		// Each section refers to records in the sections before it
+		if index < b.last {
+			return "", nil, fmt.Errorf("backup section %q comes after %q", key, backupSections[b.last])
+		}
+		if tok, err := b.dec.Token(); err != nil || tok != json.Delim('[') {
+			return "", nil, fmt.Errorf("backup section %q is not a list", key)
+		}
+		b.section = key
+		b.last = index
+	}
+	return "", nil, io.EOF
+}
+
+// key reads an object key
+func (b *BackupReader) key() (string, error) {
+	tok, err := b.dec.Token()
+	if err != nil {
+		return "", fmt.Errorf("invalid backup: %w", err)
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid backup: unexpected %v", tok)
+	}
+	return key, nil
+}
+
+func sectionIndex(name string) int {
+	for i, section := range backupSections {
+		if section == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Workspace converts the record to the stored form
+func (r *WorkspaceRecord) Workspace() *storage.Workspace {
+	return &storage.Workspace{ID: r.ID, Name: r.Name, CreatedAt: r.CreatedAt}
+}
+
+// Project converts the record to the stored form
+func (r *ProjectRecord) Project() *storage.Project {
+	return &storage.Project{
+		ID:          r.ID,
+		Name:        r.Name,
+		Path:        r.Path,
+		WorkspaceID: ref(r.WorkspaceID),
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+// Session converts the record to the stored form
+func (r *SessionRecord) Session() *storage.Session {
+	return &storage.Session{
+		ID:              r.ID,
+		ProjectID:       r.ProjectID,
+		StartedAt:       r.StartedAt,
+		EndedAt:         r.EndedAt,
+		DurationSeconds: r.DurationSeconds,
+		Summary:         r.Summary,
+	}
+}
+
+// Memory converts the record to the stored form; its vector is in Embedding
+func (m *BackupMemory) Memory() *storage.Memory {
+	return &storage.Memory{
+		ID:                m.ID,
+		ProjectID:         m.ProjectID,
+		SessionID:         ref(m.SessionID),
+		Content:           m.Content,
+		Importance:        m.Importance,
+		ContextType:       ref(m.ContextType),
+		TemporalRelevance: ref(m.TemporalRelevance),
+		ActionRequired:    m.ActionRequired,
+		Tags:              m.Tags,
+		TriggerPhrases:    m.TriggerPhrases,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+		EmbedderID:        m.EmbedderID,
+		Reasoning:         m.Reasoning,
+		ImportanceMethod:  m.ImportanceMethod,
+		AccessCount:       m.AccessCount,
+		LastAccessedAt:    m.LastAccessedAt,
+		Archived:          m.Archived,
+		ArchivedAt:        m.ArchivedAt,
+	}
+}
+
+// Relationship converts the record to the stored form
+func (r *RelationshipRecord) Relationship() *storage.MemoryRelationship {
+	return &storage.MemoryRelationship{
+		FromMemoryID:     r.From,
+		ToMemoryID:       r.To,
+		RelationshipType: r.Type,
+		CreatedAt:        r.CreatedAt,
+	}
+}
+
+// ref is the inverse of deref: empty strings become nil
+func ref(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,206 @@
+// Package locale renders the few strings alaala generates for the user,
+// such as how long ago the last session was, in the configured language.
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultLocale is used for locales without a bundle
+const DefaultLocale = "en"
+
+// unit is a unit of time a duration is rounded to
+type unit int
+
+const (
+	minute unit = iota
+	hour
+	day
+	week
+	month
+)
+
+// bundle holds a locale's strings
+type bundle struct {
+	justNow string
+	ago     string       // Wraps a count and unit, e.g. "%s ago"
+	units   [5][2]string // Singular and plural of each unit, as used in ago
+	date    string       // time.Format layout for absolute dates
+	first   string       // The primer of a project's first session
+	more    string       // Omitted memories, the limit and the next offset
+	cut     string       // Marks text cut to fit
+}
+
+var bundles = map[string]*bundle{
+	"en": {
+		justNow: "just now",
+		ago:     "%s ago",
+		units: [5][2]string{
+			{"minute", "minutes"},
+			{"hour", "hours"},
+			{"day", "days"},
+			{"week", "weeks"},
+			{"month", "months"},
+		},
+		date:  "Jan 2, 2006",
+		first: "This is the first session for this project.",
+		more: "%d more memories not shown (limit %d). Use ?offset=%d for the next page, " +
+			"narrow with ?context_type= or ?tag=, or use search_memories.",
+		cut: "truncated",
+	},
+	"de": {
+		justNow: "gerade eben",
+		ago:     "vor %s",
+		units: [5][2]string{
+			{"Minute", "Minuten"},
+			{"Stunde", "Stunden"},
+			{"Tag", "Tagen"},
+			{"Woche", "Wochen"},
+			{"Monat", "Monaten"},
+		},
+		date:  "02.01.2006",
+		first: "Dies ist die erste Sitzung für dieses Projekt.",
+		more: "%d weitere Erinnerungen nicht angezeigt (Limit %d). ?offset=%d lädt die nächste Seite, " +
+			"?context_type= oder ?tag= grenzen ein, oder search_memories verwenden.",
+		cut: "gekürzt",
+	},
+	"es": {
+		justNow: "justo ahora",
+		ago:     "hace %s",
+		units: [5][2]string{
+			{"minuto", "minutos"},
+			{"hora", "horas"},
+			{"día", "días"},
+			{"semana", "semanas"},
+			{"mes", "meses"},
+		},
+		date:  "02/01/2006",
+		first: "Esta es la primera sesión de este proyecto.",
+		more: "%d recuerdos más no mostrados (límite %d). Usa ?offset=%d para la página siguiente, " +
+			"filtra con ?context_type= o ?tag=, o usa search_memories.",
+		cut: "truncado",
+	},
+	"fr": {
+		justNow: "à l'instant",
+		ago:     "il y a %s",
+		units: [5][2]string{
+			{"minute", "minutes"},
+			{"heure", "heures"},
+			{"jour", "jours"},
+			{"semaine", "semaines"},
+			{"mois", "mois"},
+		},
+		date:  "02/01/2006",
+		first: "C'est la première session de ce projet.",
+		more: "%d souvenirs supplémentaires non affichés (limite %d). Utilisez ?offset=%d pour la page suivante, " +
+			"affinez avec ?context_type= ou ?tag=, ou utilisez search_memories.",
+		cut: "tronqué",
+	},
+}
+
+// Localizer renders generated strings in one locale
+type Localizer struct {
+	locale string
+	bundle *bundle
+}
+
+// New returns a localizer for a locale such as "de" or "de-AT". A region
+// without its own bundle falls back to its language, and a language
+// without one to English; Supported tells whether that happens.
+func New(locale string) *Localizer {
+	name := language(locale)
+	if b, ok := bundles[name]; ok {
+		return &Localizer{locale: name, bundle: b}
+	}
+	return &Localizer{locale: DefaultLocale, bundle: bundles[DefaultLocale]}
+}
+
+// Supported reports whether a locale's language has a bundle
+func Supported(locale string) bool {
+	_, ok := bundles[language(locale)]
+	return ok
+}
+
+// Locales lists the locales with a bundle
+func Locales() []string {
+	locales := make([]string, 0, len(bundles))
+	for locale := range bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// language reduces a locale such as "de_DE.UTF-8" to its language
+func language(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_."); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// Locale returns the locale whose strings are rendered
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Ago describes a time d in the past, e.g. "3 weeks ago"
+func (l *Localizer) Ago(d time.Duration) string {
+	if d < time.Minute {
+		return l.bundle.justNow
+	}
+
+	var n int
+	var u unit
+	switch days := int(d.Hours() / 24); {
+	case d < time.Hour:
+		n, u = int(d.Minutes()), minute
+	case d < 24*time.Hour:
+		n, u = int(d.Hours()), hour
+	case days < 7:
+		n, u = days, day
+	case days < 28:
+		n, u = days/7, week
+	default:
+		n, u = days/30, month
+		if n < 1 {
+			n = 1
+		}
+	}
+
+	name := l.bundle.units[u][1]
+	if n == 1 {
+		name = l.bundle.units[u][0]
+	}
+	return fmt.Sprintf(l.bundle.ago, fmt.Sprintf("%d %s", n, name))
+}
+
+// Date renders the calendar date of t in local time
+func (l *Localizer) Date(t time.Time) string {
+	return t.Local().Format(l.bundle.date)
+}
+
+// When renders t as how long ago it was followed by its date, e.g.
+// "3 weeks ago (Sep 25, 2026)"
+func (l *Localizer) When(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", l.Ago(time.Since(t)), l.Date(t))
+}
+
+// FirstSession notes that a project has no earlier session
+func (l *Localizer) FirstSession() string {
+	return l.bundle.first
+}
+
+// MoreMemories notes that a page of memories left some out
+func (l *Localizer) MoreMemories(omitted, limit, nextOffset int) string {
+	return fmt.Sprintf(l.bundle.more, omitted, limit, nextOffset)
+}
+
+// Truncated marks text cut to fit
+func (l *Localizer) Truncated() string {
+	return l.bundle.cut
+}
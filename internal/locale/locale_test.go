@@ -0,0 +1,152 @@
+package locale
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	days   = 24 * time.Hour
+	weeks  = 7 * days
+	months = 30 * days
+)
+
+func TestAgo(t *testing.T) {
+	tests := []struct {
+		locale string
+		d      time.Duration
+		want   string
+	}{
+		{"en", 30 * time.Second, "just now"},
+		{"en", time.Minute, "1 minute ago"},
+		{"en", 45 * time.Minute, "45 minutes ago"},
+		{"en", time.Hour, "1 hour ago"},
+		{"en", 23 * time.Hour, "23 hours ago"},
+		{"en", days, "1 day ago"},
+		{"en", 6 * days, "6 days ago"},
+		{"en", weeks, "1 week ago"},
+		{"en", 3 * weeks, "3 weeks ago"},
+		{"en", 28 * days, "1 month ago"},
+		{"en", 29 * days, "1 month ago"},
+		{"en", 3 * months, "3 months ago"},
+
+		{"de", 30 * time.Second, "gerade eben"},
+		{"de", time.Minute, "vor 1 Minute"},
+		{"de", 2 * time.Hour, "vor 2 Stunden"},
+		{"de", days, "vor 1 Tag"},
+		{"de", 3 * weeks, "vor 3 Wochen"},
+		{"de", 2 * months, "vor 2 Monaten"},
+
+		{"es", 30 * time.Second, "justo ahora"},
+		{"es", time.Hour, "hace 1 hora"},
+		{"es", 5 * days, "hace 5 días"},
+		{"es", weeks, "hace 1 semana"},
+		{"es", 4 * months, "hace 4 meses"},
+
+		{"fr", 30 * time.Second, "à l'instant"},
+		{"fr", 10 * time.Minute, "il y a 10 minutes"},
+		{"fr", days, "il y a 1 jour"},
+		{"fr", 2 * weeks, "il y a 2 semaines"},
+		{"fr", months, "il y a 1 mois"},
+		{"fr", 2 * months, "il y a 2 mois"},
+	}
+	for _, tt := range tests {
+		if got := New(tt.locale).Ago(tt.d); got != tt.want {
+			t.Errorf("%s: Ago(%v) = %q, want %q", tt.locale, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestDate(t *testing.T) {
+	date := time.Date(2026, time.September, 5, 15, 4, 0, 0, time.Local)
+	want := map[string]string{
+		"en": "Sep 5, 2026",
+		"de": "05.09.2026",
+		"es": "05/09/2026",
+		"fr": "05/09/2026",
+	}
+	for locale, want := range want {
+		if got := New(locale).Date(date); got != want {
+			t.Errorf("%s: Date = %q, want %q", locale, got, want)
+		}
+	}
+}
+
+func TestWhen(t *testing.T) {
+	date := time.Now().Add(-3 * weeks)
+	want := map[string]string{
+		"en": "3 weeks ago (" + date.Format("Jan 2, 2006") + ")",
+		"de": "vor 3 Wochen (" + date.Format("02.01.2006") + ")",
+		"es": "hace 3 semanas (" + date.Format("02/01/2006") + ")",
+		"fr": "il y a 3 semaines (" + date.Format("02/01/2006") + ")",
+	}
+	for locale, want := range want {
+		if got := New(locale).When(date); got != want {
+			t.Errorf("%s: When = %q, want %q", locale, got, want)
+		}
+	}
+}
+
+func TestNotes(t *testing.T) {
+	tests := []struct {
+		locale    string
+		first     string
+		more      string
+		truncated string
+	}{
+		{"en", "This is the first session for this project.", "7 more memories not shown (limit 20). Use ?offset=40", "truncated"},
+		{"de", "Dies ist die erste Sitzung für dieses Projekt.", "7 weitere Erinnerungen nicht angezeigt (Limit 20). ?offset=40", "gekürzt"},
+		{"es", "Esta es la primera sesión de este proyecto.", "7 recuerdos más no mostrados (límite 20). Usa ?offset=40", "truncado"},
+		{"fr", "C'est la première session de ce projet.", "7 souvenirs supplémentaires non affichés (limite 20). Utilisez ?offset=40", "tronqué"},
+	}
+	for _, tt := range tests {
+		l := New(tt.locale)
+		if got := l.FirstSession(); got != tt.first {
+			t.Errorf("%s: FirstSession = %q, want %q", tt.locale, got, tt.first)
+		}
+		if got := l.MoreMemories(7, 20, 40); !strings.HasPrefix(got, tt.more) {
+			t.Errorf("%s: MoreMemories = %q, want it to start with %q", tt.locale, got, tt.more)
+		}
+		if got := l.Truncated(); got != tt.truncated {
+			t.Errorf("%s: Truncated = %q, want %q", tt.locale, got, tt.truncated)
+		}
+	}
+}
+
+func TestNewFallsBack(t *testing.T) {
+	tests := []struct {
+		locale    string
+		want      string
+		supported bool
+	}{
+		{"de", "de", true},
+		{"DE", "de", true},
+		{"de-AT", "de", true},
+		{"de_DE.UTF-8", "de", true},
+		{" fr-CA ", "fr", true},
+		{"es_419", "es", true},
+		{"pt-BR", "en", false},
+		{"", "en", false},
+		{"C.UTF-8", "en", false},
+	}
+	for _, tt := range tests {
+		if got := New(tt.locale).Locale(); got != tt.want {
+			t.Errorf("New(%q).Locale() = %q, want %q", tt.locale, got, tt.want)
+		}
+		if got := Supported(tt.locale); got != tt.supported {
+			t.Errorf("Supported(%q) = %v, want %v", tt.locale, got, tt.supported)
+		}
+	}
+
+	if got := New("pt").Ago(2 * time.Hour); got != "2 hours ago" {
+		t.Errorf("fallback Ago = %q, want English", got)
+	}
+}
+
+func TestLocales(t *testing.T) {
+	if got, want := Locales(), []string{"de", "en", "es", "fr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Locales() = %v, want %v", got, want)
+	}
+}
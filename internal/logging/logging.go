@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseLevel parses a level name: debug, info, warn or error. An empty name
+// means info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q (valid: debug, info, warn, error)", name)
+	}
+}
+
+// New creates a logger at the given level that writes to file. Errors are
+// copied to stderr so fatal problems stay visible to the MCP client. If file
+// is empty or cannot be opened the logger writes to stderr only. Close the
+// returned closer on exit.
+func New(level, file string) (*slog.Logger, io.Closer, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stderr := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: lvl})
+	if file == "" {
+		return slog.New(stderr), io.NopCloser(nil), nil
+	}
+
+	f, err := OpenFile(file)
+	if err != nil {
+		logger := slog.New(stderr)
+		logger.Warn("failed to open log file, logging to stderr", "file", file, "error", err)
+		return logger, io.NopCloser(nil), nil
+	}
+
+	handler := &teeHandler{
+		primary: slog.NewTextHandler(f, &slog.HandlerOptions{Level: lvl}),
+		errors:  slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}),
+	}
+	return slog.New(handler), f, nil
+}
+
+// OpenFile opens file for appending, expanding a leading ~ and creating
+// its directory
+func OpenFile(file string) (*os.File, error) {
+	file, err := ExpandHome(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// ExpandHome replaces a leading ~/ in path with the home directory
+func ExpandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[2:]), nil
+}
+
+// teeHandler sends records to the primary handler and errors to a second one
+type teeHandler struct {
+	primary slog.Handler
+	errors  slog.Handler
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.primary.Enabled(ctx, level) || h.errors.Enabled(ctx, level)
+}
+
+func (h *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.primary.Enabled(ctx, r.Level) {
+		err = h.primary.Handle(ctx, r.Clone())
+	}
+	if h.errors.Enabled(ctx, r.Level) {
+		if e := h.errors.Handle(ctx, r.Clone()); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &teeHandler{primary: h.primary.WithAttrs(attrs), errors: h.errors.WithAttrs(attrs)}
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	return &teeHandler{primary: h.primary.WithGroup(name), errors: h.errors.WithGroup(name)}
+}
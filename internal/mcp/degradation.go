@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+// Fallbacks the server records besides the engine's
+const (
+	degradedPrimerCompression memory.Degradation = "AI unavailable — primer truncated instead of compressed"
+	degradedSessionSummary    memory.Degradation = "AI unavailable — session summary cut from the transcript"
+)
+
+// degradationWarning is the line put before a response's text when
+// fallbacks were taken for it, so that the model does not present degraded
+// results with full confidence
+func degradationWarning(degraded []memory.Degradation) string {
+	if len(degraded) == 0 {
+		return ""
+	}
+	reasons := make([]string, len(degraded))
+	for i, d := range degraded {
+		reasons[i] = string(d)
+	}
+	return "Warning: degraded results (" + strings.Join(reasons, "; ") + ")\n\n"
+}
+
+// reportDegradation adds the fallbacks recorded in ctx to a tool result: a
+// degradation list in its structured content and a warning line before its
+// text
+func reportDegradation(ctx context.Context, result interface{}) interface{} {
+	degraded := memory.Degradations(ctx)
+	response, ok := result.(map[string]interface{})
+	if len(degraded) == 0 || !ok {
+		return result
+	}
+
+	if content, ok := response["content"].([]map[string]interface{}); ok && len(content) > 0 {
+		if text, ok := content[0]["text"].(string); ok {
+			content[0]["text"] = degradationWarning(degraded) + text
+		}
+	}
+
+	reasons := make([]string, len(degraded))
+	for i, d := range degraded {
+		reasons[i] = string(d)
+	}
+	switch structured := response["structuredContent"].(type) {
+	case map[string]interface{}:
+		structured["degradation"] = reasons
+	case nil:
+		response["structuredContent"] = map[string]interface{}{"degradation": reasons}
+	}
+
+	return response
+}
@@ -0,0 +1,340 @@
+package mcp
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0xGurg/alaala/internal/ai"
+	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+var errDown = errors.New("service is down")
+
+// brokenVectors is a local vector store whose parts can be made to fail
+type brokenVectors struct {
+	*storage.LocalVectorStore
+	searchDown bool // Search fails
+	fetchDown  bool // Vectors fails, so results cannot be diversified
+	storeDown  bool // Batches are rejected and single stores fail
+}
+
+func (b *brokenVectors) Search(ctx context.Context, embedding []float32, limit int, filterMap map[string]interface{}) ([]storage.VectorSearchResult, error) {
+	if b.searchDown {
+		return nil, errDown
+	}
+	return b.LocalVectorStore.Search(ctx, embedding, limit, filterMap)
+}
+
+func (b *brokenVectors) Vectors(ctx context.Context, ids []string) (map[string][]float32, error) {
+	if b.fetchDown {
+		return nil, errDown
+	}
+	return b.LocalVectorStore.Vectors(ctx, ids)
+}
+
+func (b *brokenVectors) Store(ctx context.Context, id, content string, embedding []float32, metadata map[string]interface{}) error {
+	if b.storeDown {
+		return errDown
+	}
+	return b.LocalVectorStore.Store(ctx, id, content, embedding, metadata)
+}
+
+func (b *brokenVectors) StoreBatch(ctx context.Context, vectors []storage.Vector) error {
+	if !b.storeDown {
+		return b.LocalVectorStore.StoreBatch(ctx, vectors)
+	}
+	partial := &storage.PartialBatchError{Op: "store", Total: len(vectors)}
+	for _, v := range vectors {
+		partial.Failed = append(partial.Failed, storage.BatchFailure{ID: v.ID, Reason: "rejected"})
+	}
+	return partial
+}
+
+// brokenEmbedder is the hash embedder, failing while down
+type brokenEmbedder struct {
+	*embeddings.Client
+	down bool
+}
+
+func (b *brokenEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if b.down {
+		return nil, errDown
+	}
+	return b.Client.Embed(ctx, text)
+}
+
+func (b *brokenEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if b.down {
+		return nil, errDown
+	}
+	return b.Client.EmbedBatch(ctx, texts)
+}
+
+// offlineAI curates a fixed draft but cannot score, summarize or compress
+type offlineAI struct {
+	draft *ai.CurationResponse
+}
+
+func (o *offlineAI) CurateMemories(req *ai.CurationRequest) (*ai.CurationResponse, error) {
+	return o.draft, nil
+}
+
+func (o *offlineAI) ScoreImportance(content, contextType string) (float64, error) {
+	return 0, errDown
+}
+
+func (o *offlineAI) Summarize(transcript string) (string, error) { return "", errDown }
+
+func (o *offlineAI) Compress(text string, maxChars int) (string, error) { return "", errDown }
+
+// degradedEnv is a server whose dependencies a test can break
+type degradedEnv struct {
+	server    *Server
+	projectID string
+	path      string
+	vectors   *brokenVectors
+	embedder  *brokenEmbedder
+	ai        *offlineAI
+}
+
+func newDegradedEnv(t *testing.T) *degradedEnv {
+	t.Helper()
+	env := &degradedEnv{
+		embedder: &brokenEmbedder{Client: embeddings.NewHashClient()},
+		ai: &offlineAI{draft: &ai.CurationResponse{
+			Memories: []ai.CuratedMemory{{Content: "Deploys need a green build", Importance: 0.7, ContextType: "DECISION"}},
+			Summary:  "Talked about deploys",
+		}},
+	}
+	env.server, env.projectID, env.path = newTestServerWithStores(t, env.embedder, func(local *storage.LocalVectorStore) memory.VectorStore {
+		env.vectors = &brokenVectors{LocalVectorStore: local}
+		return env.vectors
+	})
+	env.server.curator = memory.NewCurator(env.server.engine, env.ai)
+
+	for _, content := range []string{
+		"Deploys go through the release pipeline",
+		"Deploys are rolled back with the previous image",
+	} {
+		mem := &memory.Memory{ProjectID: env.projectID, Content: content, Importance: 0.5}
+		if _, err := env.server.engine.CreateMemory(context.Background(), mem); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return env
+}
+
+// dropTables removes tables behind the store's back, with the triggers
+// that write to them
+func (env *degradedEnv) dropTables(t *testing.T, tables ...string) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", env.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, table := range tables {
+		rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'trigger' AND sql LIKE '%' || ? || '%'`, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var triggers []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			triggers = append(triggers, name)
+		}
+		rows.Close()
+
+		for _, trigger := range triggers {
+			if _, err := db.Exec(`DROP TRIGGER ` + trigger); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := db.Exec(`DROP TABLE ` + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// call runs a tool the way tools/call does and returns its text and the
+// degradation list of its structured content
+func (env *degradedEnv) call(t *testing.T, tool string, args map[string]interface{}) (string, []string) {
+	t.Helper()
+	if _, ok := args["project_id"]; !ok {
+		args["project_id"] = env.projectID
+	}
+	params, err := json.Marshal(map[string]interface{}{"name": tool, "arguments": args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := env.server.handleCallTool(context.Background(), params)
+	if err != nil {
+		t.Fatalf("%s: %v", tool, err)
+	}
+
+	var degradation []string
+	if structured, ok := result.(map[string]interface{})["structuredContent"].(map[string]interface{}); ok {
+		degradation, _ = structured["degradation"].([]string)
+	}
+	return toolText(t, result), degradation
+}
+
+func TestToolsReportEachFallback(t *testing.T) {
+	// Full-text search needs every word of the query, so cases that fall
+	// back to it search for "deploys" alone
+	search := map[string]interface{}{"query": "how do deploys work"}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, env *degradedEnv)
+		tool  string
+		args  map[string]interface{}
+		want  []memory.Degradation
+	}{
+		{
+			name: "nothing down",
+			tool: "search_memories", args: search,
+		},
+		{
+			name:  "embedder down",
+			setup: func(t *testing.T, env *degradedEnv) { env.embedder.down = true },
+			tool:  "search_memories", args: map[string]interface{}{"query": "deploys"},
+			want: []memory.Degradation{memory.DegradedVectorSearch},
+		},
+		{
+			name:  "vector search down",
+			setup: func(t *testing.T, env *degradedEnv) { env.vectors.searchDown = true },
+			tool:  "search_memories", args: map[string]interface{}{"query": "deploys"},
+			want: []memory.Degradation{memory.DegradedVectorSearch},
+		},
+		{
+			name:  "full-text index missing",
+			setup: func(t *testing.T, env *degradedEnv) { env.dropTables(t, "memories_fts") },
+			tool:  "search_memories", args: search,
+			want: []memory.Degradation{memory.DegradedKeywordSearch},
+		},
+		{
+			name: "stored vectors unavailable",
+			setup: func(t *testing.T, env *degradedEnv) {
+				env.server.engine.SetMMRLambda(0.5)
+				env.vectors.fetchDown = true
+			},
+			tool: "search_memories", args: search,
+			want: []memory.Degradation{memory.DegradedDiversity},
+		},
+		{
+			name:  "relationships unavailable",
+			setup: func(t *testing.T, env *degradedEnv) { env.dropTables(t, "memory_relationships") },
+			tool:  "search_memories", args: map[string]interface{}{"query": "how do deploys work", "graph_depth": 1},
+			want: []memory.Degradation{memory.DegradedGraph},
+		},
+		{
+			name: "vector search down and stored vectors unavailable",
+			setup: func(t *testing.T, env *degradedEnv) {
+				env.server.engine.SetMMRLambda(0.5)
+				env.vectors.searchDown = true
+				env.vectors.fetchDown = true
+			},
+			tool: "search_memories", args: map[string]interface{}{"query": "deploys"},
+			want: []memory.Degradation{memory.DegradedVectorSearch, memory.DegradedDiversity},
+		},
+		{
+			name: "embedder down and relationships unavailable",
+			setup: func(t *testing.T, env *degradedEnv) {
+				env.embedder.down = true
+				env.dropTables(t, "memory_relationships")
+			},
+			tool: "search_memories", args: map[string]interface{}{"query": "deploys", "graph_depth": 1},
+			want: []memory.Degradation{memory.DegradedVectorSearch, memory.DegradedGraph},
+		},
+		{
+			name: "full-text, stored vectors and relationships unavailable",
+			setup: func(t *testing.T, env *degradedEnv) {
+				env.server.engine.SetMMRLambda(0.5)
+				env.vectors.fetchDown = true
+				env.dropTables(t, "memories_fts", "memory_relationships")
+			},
+			tool: "search_memories", args: map[string]interface{}{"query": "how do deploys work", "graph_depth": 1},
+			want: []memory.Degradation{memory.DegradedKeywordSearch, memory.DegradedDiversity, memory.DegradedGraph},
+		},
+		{
+			name:  "AI importance scoring down",
+			setup: func(t *testing.T, env *degradedEnv) { env.server.engine.SetImportanceScorer(env.ai, 0) },
+			tool:  "save_memory", args: map[string]interface{}{"content": "Deploys happen on weekdays", "importance": "auto"},
+			want: []memory.Degradation{memory.DegradedImportanceAI},
+		},
+		{
+			name:  "vector store down while curating",
+			setup: func(t *testing.T, env *degradedEnv) { env.vectors.storeDown = true },
+			tool:  "curate_session", args: map[string]interface{}{"transcript": "We agreed deploys need a green build."},
+			want: []memory.Degradation{memory.DegradedVectorStore},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newDegradedEnv(t)
+			if tt.setup != nil {
+				tt.setup(t, env)
+			}
+			args := make(map[string]interface{}, len(tt.args))
+			for k, v := range tt.args {
+				args[k] = v
+			}
+
+			text, degradation := env.call(t, tt.tool, args)
+
+			want := make([]string, len(tt.want))
+			for i, d := range tt.want {
+				want[i] = string(d)
+			}
+			if len(want) == 0 {
+				want = nil
+			}
+			if !reflect.DeepEqual(degradation, want) {
+				t.Errorf("degradation = %q, want %q", degradation, want)
+			}
+			if warning := degradationWarning(tt.want); !strings.HasPrefix(text, warning) || (warning == "" && strings.HasPrefix(text, "Warning:")) {
+				t.Errorf("text does not start with the warning %q:\n%s", warning, text)
+			}
+		})
+	}
+}
+
+func TestEndSessionReportsSummaryFallback(t *testing.T) {
+	env := newDegradedEnv(t)
+	env.call(t, "start_session", map[string]interface{}{})
+	env.call(t, "append_transcript", map[string]interface{}{"text": "We agreed deploys need a green build."})
+
+	text, degradation := env.call(t, "end_session", map[string]interface{}{})
+	if want := []string{string(degradedSessionSummary)}; !reflect.DeepEqual(degradation, want) {
+		t.Errorf("degradation = %q, want %q", degradation, want)
+	}
+	if !strings.HasPrefix(text, degradationWarning([]memory.Degradation{degradedSessionSummary})) {
+		t.Errorf("text does not start with a warning:\n%s", text)
+	}
+}
+
+func TestCompressPrimerReportsFallback(t *testing.T) {
+	env := newDegradedEnv(t)
+	env.server.SetPrimerMaxChars(40)
+	ctx := memory.WithDegradations(context.Background())
+
+	text := env.server.compressPrimer(ctx, strings.Repeat("A line of the session primer\n", 10))
+	if len(text) > 40 {
+		t.Errorf("primer is %d bytes, want it truncated to 40", len(text))
+	}
+	if got, want := memory.Degradations(ctx), []memory.Degradation{degradedPrimerCompression}; !reflect.DeepEqual(got, want) {
+		t.Errorf("degradations = %q, want %q", got, want)
+	}
+}
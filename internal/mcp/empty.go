@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reasons reported when a search or listing returns no memories
+const (
+	emptyReasonEmptyProject  = "empty_project"
+	emptyReasonFiltersStrict = "filters_too_strict"
+	emptyReasonNoMatch       = "no_match"
+	emptyReasonNotRelevant   = "not_relevant"
+)
+
+// emptyResult explains why a query returned no memories so clients can tell
+// an empty project apart from over-strict filters or a query with no matches
+type emptyResult struct {
+	Reason string `json:"reason"`
+	Hint   string `json:"hint"`
+}
+
+// explainEmpty determines why no memories were returned for a project
+func (s *Server) explainEmpty(ctx context.Context, projectID string, minImportance float64, hasQuery bool) (*emptyResult, error) {
+	total, err := s.engine.CountMemories(ctx, projectID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count memories: %w", err)
+	}
+
+	if total == 0 {
+		return &emptyResult{
+			Reason: emptyReasonEmptyProject,
+			Hint:   "This project has no memories yet. Save one with save_memory or extract some with curate_session.",
+		}, nil
+	}
+
+	if minImportance > 0 {
+		eligible, err := s.engine.CountMemories(ctx, projectID, minImportance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count memories: %w", err)
+		}
+		if eligible == 0 {
+			return &emptyResult{
+				Reason: emptyReasonFiltersStrict,
+				Hint: fmt.Sprintf("The project has %d memories but none have importance >= %.2f. Lower min_importance to see them.",
+					total, minImportance),
+			}, nil
+		}
+	}
+
+	hint := fmt.Sprintf("The project has %d memories but none matched. Try a broader query or different keywords.", total)
+	if !hasQuery {
+		hint = fmt.Sprintf("The project has %d memories but none matched the filters.", total)
+	}
+
+	return &emptyResult{
+		Reason: emptyReasonNoMatch,
+		Hint:   hint,
+	}, nil
+}
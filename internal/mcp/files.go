@@ -0,0 +1,74 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxTranscriptFileSize caps transcripts curate_file reads from disk
+const maxTranscriptFileSize = 10 << 20
+
+// readProjectFile reads a text file inside a project's directory. Relative
+// paths are taken from the project root. Symlinks are resolved before the
+// check, so no link can reach outside the project.
+func (s *Server) readProjectFile(ctx context.Context, projectID, path string) (string, error) {
+	project, err := s.engine.GetProject(ctx, projectID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load project: %w", err)
+	}
+	if project == nil {
+		return "", fmt.Errorf("project %s not found", projectID)
+	}
+	if project.Path == "" {
+		return "", fmt.Errorf("project %s has no directory to read files from", project.Name)
+	}
+
+	root, err := filepath.EvalSymlinks(project.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the project directory %s", path, project.Path)
+	}
+
+	file, err := os.Open(resolved)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() > maxTranscriptFileSize {
+		return "", fmt.Errorf("%s is %d bytes, more than the %d bytes a transcript may have", path, info.Size(), maxTranscriptFileSize)
+	}
+
+	// The file may grow after Stat, so the read is capped as well
+	var b strings.Builder
+	b.Grow(int(info.Size()))
+	n, err := io.Copy(&b, io.LimitReader(file, maxTranscriptFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if n > maxTranscriptFileSize {
+		return "", fmt.Errorf("%s is more than the %d bytes a transcript may have", path, maxTranscriptFileSize)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,128 @@
+package mcp
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or rewrites the file with
+// -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s (run with -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// formatFixture covers every optional part of a formatted memory
+func formatFixture() []*memory.SearchResult {
+	created := time.Date(2026, 3, 14, 9, 26, 53, 0, time.FixedZone("CET", 3600))
+	accessed := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	return []*memory.SearchResult{
+		{
+			Memory: &memory.Memory{
+				ID: "11111111-1111-1111-1111-111111111111", ProjectID: "p1",
+				Content: "Deploys go through the release pipeline", Importance: 0.9,
+				ContextType: memory.ContextTypeDecision, SemanticTags: []string{"deploy", "ci"},
+				CreatedAt: created, AccessCount: 4, LastAccessedAt: &accessed,
+			},
+			RelevanceScore: 0.87, TriggerMatched: true, MatchedTrigger: "how do we deploy",
+		},
+		{
+			Memory: &memory.Memory{
+				ID: "22222222-2222-2222-2222-222222222222", ProjectID: "p2",
+				Content: "Release notes are generated from commit subjects", Importance: 0.5,
+				CreatedAt: created.Add(time.Hour),
+			},
+			RelevanceScore: 0.4, GraphExpanded: true, RelationshipType: memory.RelationshipTypeExpands,
+		},
+		{
+			Memory: &memory.Memory{
+				ID: "33333333-3333-3333-3333-333333333333", ProjectID: "p1",
+				Content: "The old deploy script is kept for rollbacks", Importance: 0.2,
+				ContextType: memory.ContextTypeTechnicalImplementation, Archived: true,
+				CreatedAt: created.Add(-24 * time.Hour),
+			},
+			RelevanceScore: 0.25, GraphExpanded: true, TextFallback: true,
+		},
+	}
+}
+
+func TestFormatMemoriesAsText(t *testing.T) {
+	projects := map[string]string{"p2": "website"}
+
+	tests := []struct {
+		name    string
+		golden  string
+		results []*memory.SearchResult
+		listing bool
+	}{
+		{"search", "format_search.golden", formatFixture(), false},
+		{"listing", "format_listing.golden", formatFixture(), true},
+		{"empty", "format_empty.golden", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.golden, formatMemoriesAsText(tt.results, tt.listing, projects))
+		})
+	}
+}
+
+// toolText returns the text content of a tool result
+func toolText(t testing.TB, result interface{}) string {
+	t.Helper()
+	response, ok := result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("tool result is %T, want a map", result)
+	}
+	content, ok := response["content"].([]map[string]interface{})
+	if !ok || len(content) == 0 {
+		t.Fatalf("tool result has no content: %v", response)
+	}
+	text, _ := content[0]["text"].(string)
+	return text
+}
+
+func TestSearchMemoriesPrintsTags(t *testing.T) {
+	s, projectID := newTestServer(t)
+	mem := &memory.Memory{
+		ProjectID: projectID, Content: "Deploys go through the release pipeline", Importance: 0.5,
+		ContextType: memory.ContextTypeDecision, SemanticTags: []string{"deploy", "ci"},
+	}
+	if _, err := s.engine.CreateMemory(context.Background(), mem); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := callTool(t, s.toolSearchMemories, map[string]interface{}{
+		"query": "how do deploys work", "project_id": projectID,
+	})
+	if err != nil {
+		t.Fatalf("search_memories: %v", err)
+	}
+	text := toolText(t, result)
+	for _, want := range []string{"ID: " + mem.ID, "Type: DECISION", "Tags: ci, deploy"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("search_memories text lacks %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+// HealthReport is what `alaala status` and the memory://status resource
+// show: the features the server starts with and the engine's status
+type HealthReport struct {
+	ConfigPath string    `json:"config_path,omitempty"`
+	Minimal    bool      `json:"minimal"`
+	Features   []Feature `json:"features"`
+	*memory.Status
+}
+
+// NewHealthReport probes the engine and, if given, the curator's AI
+// provider, and adds the features in setup
+func NewHealthReport(ctx context.Context, setup *SetupStatus, engine *memory.Engine, curator *memory.Curator) *HealthReport {
+	if setup == nil {
+		setup = &SetupStatus{}
+	}
+	report := &HealthReport{
+		Minimal:  setup.Minimal(),
+		Features: setup.Features,
+		Status:   engine.Status(ctx, curator),
+	}
+	for _, f := range setup.Features {
+		if !f.Enabled {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s is disabled: %s", f.Name, f.Detail))
+		}
+	}
+	return report
+}
+
+// resourceStatus reports the server's health, the same report as
+// `alaala status --json`
+func (s *Server) resourceStatus(ctx context.Context) (interface{}, error) {
+	data, err := json.Marshal(NewHealthReport(ctx, s.setup, s.engine, s.curator))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"uri":      "memory://status",
+				"mimeType": "application/json",
+				"text":     string(data),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,126 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// NotificationHandler handles an MCP notification, which gets no response.
+// It runs while the input is being read, so it must not block.
+type NotificationHandler func(params json.RawMessage)
+
+// activeRequest is a client request that has not been answered yet
+type activeRequest struct {
+	cancel    context.CancelFunc // Set while the request is being handled
+	cancelled bool               // The client gave up on it
+}
+
+// registerNotificationHandlers registers the notifications the server acts
+// on; any other notification is dropped
+func (s *Server) registerNotificationHandlers() {
+	s.notifications["notifications/initialized"] = func(json.RawMessage) {
+		s.logger.Debug("client initialized")
+	}
+	s.notifications["notifications/cancelled"] = s.handleCancelled
+}
+
+// handleNotification runs the handler of a notification, if it has one
+func (s *Server) handleNotification(msg *jsonrpcMessage) {
+	handler, ok := s.notifications[msg.Method]
+	if !ok {
+		s.logger.Debug("ignoring notification", "method", msg.Method)
+		return
+	}
+	handler(msg.Params)
+}
+
+// isNotification reports whether a message that could not be parsed was
+// meant as a notification: a JSON object without an id. Those get no
+// response, not even a parse error.
+func isNotification(body []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return false
+	}
+	_, hasID := fields["id"]
+	return !hasID
+}
+
+// handleCancelled stops work on a request the client is no longer
+// interested in. A request being handled has its context canceled and one
+// still queued is skipped; neither is answered.
+func (s *Server) handleCancelled(params json.RawMessage) {
+	var p struct {
+		RequestID interface{} `json:"requestId"`
+		Reason    string      `json:"reason"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.RequestID == nil {
+		s.logger.Debug("ignoring malformed cancellation", "params", string(params))
+		return
+	}
+	id := fmt.Sprint(p.RequestID)
+
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	req, ok := s.active[id]
+	if !ok {
+		// Already answered, or never received
+		return
+	}
+	req.cancelled = true
+	if req.cancel != nil {
+		req.cancel()
+	}
+	s.logger.Debug("request cancelled by the client", "id", id, "reason", p.Reason)
+}
+
+// trackRequest records a request that was received, so that it can be
+// cancelled until it is answered
+func (s *Server) trackRequest(id interface{}) {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	s.active[fmt.Sprint(id)] = &activeRequest{}
+}
+
+// beginRequest records that a request is being handled with the given
+// cancel function. It returns false if the client cancelled the request
+// while it was queued.
+func (s *Server) beginRequest(id interface{}, cancel context.CancelFunc) bool {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	req, ok := s.active[fmt.Sprint(id)]
+	if !ok {
+		return true
+	}
+	if req.cancelled {
+		delete(s.active, fmt.Sprint(id))
+		return false
+	}
+	req.cancel = cancel
+	return true
+}
+
+// endRequest forgets a handled request and reports whether the client
+// cancelled it meanwhile, in which case it must not be answered
+func (s *Server) endRequest(id interface{}) bool {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	key := fmt.Sprint(id)
+	req, ok := s.active[key]
+	delete(s.active, key)
+	return ok && req.cancelled
+}
+
+// cancelActive cancels every request being handled; none of them is
+// answered
+func (s *Server) cancelActive() {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	for _, req := range s.active {
+		req.cancelled = true
+		if req.cancel != nil {
+			req.cancel()
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
+	"github.com/0xGurg/alaala/internal/locale"
 	"github.com/0xGurg/alaala/internal/memory"
 )
 
@@ -15,7 +17,7 @@ type Prompt struct {
 }
 
 // handleListPrompts returns the list of available prompts
-func (s *Server) handleListPrompts(params json.RawMessage) (interface{}, error) {
+func (s *Server) handleListPrompts(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	prompts := []Prompt{
 		{
 			Name:        "session_primer",
@@ -30,7 +32,7 @@ func (s *Server) handleListPrompts(params json.RawMessage) (interface{}, error)
 }
 
 // handleGetPrompt gets a prompt
-func (s *Server) handleGetPrompt(params json.RawMessage) (interface{}, error) {
+func (s *Server) handleGetPrompt(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
@@ -42,28 +44,30 @@ func (s *Server) handleGetPrompt(params json.RawMessage) (interface{}, error) {
 
 	switch req.Name {
 	case "session_primer":
-		return s.promptSessionPrimer()
+		return s.promptSessionPrimer(ctx)
 	default:
 		return nil, fmt.Errorf("unknown prompt: %s", req.Name)
 	}
 }
 
 // promptSessionPrimer generates the session primer prompt
-func (s *Server) promptSessionPrimer() (interface{}, error) {
+func (s *Server) promptSessionPrimer(ctx context.Context) (interface{}, error) {
 	// Get current project
-	projectID, err := s.getCurrentProjectID()
+	projectID, err := s.getCurrentProjectID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get session primer
-	primer, err := s.engine.GetSessionPrimer(projectID)
+	ctx = memory.WithDegradations(ctx)
+	primer, err := s.engine.GetSessionPrimer(ctx, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session primer: %w", err)
 	}
 
 	// Format as prompt
-	text := formatSessionPrimerAsPrompt(primer)
+	text := s.setupBanner() + s.compressPrimer(ctx, formatSessionPrimerAsPrompt(primer, s.localizer))
+	text = degradationWarning(memory.Degradations(ctx)) + text
 
 	return map[string]interface{}{
 		"description": "Session context and relevant memories",
@@ -81,18 +85,18 @@ func (s *Server) promptSessionPrimer() (interface{}, error) {
 
 // Helper functions
 
-func formatSessionPrimerAsPrompt(primer *memory.SessionPrimer) string {
+func formatSessionPrimerAsPrompt(primer *memory.SessionPrimer, loc *locale.Localizer) string {
 	text := "# Session Context\n\n"
 	text += fmt.Sprintf("Project: %s\n\n", primer.ProjectName)
 
 	if primer.LastSessionDate != nil {
-		text += fmt.Sprintf("Time since last session: %s\n\n", primer.TimeSinceLastSession)
+		text += fmt.Sprintf("Time since last session: %s\n\n", loc.When(*primer.LastSessionDate))
 
 		if primer.LastSessionSummary != "" {
 			text += fmt.Sprintf("Last session summary: %s\n\n", primer.LastSessionSummary)
 		}
 	} else {
-		text += "This is the first session for this project.\n\n"
+		text += loc.FirstSession() + "\n\n"
 	}
 
 	if len(primer.TopMemories) > 0 {
@@ -123,6 +127,16 @@ func formatSessionPrimerAsPrompt(primer *memory.SessionPrimer) string {
 		}
 	}
 
+	if len(primer.WorkspaceMemories) > 0 {
+		text += "## Across the Workspace\n\n"
+		text += fmt.Sprintf("Key memories from other projects in the %s workspace:\n\n", primer.WorkspaceName)
+
+		for i, mem := range primer.WorkspaceMemories {
+			text += fmt.Sprintf("%d. **%s** (%s)\n\n", i+1, mem.Content, primer.ProjectNames[mem.ProjectID])
+		}
+		text += "Search with scope \"workspace\" to find more.\n\n"
+	}
+
 	text += "\n---\n\n"
 	text += "Memories will surface naturally as we converse. You can search for specific memories or save important insights as we work together.\n"
 
@@ -1,9 +1,15 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/locale"
 	"github.com/0xGurg/alaala/internal/memory"
 )
 
@@ -16,7 +22,7 @@ type Resource struct {
 }
 
 // handleListResources returns the list of available resources
-func (s *Server) handleListResources(params json.RawMessage) (interface{}, error) {
+func (s *Server) handleListResources(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	resources := []Resource{
 		{
 			URI:         "memory://session-context",
@@ -27,7 +33,19 @@ func (s *Server) handleListResources(params json.RawMessage) (interface{}, error
 		{
 			URI:         "memory://project-memories",
 			Name:        "Project Memories",
-			Description: "All memories for the current project",
+			Description: "The current project's memories by importance, then recency. Accepts ?context_type=, ?tag=, ?order_by=, ?limit= and ?offset=",
+			MimeType:    "application/json",
+		},
+		{
+			URI:         "memory://embedding-info",
+			Name:        "Embedding Info",
+			Description: "Embedding provider, model, vector dimension and normalization, and query cache hits and misses",
+			MimeType:    "application/json",
+		},
+		{
+			URI:         "memory://status",
+			Name:        "Status",
+			Description: "Health of storage, vector store, embeddings and AI provider with latencies, counts, AI spend and detected problems",
 			MimeType:    "application/json",
 		},
 	}
@@ -38,7 +56,7 @@ func (s *Server) handleListResources(params json.RawMessage) (interface{}, error
 }
 
 // handleReadResource reads a resource
-func (s *Server) handleReadResource(params json.RawMessage) (interface{}, error) {
+func (s *Server) handleReadResource(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var req struct {
 		URI string `json:"uri"`
 	}
@@ -47,32 +65,43 @@ func (s *Server) handleReadResource(params json.RawMessage) (interface{}, error)
 		return nil, fmt.Errorf("invalid read resource params: %w", err)
 	}
 
-	switch req.URI {
+	uri, err := url.Parse(req.URI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URI %q: %w", req.URI, err)
+	}
+
+	switch uri.Scheme + "://" + uri.Host + uri.Path {
 	case "memory://session-context":
-		return s.resourceSessionContext()
+		return s.resourceSessionContext(ctx)
 	case "memory://project-memories":
-		return s.resourceProjectMemories()
+		return s.resourceProjectMemories(ctx, req.URI, uri.Query())
+	case "memory://embedding-info":
+		return s.resourceEmbeddingInfo(ctx)
+	case "memory://status":
+		return s.resourceStatus(ctx)
 	default:
 		return nil, fmt.Errorf("unknown resource URI: %s", req.URI)
 	}
 }
 
 // resourceSessionContext provides session context
-func (s *Server) resourceSessionContext() (interface{}, error) {
+func (s *Server) resourceSessionContext(ctx context.Context) (interface{}, error) {
 	// Get current project
-	projectID, err := s.getCurrentProjectID()
+	projectID, err := s.getCurrentProjectID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get session primer
-	primer, err := s.engine.GetSessionPrimer(projectID)
+	ctx = memory.WithDegradations(ctx)
+	primer, err := s.engine.GetSessionPrimer(ctx, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session primer: %w", err)
 	}
 
 	// Format as text
-	text := formatSessionPrimer(primer)
+	text := s.setupBanner() + s.compressPrimer(ctx, formatSessionPrimer(primer, s.localizer))
+	text = degradationWarning(memory.Degradations(ctx)) + text
 
 	return map[string]interface{}{
 		"contents": []map[string]interface{}{
@@ -86,38 +115,95 @@ func (s *Server) resourceSessionContext() (interface{}, error) {
 }
 
 // resourceProjectMemories provides all project memories
-func (s *Server) resourceProjectMemories() (interface{}, error) {
+func (s *Server) resourceProjectMemories(ctx context.Context, uri string, params url.Values) (interface{}, error) {
 	// Get current project
-	projectID, err := s.getCurrentProjectID()
+	projectID, err := s.getCurrentProjectID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Search for all memories (high limit)
-	results, err := s.engine.SearchMemories(&memory.SearchQuery{
-		Query:         "",
-		ProjectID:     projectID,
-		Limit:         100,
-		MinImportance: 0,
-	})
+	query := &memory.ListQuery{
+		ProjectID: projectID,
+		Limit:     s.resourceLimit,
+		OrderBy:   "importance",
+		Tag:       params.Get("tag"),
+	}
+	if v := params.Get("order_by"); v != "" {
+		query.OrderBy = v
+	}
+	if ct := params.Get("context_type"); ct != "" {
+		contextType, err := memory.ParseContextType(strings.ToUpper(ct))
+		if err != nil {
+			return nil, err
+		}
+		query.ContextType = contextType
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %q (must be a positive integer)", v)
+		}
+		if limit < query.Limit {
+			query.Limit = limit
+		}
+	}
+	if v := params.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("invalid offset %q (must be a non-negative integer)", v)
+		}
+		query.Offset = offset
+	}
+
+	mems, total, err := s.engine.ListMemories(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project memories: %w", err)
 	}
 
 	// Format memories
-	var memories []map[string]interface{}
-	for _, result := range results {
+	memories := []map[string]interface{}{}
+	for _, mem := range mems {
+		tags := mem.SemanticTags
+		if tags == nil {
+			tags = []string{}
+		}
 		memories = append(memories, map[string]interface{}{
-			"id":          result.Memory.ID,
-			"content":     result.Memory.Content,
-			"importance":  result.Memory.Importance,
-			"tags":        result.Memory.SemanticTags,
-			"contextType": result.Memory.ContextType,
-			"createdAt":   result.Memory.CreatedAt,
+			"id":          mem.ID,
+			"content":     mem.Content,
+			"importance":  mem.Importance,
+			"tags":        tags,
+			"contextType": mem.ContextType,
+			"createdAt":   mem.CreatedAt,
 		})
 	}
 
-	data, err := json.Marshal(memories)
+	payload := map[string]interface{}{
+		"project_id": projectID,
+		"memories":   memories,
+		"count":      len(memories),
+		"total":      total,
+		"offset":     query.Offset,
+		"limit":      query.Limit,
+		"order_by":   query.OrderBy,
+		"empty":      len(memories) == 0,
+	}
+
+	if omitted := total - query.Offset - len(memories); omitted > 0 {
+		payload["omitted"] = omitted
+		payload["next_offset"] = query.Offset + len(memories)
+		payload["note"] = s.localizer.MoreMemories(omitted, query.Limit, query.Offset+len(memories))
+	}
+
+	if len(memories) == 0 {
+		empty, err := s.explainEmpty(ctx, projectID, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		payload["reason"] = empty.Reason
+		payload["hint"] = empty.Hint
+	}
+
+	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +211,34 @@ func (s *Server) resourceProjectMemories() (interface{}, error) {
 	return map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
-				"uri":      "memory://project-memories",
+				"uri":      uri,
+				"mimeType": "application/json",
+				"text":     string(data),
+			},
+		},
+	}, nil
+}
+
+// resourceEmbeddingInfo describes the vector space memories are embedded in
+// and how often the query embedding cache saved calls to the embedder
+func (s *Server) resourceEmbeddingInfo(ctx context.Context) (interface{}, error) {
+	info, ok := s.engine.EmbeddingInfo()
+	if !ok {
+		return nil, fmt.Errorf("embedder does not report embedding info")
+	}
+
+	data, err := json.Marshal(struct {
+		embeddings.Info
+		QueryCache memory.QueryCacheStats `json:"query_cache"`
+	}{info, s.engine.QueryCacheStats()})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"uri":      "memory://embedding-info",
 				"mimeType": "application/json",
 				"text":     string(data),
 			},
@@ -135,13 +248,16 @@ func (s *Server) resourceProjectMemories() (interface{}, error) {
 
 // Helper functions
 
-func formatSessionPrimer(primer *memory.SessionPrimer) string {
+func formatSessionPrimer(primer *memory.SessionPrimer, loc *locale.Localizer) string {
 	text := fmt.Sprintf("# Session Context for %s\n\n", primer.ProjectName)
 
 	if primer.LastSessionDate != nil {
-		text += fmt.Sprintf("Last session: %s\n\n", primer.TimeSinceLastSession)
+		text += fmt.Sprintf("Last session: %s\n\n", loc.When(*primer.LastSessionDate))
+		if primer.LastSessionSummary != "" {
+			text += fmt.Sprintf("Last session summary: %s\n\n", primer.LastSessionSummary)
+		}
 	} else {
-		text += "This is the first session for this project.\n\n"
+		text += loc.FirstSession() + "\n\n"
 	}
 
 	if len(primer.TopMemories) > 0 {
@@ -162,5 +278,12 @@ func formatSessionPrimer(primer *memory.SessionPrimer) string {
 		}
 	}
 
+	if len(primer.WorkspaceMemories) > 0 {
+		text += fmt.Sprintf("## Across Workspace %s:\n\n", primer.WorkspaceName)
+		for i, mem := range primer.WorkspaceMemories {
+			text += fmt.Sprintf("%d. [%s] %s\n\n", i+1, primer.ProjectNames[mem.ProjectID], mem.Content)
+		}
+	}
+
 	return text
 }
@@ -0,0 +1,251 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/ai"
+	"github.com/0xGurg/alaala/internal/locale"
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+// samplingTimeout bounds how long a sampling request waits for the client;
+// clients may ask the user to approve it first
+const samplingTimeout = 2 * time.Minute
+
+// summaryTruncateChars caps a session summary cut from the transcript when
+// no model can write one
+const summaryTruncateChars = 280
+
+// errServerClosed fails requests to the client once its input has ended
+var errServerClosed = errors.New("client connection closed")
+
+// jsonrpcMessage is any message read from the client: a request or
+// notification (Method set), or a response to a request of ours
+type jsonrpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+}
+
+// isResponse reports whether the message answers a request of ours
+func (m *jsonrpcMessage) isResponse() bool {
+	return m.Method == "" && m.ID != nil
+}
+
+// SetClientSampling lets session summaries and primer compression use the
+// client's model through MCP sampling when the client supports it
+func (s *Server) SetClientSampling(enabled bool) {
+	s.useSampling = enabled
+}
+
+// SetPrimerMaxChars sets the length above which the session primer is
+// compressed; values below 1 disable compression
+func (s *Server) SetPrimerMaxChars(max int) {
+	s.primerMaxChars = max
+}
+
+// samplingAvailable reports whether sampling is enabled and the client
+// declared support for it
+func (s *Server) samplingAvailable() bool {
+	return s.useSampling && s.clientSampling
+}
+
+// request sends a request to the client and waits for its response
+func (s *Server) request(ctx context.Context, method string, params interface{}, timeout time.Duration) (json.RawMessage, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s params: %w", method, err)
+	}
+
+	s.pendingMu.Lock()
+	if s.closed {
+		s.pendingMu.Unlock()
+		return nil, errServerClosed
+	}
+	s.nextRequestID++
+	id := fmt.Sprintf("alaala-%d", s.nextRequestID)
+	reply := make(chan *jsonrpcMessage, 1)
+	s.pending[id] = reply
+	s.pendingMu.Unlock()
+
+	defer func() {
+		s.pendingMu.Lock()
+		delete(s.pending, id)
+		s.pendingMu.Unlock()
+	}()
+
+	if err := s.writeMessage(JSONRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: rawParams}); err != nil {
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			return nil, errServerClosed
+		}
+		if msg.Error != nil {
+			return nil, fmt.Errorf("client rejected %s: %s (code %d)", method, msg.Error.Message, msg.Error.Code)
+		}
+		return msg.Result, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("client did not answer %s within %s", method, timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliverResponse hands a client response to the request waiting for it
+func (s *Server) deliverResponse(msg *jsonrpcMessage) {
+	id := fmt.Sprint(msg.ID)
+
+	s.pendingMu.Lock()
+	reply, ok := s.pending[id]
+	delete(s.pending, id)
+	s.pendingMu.Unlock()
+
+	if !ok {
+		s.logger.Warn("response to unknown request", "id", id)
+		return
+	}
+	reply <- msg
+}
+
+// closePending fails all requests waiting for the client, and any sent
+// later
+func (s *Server) closePending() {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+
+	s.closed = true
+	for id, reply := range s.pending {
+		close(reply)
+		delete(s.pending, id)
+	}
+}
+
+// createMessage asks the client's model to reply to a single prompt
+func (s *Server) createMessage(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	params := map[string]interface{}{
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": map[string]interface{}{
+					"type": "text",
+					"text": prompt,
+				},
+			},
+		},
+		"includeContext": "none",
+		"maxTokens":      maxTokens,
+	}
+
+	raw, err := s.request(ctx, "sampling/createMessage", params, samplingTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Content struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return "", fmt.Errorf("invalid sampling result: %w", err)
+	}
+	if result.Content.Type != "text" {
+		return "", fmt.Errorf("sampling returned %q content instead of text", result.Content.Type)
+	}
+
+	text := strings.TrimSpace(result.Content.Text)
+	if text == "" {
+		return "", fmt.Errorf("sampling returned an empty reply")
+	}
+	s.logger.Debug("sampling request answered", "model", result.Model, "chars", len(text))
+	return text, nil
+}
+
+// summarizeTranscript writes a session summary with the client's model, the
+// configured AI provider, or by truncating the transcript, in that order
+func (s *Server) summarizeTranscript(ctx context.Context, transcript string) string {
+	if s.samplingAvailable() {
+		summary, err := s.createMessage(ctx, ai.SummaryPrompt(transcript), ai.SummaryMaxOutputTokens)
+		if err == nil {
+			return summary
+		}
+		s.logger.Warn("sampling failed for session summary", "error", err)
+	}
+
+	if s.curator != nil {
+		summary, err := s.curator.Summarize(transcript)
+		if err == nil {
+			return summary
+		}
+		s.logger.Debug("AI provider did not summarize session", "error", err)
+	}
+
+	if s.samplingAvailable() || s.curator != nil {
+		memory.NoteDegradation(ctx, degradedSessionSummary)
+	}
+	return truncateText(transcript, summaryTruncateChars)
+}
+
+// compressPrimer shortens a session primer longer than the configured
+// maximum with the client's model, the configured AI provider, or by
+// dropping its last lines, in that order
+func (s *Server) compressPrimer(ctx context.Context, text string) string {
+	if s.primerMaxChars <= 0 || len(text) <= s.primerMaxChars {
+		return text
+	}
+
+	if s.samplingAvailable() {
+		compressed, err := s.createMessage(ctx, ai.CompressionPrompt(text, s.primerMaxChars), ai.CompressionMaxOutputTokens(s.primerMaxChars))
+		if err == nil && len(compressed) <= s.primerMaxChars {
+			return compressed
+		}
+		s.logger.Warn("sampling failed to compress session primer", "error", err, "chars", len(compressed))
+	}
+
+	if s.curator != nil {
+		compressed, err := s.curator.Compress(text, s.primerMaxChars)
+		if err == nil && len(compressed) <= s.primerMaxChars {
+			return compressed
+		}
+		s.logger.Debug("AI provider did not compress session primer", "error", err, "chars", len(compressed))
+	}
+
+	if s.samplingAvailable() || s.curator != nil {
+		memory.NoteDegradation(ctx, degradedPrimerCompression)
+	}
+	return truncateLines(text, s.primerMaxChars, s.localizer)
+}
+
+// truncateLines cuts text to at most max bytes at a line break, marking
+// the cut
+func truncateLines(text string, max int, loc *locale.Localizer) string {
+	marker := "\n\n_(" + loc.Truncated() + ")_\n"
+	if len(text) <= max {
+		return text
+	}
+
+	cut := max - len(marker)
+	if cut < 0 {
+		cut = 0
+	}
+	if i := strings.LastIndex(text[:cut], "\n"); i > 0 {
+		cut = i
+	}
+	return strings.TrimRight(text[:cut], "\n") + marker
+}
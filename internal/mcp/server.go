@@ -2,40 +2,119 @@ package mcp
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/0xGurg/alaala/internal/locale"
 	"github.com/0xGurg/alaala/internal/memory"
 )
 
 // Server implements the MCP (Model Context Protocol) server
 type Server struct {
-	engine   *memory.Engine
-	curator  *memory.Curator
-	reader   *bufio.Reader
-	writer   io.Writer
-	handlers map[string]RequestHandler
+	engine        *memory.Engine
+	curator       *memory.Curator
+	reader        *bufio.Reader
+	writer        io.Writer
+	handlers      map[string]RequestHandler
+	notifications map[string]NotificationHandler
+	setup         *SetupStatus
+	logger        *slog.Logger
+
+	localizer *locale.Localizer // Language of generated text in the primer and resources
+
+	resourceLimit  int  // Maximum memories in the project-memories resource
+	autoImportance bool // Estimate importance when save_memory omits it
+	useSampling    bool // Ask the client's model for summaries and compression
+	clientSampling bool // The client declared the sampling capability
+	primerMaxChars int  // Compress longer session primers; 0 = never
+
+	requestTimeout time.Duration // Deadline for handling one request; 0 = none
+
+	// Client requests received and not yet answered
+	activeMu sync.Mutex
+	active   map[string]*activeRequest
+
+	writeMu sync.Mutex // Serializes messages written to the client
+	framing framing    // How the client delimits messages; replies use the same
+
+	// Requests sent to the client, waiting for its response
+	pendingMu     sync.Mutex
+	pending       map[string]chan *jsonrpcMessage
+	nextRequestID int64
+	closed        bool
 }
 
+// defaultResourceLimit caps the project-memories resource unless configured
+const defaultResourceLimit = 100
+
+// defaultRequestTimeout bounds a request unless configured
+const defaultRequestTimeout = 5 * time.Minute
+
 // RequestHandler handles MCP requests
-type RequestHandler func(params json.RawMessage) (interface{}, error)
+type RequestHandler func(ctx context.Context, params json.RawMessage) (interface{}, error)
 
 // NewServer creates a new MCP server
 func NewServer(engine *memory.Engine, curator *memory.Curator) *Server {
 	server := &Server{
-		engine:   engine,
-		curator:  curator,
-		reader:   bufio.NewReader(os.Stdin),
-		writer:   os.Stdout,
-		handlers: make(map[string]RequestHandler),
+		engine:        engine,
+		curator:       curator,
+		reader:        bufio.NewReader(os.Stdin),
+		writer:        os.Stdout,
+		handlers:      make(map[string]RequestHandler),
+		notifications: make(map[string]NotificationHandler),
+		active:        make(map[string]*activeRequest),
+		logger:        slog.Default(),
+		pending:       make(map[string]chan *jsonrpcMessage),
+		localizer:     locale.New(locale.DefaultLocale),
+
+		resourceLimit:  defaultResourceLimit,
+		requestTimeout: defaultRequestTimeout,
 	}
 
 	server.registerHandlers()
+	server.registerNotificationHandlers()
 	return server
 }
 
+// SetLogger sets the logger used for request and error logging
+func (s *Server) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetLocalizer sets the language of generated text such as durations in
+// the session primer
+func (s *Server) SetLocalizer(localizer *locale.Localizer) {
+	s.localizer = localizer
+}
+
+// SetRequestTimeout sets how long a request may take before the work on it
+// is canceled and it fails; 0 or less disables the deadline
+func (s *Server) SetRequestTimeout(timeout time.Duration) {
+	s.requestTimeout = timeout
+}
+
+// SetResourceLimit sets the maximum number of memories the project-memories
+// resource returns; values below 1 keep the current limit
+func (s *Server) SetResourceLimit(limit int) {
+	if limit > 0 {
+		s.resourceLimit = limit
+	}
+}
+
+// SetAutoImportance makes save_memory and save_memories estimate the
+// importance of memories saved without one, instead of using 0.5
+func (s *Server) SetAutoImportance(auto bool) {
+	s.autoImportance = auto
+}
+
 // registerHandlers registers all MCP request handlers
 func (s *Server) registerHandlers() {
 	// Tool handlers
@@ -54,13 +133,86 @@ func (s *Server) registerHandlers() {
 	s.handlers["initialize"] = s.handleInitialize
 }
 
-// Run starts the MCP server
+// requestQueueSize is how many client requests may wait while one is being
+// handled; the reader keeps delivering responses to sampling requests
+// meanwhile
+const requestQueueSize = 64
+
+// shutdownTimeout is how long RunContext lets the request being handled
+// finish after the context is done, before canceling it
+const shutdownTimeout = 10 * time.Second
+
+// Run starts the MCP server and serves until the input ends
 func (s *Server) Run() error {
-	fmt.Fprintln(os.Stderr, "MCP server started, waiting for requests...")
+	return s.RunContext(context.Background())
+}
+
+// RunContext starts the MCP server. Requests are handled one at a time, in
+// order, while the input keeps being read so that a handler can wait for
+// the client's answer to a request of its own. It returns when the input
+// ends or ctx is done; then no more requests are started, the one being
+// handled gets shutdownTimeout to finish before it is canceled, and its
+// response is written out in full.
+func (s *Server) RunContext(ctx context.Context) error {
+	s.logger.Info("MCP server started, waiting for requests")
+
+	requests := make(chan *JSONRPCRequest, requestQueueSize)
+	done := make(chan struct{})
+	var stopping atomic.Bool
+	go func() {
+		defer close(done)
+		for req := range requests {
+			if stopping.Load() {
+				s.endRequest(req.ID)
+				s.logger.Debug("dropping request queued before shutdown", "method", req.Method)
+				continue
+			}
+			s.handleRequest(req)
+		}
+	}()
+	defer func() {
+		stopping.Store(ctx.Err() != nil)
+		s.closePending()
+		close(requests)
+		if stopping.Load() {
+			s.awaitHandler(done)
+		} else {
+			<-done
+		}
+		s.flush()
+	}()
+
+	// Reading stdin cannot be interrupted, so it happens on its own
+	// goroutine, which is abandoned on shutdown
+	type read struct {
+		body []byte
+		err  error
+	}
+	reads := make(chan read)
+	go func() {
+		for {
+			body, err := s.readMessage()
+			select {
+			case reads <- read{body, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
 
 	for {
-		// Read JSON-RPC request from stdin
-		line, err := s.reader.ReadString('\n')
+		var r read
+		select {
+		case <-ctx.Done():
+			s.logger.Info("MCP server shutting down", "reason", context.Cause(ctx))
+			return nil
+		case r = <-reads:
+		}
+
+		body, err := r.body, r.err
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -68,46 +220,128 @@ func (s *Server) Run() error {
 			return fmt.Errorf("failed to read request: %w", err)
 		}
 
-		// Parse request
-		var req JSONRPCRequest
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			s.sendError(nil, -32700, "Parse error", err)
+		// Parse message
+		var msg jsonrpcMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.logger.Warn("failed to parse request", "error", err)
+			if !isNotification(body) {
+				s.sendError(nil, -32700, "Parse error", err.Error())
+			}
 			continue
 		}
 
-		// Handle request
-		s.handleRequest(&req)
+		if msg.isResponse() {
+			s.deliverResponse(&msg)
+			continue
+		}
+		// Notifications are handled right away, so that a cancellation
+		// reaches the request it is about while that is being handled
+		if msg.ID == nil {
+			s.handleNotification(&msg)
+			continue
+		}
+		s.trackRequest(msg.ID)
+		select {
+		case requests <- &JSONRPCRequest{
+			JSONRPC: msg.JSONRPC,
+			ID:      msg.ID,
+			Method:  msg.Method,
+			Params:  msg.Params,
+		}:
+		case <-ctx.Done():
+			s.endRequest(msg.ID)
+		}
 	}
 
 	return nil
 }
 
+// awaitHandler waits for the request being handled when the server shuts
+// down, canceling it once shutdownTimeout has passed
+func (s *Server) awaitHandler(done <-chan struct{}) {
+	select {
+	case <-done:
+		return
+	case <-time.After(shutdownTimeout):
+	}
+	s.logger.Warn("request still running at shutdown, canceling it", "timeout", shutdownTimeout)
+	s.cancelActive()
+	<-done
+}
+
 // handleRequest processes a single JSON-RPC request
 func (s *Server) handleRequest(req *JSONRPCRequest) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if !s.beginRequest(req.ID, cancel) {
+		s.logger.Debug("skipping request cancelled by the client", "method", req.Method)
+		return
+	}
+
 	handler, ok := s.handlers[req.Method]
 	if !ok {
-		s.sendError(req.ID, -32601, "Method not found", nil)
+		s.logger.Debug("method not found", "method", req.Method)
+		if !s.endRequest(req.ID) {
+			s.sendError(req.ID, -32601, "Method not found", nil)
+		}
 		return
 	}
 
-	result, err := handler(req.Params)
+	if s.requestTimeout > 0 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeout(ctx, s.requestTimeout)
+		defer stop()
+	}
+
+	start := time.Now()
+	result, err := handler(ctx, req.Params)
+	latency := time.Since(start)
+	if s.endRequest(req.ID) {
+		s.logger.Debug("request cancelled by the client", "method", req.Method, "latency", latency)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%s did not finish within %s: %w", req.Method, s.requestTimeout, err)
+	}
 	if err != nil {
-		s.sendError(req.ID, -32603, "Internal error", err)
+		s.logger.Warn("request failed", "method", req.Method, "latency", latency, "error", err)
+		s.sendError(req.ID, -32603, "Internal error", err.Error())
 		return
 	}
+	s.logger.Debug("request handled", "method", req.Method, "latency", latency)
 
 	s.sendResult(req.ID, result)
 }
 
 // handleInitialize handles the initialize request
-func (s *Server) handleInitialize(params json.RawMessage) (interface{}, error) {
+func (s *Server) handleInitialize(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Capabilities struct {
+			Sampling json.RawMessage `json:"sampling"`
+		} `json:"capabilities"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, fmt.Errorf("invalid initialize params: %w", err)
+		}
+	}
+	s.clientSampling = len(req.Capabilities.Sampling) > 0 && string(req.Capabilities.Sampling) != "null"
+	s.logger.Debug("client capabilities", "sampling", s.clientSampling)
+
+	capabilities := map[string]interface{}{
+		"tools":     map[string]bool{},
+		"resources": map[string]bool{},
+		"prompts":   map[string]bool{},
+	}
+	if info, ok := s.engine.EmbeddingInfo(); ok {
+		capabilities["experimental"] = map[string]interface{}{
+			"embeddingInfo": info,
+		}
+	}
+
 	return map[string]interface{}{
 		"protocolVersion": "2024-11-05",
-		"capabilities": map[string]interface{}{
-			"tools":     map[string]bool{},
-			"resources": map[string]bool{},
-			"prompts":   map[string]bool{},
-		},
+		"capabilities":    capabilities,
 		"serverInfo": map[string]interface{}{
 			"name":    "alaala",
 			"version": "0.1.0",
@@ -143,13 +377,9 @@ func (s *Server) sendError(id interface{}, code int, message string, data interf
 
 // sendResponse sends a JSON-RPC response
 func (s *Server) sendResponse(resp *JSONRPCResponse) {
-	data, err := json.Marshal(resp)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to marshal response: %v\n", err)
-		return
+	if err := s.writeMessage(resp); err != nil {
+		s.logger.Error("failed to send response", "error", err)
 	}
-
-	fmt.Fprintf(s.writer, "%s\n", data)
 }
 
 // JSON-RPC types
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowEmbedder answers only after delay unless its context ends first
+type slowEmbedder struct {
+	delay time.Duration
+}
+
+func (s slowEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	select {
+	case <-time.After(s.delay):
+		return []float32{1, 0, 0}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// serve runs the server over the given newline-delimited input until it
+// ends and returns the messages it wrote
+func serve(t *testing.T, s *Server, input string) []jsonrpcMessage {
+	t.Helper()
+	var out bytes.Buffer
+	s.reader = bufio.NewReader(strings.NewReader(input))
+	s.writer = &out
+
+	if err := s.RunContext(context.Background()); err != nil {
+		t.Fatalf("RunContext: %v", err)
+	}
+
+	var messages []jsonrpcMessage
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var msg jsonrpcMessage
+		if err := decoder.Decode(&msg); err != nil {
+			t.Fatalf("invalid output: %v\n%s", err, out.String())
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func TestRequestTimeoutCancelsSlowSearch(t *testing.T) {
+	s, projectID := newTestServerWithEmbedder(t, slowEmbedder{delay: time.Minute})
+	s.SetRequestTimeout(50 * time.Millisecond)
+
+	call, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "search_memories",
+			"arguments": map[string]interface{}{"query": "deploys", "project_id": projectID},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	messages := serve(t, s, string(call)+"\n")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v with a 50ms timeout", elapsed)
+	}
+
+	if len(messages) != 1 || messages[0].Error == nil {
+		t.Fatalf("got %+v, want one error response", messages)
+	}
+	if data, _ := messages[0].Error.Data.(string); !strings.Contains(data, "did not finish within 50ms") ||
+		!strings.Contains(data, "context deadline exceeded") {
+		t.Errorf("error data = %q, want the request timeout", data)
+	}
+}
@@ -0,0 +1,135 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Optional features reported by setup_status
+const (
+	FeatureVectorStore = "vector_store"
+	FeatureEmbeddings  = "embeddings"
+	FeatureCuration    = "curation"
+)
+
+// Feature describes whether an optional feature is available
+type Feature struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Detail  string `json:"detail"`
+	Enable  string `json:"enable,omitempty"` // One-line command that enables the feature
+}
+
+// SetupStatus describes the features the server started with. The server
+// runs in minimal mode when any of them is disabled.
+type SetupStatus struct {
+	Features []Feature `json:"features"`
+}
+
+// Minimal reports whether any feature is disabled
+func (st *SetupStatus) Minimal() bool {
+	for _, f := range st.Features {
+		if !f.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether the named feature is available. Features that
+// were not reported are assumed to be available.
+func (st *SetupStatus) Enabled(name string) bool {
+	for _, f := range st.Features {
+		if f.Name == name {
+			return f.Enabled
+		}
+	}
+	return true
+}
+
+// toolFeatures maps tools to the feature they cannot work without
+var toolFeatures = map[string]string{
+	"curate_session":       FeatureCuration,
+	"curate_file":          FeatureCuration,
+	"show_curation_prompt": FeatureCuration,
+	"test_ai_connection":   FeatureCuration,
+}
+
+// SetSetupStatus records the features the server started with. Tools whose
+// feature is disabled are hidden and the session primer explains how to
+// enable them.
+func (s *Server) SetSetupStatus(status *SetupStatus) {
+	s.setup = status
+}
+
+// toolAvailable reports whether a tool can be offered
+func (s *Server) toolAvailable(name string) bool {
+	feature, ok := toolFeatures[name]
+	if !ok || s.setup == nil {
+		return true
+	}
+	return s.setup.Enabled(feature)
+}
+
+// setupBanner explains disabled features at the top of the session primer.
+// It is empty when everything is enabled.
+func (s *Server) setupBanner() string {
+	if s.setup == nil || !s.setup.Minimal() {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("> alaala is running in minimal mode. Memories are saved and searchable, but:\n")
+	for _, f := range s.setup.Features {
+		if f.Enabled {
+			continue
+		}
+		fmt.Fprintf(&b, "> - %s: %s\n", f.Name, f.Detail)
+		if f.Enable != "" {
+			fmt.Fprintf(&b, ">   Enable with: %s\n", f.Enable)
+		}
+	}
+	b.WriteString("> Restart alaala after enabling a feature; existing memories are kept. Call setup_status for details.\n\n")
+
+	return b.String()
+}
+
+// toolSetupStatus implements the setup_status tool
+func (s *Server) toolSetupStatus(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	status := s.setup
+	if status == nil {
+		status = &SetupStatus{}
+	}
+
+	var b strings.Builder
+	if status.Minimal() {
+		b.WriteString("Running in minimal mode.\n\n")
+	} else {
+		b.WriteString("All features are enabled.\n\n")
+	}
+	for _, f := range status.Features {
+		state := "enabled"
+		if !f.Enabled {
+			state = "disabled"
+		}
+		fmt.Fprintf(&b, "%s: %s - %s\n", f.Name, state, f.Detail)
+		if !f.Enabled && f.Enable != "" {
+			fmt.Fprintf(&b, "  Enable with: %s\n", f.Enable)
+		}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": strings.TrimRight(b.String(), "\n"),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"minimal":  status.Minimal(),
+			"features": status.Features,
+		},
+	}, nil
+}
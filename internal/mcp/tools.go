@@ -1,11 +1,16 @@
 package mcp
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/0xGurg/alaala/internal/ai"
 	"github.com/0xGurg/alaala/internal/memory"
 )
 
@@ -17,7 +22,7 @@ type Tool struct {
 }
 
 // handleListTools returns the list of available tools
-func (s *Server) handleListTools(params json.RawMessage) (interface{}, error) {
+func (s *Server) handleListTools(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	tools := []Tool{
 		{
 			Name:        "search_memories",
@@ -43,10 +48,108 @@ func (s *Server) handleListTools(params json.RawMessage) (interface{}, error) {
 						"description": "Minimum importance threshold (0-1)",
 						"default":     0.3,
 					},
+					"min_similarity": map[string]interface{}{
+						"type":        "number",
+						"description": "Minimum similarity to the query (0-1, optional; defaults to retrieval.min_similarity, 0 keeps every match)",
+					},
+					"context_types": map[string]interface{}{
+						"type":        "array",
+						"description": "Only return memories of any of these context types, e.g. DECISION and ARCHITECTURE (optional)",
+						"items":       map[string]string{"type": "string"},
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"description": "Only return memories with these tags (optional, see tag_match)",
+						"items":       map[string]string{"type": "string"},
+					},
+					"tag_match": map[string]interface{}{
+						"type":        "string",
+						"description": "Whether memories need any (default) or all of the tags",
+						"enum":        []string{"any", "all"},
+					},
+					"graph_depth": map[string]interface{}{
+						"type":        "number",
+						"description": "Relationship hops to include related memories from (optional; defaults to retrieval.include_graph_depth, 0 disables)",
+					},
+					"graph_direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Relationships to follow when including related memories (default both; supersedes and references are then followed forward only)",
+						"enum":        []string{"outgoing", "incoming", "both"},
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Search only the project (default) or every project in its workspace",
+						"enum":        []string{"project", "workspace"},
+					},
+					"include_archived": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also return archived memories; having no vectors, they only match by keyword",
+						"default":     false,
+					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"description": "Rank by meaning and exact words together (hybrid), by meaning only (semantic) or by the words only (keyword), e.g. for identifiers",
+						"enum":        []string{"hybrid", "semantic", "keyword"},
+						"default":     "hybrid",
+					},
+					"created_after": map[string]interface{}{
+						"type":        []string{"number", "string"},
+						"description": "Only return memories created at or after this time, as Unix seconds or RFC3339 (optional)",
+					},
+					"created_before": map[string]interface{}{
+						"type":        []string{"number", "string"},
+						"description": "Only return memories created before this time, as Unix seconds or RFC3339 (optional)",
+					},
 				},
 				"required": []string{"query"},
 			},
 		},
+		{
+			Name:        "list_memories",
+			Description: "Browse memories page by page without a search query",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID to list (optional, defaults to current project)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of memories to return",
+						"default":     20,
+					},
+					"offset": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of memories to skip",
+						"default":     0,
+					},
+					"order_by": map[string]interface{}{
+						"type":        "string",
+						"description": "Sort order (newest or highest first)",
+						"enum":        []string{"created_at", "importance", "updated_at", "access_count", "last_accessed_at"},
+						"default":     "created_at",
+					},
+					"context_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list memories of this context type",
+					},
+					"tag": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list memories with this tag",
+					},
+					"action_required": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only list memories with this action_required value",
+					},
+					"include_archived": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also list archived memories",
+						"default":     false,
+					},
+				},
+			},
+		},
 		{
 			Name:        "save_memory",
 			Description: "Save a new memory",
@@ -58,9 +161,8 @@ func (s *Server) handleListTools(params json.RawMessage) (interface{}, error) {
 						"description": "The memory content",
 					},
 					"importance": map[string]interface{}{
-						"type":        "number",
-						"description": "Importance weight (0-1)",
-						"default":     0.5,
+						"type":        []string{"number", "string"},
+						"description": "Importance weight (0-1), or \"auto\" to estimate it from the content",
 					},
 					"tags": map[string]interface{}{
 						"type":        "array",
@@ -69,16 +171,277 @@ func (s *Server) handleListTools(params json.RawMessage) (interface{}, error) {
 					},
 					"context_type": map[string]interface{}{
 						"type":        "string",
-						"description": "Context type (TECHNICAL_IMPLEMENTATION, ARCHITECTURE, etc.)",
+						"description": contextTypeDescription(),
+					},
+					"trigger_phrases": map[string]interface{}{
+						"type":        "array",
+						"description": "Phrases that should bring this memory up",
+						"items":       map[string]string{"type": "string"},
+					},
+					"temporal_relevance": map[string]interface{}{
+						"type":        "string",
+						"description": "persistent (default), session, or temporary; session and temporary memories expire",
+					},
+					"action_required": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether the memory needs follow-up; such memories are listed as unresolved items in the session primer",
 					},
 					"project_id": map[string]interface{}{
 						"type":        "string",
 						"description": "Project ID",
 					},
+					"session_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Session to attach the memory to (optional, defaults to the project's active session)",
+					},
 				},
 				"required": []string{"content", "project_id"},
 			},
 		},
+		{
+			Name:        "save_memories",
+			Description: "Save several memories and the relationships between them in one atomic call; either all are saved or none",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"memories": map[string]interface{}{
+						"type":        "array",
+						"description": "Memories to save, in order",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"content": map[string]interface{}{
+									"type":        "string",
+									"description": "The memory content",
+								},
+								"importance": map[string]interface{}{
+									"type":        []string{"number", "string"},
+									"description": "Importance weight (0-1), or \"auto\" to estimate it from the content",
+								},
+								"tags": map[string]interface{}{
+									"type":        "array",
+									"description": "Semantic tags",
+									"items":       map[string]string{"type": "string"},
+								},
+								"context_type": map[string]interface{}{
+									"type":        "string",
+									"description": contextTypeDescription(),
+								},
+								"trigger_phrases": map[string]interface{}{
+									"type":        "array",
+									"description": "Phrases that should bring this memory up",
+									"items":       map[string]string{"type": "string"},
+								},
+								"temporal_relevance": map[string]interface{}{
+									"type":        "string",
+									"description": "persistent, session, or temporary",
+								},
+								"action_required": map[string]interface{}{
+									"type":        "boolean",
+									"description": "Whether the memory needs follow-up",
+								},
+							},
+							"required": []string{"content"},
+						},
+					},
+					"relationships": map[string]interface{}{
+						"type":        "array",
+						"description": "Relationships between the memories, by array index",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"from_index": map[string]interface{}{
+									"type":        "number",
+									"description": "Index of the source memory",
+								},
+								"to_index": map[string]interface{}{
+									"type":        "number",
+									"description": "Index of the target memory",
+								},
+								"type": map[string]interface{}{
+									"type":        "string",
+									"description": "Relationship type (references, supersedes, related_to, conflicts, expands)",
+								},
+							},
+							"required": []string{"from_index", "to_index", "type"},
+						},
+					},
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID",
+					},
+					"session_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Session to attach the memories to (optional, defaults to the project's active session)",
+					},
+				},
+				"required": []string{"memories", "project_id"},
+			},
+		},
+		{
+			Name:        "get_memory",
+			Description: "Get the full record of one memory, including its relationships",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the memory",
+					},
+					"relationship_limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum relationships to show in each direction, oldest first (default 100, at most 1000)",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "update_memory",
+			Description: "Update an existing memory and show what changed",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the memory to update",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "New memory content",
+					},
+					"importance": map[string]interface{}{
+						"type":        "number",
+						"description": "New importance weight (0-1)",
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"description": "Replacement semantic tags",
+						"items":       map[string]string{"type": "string"},
+					},
+					"context_type": map[string]interface{}{
+						"type":        "string",
+						"description": "New context type",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "archive_memory",
+			Description: "Take a memory out of search and listings without deleting it; unarchive_memory restores it",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the memory to archive",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "unarchive_memory",
+			Description: "Restore an archived memory to search and listings",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the memory to restore",
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name: "reinforce_memory",
+			Description: "Tell alaala a memory proved useful (positive delta) or misleading (negative delta), nudging its importance. " +
+				"Call it when a retrieved memory actually helped, not for every search result",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the memory",
+					},
+					"delta": map[string]interface{}{
+						"type":        "number",
+						"description": fmt.Sprintf("Change in importance, between %v and %v (default %v)", -memory.MaxReinforceDelta, memory.MaxReinforceDelta, defaultReinforceDelta),
+						"minimum":     -memory.MaxReinforceDelta,
+						"maximum":     memory.MaxReinforceDelta,
+					},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			Name:        "relate_memories",
+			Description: "Create a relationship between two memories",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"from_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the source memory",
+					},
+					"to_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the target memory",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Relationship type",
+						"enum":        []string{"references", "supersedes", "related_to", "conflicts", "expands"},
+					},
+				},
+				"required": []string{"from_id", "to_id", "type"},
+			},
+		},
+		{
+			Name:        "start_session",
+			Description: "Start a session; memories saved without a session_id are attached to it until it ends",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (optional, defaults to the current project)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "end_session",
+			Description: "End a session and report how long it lasted",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"session_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Session ID (optional, defaults to the project's active session)",
+					},
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (optional, defaults to the current project)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "prune_memories",
+			Description: "Delete temporary and session memories past the expiry configured in expiry.temporary_days and expiry.session_days",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (optional, defaults to the current project)",
+					},
+				},
+			},
+		},
 		{
 			Name:        "curate_session",
 			Description: "Curate memories from a session transcript",
@@ -102,185 +465,1686 @@ func (s *Server) handleListTools(params json.RawMessage) (interface{}, error) {
 			},
 		},
 		{
-			Name:        "list_projects",
-			Description: "List all projects",
+			Name:        "curate_file",
+			Description: "Curate memories from a transcript file inside the project directory, for transcripts too large to pass to curate_session",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path of the transcript file, absolute or relative to the project directory",
+					},
+					"session_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Session ID",
+					},
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (optional, defaults to the current project)",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+		{
+			Name:        "append_transcript",
+			Description: "Append to a session's transcript; once curation.auto_curate_chars uncurated characters accumulate, the new part is curated automatically",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "Transcript text to append",
+					},
+					"session_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Session ID (optional, defaults to the project's active session)",
+					},
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (optional, defaults to the current project)",
+					},
+				},
+				"required": []string{"text"},
+			},
+		},
+		{
+			Name:        "show_curation_prompt",
+			Description: "Show the fully rendered curation prompt sent to the AI provider (transcript replaced by a placeholder)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"placeholder": map[string]interface{}{
+						"type":        "string",
+						"description": "Text to substitute for the transcript",
+						"default":     "<transcript>",
+					},
+				},
+			},
+		},
+		{
+			Name:        "test_ai_connection",
+			Description: "Check that the configured AI provider is reachable and the API key and model work",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+			},
+		},
+		{
+			Name:        "usage_report",
+			Description: "Report AI curation spend from the usage ledger, aggregated by day and model",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"days": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of days to report on",
+						"default":     30,
+					},
+				},
+			},
+		},
+		{
+			Name:        "generate_digest",
+			Description: "Summarize the memories created in every project over the last days, grouped by context type, with each project's open action items and new conflicts",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"days": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of days to cover",
+						"default":     7,
+					},
+				},
+			},
+		},
+		{
+			Name:        "list_stale_embeddings",
+			Description: "List memories whose vectors were made by a different embedder than the current one and need `alaala reindex`",
 			InputSchema: map[string]interface{}{
 				"type": "object",
+				"properties": map[string]interface{}{
+					"project_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Project ID (optional, defaults to all projects)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of memories to list",
+						"default":     50,
+					},
+				},
+			},
+		},
+		{
+			Name:        "list_projects",
+			Description: "List all projects",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+			},
+		},
+		{
+			Name:        "setup_status",
+			Description: "Report which optional features are enabled and how to enable the rest",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+			},
+		},
+	}
+
+	// Hide tools whose feature is disabled
+	available := tools[:0]
+	for _, tool := range tools {
+		if s.toolAvailable(tool.Name) {
+			available = append(available, tool)
+		}
+	}
+
+	return map[string]interface{}{
+		"tools": available,
+	}, nil
+}
+
+// handleCallTool executes a tool
+func (s *Server) handleCallTool(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var req struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, fmt.Errorf("invalid tool call params: %w", err)
+	}
+
+	if !s.toolAvailable(req.Name) {
+		return nil, fmt.Errorf("%s is unavailable because %s is disabled; call setup_status to see how to enable it",
+			req.Name, toolFeatures[req.Name])
+	}
+
+	ctx = memory.WithDegradations(ctx)
+	result, err := s.callTool(ctx, req.Name, req.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	return reportDegradation(ctx, result), nil
+}
+
+// callTool runs the named tool
+func (s *Server) callTool(ctx context.Context, name string, args json.RawMessage) (interface{}, error) {
+	switch name {
+	case "search_memories":
+		return s.toolSearchMemories(ctx, args)
+	case "list_memories":
+		return s.toolListMemories(ctx, args)
+	case "save_memory":
+		return s.toolSaveMemory(ctx, args)
+	case "save_memories":
+		return s.toolSaveMemories(ctx, args)
+	case "get_memory":
+		return s.toolGetMemory(ctx, args)
+	case "update_memory":
+		return s.toolUpdateMemory(ctx, args)
+	case "archive_memory":
+		return s.toolArchiveMemory(ctx, args)
+	case "unarchive_memory":
+		return s.toolUnarchiveMemory(ctx, args)
+	case "reinforce_memory":
+		return s.toolReinforceMemory(ctx, args)
+	case "relate_memories":
+		return s.toolRelateMemories(ctx, args)
+	case "start_session":
+		return s.toolStartSession(ctx, args)
+	case "end_session":
+		return s.toolEndSession(ctx, args)
+	case "prune_memories":
+		return s.toolPruneMemories(ctx, args)
+	case "curate_session":
+		return s.toolCurateSession(ctx, args)
+	case "curate_file":
+		return s.toolCurateFile(ctx, args)
+	case "append_transcript":
+		return s.toolAppendTranscript(ctx, args)
+	case "show_curation_prompt":
+		return s.toolShowCurationPrompt(ctx, args)
+	case "test_ai_connection":
+		return s.toolTestAIConnection(ctx, args)
+	case "usage_report":
+		return s.toolUsageReport(ctx, args)
+	case "generate_digest":
+		return s.toolGenerateDigest(ctx, args)
+	case "list_stale_embeddings":
+		return s.toolListStaleEmbeddings(ctx, args)
+	case "list_projects":
+		return s.toolListProjects(ctx, args)
+	case "setup_status":
+		return s.toolSetupStatus(ctx, args)
+	default:
+		return nil, fmt.Errorf("unknown tool: %s", name)
+	}
+}
+
+// contextTypeDescription describes the context_type argument, listing the
+// built-in and custom context types
+func contextTypeDescription() string {
+	names := make([]string, 0, len(memory.ContextTypes))
+	for _, t := range memory.AllContextTypes() {
+		names = append(names, string(t))
+	}
+	return "Context type, one of " + strings.Join(names, ", ")
+}
+
+// parseContextType validates an optional context type argument in any case
+func parseContextType(name string) (memory.ContextType, error) {
+	if name == "" {
+		return "", nil
+	}
+	return memory.ParseContextType(strings.ToUpper(name))
+}
+
+// parseTemporalRelevance validates an optional temporal relevance argument
+// in any case
+func parseTemporalRelevance(name string) (memory.TemporalRelevance, error) {
+	if name == "" {
+		return "", nil
+	}
+	return memory.ParseTemporalRelevance(strings.ToLower(name))
+}
+
+// parseTimeArg validates an optional time argument given as Unix seconds or
+// an RFC3339 string
+func parseTimeArg(name string, value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case nil:
+		return time.Time{}, nil
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	case string:
+		if v == "" {
+			return time.Time{}, nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s %q: want Unix seconds or RFC3339, e.g. 2024-01-02T15:04:05Z", name, v)
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid %s: want Unix seconds or an RFC3339 string", name)
+	}
+}
+
+// toolSearchMemories implements the search_memories tool
+func (s *Server) toolSearchMemories(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Query          string   `json:"query"`
+		Limit          int      `json:"limit"`
+		ProjectID      string   `json:"project_id"`
+		MinImportance  *float64 `json:"min_importance"`
+		MinSimilarity  *float64 `json:"min_similarity"`
+		ContextTypes   []string `json:"context_types"`
+		Tags           []string `json:"tags"`
+		TagMatch       string   `json:"tag_match"`
+		GraphDepth     *int     `json:"graph_depth"`
+		GraphDirection string   `json:"graph_direction"`
+		Scope          string   `json:"scope"`
+		Mode           string   `json:"mode"`
+
+		IncludeArchived bool        `json:"include_archived"`
+		CreatedAfter    interface{} `json:"created_after"`
+		CreatedBefore   interface{} `json:"created_before"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	// Default values
+	if params.Limit == 0 {
+		params.Limit = 5
+	}
+	// An explicit 0 asks for every memory, so only a missing threshold
+	// gets the default
+	minImportance := 0.3
+	if params.MinImportance != nil {
+		minImportance = *params.MinImportance
+	}
+	createdAfter, err := parseTimeArg("created_after", params.CreatedAfter)
+	if err != nil {
+		return nil, err
+	}
+	createdBefore, err := parseTimeArg("created_before", params.CreatedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get current project if not specified
+	if params.ProjectID == "" {
+		projectID, err := s.getCurrentProjectID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		params.ProjectID = projectID
+	}
+
+	// Search memories
+	query := &memory.SearchQuery{
+		Query:             params.Query,
+		ProjectID:         params.ProjectID,
+		Limit:             params.Limit,
+		MinImportance:     minImportance,
+		MinSimilarity:     params.MinSimilarity,
+		Tags:              params.Tags,
+		TagMatch:          params.TagMatch,
+		IncludeGraphDepth: params.GraphDepth,
+		GraphDirection:    params.GraphDirection,
+		Scope:             params.Scope,
+		IncludeArchived:   params.IncludeArchived,
+		Mode:              params.Mode,
+		CreatedAfter:      createdAfter,
+		CreatedBefore:     createdBefore,
+	}
+	for _, ct := range params.ContextTypes {
+		contextType, err := memory.ParseContextType(strings.ToUpper(ct))
+		if err != nil {
+			return nil, fmt.Errorf("invalid context_types: %w", err)
+		}
+		query.ContextTypes = append(query.ContextTypes, contextType)
+	}
+
+	results, err := s.engine.SearchMemories(ctx, query)
+	if errors.Is(err, memory.ErrNoRelevantMemories) {
+		hint := "Every candidate was less similar to the query than min_similarity. Try different keywords or lower min_similarity."
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": "No sufficiently relevant memories found. " + hint,
+				},
+			},
+			"structuredContent": map[string]interface{}{
+				"memories": []map[string]interface{}{},
+				"count":    0,
+				"empty":    true,
+				"reason":   emptyReasonNotRelevant,
+				"hint":     hint,
+			},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+
+	// Format results
+	var memories []map[string]interface{}
+	projectNames := make(map[string]string)
+	for _, result := range results {
+		mem := map[string]interface{}{
+			"id":               result.Memory.ID,
+			"content":          result.Memory.Content,
+			"importance":       result.Memory.Importance,
+			"tags":             result.Memory.SemanticTags,
+			"context_type":     result.Memory.ContextType,
+			"similarity_score": result.SimilarityScore,
+			"relevance_score":  result.RelevanceScore,
+			"trigger_matched":  result.TriggerMatched,
+			"graph_expanded":   result.GraphExpanded,
+			"created_at":       result.Memory.CreatedAt,
+		}
+		if result.TriggerMatched {
+			mem["matched_trigger"] = result.MatchedTrigger
+		}
+		if result.DistanceMetric != "" {
+			mem["distance"] = result.Distance
+			mem["distance_metric"] = result.DistanceMetric
+		}
+		if result.FusionScore > 0 {
+			mem["fusion_score"] = result.FusionScore
+		}
+		if result.TextFallback {
+			mem["text_fallback"] = true
+		}
+		if result.Memory.Archived {
+			mem["archived"] = true
+		}
+		if result.GraphExpanded {
+			mem["relationship_type"] = result.RelationshipType
+			mem["relationship_direction"] = result.Direction
+		}
+		if params.Scope == memory.ScopeWorkspace {
+			mem["project_id"] = result.Memory.ProjectID
+			mem["project"] = s.projectName(ctx, result.Memory.ProjectID, projectNames)
+		}
+		memories = append(memories, mem)
+	}
+
+	if len(memories) == 0 {
+		empty, err := s.explainEmpty(ctx, params.ProjectID, minImportance, params.Query != "")
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("No memories found. %s", empty.Hint),
+				},
+			},
+			"structuredContent": map[string]interface{}{
+				"memories": []map[string]interface{}{},
+				"count":    0,
+				"empty":    true,
+				"reason":   empty.Reason,
+				"hint":     empty.Hint,
+			},
+		}, nil
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": formatMemoriesAsText(results, false, projectNames),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"memories": memories,
+			"count":    len(memories),
+			"empty":    false,
+		},
+	}, nil
+}
+
+// toolListMemories implements the list_memories tool
+func (s *Server) toolListMemories(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ProjectID      string `json:"project_id"`
+		Limit          int    `json:"limit"`
+		Offset         int    `json:"offset"`
+		OrderBy        string `json:"order_by"`
+		ContextType    string `json:"context_type"`
+		Tag            string `json:"tag"`
+		ActionRequired *bool  `json:"action_required"`
+
+		IncludeArchived bool `json:"include_archived"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	// Default values
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+
+	// Get current project if not specified
+	if params.ProjectID == "" {
+		projectID, err := s.getCurrentProjectID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		params.ProjectID = projectID
+	}
+
+	contextType, err := parseContextType(params.ContextType)
+	if err != nil {
+		return nil, err
+	}
+
+	results, total, err := s.engine.ListMemories(ctx, &memory.ListQuery{
+		ProjectID:       params.ProjectID,
+		Limit:           params.Limit,
+		Offset:          params.Offset,
+		OrderBy:         params.OrderBy,
+		ContextType:     contextType,
+		Tag:             params.Tag,
+		ActionRequired:  params.ActionRequired,
+		IncludeArchived: params.IncludeArchived,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	memories := []map[string]interface{}{}
+	listed := make([]*memory.SearchResult, len(results))
+	for i, mem := range results {
+		listed[i] = &memory.SearchResult{Memory: mem}
+		memories = append(memories, map[string]interface{}{
+			"id":               mem.ID,
+			"content":          mem.Content,
+			"importance":       mem.Importance,
+			"tags":             mem.SemanticTags,
+			"context_type":     mem.ContextType,
+			"action_required":  mem.ActionRequired,
+			"created_at":       mem.CreatedAt,
+			"updated_at":       mem.UpdatedAt,
+			"access_count":     mem.AccessCount,
+			"last_accessed_at": mem.LastAccessedAt,
+			"archived":         mem.Archived,
+		})
+	}
+
+	text := formatMemoriesAsText(listed, true, nil)
+	if len(memories) > 0 {
+		text = fmt.Sprintf("Showing %d-%d of %d memories\n\n%s",
+			params.Offset+1, params.Offset+len(memories), total, text)
+	} else if total > 0 {
+		text = fmt.Sprintf("No memories at offset %d (%d total)", params.Offset, total)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"memories": memories,
+			"total":    total,
+			"offset":   params.Offset,
+			"limit":    params.Limit,
+		},
+	}, nil
+}
+
+// toolSaveMemory implements the save_memory tool
+func (s *Server) toolSaveMemory(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Content           string          `json:"content"`
+		Importance        json.RawMessage `json:"importance"`
+		Tags              []string        `json:"tags"`
+		ContextType       string          `json:"context_type"`
+		TriggerPhrases    []string        `json:"trigger_phrases"`
+		TemporalRelevance string          `json:"temporal_relevance"`
+		ActionRequired    bool            `json:"action_required"`
+		ProjectID         string          `json:"project_id"`
+		SessionID         string          `json:"session_id"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	importance, method, err := s.parseImportance(params.Importance)
+	if err != nil {
+		return nil, err
+	}
+	contextType, err := parseContextType(params.ContextType)
+	if err != nil {
+		return nil, err
+	}
+	temporalRelevance, err := parseTemporalRelevance(params.TemporalRelevance)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach to the given session, or the active one if there is one
+	sessionID, err := s.engine.ResolveSession(ctx, params.ProjectID, params.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create memory
+	mem := &memory.Memory{
+		ProjectID:         params.ProjectID,
+		SessionID:         sessionID,
+		Content:           params.Content,
+		Importance:        importance,
+		ImportanceMethod:  method,
+		SemanticTags:      params.Tags,
+		ContextType:       contextType,
+		TriggerPhrases:    params.TriggerPhrases,
+		TemporalRelevance: temporalRelevance,
+		ActionRequired:    params.ActionRequired,
+	}
+
+	dup, err := s.engine.CreateMemory(ctx, mem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create memory: %w", err)
+	}
+
+	if dup != nil && !dup.Superseded {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("Not saved: memory %s already says nearly the same (similarity %.2f) and was updated instead",
+						dup.ExistingID, dup.Similarity),
+				},
+			},
+			"structuredContent": map[string]interface{}{
+				"duplicate_of": dup.ExistingID,
+				"similarity":   dup.Similarity,
+			},
+		}, nil
+	}
+
+	text := fmt.Sprintf("Memory saved successfully with ID: %s", mem.ID)
+	if method == memory.ImportanceAuto {
+		text += fmt.Sprintf(" (estimated importance %.2f, %s)", mem.Importance, mem.ImportanceMethod)
+	}
+	if dup != nil {
+		text += fmt.Sprintf(". It supersedes the similar memory %s (similarity %.2f)", dup.ExistingID, dup.Similarity)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+	}, nil
+}
+
+// toolSaveMemories implements the save_memories tool
+func (s *Server) toolSaveMemories(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Memories []struct {
+			Content           string          `json:"content"`
+			Importance        json.RawMessage `json:"importance"`
+			Tags              []string        `json:"tags"`
+			ContextType       string          `json:"context_type"`
+			TriggerPhrases    []string        `json:"trigger_phrases"`
+			TemporalRelevance string          `json:"temporal_relevance"`
+			ActionRequired    bool            `json:"action_required"`
+		} `json:"memories"`
+		Relationships []struct {
+			FromIndex int    `json:"from_index"`
+			ToIndex   int    `json:"to_index"`
+			Type      string `json:"type"`
+		} `json:"relationships"`
+		ProjectID string `json:"project_id"`
+		SessionID string `json:"session_id"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	// Attach to the given session, or the active one if there is one
+	sessionID, err := s.engine.ResolveSession(ctx, params.ProjectID, params.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	mems := make([]*memory.Memory, len(params.Memories))
+	for i, m := range params.Memories {
+		importance, method, err := s.parseImportance(m.Importance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save memories (nothing was saved): memories[%d]: %w", i, err)
+		}
+		mems[i] = &memory.Memory{
+			ProjectID:         params.ProjectID,
+			SessionID:         sessionID,
+			Content:           m.Content,
+			Importance:        importance,
+			ImportanceMethod:  method,
+			SemanticTags:      m.Tags,
+			ContextType:       memory.ContextType(m.ContextType),
+			TriggerPhrases:    m.TriggerPhrases,
+			TemporalRelevance: memory.TemporalRelevance(m.TemporalRelevance),
+			ActionRequired:    m.ActionRequired,
+		}
+	}
+
+	rels := make([]memory.BatchRelationship, len(params.Relationships))
+	for i, r := range params.Relationships {
+		rels[i] = memory.BatchRelationship{
+			FromIndex: r.FromIndex,
+			ToIndex:   r.ToIndex,
+			Type:      memory.RelationshipType(r.Type),
+		}
+	}
+
+	if err := s.engine.CreateMemoryBatch(ctx, mems, rels); err != nil {
+		return nil, fmt.Errorf("failed to save memories (nothing was saved): %w", err)
+	}
+
+	ids := make([]string, len(mems))
+	for i, mem := range mems {
+		ids[i] = mem.ID
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Saved %d memories and %d relationships. IDs in order: %s",
+					len(ids), len(rels), strings.Join(ids, ", ")),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"ids":           ids,
+			"relationships": len(rels),
+		},
+	}, nil
+}
+
+// toolGetMemory implements the get_memory tool
+func (s *Server) toolGetMemory(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ID                string `json:"id"`
+		RelationshipLimit int    `json:"relationship_limit"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	mem, err := s.engine.GetMemoryWithRelationships(ctx, params.ID, params.RelationshipLimit)
+	if err != nil {
+		return nil, err
+	}
+	if mem == nil {
+		return nil, fmt.Errorf("memory not found: %s", params.ID)
+	}
+	s.engine.RecordAccess(ctx, mem.ID)
+
+	relationships := []map[string]interface{}{}
+	for _, rel := range mem.Relationships {
+		other := rel.ToMemoryID
+		if rel.Direction == "incoming" {
+			other = rel.FromMemoryID
+		}
+		relationships = append(relationships, map[string]interface{}{
+			"from_id":    rel.FromMemoryID,
+			"to_id":      rel.ToMemoryID,
+			"type":       rel.Type,
+			"direction":  rel.Direction,
+			"other_id":   other,
+			"created_at": rel.CreatedAt,
+		})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Memory %s\n\n%s\n\n", mem.ID, mem.Content)
+	fmt.Fprintf(&b, "Importance: %.2f", mem.Importance)
+	if mem.ContextType != "" {
+		fmt.Fprintf(&b, " | Context: %s", mem.ContextType)
+	}
+	if mem.TemporalRelevance != "" {
+		fmt.Fprintf(&b, " | Relevance: %s", mem.TemporalRelevance)
+	}
+	if mem.ActionRequired {
+		b.WriteString(" | Action required")
+	}
+	if mem.Archived {
+		fmt.Fprintf(&b, " | Archived %s", mem.ArchivedAt.Format(time.RFC3339))
+	}
+	b.WriteString("\n")
+	if len(mem.SemanticTags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(mem.SemanticTags, ", "))
+	}
+	if len(mem.TriggerPhrases) > 0 {
+		fmt.Fprintf(&b, "Trigger phrases: %s\n", strings.Join(mem.TriggerPhrases, "; "))
+	}
+	if mem.Reasoning != "" {
+		fmt.Fprintf(&b, "Reasoning: %s\n", mem.Reasoning)
+	}
+	fmt.Fprintf(&b, "Created: %s | Updated: %s\n",
+		mem.CreatedAt.Format(time.RFC3339), mem.UpdatedAt.Format(time.RFC3339))
+	if mem.LastAccessedAt != nil {
+		fmt.Fprintf(&b, "Accessed %d times before this read, last %s\n",
+			mem.AccessCount, mem.LastAccessedAt.Format(time.RFC3339))
+	}
+
+	if len(relationships) == 0 {
+		b.WriteString("\nNo relationships.")
+	} else {
+		if len(relationships) < mem.RelationshipCount {
+			fmt.Fprintf(&b, "\nRelationships (showing %d of %d):\n", len(relationships), mem.RelationshipCount)
+		} else {
+			fmt.Fprintf(&b, "\nRelationships (%d):\n", len(relationships))
+		}
+		for _, rel := range relationships {
+			if rel["direction"] == "outgoing" {
+				fmt.Fprintf(&b, "- %s -> %s\n", rel["type"], rel["other_id"])
+			} else {
+				fmt.Fprintf(&b, "- %s <- %s\n", rel["type"], rel["other_id"])
+			}
+		}
+	}
+
+	tags := mem.SemanticTags
+	if tags == nil {
+		tags = []string{}
+	}
+	triggers := mem.TriggerPhrases
+	if triggers == nil {
+		triggers = []string{}
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": strings.TrimRight(b.String(), "\n"),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"id":                 mem.ID,
+			"project_id":         mem.ProjectID,
+			"session_id":         mem.SessionID,
+			"content":            mem.Content,
+			"importance":         mem.Importance,
+			"tags":               tags,
+			"context_type":       mem.ContextType,
+			"trigger_phrases":    triggers,
+			"temporal_relevance": mem.TemporalRelevance,
+			"action_required":    mem.ActionRequired,
+			"reasoning":          mem.Reasoning,
+			"created_at":         mem.CreatedAt,
+			"updated_at":         mem.UpdatedAt,
+			"access_count":       mem.AccessCount,
+			"last_accessed_at":   mem.LastAccessedAt,
+			"archived":           mem.Archived,
+			"archived_at":        mem.ArchivedAt,
+			"relationships":      relationships,
+			"relationship_count": mem.RelationshipCount,
+		},
+	}, nil
+}
+
+// toolUpdateMemory implements the update_memory tool
+func (s *Server) toolUpdateMemory(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ID          string   `json:"id"`
+		Content     *string  `json:"content"`
+		Importance  *float64 `json:"importance"`
+		Tags        []string `json:"tags"`
+		ContextType *string  `json:"context_type"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if params.Importance != nil {
+		if err := checkImportance(*params.Importance); err != nil {
+			return nil, err
+		}
+	}
+
+	update := &memory.MemoryUpdate{
+		Content:      params.Content,
+		Importance:   params.Importance,
+		SemanticTags: params.Tags,
+	}
+	if params.ContextType != nil {
+		contextType, err := parseContextType(*params.ContextType)
+		if err != nil {
+			return nil, err
+		}
+		update.ContextType = &contextType
+	}
+
+	mem, diff, err := s.engine.UpdateMemory(ctx, params.ID, update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update memory: %w", err)
+	}
+
+	text := fmt.Sprintf("Memory %s updated.", mem.ID)
+	if diff != "" {
+		text += fmt.Sprintf("\nContent diff: %s", diff)
+	} else {
+		text += "\nContent unchanged."
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+	}, nil
+}
+
+// toolArchiveMemory implements the archive_memory tool
+func (s *Server) toolArchiveMemory(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	mem, err := s.engine.ArchiveMemory(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Memory %s archived. It no longer appears in search or listings; unarchive_memory restores it.", mem.ID),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"id":          mem.ID,
+			"archived":    true,
+			"archived_at": mem.ArchivedAt,
+		},
+	}, nil
+}
+
+// toolUnarchiveMemory implements the unarchive_memory tool
+func (s *Server) toolUnarchiveMemory(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ID string `json:"id"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	mem, err := s.engine.UnarchiveMemory(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Memory %s restored and re-embedded; it is searchable again.", mem.ID),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"id":       mem.ID,
+			"archived": false,
+		},
+	}, nil
+}
+
+// defaultReinforceDelta is how much reinforce_memory raises importance when
+// no delta is given
+const defaultReinforceDelta = 0.05
+
+// toolReinforceMemory implements the reinforce_memory tool
+func (s *Server) toolReinforceMemory(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ID    string   `json:"id"`
+		Delta *float64 `json:"delta"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	delta := defaultReinforceDelta
+	if params.Delta != nil {
+		delta = *params.Delta
+	}
+
+	before, err := s.engine.GetMemory(ctx, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	mem, err := s.engine.ReinforceMemory(ctx, params.ID, delta)
+	if err != nil {
+		return nil, err
+	}
+	previous := mem.Importance
+	if before != nil {
+		previous = before.Importance
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Memory %s importance %.2f -> %.2f", mem.ID, previous, mem.Importance),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"id":                  mem.ID,
+			"previous_importance": previous,
+			"importance":          mem.Importance,
+		},
+	}, nil
+}
+
+// toolRelateMemories implements the relate_memories tool
+func (s *Server) toolRelateMemories(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		FromID string `json:"from_id"`
+		ToID   string `json:"to_id"`
+		Type   string `json:"type"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if params.FromID == "" || params.ToID == "" {
+		return nil, fmt.Errorf("from_id and to_id are required")
+	}
+	if params.FromID == params.ToID {
+		return nil, fmt.Errorf("a memory cannot be related to itself")
+	}
+
+	relType, err := memory.ParseRelationshipType(params.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.engine.CreateRelationship(ctx, params.FromID, params.ToID, relType); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Created relationship: %s %s %s", params.FromID, relType, params.ToID),
+			},
+		},
+	}, nil
+}
+
+// toolStartSession implements the start_session tool
+func (s *Server) toolStartSession(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ProjectID string `json:"project_id"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	if params.ProjectID == "" {
+		projectID, err := s.getCurrentProjectID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		params.ProjectID = projectID
+	}
+
+	// The new session is the project's latest, so ResolveSession attaches
+	// later saves to it until it ends
+	session, err := s.engine.CreateSession(ctx, params.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Started session %s", session.ID),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"session_id": session.ID,
+			"project_id": session.ProjectID,
+			"started_at": session.StartedAt,
+		},
+	}, nil
+}
+
+// toolEndSession implements the end_session tool
+func (s *Server) toolEndSession(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		SessionID string `json:"session_id"`
+		ProjectID string `json:"project_id"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	if params.SessionID == "" {
+		if params.ProjectID == "" {
+			projectID, err := s.getCurrentProjectID(ctx)
+			if err != nil {
+				return nil, err
+			}
+			params.ProjectID = projectID
+		}
+
+		sessionID, err := s.engine.ResolveSession(ctx, params.ProjectID, "")
+		if err != nil {
+			return nil, err
+		}
+		if sessionID == "" {
+			return nil, fmt.Errorf("no active session for project %s; call start_session first", params.ProjectID)
+		}
+		params.SessionID = sessionID
+	}
+
+	session, err := s.engine.EndSession(ctx, params.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to end session: %w", err)
+	}
+
+	duration := time.Duration(*session.DurationSeconds) * time.Second
+	text := fmt.Sprintf("Ended session %s after %s", session.ID, duration)
+	structured := map[string]interface{}{
+		"session_id":       session.ID,
+		"project_id":       session.ProjectID,
+		"started_at":       session.StartedAt,
+		"ended_at":         session.EndedAt,
+		"duration_seconds": *session.DurationSeconds,
+	}
+
+	// Sessions fed through append_transcript but never curated get a summary
+	// for the next session's primer
+	if session.Summary == "" {
+		if summary := s.summarizeSession(ctx, session.ID); summary != "" {
+			text += "\n\nSummary: " + summary
+			structured["summary"] = summary
+		}
+	}
+
+	// A session ending is a good moment to clean up; the session has ended
+	// either way
+	pruned, err := s.engine.PruneExpiredMemories(ctx, session.ProjectID)
+	if err != nil {
+		s.logger.Warn("failed to prune expired memories", "project_id", session.ProjectID, "error", err)
+	} else if pruned.SQLiteDeleted > 0 {
+		text += "\n\n" + formatExpiryReport(pruned)
+		structured["pruned_temporary"] = pruned.Temporary
+		structured["pruned_session"] = pruned.Session
+	}
+	if decayed, err := s.engine.DecayUnusedImportance(ctx, session.ProjectID); err != nil {
+		s.logger.Warn("failed to decay unused memories", "project_id", session.ProjectID, "error", err)
+	} else if decayed.Decayed > 0 {
+		structured["decayed_unused"] = decayed.Decayed
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
 			},
 		},
+		"structuredContent": structured,
+	}, nil
+}
+
+// summarizeSession writes and stores a summary of a session's transcript,
+// returning "" when there is no transcript or the summary was not stored
+func (s *Server) summarizeSession(ctx context.Context, sessionID string) string {
+	transcript, err := s.engine.GetTranscript(ctx, sessionID)
+	if err != nil {
+		s.logger.Warn("failed to read session transcript", "session_id", sessionID, "error", err)
+		return ""
+	}
+	if transcript == nil || strings.TrimSpace(transcript.Text) == "" {
+		return ""
+	}
+
+	summary := s.summarizeTranscript(ctx, transcript.Text)
+	if err := s.engine.SetSessionSummary(ctx, sessionID, summary); err != nil {
+		s.logger.Warn("failed to store session summary", "session_id", sessionID, "error", err)
+		return ""
+	}
+	return summary
+}
+
+// toolPruneMemories implements the prune_memories tool
+func (s *Server) toolPruneMemories(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ProjectID string `json:"project_id"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	if params.ProjectID == "" {
+		projectID, err := s.getCurrentProjectID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		params.ProjectID = projectID
+	}
+
+	report, err := s.engine.PruneExpiredMemories(ctx, params.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune memories: %w", err)
+	}
+
+	policy := s.engine.ExpiryPolicy()
+	text := formatExpiryReport(report)
+	if policy.TemporaryAge == 0 && policy.SessionAge == 0 {
+		text = "Nothing expires: expiry.temporary_days and expiry.session_days are both 0"
 	}
 
 	return map[string]interface{}{
-		"tools": tools,
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"project_id":            params.ProjectID,
+			"temporary":             report.Temporary,
+			"session":               report.Session,
+			"sqlite_deleted":        report.SQLiteDeleted,
+			"relationships_deleted": report.RelationshipsDeleted,
+			"vectors_deleted":       report.VectorDeleted,
+			"vectors_failed":        report.VectorFailed,
+		},
 	}, nil
 }
 
-// handleCallTool executes a tool
-func (s *Server) handleCallTool(params json.RawMessage) (interface{}, error) {
-	var req struct {
-		Name      string          `json:"name"`
-		Arguments json.RawMessage `json:"arguments"`
+// formatExpiryReport describes what expired memories were deleted
+func formatExpiryReport(report *memory.ExpiryReport) string {
+	text := fmt.Sprintf("Pruned %d expired temporary and %d expired session memories (%d vectors deleted)",
+		report.Temporary, report.Session, report.VectorDeleted)
+	if report.RelationshipsDeleted > 0 {
+		text += fmt.Sprintf("; %d relationships to or from them were removed", report.RelationshipsDeleted)
+	}
+	if report.VectorFailed > 0 {
+		text += fmt.Sprintf("; %d vectors could not be deleted and will be retried by the next delete", report.VectorFailed)
 	}
+	return text
+}
 
-	if err := json.Unmarshal(params, &req); err != nil {
-		return nil, fmt.Errorf("invalid tool call params: %w", err)
+// toolCurateSession implements the curate_session tool
+func (s *Server) toolCurateSession(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Transcript string `json:"transcript"`
+		SessionID  string `json:"session_id"`
+		ProjectID  string `json:"project_id"`
 	}
 
-	switch req.Name {
-	case "search_memories":
-		return s.toolSearchMemories(req.Arguments)
-	case "save_memory":
-		return s.toolSaveMemory(req.Arguments)
-	case "curate_session":
-		return s.toolCurateSession(req.Arguments)
-	case "list_projects":
-		return s.toolListProjects(req.Arguments)
-	default:
-		return nil, fmt.Errorf("unknown tool: %s", req.Name)
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
+
+	return s.curateTranscript(ctx, params.ProjectID, params.SessionID, params.Transcript)
 }
 
-// toolSearchMemories implements the search_memories tool
-func (s *Server) toolSearchMemories(args json.RawMessage) (interface{}, error) {
+// toolCurateFile implements the curate_file tool
+func (s *Server) toolCurateFile(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	var params struct {
-		Query         string  `json:"query"`
-		Limit         int     `json:"limit"`
-		ProjectID     string  `json:"project_id"`
-		MinImportance float64 `json:"min_importance"`
+		Path      string `json:"path"`
+		SessionID string `json:"session_id"`
+		ProjectID string `json:"project_id"`
 	}
 
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
+	if params.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
 
-	// Default values
-	if params.Limit == 0 {
-		params.Limit = 5
+	if params.ProjectID == "" {
+		projectID, err := s.getCurrentProjectID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		params.ProjectID = projectID
 	}
-	if params.MinImportance == 0 {
-		params.MinImportance = 0.3
+
+	transcript, err := s.readProjectFile(ctx, params.ProjectID, params.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.curateTranscript(ctx, params.ProjectID, params.SessionID, transcript)
+}
+
+// curateTranscript curates a transcript and reports what was stored
+func (s *Server) curateTranscript(ctx context.Context, projectID, sessionID, transcript string) (interface{}, error) {
+	result, err := s.curator.CurateSession(ctx, projectID, sessionID, transcript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to curate session: %w", err)
+	}
+
+	text := fmt.Sprintf("Curated %d memories from session (%d duplicates skipped, %d superseding older ones, %d relationships stored, %d skipped). Summary: %s",
+		len(result.Memories), result.DuplicatesSkipped, result.Superseding, result.RelationshipsStored, result.RelationshipsSkipped, result.Summary)
+	if len(result.VectorsFailed) > 0 {
+		text += fmt.Sprintf("\n\n%d memories were saved without a vector and won't appear in searches until `alaala reindex` is run: %s",
+			len(result.VectorsFailed), strings.Join(result.VectorsFailed, ", "))
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+	}, nil
+}
+
+// toolAppendTranscript implements the append_transcript tool
+func (s *Server) toolAppendTranscript(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Text      string `json:"text"`
+		SessionID string `json:"session_id"`
+		ProjectID string `json:"project_id"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Text == "" {
+		return nil, fmt.Errorf("text is required")
 	}
 
-	// Get current project if not specified
 	if params.ProjectID == "" {
-		projectID, err := s.getCurrentProjectID()
+		projectID, err := s.getCurrentProjectID(ctx)
 		if err != nil {
 			return nil, err
 		}
 		params.ProjectID = projectID
 	}
 
-	// Search memories
-	query := &memory.SearchQuery{
-		Query:         params.Query,
-		ProjectID:     params.ProjectID,
-		Limit:         params.Limit,
-		MinImportance: params.MinImportance,
+	sessionID, err := s.engine.ResolveSession(ctx, params.ProjectID, params.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sessionID == "" {
+		return nil, fmt.Errorf("no active session for project %s; call start_session first", params.ProjectID)
 	}
 
-	results, err := s.engine.SearchMemories(query)
+	result, err := s.curator.AppendTranscript(ctx, params.ProjectID, sessionID, params.Text)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search memories: %w", err)
+		return nil, err
 	}
 
-	// Format results
-	var memories []map[string]interface{}
-	for _, result := range results {
-		memories = append(memories, map[string]interface{}{
-			"id":               result.Memory.ID,
-			"content":          result.Memory.Content,
-			"importance":       result.Memory.Importance,
-			"tags":             result.Memory.SemanticTags,
-			"context_type":     result.Memory.ContextType,
-			"similarity_score": result.SimilarityScore,
-			"relevance_score":  result.RelevanceScore,
-			"trigger_matched":  result.TriggerMatched,
-			"created_at":       result.Memory.CreatedAt,
+	text := fmt.Sprintf("Appended %d characters to session %s (%d not curated yet)", len(params.Text), sessionID, result.Pending)
+	structured := map[string]interface{}{
+		"session_id": sessionID,
+		"length":     result.Length,
+		"pending":    result.Pending,
+	}
+	if c := result.Curation; c != nil {
+		text += fmt.Sprintf("\n\nAuto-curated %d memories from the new part of the transcript (%d duplicates skipped, %d superseding older ones, %d relationships stored). Summary: %s",
+			len(c.Memories), c.DuplicatesSkipped, c.Superseding, c.RelationshipsStored, c.Summary)
+		if len(c.VectorsFailed) > 0 {
+			text += fmt.Sprintf("\n\n%d memories were saved without a vector and won't appear in searches until `alaala reindex` is run: %s",
+				len(c.VectorsFailed), strings.Join(c.VectorsFailed, ", "))
+		}
+		structured["auto_curated"] = len(c.Memories)
+	}
+	if result.CurationSkip != "" {
+		text += fmt.Sprintf("\n\nAutomatic curation is due but did not run: %s", result.CurationSkip)
+		structured["curation_skipped"] = result.CurationSkip
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+		"structuredContent": structured,
+	}, nil
+}
+
+// toolShowCurationPrompt implements the show_curation_prompt tool
+func (s *Server) toolShowCurationPrompt(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Placeholder string `json:"placeholder"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	if params.Placeholder == "" {
+		params.Placeholder = "<transcript>"
+	}
+
+	provider, model, prompt, err := s.curator.CurationPrompt(params.Placeholder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render curation prompt: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Provider: %s\nModel: %s\n\n%s", provider, model, prompt),
+			},
+		},
+	}, nil
+}
+
+// toolTestAIConnection implements the test_ai_connection tool
+func (s *Server) toolTestAIConnection(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	status, err := s.curator.TestConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	if status.Err == nil {
+		text = fmt.Sprintf("AI provider OK\nProvider: %s\nModel: %s\nLatency: %dms",
+			status.Provider, status.Model, status.Latency.Milliseconds())
+	} else {
+		text = fmt.Sprintf("AI provider check FAILED (%s)\nProvider: %s\nModel: %s\nLatency: %dms\nError: %v",
+			ai.ClassifyError(status.Err), status.Provider, status.Model, status.Latency.Milliseconds(), status.Err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+		"isError": status.Err != nil,
+	}, nil
+}
+
+// toolUsageReport implements the usage_report tool
+func (s *Server) toolUsageReport(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Days int `json:"days"`
+	}
+
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	if params.Days <= 0 {
+		params.Days = 30
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-params.Days)
+	summaries, err := s.engine.UsageReport(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build usage report: %w", err)
+	}
+
+	if len(summaries) == 0 {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("No AI usage recorded in the last %d days. Usage is only recorded when ai.track_usage is enabled in the config.", params.Days),
+				},
+			},
+		}, nil
+	}
+
+	var b strings.Builder
+	var totalCalls, totalPrompt, totalCompletion int
+	var totalCost float64
+	rows := make([]map[string]interface{}, 0, len(summaries))
+
+	fmt.Fprintf(&b, "AI usage for the last %d days:\n\n", params.Days)
+	for _, summary := range summaries {
+		fmt.Fprintf(&b, "%s  %s/%s: %d calls, %d prompt + %d completion tokens, $%.4f\n",
+			summary.Day, summary.Provider, summary.Model, summary.Calls,
+			summary.PromptTokens, summary.CompletionTokens, summary.Cost)
+
+		totalCalls += summary.Calls
+		totalPrompt += summary.PromptTokens
+		totalCompletion += summary.CompletionTokens
+		totalCost += summary.Cost
+
+		rows = append(rows, map[string]interface{}{
+			"day":               summary.Day,
+			"provider":          summary.Provider,
+			"model":             summary.Model,
+			"calls":             summary.Calls,
+			"prompt_tokens":     summary.PromptTokens,
+			"completion_tokens": summary.CompletionTokens,
+			"cost":              summary.Cost,
 		})
 	}
+	fmt.Fprintf(&b, "\nTotal: %d calls, %d prompt + %d completion tokens, $%.4f (estimated)",
+		totalCalls, totalPrompt, totalCompletion, totalCost)
 
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": formatMemoriesAsText(memories),
+				"text": b.String(),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"days": params.Days,
+			"rows": rows,
+			"total": map[string]interface{}{
+				"calls":             totalCalls,
+				"prompt_tokens":     totalPrompt,
+				"completion_tokens": totalCompletion,
+				"cost":              totalCost,
 			},
 		},
 	}, nil
 }
 
-// toolSaveMemory implements the save_memory tool
-func (s *Server) toolSaveMemory(args json.RawMessage) (interface{}, error) {
+// toolGenerateDigest implements the generate_digest tool
+func (s *Server) toolGenerateDigest(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	var params struct {
-		Content     string   `json:"content"`
-		Importance  float64  `json:"importance"`
-		Tags        []string `json:"tags"`
-		ContextType string   `json:"context_type"`
-		ProjectID   string   `json:"project_id"`
+		Days int `json:"days"`
 	}
 
-	if err := json.Unmarshal(args, &params); err != nil {
-		return nil, fmt.Errorf("invalid arguments: %w", err)
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
 	}
 
-	// Default importance
-	if params.Importance == 0 {
-		params.Importance = 0.5
+	if params.Days <= 0 {
+		params.Days = 7
 	}
 
-	// Create memory
-	mem := &memory.Memory{
-		ProjectID:    params.ProjectID,
-		Content:      params.Content,
-		Importance:   params.Importance,
-		SemanticTags: params.Tags,
-		ContextType:  memory.ContextType(params.ContextType),
+	digest, err := s.engine.Digest(ctx, time.Now().AddDate(0, 0, -params.Days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate digest: %w", err)
 	}
 
-	if err := s.engine.CreateMemory(mem); err != nil {
-		return nil, fmt.Errorf("failed to create memory: %w", err)
+	// The digest stands on its own, so a failed summary is only reported
+	summarySkipped := ""
+	if s.curator.DigestSummaryEnabled() && !digest.Empty() {
+		if err := s.curator.NarrateDigest(ctx, digest); err != nil {
+			s.logger.Warn("failed to summarize digest", "error", err)
+			summarySkipped = err.Error()
+		}
+	}
+
+	projects := make([]map[string]interface{}, 0, len(digest.Projects))
+	for _, project := range digest.Projects {
+		projects = append(projects, map[string]interface{}{
+			"project_id":   project.Project.ID,
+			"project_name": project.Project.Name,
+			"new_memories": project.NewMemories,
+			"open_actions": project.OpenActions,
+			"conflicts":    len(project.Conflicts),
+		})
+	}
+
+	structured := map[string]interface{}{
+		"days":     params.Days,
+		"since":    digest.Since.UTC().Format(time.RFC3339),
+		"projects": projects,
+	}
+	if digest.Narrative != "" {
+		structured["summary"] = digest.Narrative
+	}
+	if summarySkipped != "" {
+		structured["summary_skipped"] = summarySkipped
 	}
 
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": fmt.Sprintf("Memory saved successfully with ID: %s", mem.ID),
+				"text": digest.Markdown(),
 			},
 		},
+		"structuredContent": structured,
 	}, nil
 }
 
-// toolCurateSession implements the curate_session tool
-func (s *Server) toolCurateSession(args json.RawMessage) (interface{}, error) {
+// toolListStaleEmbeddings implements the list_stale_embeddings tool
+func (s *Server) toolListStaleEmbeddings(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	var params struct {
-		Transcript string `json:"transcript"`
-		SessionID  string `json:"session_id"`
-		ProjectID  string `json:"project_id"`
+		ProjectID string `json:"project_id"`
+		Limit     int    `json:"limit"`
 	}
 
-	if err := json.Unmarshal(args, &params); err != nil {
-		return nil, fmt.Errorf("invalid arguments: %w", err)
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if params.Limit <= 0 {
+		params.Limit = 50
 	}
 
-	// Curate memories
-	result, err := s.curator.CurateSession(params.ProjectID, params.SessionID, params.Transcript)
+	stale, total, err := s.engine.ListStaleEmbeddings(ctx, params.ProjectID, params.Limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to curate session: %w", err)
+		return nil, err
+	}
+	current := s.engine.EmbedderID()
+
+	var text strings.Builder
+	if total == 0 {
+		fmt.Fprintf(&text, "All embeddings come from the current embedder (%s).", current)
+	} else {
+		fmt.Fprintf(&text, "%d memories have embeddings from another embedder (current: %s). "+
+			"Run `alaala reindex` to re-embed them.\n\n", total, current)
+		for i, m := range stale {
+			embedder := m.EmbedderID
+			if embedder == "" {
+				embedder = "unknown"
+			}
+			fmt.Fprintf(&text, "%d. %s [%s]\n   %s\n", i+1, m.ID, embedder, truncateText(m.Content, 100))
+		}
+		if total > len(stale) {
+			fmt.Fprintf(&text, "\n...and %d more.", total-len(stale))
+		}
+	}
+
+	memories := make([]map[string]interface{}, len(stale))
+	for i, m := range stale {
+		memories[i] = map[string]interface{}{
+			"id":          m.ID,
+			"project_id":  m.ProjectID,
+			"embedder_id": m.EmbedderID,
+		}
 	}
 
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": fmt.Sprintf("Curated %d memories from session. Summary: %s", len(result.Memories), result.Summary),
+				"text": strings.TrimRight(text.String(), "\n"),
 			},
 		},
+		"structuredContent": map[string]interface{}{
+			"current_embedder": current,
+			"total":            total,
+			"memories":         memories,
+		},
 	}, nil
 }
 
 // toolListProjects implements the list_projects tool
-func (s *Server) toolListProjects(args json.RawMessage) (interface{}, error) {
+func (s *Server) toolListProjects(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	// TODO: Implement project listing
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
@@ -294,7 +2158,22 @@ func (s *Server) toolListProjects(args json.RawMessage) (interface{}, error) {
 
 // Helper functions
 
-func (s *Server) getCurrentProjectID() (string, error) {
+// projectName returns a project's name, falling back to its ID, and caches
+// it in names
+func (s *Server) projectName(ctx context.Context, id string, names map[string]string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+
+	name := id
+	if project, err := s.engine.GetProject(ctx, id); err == nil && project != nil {
+		name = project.Name
+	}
+	names[id] = name
+	return name
+}
+
+func (s *Server) getCurrentProjectID(ctx context.Context) (string, error) {
 	// Get current working directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -306,7 +2185,7 @@ func (s *Server) getCurrentProjectID() (string, error) {
 	if _, err := os.Stat(projectFile); err != nil {
 		// Create a new project
 		projectName := filepath.Base(cwd)
-		project, err := s.engine.GetOrCreateProject(projectName, cwd)
+		project, err := s.engine.GetOrCreateProject(ctx, projectName, cwd)
 		if err != nil {
 			return "", err
 		}
@@ -327,7 +2206,7 @@ func (s *Server) getCurrentProjectID() (string, error) {
 	}
 
 	// Get or create project
-	project, err := s.engine.GetOrCreateProject(projectConfig.Name, cwd)
+	project, err := s.engine.GetOrCreateProject(ctx, projectConfig.Name, cwd)
 	if err != nil {
 		return "", err
 	}
@@ -335,20 +2214,109 @@ func (s *Server) getCurrentProjectID() (string, error) {
 	return project.ID, nil
 }
 
-func formatMemoriesAsText(memories []map[string]interface{}) string {
-	if len(memories) == 0 {
+// formatMemoriesAsText renders search results or a list_memories page
+// (listing) as text, one memory per block: content, then its ID, context
+// type and creation time, then its scores and markers, then its tags.
+// projects names the project of each memory when several are searched.
+func formatMemoriesAsText(results []*memory.SearchResult, listing bool, projects map[string]string) string {
+	if len(results) == 0 {
 		return "No memories found."
 	}
 
-	result := fmt.Sprintf("Found %d relevant memories:\n\n", len(memories))
-	for i, mem := range memories {
-		result += fmt.Sprintf("%d. %s\n", i+1, mem["content"])
-		result += fmt.Sprintf("   Importance: %.2f | Relevance: %.2f\n", mem["importance"], mem["relevance_score"])
-		if tags, ok := mem["tags"].([]string); ok && len(tags) > 0 {
-			result += fmt.Sprintf("   Tags: %v\n", tags)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d relevant memories:\n\n", len(results))
+	for i, result := range results {
+		mem := result.Memory
+		fmt.Fprintf(&b, "%d. %s\n", i+1, mem.Content)
+
+		fmt.Fprintf(&b, "   ID: %s", mem.ID)
+		if mem.ContextType != "" {
+			fmt.Fprintf(&b, " | Type: %s", mem.ContextType)
+		}
+		fmt.Fprintf(&b, " | Created: %s\n", mem.CreatedAt.UTC().Format(time.RFC3339))
+
+		fmt.Fprintf(&b, "   Importance: %.2f", mem.Importance)
+		if !listing {
+			fmt.Fprintf(&b, " | Relevance: %.2f", result.RelevanceScore)
+		}
+		if result.TriggerMatched {
+			fmt.Fprintf(&b, " | Trigger match %q", result.MatchedTrigger)
+		}
+		if result.GraphExpanded {
+			if result.RelationshipType != "" {
+				fmt.Fprintf(&b, " | via %s relationship", result.RelationshipType)
+			} else {
+				b.WriteString(" | via related memory")
+			}
+		}
+		if project, ok := projects[mem.ProjectID]; ok {
+			fmt.Fprintf(&b, " | project %s", project)
+		}
+		if mem.Archived {
+			b.WriteString(" | Archived")
+		}
+		if result.TextFallback {
+			b.WriteString(" | Text match (vector search unavailable)")
+		}
+		if listing {
+			fmt.Fprintf(&b, " | Accessed %d times", mem.AccessCount)
+			if mem.LastAccessedAt != nil {
+				fmt.Fprintf(&b, ", last %s", mem.LastAccessedAt.UTC().Format(time.RFC3339))
+			}
+		}
+		b.WriteString("\n")
+
+		if len(mem.SemanticTags) > 0 {
+			fmt.Fprintf(&b, "   Tags: %s\n", strings.Join(mem.SemanticTags, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// parseImportance reads an importance argument: a number, "auto", or
+// nothing. It returns the importance and how it was chosen; an "auto"
+// importance is estimated when the memory is created.
+func (s *Server) parseImportance(raw json.RawMessage) (float64, string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		if s.autoImportance {
+			return 0, memory.ImportanceAuto, nil
+		}
+		return 0.5, memory.ImportanceMethodDefault, nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		if mode != memory.ImportanceAuto {
+			return 0, "", fmt.Errorf("importance must be a number between 0 and 1 or \"auto\", got %q", mode)
 		}
-		result += "\n"
+		return 0, memory.ImportanceAuto, nil
 	}
 
-	return result
+	var importance float64
+	if err := json.Unmarshal(raw, &importance); err != nil {
+		return 0, "", fmt.Errorf("importance must be a number between 0 and 1 or \"auto\"")
+	}
+	if err := checkImportance(importance); err != nil {
+		return 0, "", err
+	}
+	return importance, memory.ImportanceMethodManual, nil
+}
+
+// checkImportance rejects importances outside 0-1
+func checkImportance(importance float64) error {
+	if importance < 0 || importance > 1 {
+		return fmt.Errorf("importance must be a number between 0 and 1, got %v", importance)
+	}
+	return nil
+}
+
+// truncateText shortens text to at most max runes on a single line
+func truncateText(text string, max int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= max {
+		return string(runes)
+	}
+	return string(runes[:max-3]) + "..."
 }
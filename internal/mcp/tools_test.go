@@ -0,0 +1,257 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/memory"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// newTestServer returns a server over a fresh SQLite database with the local
+// vector store and the hash embedder, and the ID of a project in it
+func newTestServer(t testing.TB) (*Server, string) {
+	t.Helper()
+	return newTestServerWithEmbedder(t, embeddings.NewHashClient())
+}
+
+// newTestServerWithEmbedder is newTestServer with the given embedder
+func newTestServerWithEmbedder(t testing.TB, embedder memory.Embedder) (*Server, string) {
+	t.Helper()
+	s, projectID, _ := newTestServerWithStores(t, embedder, nil)
+	return s, projectID
+}
+
+// newTestServerWithStores is newTestServerWithEmbedder with the vector store
+// wrap returns for the local one, unless wrap is nil. It also returns the
+// database's path.
+func newTestServerWithStores(t testing.TB, embedder memory.Embedder, wrap func(*storage.LocalVectorStore) memory.VectorStore) (*Server, string, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "alaala.db")
+	store, err := storage.NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	var vectors memory.VectorStore = storage.NewLocalVectorStore(store)
+	if wrap != nil {
+		vectors = wrap(storage.NewLocalVectorStore(store))
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	engine := memory.NewEngine(store, vectors, embedder)
+	engine.SetLogger(logger)
+
+	project, err := engine.GetOrCreateProject(context.Background(), "test", t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+
+	server := NewServer(engine, nil)
+	server.SetLogger(logger)
+	return server, project.ID, path
+}
+
+// callTool calls a tool handler with the given arguments
+func callTool(t testing.TB, handler func(context.Context, json.RawMessage) (interface{}, error), args map[string]interface{}) (interface{}, error) {
+	t.Helper()
+	raw, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler(context.Background(), raw)
+}
+
+func TestParseImportance(t *testing.T) {
+	s := &Server{}
+	tests := []struct {
+		raw        string
+		want       float64
+		wantMethod string
+		wantErr    string
+	}{
+		{``, 0.5, memory.ImportanceMethodDefault, ""},
+		{`null`, 0.5, memory.ImportanceMethodDefault, ""},
+		{`0`, 0, memory.ImportanceMethodManual, ""},
+		{`0.8`, 0.8, memory.ImportanceMethodManual, ""},
+		{`1`, 1, memory.ImportanceMethodManual, ""},
+		{`"auto"`, 0, memory.ImportanceAuto, ""},
+		{`1.5`, 0, "", "importance must be a number between 0 and 1"},
+		{`-0.1`, 0, "", "importance must be a number between 0 and 1"},
+		{`"high"`, 0, "", "importance must be a number between 0 and 1"},
+		{`[0.5]`, 0, "", "importance must be a number between 0 and 1"},
+	}
+	for _, tt := range tests {
+		got, method, err := s.parseImportance(json.RawMessage(tt.raw))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseImportance(%s) error = %v, want %q", tt.raw, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want || method != tt.wantMethod {
+			t.Errorf("parseImportance(%s) = %v, %q, %v; want %v, %q", tt.raw, got, method, err, tt.want, tt.wantMethod)
+		}
+	}
+}
+
+func TestSaveAndUpdateRejectOutOfRangeImportance(t *testing.T) {
+	s, projectID := newTestServer(t)
+
+	_, err := callTool(t, s.toolSaveMemory, map[string]interface{}{
+		"content": "Too important", "importance": 7, "project_id": projectID,
+	})
+	if err == nil || !strings.Contains(err.Error(), "importance must be a number between 0 and 1") {
+		t.Fatalf("save_memory error = %v, want a range error", err)
+	}
+
+	mem := &memory.Memory{ProjectID: projectID, Content: "Deploys go through CI", Importance: 0.5}
+	if _, err := s.engine.CreateMemory(context.Background(), mem); err != nil {
+		t.Fatal(err)
+	}
+	for _, importance := range []float64{-1, 1.01} {
+		_, err := callTool(t, s.toolUpdateMemory, map[string]interface{}{"id": mem.ID, "importance": importance})
+		if err == nil || !strings.Contains(err.Error(), "importance must be a number between 0 and 1") {
+			t.Errorf("update_memory with importance %v: error = %v, want a range error", importance, err)
+		}
+	}
+
+	stored, err := s.engine.GetMemory(context.Background(), mem.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Importance != 0.5 {
+		t.Errorf("importance changed to %v by a rejected update", stored.Importance)
+	}
+
+	if _, err := callTool(t, s.toolUpdateMemory, map[string]interface{}{"id": mem.ID, "importance": 0.9}); err != nil {
+		t.Errorf("update_memory with importance 0.9: %v", err)
+	}
+}
+
+// searchContents returns the contents of the memories search_memories found
+func searchContents(t *testing.T, s *Server, args map[string]interface{}) []string {
+	t.Helper()
+	result, err := callTool(t, s.toolSearchMemories, args)
+	if err != nil {
+		t.Fatalf("search_memories: %v", err)
+	}
+	var contents []string
+	memories := result.(map[string]interface{})["structuredContent"].(map[string]interface{})["memories"]
+	for _, mem := range memories.([]map[string]interface{}) {
+		contents = append(contents, mem["content"].(string))
+	}
+	sort.Strings(contents)
+	return contents
+}
+
+func TestSearchMemoriesHonorsExplicitZero(t *testing.T) {
+	const (
+		pipeline = "Deploys go through the release pipeline"
+		minor    = "Deploys were slow once"
+		cache    = "The cache is warmed on startup"
+	)
+
+	tests := []struct {
+		name          string
+		minSimilarity float64 // Engine defaults
+		graphDepth    int
+		args          map[string]interface{}
+		want          []string
+	}{
+		{"min_importance omitted", 0, 0,
+			map[string]interface{}{"query": "deploys", "mode": "keyword"}, []string{pipeline}},
+		{"min_importance 0", 0, 0,
+			map[string]interface{}{"query": "deploys", "mode": "keyword", "min_importance": 0}, []string{pipeline, minor}},
+		{"min_importance 0.6", 0, 0,
+			map[string]interface{}{"query": "deploys", "mode": "keyword", "min_importance": 0.6}, nil},
+
+		{"min_similarity omitted", 0.99, 0,
+			map[string]interface{}{"query": "deploys", "mode": "semantic", "min_importance": 0}, nil},
+		{"min_similarity 0", 0.99, 0,
+			map[string]interface{}{"query": "deploys", "mode": "semantic", "min_importance": 0, "min_similarity": 0}, []string{pipeline, minor, cache}},
+		{"min_similarity 0.99", 0, 0,
+			map[string]interface{}{"query": "deploys", "mode": "semantic", "min_importance": 0, "min_similarity": 0.99}, nil},
+
+		{"graph_depth omitted", 0, 1,
+			map[string]interface{}{"query": "pipeline", "mode": "keyword"}, []string{pipeline, cache}},
+		{"graph_depth 0", 0, 1,
+			map[string]interface{}{"query": "pipeline", "mode": "keyword", "graph_depth": 0}, []string{pipeline}},
+		{"graph_depth 1", 0, 0,
+			map[string]interface{}{"query": "pipeline", "mode": "keyword", "graph_depth": 1}, []string{pipeline, cache}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, projectID := newTestServer(t)
+			s.engine.SetMinSimilarity(tt.minSimilarity)
+			s.engine.SetGraphDepth(tt.graphDepth)
+
+			ids := make(map[string]string)
+			for content, importance := range map[string]float64{pipeline: 0.5, minor: 0.1, cache: 0.5} {
+				mem := &memory.Memory{ProjectID: projectID, Content: content, Importance: importance}
+				if _, err := s.engine.CreateMemory(ctx, mem); err != nil {
+					t.Fatal(err)
+				}
+				ids[content] = mem.ID
+			}
+			if err := s.engine.CreateRelationship(ctx, ids[pipeline], ids[cache], memory.RelationshipTypeRelatedTo); err != nil {
+				t.Fatal(err)
+			}
+
+			args := map[string]interface{}{"project_id": projectID}
+			for k, v := range tt.args {
+				args[k] = v
+			}
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if got := searchContents(t, s, args); !reflect.DeepEqual(got, want) {
+				t.Errorf("found %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSaveMemoryHonorsExplicitZeroImportance(t *testing.T) {
+	tests := []struct {
+		name       string
+		importance interface{} // nil leaves it out
+		want       float64
+	}{
+		{"omitted", nil, 0.5},
+		{"0", 0, 0},
+		{"0.8", 0.8, 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, projectID := newTestServer(t)
+			args := map[string]interface{}{"content": "Deploys go through the release pipeline", "project_id": projectID}
+			if tt.importance != nil {
+				args["importance"] = tt.importance
+			}
+
+			result, err := callTool(t, s.toolSaveMemory, args)
+			if err != nil {
+				t.Fatalf("save_memory: %v", err)
+			}
+			id := strings.TrimPrefix(toolText(t, result), "Memory saved successfully with ID: ")
+			mem, err := s.engine.GetMemory(context.Background(), id)
+			if err != nil {
+				t.Fatalf("GetMemory(%q): %v", id, err)
+			}
+			if mem.Importance != tt.want {
+				t.Errorf("importance = %v, want %v", mem.Importance, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// framing is how messages are delimited on the wire
+type framing int
+
+const (
+	framingLines   framing = iota // One JSON message per line
+	framingHeaders                // Content-Length headers, a blank line, then the body, as in LSP
+)
+
+// maxMessageSize bounds the body a Content-Length header may announce, so
+// that a corrupt header does not make the server allocate without limit
+const maxMessageSize = 256 << 20
+
+// readMessage reads the body of the next message from the client. Each
+// message may be a line of JSON or be framed by headers; the framing of the
+// latest message is used for the server's own messages. Blank lines between
+// messages are skipped.
+func (s *Server) readMessage() ([]byte, error) {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || strings.TrimSpace(line) == "") {
+			return nil, err
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !isHeaderLine(trimmed) {
+			s.setFraming(framingLines)
+			return []byte(line), nil
+		}
+
+		body, err := s.readFramedBody(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		s.setFraming(framingHeaders)
+		return body, nil
+	}
+}
+
+// isHeaderLine reports whether a line starts a header-framed message rather
+// than holding a JSON message
+func isHeaderLine(line string) bool {
+	if strings.HasPrefix(line, "{") || strings.HasPrefix(line, "[") {
+		return false
+	}
+	name, _, ok := strings.Cut(line, ":")
+	return ok && name != "" && !strings.ContainsAny(name, " \t\"")
+}
+
+// readFramedBody reads the rest of a header-framed message whose first
+// header line has been read
+func (s *Server) readFramedBody(first string) ([]byte, error) {
+	length := -1
+	line := first
+	for line != "" {
+		name, value, _ := strings.Cut(line, ":")
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid Content-Length header %q", line)
+			}
+			length = n
+		}
+
+		next, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message headers: %w", err)
+		}
+		line = strings.TrimSpace(next)
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("message headers have no Content-Length")
+	}
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds the %d byte limit", length, maxMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.reader, body); err != nil {
+		return nil, fmt.Errorf("failed to read %d byte message body: %w", length, err)
+	}
+	return body, nil
+}
+
+// setFraming records the framing the client uses
+func (s *Server) setFraming(f framing) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.framing = f
+}
+
+// writeMessage writes one message to the client in the framing it uses. A
+// line of JSON is encoded straight to the writer instead of being marshaled
+// and copied again, so a large result is only held in memory once; a
+// header-framed message has to be marshaled first to know its length.
+func (s *Server) writeMessage(msg interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if s.framing == framingLines {
+		return json.NewEncoder(s.writer).Encode(msg)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var header bytes.Buffer
+	fmt.Fprintf(&header, "Content-Length: %d\r\n\r\n", len(body))
+	if _, err := s.writer.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err = s.writer.Write(body)
+	return err
+}
+
+// flush waits for a message being written to finish and flushes the writer
+// if it buffers, so that the client never sees half a message
+func (s *Server) flush() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if flusher, ok := s.writer.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			s.logger.Warn("failed to flush output", "error", err)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/0xGurg/alaala/internal/ai"
+	"github.com/0xGurg/alaala/internal/memory"
+)
+
+// recordingAI remembers the transcript it was asked to curate
+type recordingAI struct {
+	transcript string
+}
+
+func (r *recordingAI) CurateMemories(req *ai.CurationRequest) (*ai.CurationResponse, error) {
+	r.transcript = req.Transcript
+	return &ai.CurationResponse{Summary: "Talked about deploys"}, nil
+}
+
+// encodeMessage writes msg to buf in the given framing
+func encodeMessage(t *testing.T, buf *bytes.Buffer, f framing, msg interface{}) {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f == framingHeaders {
+		fmt.Fprintf(buf, "Content-Length: %d\r\n\r\n", len(body))
+		buf.Write(body)
+		return
+	}
+	buf.Write(body)
+	buf.WriteByte('\n')
+}
+
+// decodeMessages reads the messages the server wrote, requiring each to be
+// in the given framing
+func decodeMessages(t *testing.T, out io.Reader, f framing) []jsonrpcMessage {
+	t.Helper()
+	reader := bufio.NewReader(out)
+	var messages []jsonrpcMessage
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+
+		body := []byte(line)
+		if f == framingHeaders {
+			value, ok := strings.CutPrefix(line, "Content-Length: ")
+			if !ok {
+				t.Fatalf("message starts with %q, want a Content-Length header", line)
+			}
+			length, err := strconv.Atoi(strings.TrimSuffix(value, "\r\n"))
+			if err != nil {
+				t.Fatalf("invalid Content-Length header %q", line)
+			}
+			if blank, _ := reader.ReadString('\n'); blank != "\r\n" {
+				t.Fatalf("headers end with %q, want a blank line", blank)
+			}
+			body = make([]byte, length)
+			if _, err := io.ReadFull(reader, body); err != nil {
+				t.Fatalf("failed to read %d byte body: %v", length, err)
+			}
+		}
+
+		var msg jsonrpcMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid message: %v\n%.200s", err, body)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func TestRunContextRoundTripsEachFraming(t *testing.T) {
+	// Embedded newlines must not split a message in either framing
+	small := "We agreed deploys need a green build.\nThen we rolled back.\n"
+	large := strings.Repeat(small, (5<<20)/len(small)+1)
+
+	tests := []struct {
+		name       string
+		framing    framing
+		transcript string
+	}{
+		{"newline-delimited", framingLines, small},
+		{"Content-Length", framingHeaders, small},
+		{"newline-delimited 5MB", framingLines, large},
+		{"Content-Length 5MB", framingHeaders, large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, projectID := newTestServer(t)
+			recorder := &recordingAI{}
+			s.curator = memory.NewCurator(s.engine, recorder)
+
+			var in, out bytes.Buffer
+			encodeMessage(t, &in, tt.framing, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"method":  "tools/call",
+				"params": map[string]interface{}{
+					"name":      "curate_session",
+					"arguments": map[string]interface{}{"transcript": tt.transcript, "project_id": projectID},
+				},
+			})
+			s.reader = bufio.NewReader(&in)
+			s.writer = &out
+
+			if err := s.RunContext(context.Background()); err != nil {
+				t.Fatalf("RunContext: %v", err)
+			}
+
+			messages := decodeMessages(t, &out, tt.framing)
+			if len(messages) != 1 || messages[0].Error != nil {
+				t.Fatalf("got %+v, want one result", messages)
+			}
+			if id, _ := messages[0].ID.(float64); id != 1 {
+				t.Errorf("response ID = %v, want 1", messages[0].ID)
+			}
+			if !bytes.Contains(messages[0].Result, []byte("Talked about deploys")) {
+				t.Errorf("result = %.200s, want the curation summary", messages[0].Result)
+			}
+			if recorder.transcript != tt.transcript {
+				t.Errorf("curated a %d byte transcript, want the %d bytes sent", len(recorder.transcript), len(tt.transcript))
+			}
+		})
+	}
+}
+
+func TestRunContextAnswersInTheLatestFraming(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	// The client switches framing between runs; each response must follow
+	// the request it answers, not the framing of the one before
+	var out bytes.Buffer
+	for i, f := range []framing{framingHeaders, framingLines, framingHeaders} {
+		var in bytes.Buffer
+		encodeMessage(t, &in, f, map[string]interface{}{"jsonrpc": "2.0", "id": i, "method": "tools/list"})
+		s.reader = bufio.NewReader(&in)
+		s.writer = &out
+		if err := s.RunContext(context.Background()); err != nil {
+			t.Fatalf("RunContext: %v", err)
+		}
+
+		messages := decodeMessages(t, &out, f)
+		if len(messages) != 1 || messages[0].Error != nil {
+			t.Fatalf("request %d: got %+v, want one result", i, messages)
+		}
+		out.Reset()
+	}
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+)
+
+// ArchiveMemory takes a memory out of search and listings without deleting
+// it. Its vector is removed; the SQLite row is kept so UnarchiveMemory can
+// restore it. A vector that fails to be removed is only logged, since
+// search skips archived memories either way.
+func (e *Engine) ArchiveMemory(ctx context.Context, id string) (*Memory, error) {
+	mem, err := e.GetMemory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if mem == nil {
+		return nil, fmt.Errorf("memory not found: %s", id)
+	}
+	if mem.Archived {
+		return nil, fmt.Errorf("memory %s is already archived", id)
+	}
+
+	if err := e.sqlStore.SetMemoryArchived(ctx, id, true); err != nil {
+		return nil, fmt.Errorf("failed to archive memory: %w", err)
+	}
+	if err := e.vectorStore.Delete(ctx, id); err != nil {
+		e.logger.Warn("failed to remove vector of archived memory", "id", id, "error", err)
+	}
+
+	return e.GetMemory(ctx, id)
+}
+
+// UnarchiveMemory restores an archived memory, re-embedding it so that it
+// is searchable again. The memory stays archived if that fails.
+func (e *Engine) UnarchiveMemory(ctx context.Context, id string) (*Memory, error) {
+	mem, err := e.GetMemory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if mem == nil {
+		return nil, fmt.Errorf("memory not found: %s", id)
+	}
+	if !mem.Archived {
+		return nil, fmt.Errorf("memory %s is not archived", id)
+	}
+
+	if err := e.replaceVector(ctx, mem); err != nil {
+		return nil, err
+	}
+	if err := e.sqlStore.SetMemoryArchived(ctx, id, false); err != nil {
+		return nil, fmt.Errorf("failed to unarchive memory: %w", err)
+	}
+
+	return e.GetMemory(ctx, id)
+}
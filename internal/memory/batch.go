@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+	"github.com/google/uuid"
+)
+
+// BatchRelationship relates two memories of a batch by their positions
+type BatchRelationship struct {
+	FromIndex int
+	ToIndex   int
+	Type      RelationshipType
+}
+
+// BatchError identifies the batch entry that was rejected
+type BatchError struct {
+	Field string // "memories" or "relationships"
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%s[%d]: %v", e.Field, e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// CreateMemoryBatch stores memories and the relationships between them
+// atomically. Everything is validated and embedded before anything is
+// written; if storing a vector fails, the memories already written are
+// removed again. On success the memories carry their new IDs, in order.
+func (e *Engine) CreateMemoryBatch(ctx context.Context, mems []*Memory, rels []BatchRelationship) error {
+	if len(mems) == 0 {
+		return fmt.Errorf("no memories to save")
+	}
+
+	for i, mem := range mems {
+		if err := validateBatchMemory(mem); err != nil {
+			return &BatchError{Field: "memories", Index: i, Err: err}
+		}
+	}
+	for i, rel := range rels {
+		if err := validateBatchRelationship(rel, len(mems)); err != nil {
+			return &BatchError{Field: "relationships", Index: i, Err: err}
+		}
+	}
+
+	now := time.Now()
+	texts := make([]string, len(mems))
+	for i, mem := range mems {
+		if mem.ID == "" {
+			mem.ID = uuid.New().String()
+		}
+		if mem.CreatedAt.IsZero() {
+			mem.CreatedAt = now
+		}
+		mem.UpdatedAt = mem.CreatedAt
+		texts[i] = mem.Content
+	}
+
+	embeddings, err := e.embedAll(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	embedderID := e.EmbedderID()
+	sqlMemories := make([]*storage.Memory, len(mems))
+	for i, mem := range mems {
+		e.resolveImportance(ctx, mem, embeddings[i])
+		sqlMemories[i] = memoryToSQLMemory(mem)
+		sqlMemories[i].EmbedderID = embedderID
+	}
+
+	var sqlRels []*storage.MemoryRelationship
+	var revisions []*storage.MemoryRevision
+	for _, rel := range rels {
+		from, to := mems[rel.FromIndex], mems[rel.ToIndex]
+		sqlRels = append(sqlRels, &storage.MemoryRelationship{
+			FromMemoryID:     from.ID,
+			ToMemoryID:       to.ID,
+			RelationshipType: string(rel.Type),
+		})
+
+		// Record supersedes as a revision, as CreateRelationship does
+		if rel.Type == RelationshipTypeSupersedes {
+			if diff := WordDiff(to.Content, from.Content); diff != "" {
+				revisions = append(revisions, &storage.MemoryRevision{
+					MemoryID:        from.ID,
+					PreviousContent: to.Content,
+					Diff:            diff,
+					Reason:          fmt.Sprintf("supersedes %s", to.ID),
+				})
+			}
+		}
+	}
+
+	if err := e.sqlStore.CreateMemoryBatch(ctx, sqlMemories, sqlRels, revisions); err != nil {
+		return fmt.Errorf("failed to store memories in SQLite: %w", err)
+	}
+	for i, mem := range mems {
+		mem.SemanticTags = sqlMemories[i].Tags
+		mem.TriggerPhrases = sqlMemories[i].TriggerPhrases
+	}
+
+	// Vectors can't join the SQLite transaction, so undo by hand on failure
+	if stored, err := e.storeVectors(ctx, mems, embeddings); err != nil {
+		e.rollbackBatch(ctx, mems, stored)
+		return fmt.Errorf("failed to store memory in vector database: %w", err)
+	}
+
+	return nil
+}
+
+// rollbackBatch removes the first stored vectors and all SQLite rows of a
+// batch whose vector writes failed part way
+func (e *Engine) rollbackBatch(ctx context.Context, mems []*Memory, stored int) {
+	// The batch may have failed because ctx ended; the rollback must still run
+	ctx = context.WithoutCancel(ctx)
+
+	ids := make([]string, len(mems))
+	for i, mem := range mems {
+		ids[i] = mem.ID
+	}
+
+	failed := make(map[string]string)
+	for _, id := range ids[:stored] {
+		if err := e.vectorStore.Delete(ctx, id); err != nil {
+			e.logger.Warn("failed to roll back vector", "id", id, "error", err)
+			failed[id] = err.Error()
+		}
+	}
+	e.queueVectorDeletes(ctx, failed)
+	if _, err := e.sqlStore.DeleteMemories(ctx, ids); err != nil {
+		e.logger.Warn("failed to roll back memories", "ids", ids, "error", err)
+	}
+}
+
+// validateBatchMemory checks the fields a batch memory must have
+func validateBatchMemory(mem *Memory) error {
+	if strings.TrimSpace(mem.Content) == "" {
+		return fmt.Errorf("content is required")
+	}
+	if mem.ProjectID == "" {
+		return fmt.Errorf("project_id is required")
+	}
+	if mem.Importance < 0 || mem.Importance > 1 {
+		return fmt.Errorf("importance %v is out of range (0-1)", mem.Importance)
+	}
+	if mem.ContextType != "" {
+		if _, err := ParseContextType(string(mem.ContextType)); err != nil {
+			return err
+		}
+	}
+	if mem.TemporalRelevance != "" {
+		if _, err := ParseTemporalRelevance(string(mem.TemporalRelevance)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBatchRelationship checks that a relationship points at two
+// different memories of the batch
+func validateBatchRelationship(rel BatchRelationship, count int) error {
+	if rel.FromIndex < 0 || rel.FromIndex >= count {
+		return fmt.Errorf("from_index %d is out of range (0-%d)", rel.FromIndex, count-1)
+	}
+	if rel.ToIndex < 0 || rel.ToIndex >= count {
+		return fmt.Errorf("to_index %d is out of range (0-%d)", rel.ToIndex, count-1)
+	}
+	if rel.FromIndex == rel.ToIndex {
+		return fmt.Errorf("a memory cannot be related to itself")
+	}
+	if _, err := ParseRelationshipType(string(rel.Type)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowEmbedder takes far longer than any test deadline unless its context
+// ends first, like an Ollama server that has stopped answering
+type slowEmbedder struct {
+	delay time.Duration
+}
+
+func (s slowEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	select {
+	case <-time.After(s.delay):
+		return []float32{1, 0, 0}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestSearchWithSlowEmbedderHitsDeadline(t *testing.T) {
+	env := newTestEnv(t)
+	env.save(t, &Memory{Content: "Deploys go through CI", Importance: 0.5})
+	env.engine.embedder = slowEmbedder{delay: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := env.engine.SearchMemories(ctx, &SearchQuery{Query: "deploys", ProjectID: env.projectID, Limit: 5})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SearchMemories error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("search took %v after a 50ms deadline", elapsed)
+	}
+}
+
+func TestCreateMemoryWithSlowEmbedderHitsDeadline(t *testing.T) {
+	env := newTestEnv(t)
+	env.engine.embedder = slowEmbedder{delay: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := env.engine.CreateMemory(ctx, &Memory{ProjectID: env.projectID, Content: "Never saved", Importance: 0.5})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CreateMemory error = %v, want context.DeadlineExceeded", err)
+	}
+	if n := env.countMemories(t); n != 0 {
+		t.Errorf("%d memories saved after the deadline, want 0", n)
+	}
+}
+
+func TestSearchWithSlowEmbedderWithinDeadline(t *testing.T) {
+	env := newTestEnv(t)
+	env.engine.embedder = slowEmbedder{delay: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := env.engine.SearchMemories(ctx, &SearchQuery{Query: "deploys", ProjectID: env.projectID, Limit: 5}); err != nil && !errors.Is(err, ErrNoRelevantMemories) {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+}
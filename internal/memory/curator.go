@@ -1,9 +1,15 @@
 package memory
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/0xGurg/alaala/internal/ai"
+	"github.com/0xGurg/alaala/internal/storage"
 	"github.com/google/uuid"
 )
 
@@ -11,6 +17,13 @@ import (
 type Curator struct {
 	engine   *Engine
 	aiClient AIClient
+	logger   *slog.Logger
+
+	autoChars  int
+	autoBudget float64
+
+	digestSummary bool    // Have the AI provider summarize digests
+	digestBudget  float64 // Daily AI budget (USD) for digest summaries; 0 = no limit
 }
 
 // AIClient is an interface for AI-powered curation
@@ -18,21 +31,315 @@ type AIClient interface {
 	CurateMemories(req *ai.CurationRequest) (*ai.CurationResponse, error)
 }
 
+// PromptInspector is implemented by AI clients that can render their
+// curation prompt without calling the provider
+type PromptInspector interface {
+	CurationPrompt(req *ai.CurationRequest) string
+	Provider() string
+	Model() string
+}
+
+// Summarizer is implemented by AI clients that can summarize a session
+// transcript and shorten text
+type Summarizer interface {
+	Summarize(transcript string) (string, error)
+	Compress(text string, maxChars int) (string, error)
+}
+
+// DigestNarrator is implemented by AI clients that can summarize a digest
+type DigestNarrator interface {
+	NarrateDigest(digest string) (string, error)
+}
+
+// ConnectionTester is implemented by AI clients that can verify connectivity
+type ConnectionTester interface {
+	Ping() error
+}
+
+// ConnectionStatus reports the result of an AI provider connectivity test
+type ConnectionStatus struct {
+	Provider string
+	Model    string
+	Latency  time.Duration
+	Err      error
+}
+
 // NewCurator creates a new curator
 func NewCurator(engine *Engine, aiClient AIClient) *Curator {
 	return &Curator{
 		engine:   engine,
 		aiClient: aiClient,
+		logger:   slog.Default(),
+	}
+}
+
+// SetLogger sets the logger used for diagnostics
+func (c *Curator) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// SetAutoCuration makes AppendTranscript curate a session once chars bytes
+// of its transcript are uncurated; 0 disables it. While the AI spend recorded
+// today is at or above dailyBudget (USD), automatic curation is skipped. A
+// dailyBudget of 0 means no limit.
+func (c *Curator) SetAutoCuration(chars int, dailyBudget float64) {
+	c.autoChars = chars
+	c.autoBudget = dailyBudget
+}
+
+// SetDigestSummary enables AI summaries of digests, skipped while the AI
+// spend recorded today is at or above dailyBudget (USD); 0 means no limit
+func (c *Curator) SetDigestSummary(dailyBudget float64) {
+	c.digestSummary = true
+	c.digestBudget = dailyBudget
+}
+
+// DigestSummaryEnabled reports whether digests get an AI summary
+func (c *Curator) DigestSummaryEnabled() bool {
+	return c.digestSummary
+}
+
+// CurationPrompt renders the prompt that would be sent to the AI provider,
+// with the transcript replaced by the given placeholder
+func (c *Curator) CurationPrompt(placeholder string) (provider, model, prompt string, err error) {
+	inspector, ok := c.aiClient.(PromptInspector)
+	if !ok {
+		return "", "", "", fmt.Errorf("AI client does not support prompt inspection")
+	}
+
+	req := &ai.CurationRequest{Transcript: placeholder, ContextTypes: curationContextTypes()}
+	return inspector.Provider(), inspector.Model(), inspector.CurationPrompt(req), nil
+}
+
+// TestConnection sends a tiny prompt to the AI provider and measures the round trip
+func (c *Curator) TestConnection() (*ConnectionStatus, error) {
+	tester, ok := c.aiClient.(ConnectionTester)
+	if !ok {
+		return nil, fmt.Errorf("AI client does not support connection tests")
+	}
+
+	status := &ConnectionStatus{}
+	if inspector, ok := c.aiClient.(PromptInspector); ok {
+		status.Provider = inspector.Provider()
+		status.Model = inspector.Model()
+	}
+
+	start := time.Now()
+	status.Err = tester.Ping()
+	status.Latency = time.Since(start)
+
+	return status, nil
+}
+
+// Summarize writes a short summary of a session transcript with the
+// configured AI provider
+func (c *Curator) Summarize(transcript string) (string, error) {
+	summarizer, err := c.summarizer()
+	if err != nil {
+		return "", err
+	}
+	return summarizer.Summarize(transcript)
+}
+
+// Compress shortens text to at most maxChars characters with the configured
+// AI provider
+func (c *Curator) Compress(text string, maxChars int) (string, error) {
+	summarizer, err := c.summarizer()
+	if err != nil {
+		return "", err
+	}
+	return summarizer.Compress(text, maxChars)
+}
+
+// NarrateDigest has the configured AI provider write the digest's
+// narrative summary. The digest itself is left as it was if the provider
+// cannot, or the digest budget is spent.
+func (c *Curator) NarrateDigest(ctx context.Context, digest *Digest) error {
+	if !c.digestSummary {
+		return fmt.Errorf("AI digest summaries are disabled")
+	}
+	if c.aiClient == nil {
+		return fmt.Errorf("no AI provider is configured")
+	}
+	narrator, ok := c.aiClient.(DigestNarrator)
+	if !ok {
+		return fmt.Errorf("the configured AI provider cannot summarize digests")
+	}
+	if reason := c.budgetReached(ctx, c.digestBudget); reason != "" {
+		return fmt.Errorf("digest summary skipped: %s", reason)
 	}
+
+	narrative, err := narrator.NarrateDigest(digest.Markdown())
+	if err != nil {
+		return err
+	}
+	digest.Narrative = narrative
+	return nil
+}
+
+func (c *Curator) summarizer() (Summarizer, error) {
+	if c.aiClient == nil {
+		return nil, fmt.Errorf("no AI provider is configured")
+	}
+	summarizer, ok := c.aiClient.(Summarizer)
+	if !ok {
+		return nil, fmt.Errorf("the configured AI provider cannot summarize")
+	}
+	return summarizer, nil
 }
 
 // CurateSession curates memories from a session transcript
-func (c *Curator) CurateSession(projectID, sessionID, transcript string) (*CurationResponse, error) {
+func (c *Curator) CurateSession(ctx context.Context, projectID, sessionID, transcript string) (*CurationResponse, error) {
+	return c.curate(ctx, projectID, sessionID, transcript, false)
+}
+
+// AppendTranscript adds text to a session's transcript. When automatic
+// curation is enabled and enough of the transcript is uncurated, only that
+// part is curated; duplicates of memories from earlier parts are merged as
+// usual. A failed curation is logged and retried on the next append.
+func (c *Curator) AppendTranscript(ctx context.Context, projectID, sessionID, text string) (*TranscriptAppend, error) {
+	transcript, err := c.engine.AppendTranscript(ctx, sessionID, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append transcript: %w", err)
+	}
+
+	result := &TranscriptAppend{
+		SessionID: sessionID,
+		Length:    len(transcript.Text),
+		Pending:   len(transcript.Pending()),
+	}
+	if c.autoChars <= 0 || result.Pending < c.autoChars {
+		return result, nil
+	}
+
+	if reason := c.autoCurationBlocked(ctx); reason != "" {
+		c.logger.Debug("automatic curation skipped", "session_id", sessionID, "reason", reason)
+		result.CurationSkip = reason
+		return result, nil
+	}
+
+	curation, err := c.curate(ctx, projectID, sessionID, transcript.Pending(), true)
+	if err != nil {
+		c.logger.Warn("automatic curation failed", "session_id", sessionID, "error", err)
+		result.CurationSkip = err.Error()
+		return result, nil
+	}
+	if err := c.engine.SetTranscriptCurated(ctx, sessionID, len(transcript.Text)); err != nil {
+		// The next curation repeats this part; dedup merges the repeats
+		c.logger.Warn("failed to record curated transcript offset", "session_id", sessionID, "error", err)
+	}
+
+	result.Pending = 0
+	result.Curation = curation
+	return result, nil
+}
+
+// autoCurationBlocked returns why automatic curation must not run now, or
+// an empty string if it may
+func (c *Curator) autoCurationBlocked(ctx context.Context) string {
+	if c.aiClient == nil {
+		return "no AI provider is configured"
+	}
+	return c.budgetReached(ctx, c.autoBudget)
+}
+
+// budgetReached returns why the AI spend recorded today rules out a call
+// under a daily budget (USD), or an empty string if it does not. A budget
+// of 0 means no limit.
+func (c *Curator) budgetReached(ctx context.Context, budget float64) string {
+	if budget <= 0 {
+		return ""
+	}
+
+	spent, err := c.engine.AISpendToday(ctx)
+	if err != nil {
+		c.logger.Warn("failed to read AI usage for budget", "error", err)
+		return "AI usage could not be read"
+	}
+	if spent >= budget {
+		return fmt.Sprintf("daily AI budget of $%.2f reached", budget)
+	}
+	return ""
+}
+
+// curationBatchSize bounds how many curated memories are embedded and
+// stored at once, so a very large curation does not hold every embedding
+// and vector request in memory together
+const curationBatchSize = 100
+
+// storeCurated stores curated memories in batches of curationBatchSize,
+// deduplicating each batch against the ones stored before it. Batches
+// stored before a failing one are kept. Duplicates are returned with their
+// index in memories, along with a *VectorFailureError covering every batch
+// if some memories were saved without a vector.
+func (c *Curator) storeCurated(ctx context.Context, memories []*Memory) ([]Duplicate, error) {
+	var duplicates []Duplicate
+	var vectorFailures []storage.BatchFailure
+	for start := 0; start < len(memories); start += curationBatchSize {
+		end := start + curationBatchSize
+		if end > len(memories) {
+			end = len(memories)
+		}
+
+		batchDuplicates, err := c.engine.CreateMemoriesDeduped(ctx, memories[start:end])
+		var vectorFailure *VectorFailureError
+		if errors.As(err, &vectorFailure) {
+			vectorFailures = append(vectorFailures, vectorFailure.Failed...)
+		} else if err != nil {
+			return nil, fmt.Errorf("memories %d-%d of %d: %w", start+1, end, len(memories), err)
+		}
+		for _, dup := range batchDuplicates {
+			dup.Index += start
+			duplicates = append(duplicates, dup)
+		}
+
+		if len(memories) > curationBatchSize {
+			c.logger.Info("stored curated memories", "done", end, "total", len(memories))
+		}
+	}
+
+	if len(vectorFailures) > 0 {
+		return duplicates, &VectorFailureError{Failed: vectorFailures}
+	}
+	return duplicates, nil
+}
+
+// curationContextTypes lists the custom context types for the curation
+// prompt
+func curationContextTypes() []ai.ContextType {
+	custom := CustomContextTypes()
+	if len(custom) == 0 {
+		return nil
+	}
+	types := make([]ai.ContextType, len(custom))
+	for i, t := range custom {
+		types[i] = ai.ContextType{Name: string(t.Name), Description: t.Description}
+	}
+	return types
+}
+
+// curatedContextType validates a context type the AI assigned. One it made
+// up is dropped rather than failing the whole curation.
+func (c *Curator) curatedContextType(name string) ContextType {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	contextType, err := ParseContextType(name)
+	if err != nil {
+		c.logger.Warn("dropping context type the AI made up", "context_type", name)
+		return ""
+	}
+	return contextType
+}
+
+func (c *Curator) curate(ctx context.Context, projectID, sessionID, transcript string, auto bool) (*CurationResponse, error) {
 	// Call AI to extract memories
 	aiReq := &ai.CurationRequest{
-		Transcript: transcript,
-		ProjectID:  projectID,
-		SessionID:  sessionID,
+		Transcript:   transcript,
+		ProjectID:    projectID,
+		SessionID:    sessionID,
+		ContextTypes: curationContextTypes(),
 	}
 
 	aiResp, err := c.aiClient.CurateMemories(aiReq)
@@ -40,19 +347,21 @@ func (c *Curator) CurateSession(projectID, sessionID, transcript string) (*Curat
 		return nil, fmt.Errorf("failed to curate memories with AI: %w", err)
 	}
 
-	// Convert AI memories to our memory format and store them
-	var memories []*Memory
+	// Convert AI memories to our memory format and store them together so
+	// their contents are embedded in as few calls as possible
+	memories := make([]*Memory, 0, len(aiResp.Memories))
 	memoryIDs := make([]string, len(aiResp.Memories))
 
-	for i, curatedMem := range aiResp.Memories {
+	for _, curatedMem := range aiResp.Memories {
 		mem := &Memory{
 			ID:                uuid.New().String(),
 			ProjectID:         projectID,
 			SessionID:         sessionID,
 			Content:           curatedMem.Content,
 			Importance:        curatedMem.Importance,
+			ImportanceMethod:  ImportanceMethodCurated,
 			SemanticTags:      curatedMem.SemanticTags,
-			ContextType:       ContextType(curatedMem.ContextType),
+			ContextType:       c.curatedContextType(curatedMem.ContextType),
 			TriggerPhrases:    curatedMem.TriggerPhrases,
 			QuestionTypes:     curatedMem.QuestionTypes,
 			TemporalRelevance: TemporalRelevance(curatedMem.TemporalRelevance),
@@ -60,14 +369,43 @@ func (c *Curator) CurateSession(projectID, sessionID, transcript string) (*Curat
 			Reasoning:         curatedMem.Reasoning,
 		}
 
-		// Store memory
-		if err := c.engine.CreateMemory(mem); err != nil {
-			return nil, fmt.Errorf("failed to store memory: %w", err)
-		}
-
 		memories = append(memories, mem)
+	}
+
+	// Memories repeating existing ones (e.g. from an overlapping transcript)
+	// are merged into them, and relationships point at the existing memory,
+	// unless they are created superseding it
+	duplicates, err := c.storeCurated(ctx, memories)
+	var vectorFailure *VectorFailureError
+	if errors.As(err, &vectorFailure) {
+		c.logger.Warn("curated memories saved without vectors", "count", len(vectorFailure.Failed),
+			"ids", vectorFailure.IDs(), "error", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to store memories: %w", err)
+	}
+	for i, mem := range memories {
 		memoryIDs[i] = mem.ID
 	}
+	created := memories
+	merged, superseding := 0, 0
+	if len(duplicates) > 0 {
+		duplicate := make(map[int]bool, len(duplicates))
+		for _, dup := range duplicates {
+			if dup.Superseded {
+				superseding++
+				continue
+			}
+			memoryIDs[dup.Index] = dup.ExistingID
+			duplicate[dup.Index] = true
+			merged++
+		}
+		created = make([]*Memory, 0, len(memories)-merged)
+		for i, mem := range memories {
+			if !duplicate[i] {
+				created = append(created, mem)
+			}
+		}
+	}
 
 	// Store relationships
 	var relationships []struct {
@@ -76,18 +414,38 @@ func (c *Curator) CurateSession(projectID, sessionID, transcript string) (*Curat
 		Type   RelationshipType
 	}
 
+	stored, skipped := 0, 0
 	for _, rel := range aiResp.Relationships {
 		if rel.FromIndex < 0 || rel.FromIndex >= len(memoryIDs) ||
-			rel.ToIndex < 0 || rel.ToIndex >= len(memoryIDs) {
-			continue // Invalid indices
+			rel.ToIndex < 0 || rel.ToIndex >= len(memoryIDs) ||
+			rel.FromIndex == rel.ToIndex {
+			c.logger.Debug("skipping relationship with invalid indices", "from", rel.FromIndex, "to", rel.ToIndex)
+			skipped++
+			continue
+		}
+
+		relType, err := ParseRelationshipType(rel.Type)
+		if err != nil {
+			c.logger.Debug("skipping relationship", "type", rel.Type, "error", err)
+			skipped++
+			continue
 		}
 
 		fromID := memoryIDs[rel.FromIndex]
 		toID := memoryIDs[rel.ToIndex]
-		relType := RelationshipType(rel.Type)
+		if fromID == toID {
+			c.logger.Debug("skipping relationship between duplicates", "from", rel.FromIndex, "to", rel.ToIndex)
+			skipped++
+			continue
+		}
+
+		if err := c.engine.CreateRelationship(ctx, fromID, toID, relType); err != nil {
+			c.logger.Warn("failed to store relationship", "from", fromID, "to", toID, "error", err)
+			skipped++
+			continue
+		}
 
-		// TODO: Store relationship in database
-		// For now, just add to response
+		stored++
 		relationships = append(relationships, struct {
 			FromID string
 			ToID   string
@@ -99,9 +457,40 @@ func (c *Curator) CurateSession(projectID, sessionID, transcript string) (*Curat
 		})
 	}
 
+	// Say where automatically curated memories came from, since nobody
+	// asked for them
+	summary := aiResp.Summary
+	if auto && strings.TrimSpace(summary) != "" {
+		summary = "Auto-curated from the session transcript: " + summary
+	}
+
+	// Keep the summary for the next session's primer; the memories are
+	// stored either way
+	if sessionID != "" && strings.TrimSpace(summary) != "" {
+		if err := c.engine.SetSessionSummary(ctx, sessionID, summary); err != nil {
+			c.logger.Warn("failed to store session summary", "session_id", sessionID, "error", err)
+		}
+	}
+
+	var vectorsFailed []string
+	if vectorFailure != nil {
+		vectorsFailed = vectorFailure.IDs()
+	}
+
+	c.logger.Info("curated session", "project_id", projectID, "session_id", sessionID,
+		"memories", len(created), "duplicates", merged, "superseding", superseding, "relationships", stored, "skipped", skipped,
+		"vectors_failed", len(vectorsFailed), "truncation_recovered", aiResp.TruncationRecovered, "auto", auto)
+
 	return &CurationResponse{
-		Memories:      memories,
-		Relationships: relationships,
-		Summary:       aiResp.Summary,
+		Memories:             created,
+		DuplicatesSkipped:    merged,
+		Superseding:          superseding,
+		Relationships:        relationships,
+		RelationshipsStored:  stored,
+		RelationshipsSkipped: skipped,
+		Summary:              summary,
+		VectorsFailed:        vectorsFailed,
+		TruncationRecovered:  aiResp.TruncationRecovered,
+		AutoTriggered:        auto,
 	}, nil
 }
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/ai"
+)
+
+// fakeAIClient answers every curation request with the same response
+type fakeAIClient struct {
+	response *ai.CurationResponse
+	requests []*ai.CurationRequest
+}
+
+func (f *fakeAIClient) CurateMemories(req *ai.CurationRequest) (*ai.CurationResponse, error) {
+	f.requests = append(f.requests, req)
+	return f.response, nil
+}
+
+func TestCurateSessionStoresRelationships(t *testing.T) {
+	env := newTestEnv(t)
+	client := &fakeAIClient{response: &ai.CurationResponse{
+		Memories: []ai.CuratedMemory{
+			{Content: "Sessions are stored in Redis", Importance: 0.6, ContextType: "DECISION"},
+			{Content: "Sessions moved from Redis to Postgres for durability", Importance: 0.8, ContextType: "DECISION"},
+		},
+		Relationships: []ai.MemoryRelationship{
+			{FromIndex: 1, ToIndex: 0, Type: "supersedes"},
+			{FromIndex: 0, ToIndex: 7, Type: "references"}, // Out of range
+			{FromIndex: 0, ToIndex: 1, Type: "replaces"},   // Unknown type
+		},
+		Summary: "Moved sessions to Postgres",
+	}}
+	curator := NewCurator(env.engine, client)
+
+	result, err := curator.CurateSession(context.Background(), env.projectID, "", "transcript")
+	if err != nil {
+		t.Fatalf("CurateSession: %v", err)
+	}
+	if len(result.Memories) != 2 {
+		t.Fatalf("created %d memories, want 2", len(result.Memories))
+	}
+	if result.RelationshipsStored != 1 || result.RelationshipsSkipped != 2 {
+		t.Errorf("stored %d and skipped %d relationships, want 1 and 2",
+			result.RelationshipsStored, result.RelationshipsSkipped)
+	}
+
+	older, newer := result.Memories[0], result.Memories[1]
+	mem, err := env.engine.GetMemoryWithRelationships(context.Background(), newer.ID, 0)
+	if err != nil {
+		t.Fatalf("GetMemoryWithRelationships: %v", err)
+	}
+	if len(mem.Relationships) != 1 {
+		t.Fatalf("got %d relationships, want 1: %+v", len(mem.Relationships), mem.Relationships)
+	}
+	rel := mem.Relationships[0]
+	if rel.FromMemoryID != newer.ID || rel.ToMemoryID != older.ID || rel.Type != RelationshipTypeSupersedes {
+		t.Errorf("got relationship %s -%s-> %s, want %s -supersedes-> %s",
+			rel.FromMemoryID, rel.Type, rel.ToMemoryID, newer.ID, older.ID)
+	}
+}
+
+// hugeDraft returns a curation response with n memories of about size
+// bytes each, every one distinct so that none is merged as a duplicate
+func hugeDraft(n, size int) *ai.CurationResponse {
+	filler := strings.Repeat("The deploy pipeline retries flaky integration steps. ", size/53+1)[:size]
+	resp := &ai.CurationResponse{Summary: "A very long session"}
+	for i := 0; i < n; i++ {
+		resp.Memories = append(resp.Memories, ai.CuratedMemory{
+			Content:        fmt.Sprintf("Finding %d: %s", i, filler),
+			Importance:     0.5,
+			ContextType:    "TECHNICAL",
+			SemanticTags:   []string{"deploy", fmt.Sprintf("finding-%d", i)},
+			TriggerPhrases: []string{fmt.Sprintf("finding %d", i)},
+		})
+	}
+	return resp
+}
+
+// peakHeap samples the live heap every millisecond while fn runs and
+// returns the highest sample above the heap before fn started
+func peakHeap(fn func()) uint64 {
+	// Collect often so that samples track live memory, not garbage
+	defer debug.SetGCPercent(debug.SetGCPercent(10))
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	baseline, peak := stats.HeapAlloc, stats.HeapAlloc
+
+	done := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	fn()
+	close(done)
+	<-sampled
+	if peak < baseline {
+		return 0
+	}
+	return peak - baseline
+}
+
+func TestCurateHugeDraftBoundsPeakHeap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("curates thousands of memories")
+	}
+
+	// Holding a second copy of the draft, or every embedding at once,
+	// would take more than the draft itself
+	const (
+		count = 2000
+		size  = 4 << 10
+		bound = count * size
+	)
+	env := newTestEnv(t)
+	client := &fakeAIClient{response: hugeDraft(count, size)}
+	curator := NewCurator(env.engine, client)
+	curator.SetLogger(env.engine.logger)
+
+	var result *CurationResponse
+	var err error
+	peak := peakHeap(func() {
+		result, err = curator.CurateSession(context.Background(), env.projectID, "", "transcript")
+	})
+	if err != nil {
+		t.Fatalf("CurateSession: %v", err)
+	}
+	if len(result.Memories) != count {
+		t.Fatalf("created %d memories, want %d", len(result.Memories), count)
+	}
+
+	t.Logf("peak heap growth: %.1f MB", float64(peak)/(1<<20))
+	if peak > bound {
+		t.Errorf("peak heap grew by %.1f MB, want under the draft's %.1f MB", float64(peak)/(1<<20), float64(bound)/(1<<20))
+	}
+}
+
+// BenchmarkCurateHugeDraft curates a 2000-memory draft and reports the peak
+// heap growth while doing so
+func BenchmarkCurateHugeDraft(b *testing.B) {
+	draft := hugeDraft(2000, 4<<10)
+	var worst uint64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		env := newTestEnv(b)
+		curator := NewCurator(env.engine, &fakeAIClient{response: draft})
+		curator.SetLogger(env.engine.logger)
+		b.StartTimer()
+
+		peak := peakHeap(func() {
+			if _, err := curator.CurateSession(context.Background(), env.projectID, "", "transcript"); err != nil {
+				b.Fatal(err)
+			}
+		})
+		if peak > worst {
+			worst = peak
+		}
+	}
+	b.ReportMetric(float64(worst)/(1<<20), "peak-MB")
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// Decay curves for RecencyDecay
+const (
+	DecayExponential = "exponential" // Halves every half-life
+	DecayLinear      = "linear"      // Falls to zero at twice the half-life
+	DecayNone        = "none"
+)
+
+// RecencyDecay scales relevance down as memories age. Each temporal
+// relevance has its own half-life; a zero half-life means no decay.
+type RecencyDecay struct {
+	Curve     string
+	HalfLives map[TemporalRelevance]time.Duration
+}
+
+// DefaultRecencyDecay decays temporary memories over hours and session
+// memories over days, and leaves persistent memories alone
+func DefaultRecencyDecay() RecencyDecay {
+	return RecencyDecay{
+		Curve: DecayExponential,
+		HalfLives: map[TemporalRelevance]time.Duration{
+			TemporalRelevanceTemporary: 6 * time.Hour,
+			TemporalRelevanceSession:   3 * 24 * time.Hour,
+		},
+	}
+}
+
+// validate checks the curve name and half-lives
+func (d RecencyDecay) validate() error {
+	switch d.Curve {
+	case DecayExponential, DecayLinear, DecayNone:
+	default:
+		return fmt.Errorf("invalid decay curve %q (valid: exponential, linear, none)", d.Curve)
+	}
+	for relevance, halfLife := range d.HalfLives {
+		if halfLife < 0 {
+			return fmt.Errorf("invalid half-life %v for %s memories (must not be negative)", halfLife, relevance)
+		}
+	}
+	return nil
+}
+
+// factor returns the multiplier (0-1) for a memory of the given age
+func (d RecencyDecay) factor(relevance TemporalRelevance, age time.Duration) float64 {
+	if relevance == "" {
+		relevance = TemporalRelevancePersistent
+	}
+	halfLife := d.HalfLives[relevance]
+	if d.Curve == DecayNone || halfLife <= 0 || age <= 0 {
+		return 1
+	}
+
+	ratio := float64(age) / float64(halfLife)
+	if d.Curve == DecayLinear {
+		return math.Max(0, 1-ratio/2)
+	}
+	return math.Pow(0.5, ratio)
+}
+
+// SetRecencyDecay sets how relevance decays with a memory's age
+func (e *Engine) SetRecencyDecay(decay RecencyDecay) error {
+	if decay.Curve == "" {
+		decay.Curve = DecayExponential
+	}
+	if err := decay.validate(); err != nil {
+		return err
+	}
+
+	e.recencyDecay = decay
+	return nil
+}
+
+// memoryAge returns how long ago a memory was last written
+func memoryAge(mem *Memory, now time.Time) time.Duration {
+	touched := mem.UpdatedAt
+	if touched.IsZero() {
+		touched = mem.CreatedAt
+	}
+	if touched.IsZero() {
+		return 0
+	}
+	return now.Sub(touched)
+}
+
+// ImportanceDecayReport describes what DecayImportance changed
+type ImportanceDecayReport struct {
+	Decayed       int // Memories whose importance was lowered in SQLite
+	VectorUpdated int // Of those, memories whose vector store copy was updated
+	VectorFailed  int // Memories whose vector store copy could not be updated
+}
+
+// DecayImportance lowers the stored importance of a project's session and
+// temporary memories, or of all projects' if projectID is empty, halving it
+// every halfLifeDays since the memory was created. Persistent memories are
+// left alone. Each run only decays the time since the previous one, so the
+// job can run as often as wanted. Vector stores keeping their own copy of
+// the importance are updated too; a failure there is counted, not returned,
+// as SQLite is the source of truth.
+func (e *Engine) DecayImportance(ctx context.Context, projectID string, halfLifeDays float64) (*ImportanceDecayReport, error) {
+	if halfLifeDays <= 0 {
+		return nil, fmt.Errorf("invalid half-life %v days (must be positive)", halfLifeDays)
+	}
+
+	memories, err := e.sqlStore.DecayingMemories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+	return e.decayImportance(ctx, projectID, memories, halfLifeDays)
+}
+
+// decayImportance halves the importance of memories every halfLifeDays
+// since they were last decayed, in SQLite and the vector store. Every decay
+// pass shares that time, so no stretch of a memory's age is decayed twice.
+func (e *Engine) decayImportance(ctx context.Context, projectID string, memories []storage.DecayingMemory, halfLifeDays float64) (*ImportanceDecayReport, error) {
+	now := time.Now()
+	halfLife := time.Duration(halfLifeDays * float64(24*time.Hour))
+	importance := make(map[string]float64)
+	var searchable []string
+	for _, mem := range memories {
+		age := now.Sub(mem.Since)
+		if age <= 0 {
+			continue
+		}
+		decayed := mem.Importance * math.Pow(0.5, float64(age)/float64(halfLife))
+		if decayed == mem.Importance {
+			continue
+		}
+		importance[mem.ID] = decayed
+		if !mem.Archived {
+			searchable = append(searchable, mem.ID)
+		}
+	}
+
+	report := &ImportanceDecayReport{Decayed: len(importance)}
+	if len(importance) == 0 {
+		return report, nil
+	}
+	if err := e.sqlStore.SetDecayedImportance(ctx, importance, now); err != nil {
+		return nil, fmt.Errorf("failed to update importance: %w", err)
+	}
+
+	// Archived memories have no vector to update
+	if updater, ok := e.vectorStore.(PropertyUpdater); ok {
+		for _, id := range searchable {
+			if err := updater.UpdateProperties(ctx, id, map[string]interface{}{
+				"importance": importance[id],
+			}); err != nil {
+				e.logger.Warn("failed to update importance in vector store", "id", id, "error", err)
+				report.VectorFailed++
+				continue
+			}
+			report.VectorUpdated++
+		}
+	}
+
+	e.logger.Info("decayed memory importance", "project_id", projectID,
+		"memories", report.Decayed, "vectors", report.VectorUpdated)
+	return report, nil
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRecencyDecayFactor(t *testing.T) {
+	exponential := DefaultRecencyDecay()
+	linear := DefaultRecencyDecay()
+	linear.Curve = DecayLinear
+	none := DefaultRecencyDecay()
+	none.Curve = DecayNone
+
+	tests := []struct {
+		name      string
+		decay     RecencyDecay
+		relevance TemporalRelevance
+		age       time.Duration
+		want      float64
+	}{
+		{"fresh temporary", exponential, TemporalRelevanceTemporary, 0, 1},
+		{"temporary after one half-life", exponential, TemporalRelevanceTemporary, 6 * time.Hour, 0.5},
+		{"temporary after two half-lives", exponential, TemporalRelevanceTemporary, 12 * time.Hour, 0.25},
+		{"session after one half-life", exponential, TemporalRelevanceSession, 72 * time.Hour, 0.5},
+		{"persistent never decays", exponential, TemporalRelevancePersistent, 365 * 24 * time.Hour, 1},
+		{"unset relevance is persistent", exponential, "", 365 * 24 * time.Hour, 1},
+		{"linear after one half-life", linear, TemporalRelevanceTemporary, 6 * time.Hour, 0.5},
+		{"linear reaches zero", linear, TemporalRelevanceTemporary, 24 * time.Hour, 0},
+		{"no curve", none, TemporalRelevanceTemporary, 24 * time.Hour, 1},
+		{"future timestamp", exponential, TemporalRelevanceTemporary, -time.Hour, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.decay.factor(tt.relevance, tt.age); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("factor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRecencyDecayValidates(t *testing.T) {
+	engine := NewEngine(nil, nil, nil)
+	if err := engine.SetRecencyDecay(RecencyDecay{Curve: "cubic"}); err == nil {
+		t.Error("accepted an unknown curve")
+	}
+	negative := RecencyDecay{HalfLives: map[TemporalRelevance]time.Duration{TemporalRelevanceSession: -time.Hour}}
+	if err := engine.SetRecencyDecay(negative); err == nil {
+		t.Error("accepted a negative half-life")
+	}
+	if err := engine.SetRecencyDecay(RecencyDecay{}); err != nil || engine.recencyDecay.Curve != DecayExponential {
+		t.Errorf("empty curve: err %v, curve %q; want exponential", err, engine.recencyDecay.Curve)
+	}
+}
+
+func TestOldTemporaryMemoryRanksBelowFreshOne(t *testing.T) {
+	env := newTestEnv(t)
+	old := env.save(t, &Memory{
+		Content:           "The staging deploy is paused while the migration runs",
+		Importance:        0.5,
+		TemporalRelevance: TemporalRelevanceTemporary,
+		CreatedAt:         time.Now().Add(-60 * 24 * time.Hour),
+	})
+	fresh := env.save(t, &Memory{
+		Content:           "The staging deploy is paused while the migration runs",
+		Importance:        0.5,
+		TemporalRelevance: TemporalRelevanceTemporary,
+	})
+
+	results, err := env.engine.SearchMemories(context.Background(), &SearchQuery{
+		Query:     "staging deploy paused migration",
+		ProjectID: env.projectID,
+		Limit:     10,
+		Mode:      SearchModeSemantic,
+	})
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].Memory.ID != fresh.ID || results[1].Memory.ID != old.ID {
+		t.Fatalf("got order %s, %s; want the fresh memory first", results[0].Memory.ID, results[1].Memory.ID)
+	}
+	if results[0].SimilarityScore != results[1].SimilarityScore {
+		t.Errorf("similarities differ (%v, %v); the test needs them equal", results[0].SimilarityScore, results[1].SimilarityScore)
+	}
+	if results[1].RelevanceScore >= results[0].RelevanceScore/2 {
+		t.Errorf("old relevance %v is not decayed against fresh %v", results[1].RelevanceScore, results[0].RelevanceScore)
+	}
+}
+
+func TestPersistentMemoriesDoNotDecay(t *testing.T) {
+	engine := NewEngine(nil, nil, nil)
+	old := &Memory{Importance: 0.5, TemporalRelevance: TemporalRelevancePersistent, CreatedAt: time.Now().Add(-365 * 24 * time.Hour)}
+	fresh := &Memory{Importance: 0.5, TemporalRelevance: TemporalRelevancePersistent, CreatedAt: time.Now()}
+
+	if a, b := engine.calculateRelevanceScore(old, 0.6, false, false), engine.calculateRelevanceScore(fresh, 0.6, false, false); a != b {
+		t.Errorf("old persistent memory scored %v, fresh one %v; want equal", a, b)
+	}
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// PropertyUpdater is implemented by vector stores that keep their own copy
+// of memory metadata and can change it without replacing the vector
+type PropertyUpdater interface {
+	UpdateProperties(ctx context.Context, id string, properties map[string]interface{}) error
+}
+
+// Duplicate records a new memory that says nearly the same thing as an
+// existing memory of the same project
+type Duplicate struct {
+	Index      int    // Position of the new memory in the batch
+	ExistingID string // Memory it was merged into, or supersedes
+	Similarity float64
+	Superseded bool // Created with a supersedes relationship instead of merged (DedupSupersede)
+}
+
+// DedupAction is what happens to a new memory that duplicates an existing one
+type DedupAction string
+
+const (
+	// DedupMerge does not create the new memory; the existing memory takes
+	// the higher importance of the two
+	DedupMerge DedupAction = "merge"
+	// DedupSupersede creates the new memory with a supersedes relationship
+	// to the existing one
+	DedupSupersede DedupAction = "supersede"
+)
+
+// SetDedupThreshold sets the similarity (0-1) at or above which
+// CreateMemoriesDeduped treats a new memory as a duplicate. Zero disables
+// deduplication.
+func (e *Engine) SetDedupThreshold(threshold float64) {
+	e.dedupThreshold = threshold
+}
+
+// SetDedupAction sets what happens to duplicates: DedupMerge (the default)
+// or DedupSupersede
+func (e *Engine) SetDedupAction(action DedupAction) error {
+	switch action {
+	case "":
+		action = DedupMerge
+	case DedupMerge, DedupSupersede:
+	default:
+		return fmt.Errorf("unknown dedup action %q (valid: merge, supersede)", action)
+	}
+	e.dedupAction = action
+	return nil
+}
+
+// SetDedupSaves makes CreateMemory deduplicate too, like
+// CreateMemoriesDeduped
+func (e *Engine) SetDedupSaves(dedup bool) {
+	e.dedupSaves = dedup
+}
+
+// CreateMemoriesDeduped is CreateMemories for memories that may repeat
+// existing ones, such as those curated from overlapping transcripts. A
+// memory at least as similar as the dedup threshold to an existing memory
+// of its project, or to an earlier memory of the batch, is a duplicate:
+// it is merged into the existing memory, or created superseding it,
+// depending on the dedup action. It returns the duplicates in batch order,
+// along with a *VectorFailureError if some memories were saved without a
+// vector.
+func (e *Engine) CreateMemoriesDeduped(ctx context.Context, mems []*Memory) ([]Duplicate, error) {
+	if len(mems) == 0 {
+		return nil, nil
+	}
+
+	embeddings, err := e.prepareMemories(ctx, mems)
+	if err != nil {
+		return nil, err
+	}
+	if e.dedupThreshold <= 0 {
+		return nil, e.insertMemories(ctx, mems, embeddings)
+	}
+
+	var duplicates []Duplicate
+	var kept []*Memory
+	var keptEmbeddings [][]float32
+	for i, mem := range mems {
+		dup, err := e.findDuplicate(ctx, mem, embeddings[i], kept, keptEmbeddings)
+		if err != nil {
+			return nil, err
+		}
+		if dup == nil || e.dedupAction == DedupSupersede {
+			kept = append(kept, mem)
+			keptEmbeddings = append(keptEmbeddings, embeddings[i])
+		}
+		if dup == nil {
+			continue
+		}
+
+		dup.Index = i
+		dup.Superseded = e.dedupAction == DedupSupersede
+		duplicates = append(duplicates, *dup)
+		e.logger.Debug("duplicate memory", "existing_id", dup.ExistingID, "similarity", dup.Similarity,
+			"superseded", dup.Superseded)
+	}
+
+	// Merge into existing memories only once the batch is known to be
+	// valid, so a failed insert leaves them untouched. Memories saved
+	// without a vector were still saved.
+	insertErr := e.insertMemories(ctx, kept, keptEmbeddings)
+	var vectorFailure *VectorFailureError
+	if insertErr != nil && !errors.As(insertErr, &vectorFailure) {
+		return nil, insertErr
+	}
+	for _, dup := range duplicates {
+		if err := e.resolveDuplicate(ctx, &dup, mems[dup.Index]); err != nil {
+			return nil, err
+		}
+	}
+
+	return duplicates, insertErr
+}
+
+// resolveDuplicate merges a duplicate that was not created into the memory
+// it repeats, or relates one that was created to the memory it supersedes
+func (e *Engine) resolveDuplicate(ctx context.Context, dup *Duplicate, mem *Memory) error {
+	if dup.Superseded {
+		if err := e.CreateRelationship(ctx, mem.ID, dup.ExistingID, RelationshipTypeSupersedes); err != nil {
+			return fmt.Errorf("failed to relate %s to the memory it supersedes: %w", mem.ID, err)
+		}
+		return nil
+	}
+	if err := e.mergeDuplicate(ctx, dup.ExistingID, mem); err != nil {
+		return fmt.Errorf("failed to merge duplicate into %s: %w", dup.ExistingID, err)
+	}
+	return nil
+}
+
+// findDuplicate returns the memory mem duplicates, looking first among the
+// memories kept earlier in the batch and then in the vector store, or nil
+func (e *Engine) findDuplicate(ctx context.Context, mem *Memory, embedding []float32, kept []*Memory, keptEmbeddings [][]float32) (*Duplicate, error) {
+	for i, other := range kept {
+		if other.ProjectID != mem.ProjectID {
+			continue
+		}
+		if similarity := 1 - storage.CosineDistance(embedding, keptEmbeddings[i]); similarity >= e.dedupThreshold {
+			return &Duplicate{ExistingID: other.ID, Similarity: similarity}, nil
+		}
+	}
+
+	results, err := e.vectorStore.Search(ctx, embedding, 1, map[string]interface{}{
+		"project_id": mem.ProjectID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for duplicates: %w", err)
+	}
+	if len(results) == 0 || e.similarity(results[0].Distance) < e.dedupThreshold {
+		return nil, nil
+	}
+
+	// The vector may outlive its memory
+	existing, err := e.sqlStore.GetMemory(ctx, results[0].ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory: %w", err)
+	}
+	if existing == nil || existing.ProjectID != mem.ProjectID {
+		return nil, nil
+	}
+
+	return &Duplicate{ExistingID: existing.ID, Similarity: e.similarity(results[0].Distance)}, nil
+}
+
+// mergeDuplicate raises an existing memory's importance to that of its
+// duplicate, if higher, and marks it as updated
+func (e *Engine) mergeDuplicate(ctx context.Context, existingID string, duplicate *Memory) error {
+	existing, err := e.sqlStore.GetMemory(ctx, existingID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil // Deleted in the meantime
+	}
+
+	// An "auto" importance is not worth estimating for a memory that is
+	// never created
+	raised := duplicate.ImportanceMethod != ImportanceAuto && duplicate.Importance > existing.Importance
+	if raised {
+		existing.Importance = duplicate.Importance
+		existing.ImportanceMethod = duplicate.ImportanceMethod
+	}
+	if err := e.sqlStore.UpdateMemory(ctx, existing); err != nil {
+		return err
+	}
+
+	if updater, ok := e.vectorStore.(PropertyUpdater); ok && raised {
+		if err := updater.UpdateProperties(ctx, existing.ID, map[string]interface{}{
+			"importance": existing.Importance,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// Degradation describes a fallback taken while serving a request because a
+// dependency failed, so that callers can tell the results are incomplete
+type Degradation string
+
+// Fallbacks the engine records
+const (
+	DegradedVectorSearch  Degradation = "vector search unavailable — keyword results only"
+	DegradedKeywordSearch Degradation = "full-text search unavailable — exact keyword matches may be missing"
+	DegradedGraph         Degradation = "relationship graph unavailable — related memories not included"
+	DegradedDiversity     Degradation = "stored vectors unavailable — near-duplicate results not filtered"
+	DegradedImportanceAI  Degradation = "AI provider offline — importance auto-estimation fell back to heuristics"
+	DegradedVectorStore   Degradation = "vector store unavailable — some memories were saved without a vector and won't appear in searches until `alaala reindex`"
+	DegradedWorkspace     Degradation = "workspace memories unavailable — primer covers this project only"
+)
+
+type degradationsKey struct{}
+
+// degradations collects the fallbacks taken for one request
+type degradations struct {
+	mu   sync.Mutex
+	list []Degradation
+}
+
+// WithDegradations returns a context in which the fallbacks taken for a
+// request are recorded, to be read back with Degradations
+func WithDegradations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, degradationsKey{}, &degradations{})
+}
+
+// NoteDegradation records a fallback in ctx, if it was prepared with
+// WithDegradations
+func NoteDegradation(ctx context.Context, d Degradation) {
+	collected, ok := ctx.Value(degradationsKey{}).(*degradations)
+	if !ok {
+		return
+	}
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+	for _, noted := range collected.list {
+		if noted == d {
+			return
+		}
+	}
+	collected.list = append(collected.list, d)
+}
+
+// Degradations returns the fallbacks recorded in ctx, in the order they
+// were first taken
+func Degradations(ctx context.Context) []Degradation {
+	collected, ok := ctx.Value(degradationsKey{}).(*degradations)
+	if !ok {
+		return nil
+	}
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+	return append([]Degradation(nil), collected.list...)
+}
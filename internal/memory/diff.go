@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"strings"
+)
+
+// diffContextWords is how many unchanged words are kept around each change
+const diffContextWords = 3
+
+// WordDiff produces a compact word-level diff between two texts.
+// Deleted words are wrapped as [-...-], inserted words as {+...+}, and long
+// unchanged runs are collapsed to "..." keeping a few words of context.
+func WordDiff(oldText, newText string) string {
+	oldWords := strings.Fields(oldText)
+	newWords := strings.Fields(newText)
+
+	ops := diffWords(oldWords, newWords)
+	changed := false
+	for _, op := range ops {
+		if op.kind != diffEqual {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var parts []string
+	for i := 0; i < len(ops); {
+		op := ops[i]
+		j := i
+		var words []string
+		for j < len(ops) && ops[j].kind == op.kind {
+			words = append(words, ops[j].word)
+			j++
+		}
+
+		switch op.kind {
+		case diffDelete:
+			parts = append(parts, "[-"+strings.Join(words, " ")+"-]")
+		case diffInsert:
+			parts = append(parts, "{+"+strings.Join(words, " ")+"+}")
+		default:
+			parts = append(parts, collapseUnchanged(words, i == 0, j == len(ops)))
+		}
+		i = j
+	}
+
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffKind
+	word string
+}
+
+// diffWords computes an edit script using the longest common subsequence
+func diffWords(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, b[j]})
+	}
+
+	return ops
+}
+
+func collapseUnchanged(words []string, atStart, atEnd bool) string {
+	keepBefore := diffContextWords
+	keepAfter := diffContextWords
+	if atStart {
+		keepBefore = 0
+	}
+	if atEnd {
+		keepAfter = 0
+	}
+
+	if len(words) <= keepBefore+keepAfter+1 {
+		return strings.Join(words, " ")
+	}
+
+	var parts []string
+	if keepBefore > 0 {
+		parts = append(parts, strings.Join(words[:keepBefore], " "))
+	}
+	parts = append(parts, "...")
+	if keepAfter > 0 {
+		parts = append(parts, strings.Join(words[len(words)-keepAfter:], " "))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+const (
+	// digestMemoryLimit is how many new memories of a project a digest
+	// lists, most important first
+	digestMemoryLimit = 50
+
+	// digestActionLimit is how many open action items of a project a
+	// digest lists
+	digestActionLimit = 20
+
+	// digestConflictLimit is how many new conflicts a digest lists
+	digestConflictLimit = 100
+)
+
+// Digest summarizes what was remembered across all projects since a point
+// in time
+type Digest struct {
+	Since     time.Time
+	Until     time.Time
+	Projects  []*ProjectDigest // Projects with anything to report, busiest first
+	Narrative string           // AI-written summary, if one was requested
+}
+
+// ProjectDigest is one project's part of a digest
+type ProjectDigest struct {
+	Project *storage.Project
+
+	NewMemories int            // Memories created in the period
+	Groups      []*DigestGroup // Listed new memories by context type
+	Omitted     int            // New memories beyond digestMemoryLimit
+
+	ActionItems []*Memory // Open action items, most important first
+	OpenActions int       // All open action items, listed or not
+	Conflicts   []*DigestConflict
+}
+
+// DigestGroup is a project's new memories of one context type
+type DigestGroup struct {
+	ContextType ContextType
+	Memories    []*Memory
+}
+
+// DigestConflict is a conflicts relationship recorded in the period
+type DigestConflict struct {
+	From      *Memory
+	To        *Memory
+	CreatedAt time.Time
+}
+
+// Digest gathers the memories created since the given time in every
+// project, grouped by context type, along with each project's open action
+// items and the conflicts recorded in the period. It only reads SQLite, so
+// it works offline.
+func (e *Engine) Digest(ctx context.Context, since time.Time) (*Digest, error) {
+	digest := &Digest{Since: since, Until: time.Now()}
+
+	projects, err := e.sqlStore.ListProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	byProject := make(map[string]*ProjectDigest, len(projects))
+	for i := range projects {
+		project, err := e.projectDigest(ctx, &projects[i], since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to gather project %s: %w", projects[i].Name, err)
+		}
+		byProject[project.Project.ID] = project
+	}
+
+	if err := e.addDigestConflicts(ctx, byProject, since); err != nil {
+		return nil, fmt.Errorf("failed to gather conflicts: %w", err)
+	}
+
+	for i := range projects {
+		project := byProject[projects[i].ID]
+		if project.NewMemories > 0 || project.OpenActions > 0 || len(project.Conflicts) > 0 {
+			digest.Projects = append(digest.Projects, project)
+		}
+	}
+	sort.SliceStable(digest.Projects, func(i, j int) bool {
+		return digest.Projects[i].NewMemories > digest.Projects[j].NewMemories
+	})
+
+	return digest, nil
+}
+
+// projectDigest gathers one project's new memories and open action items
+func (e *Engine) projectDigest(ctx context.Context, project *storage.Project, since time.Time) (*ProjectDigest, error) {
+	digest := &ProjectDigest{Project: project}
+
+	memories, total, err := e.sqlStore.ListMemories(ctx, storage.ListOptions{
+		ProjectID:    project.ID,
+		CreatedAfter: since,
+		Limit:        digestMemoryLimit,
+		OrderBy:      "importance",
+	})
+	if err != nil {
+		return nil, err
+	}
+	digest.NewMemories = total
+	digest.Omitted = total - len(memories)
+
+	groups := make(map[ContextType]*DigestGroup)
+	for _, sqlMem := range memories {
+		mem := e.sqlMemoryToMemory(sqlMem)
+		bucket := ContextTypeBucket(mem.ContextType)
+		group, ok := groups[bucket]
+		if !ok {
+			group = &DigestGroup{ContextType: bucket}
+			groups[bucket] = group
+		}
+		group.Memories = append(group.Memories, mem)
+	}
+	for _, contextType := range append(AllContextTypes(), ContextTypeUnknown, "") {
+		if group, ok := groups[contextType]; ok {
+			digest.Groups = append(digest.Groups, group)
+		}
+	}
+
+	actionRequired := true
+	actions, openActions, err := e.sqlStore.ListMemories(ctx, storage.ListOptions{
+		ProjectID:      project.ID,
+		ActionRequired: &actionRequired,
+		Limit:          digestActionLimit,
+		OrderBy:        "importance",
+	})
+	if err != nil {
+		return nil, err
+	}
+	digest.OpenActions = openActions
+	for _, sqlMem := range actions {
+		digest.ActionItems = append(digest.ActionItems, e.sqlMemoryToMemory(sqlMem))
+	}
+
+	return digest, nil
+}
+
+// addDigestConflicts adds the conflicts recorded since the given time to
+// the project of the memory they start from
+func (e *Engine) addDigestConflicts(ctx context.Context, byProject map[string]*ProjectDigest, since time.Time) error {
+	page, err := e.sqlStore.GetRelationshipsSince(ctx, since, digestConflictLimit, string(RelationshipTypeConflicts))
+	if err != nil {
+		return err
+	}
+
+	for _, edge := range page.Edges {
+		from, err := e.GetMemory(ctx, edge.FromMemoryID)
+		if err != nil {
+			return err
+		}
+		to, err := e.GetMemory(ctx, edge.ToMemoryID)
+		if err != nil {
+			return err
+		}
+		if from == nil || to == nil {
+			continue
+		}
+		if project, ok := byProject[from.ProjectID]; ok {
+			project.Conflicts = append(project.Conflicts, &DigestConflict{From: from, To: to, CreatedAt: edge.CreatedAt})
+		}
+	}
+	return nil
+}
+
+// Empty reports whether nothing happened in the digest's period
+func (d *Digest) Empty() bool {
+	return len(d.Projects) == 0
+}
+
+// Markdown renders the digest as a Markdown document: the narrative, if
+// any, then per project its new memories by context type, open action
+// items and conflicts
+func (d *Digest) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# alaala digest: %s to %s\n\n", d.Since.Local().Format("Jan 2"), d.Until.Local().Format("Jan 2, 2006"))
+
+	if d.Empty() {
+		b.WriteString("No new memories, open action items or conflicts.\n")
+		return b.String()
+	}
+
+	if d.Narrative != "" {
+		fmt.Fprintf(&b, "%s\n\n", d.Narrative)
+	}
+
+	for _, project := range d.Projects {
+		fmt.Fprintf(&b, "## %s\n\n", project.Project.Name)
+		fmt.Fprintf(&b, "%d new memories, %d open action items", project.NewMemories, project.OpenActions)
+		if len(project.Conflicts) > 0 {
+			fmt.Fprintf(&b, ", %d new conflicts", len(project.Conflicts))
+		}
+		b.WriteString("\n\n")
+
+		for _, group := range project.Groups {
+			fmt.Fprintf(&b, "### %s\n\n", digestGroupTitle(group.ContextType))
+			for _, mem := range group.Memories {
+				fmt.Fprintf(&b, "- %s (importance %.2f)\n", oneLine(mem.Content), mem.Importance)
+			}
+			b.WriteString("\n")
+		}
+		if project.Omitted > 0 {
+			fmt.Fprintf(&b, "_%d less important new memories not shown._\n\n", project.Omitted)
+		}
+
+		if len(project.ActionItems) > 0 {
+			b.WriteString("### Open action items\n\n")
+			for _, mem := range project.ActionItems {
+				fmt.Fprintf(&b, "- [ ] %s\n", oneLine(mem.Content))
+			}
+			if more := project.OpenActions - len(project.ActionItems); more > 0 {
+				fmt.Fprintf(&b, "- _and %d more_\n", more)
+			}
+			b.WriteString("\n")
+		}
+
+		if len(project.Conflicts) > 0 {
+			b.WriteString("### Conflicts\n\n")
+			for _, conflict := range project.Conflicts {
+				fmt.Fprintf(&b, "- %s\n  conflicts with: %s\n", oneLine(conflict.From.Content), oneLine(conflict.To.Content))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// digestGroupTitle names a context type as a heading, e.g. "Technical
+// implementation"
+func digestGroupTitle(contextType ContextType) string {
+	switch contextType {
+	case "":
+		return "Other"
+	case ContextTypeUnknown:
+		return "Custom/unknown"
+	}
+	title := strings.ToLower(strings.ReplaceAll(string(contextType), "_", " "))
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// oneLine collapses whitespace so content fits on one list item line
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"math"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// mmrPoolFactor sets how many of the best candidates, as a multiple of the
+// search limit, maximal marginal relevance picks the results from
+const mmrPoolFactor = 4
+
+// VectorFetcher is implemented by vector stores that can return the stored
+// vectors of memories, which diversifying search results compares
+type VectorFetcher interface {
+	Vectors(ctx context.Context, ids []string) (map[string][]float32, error)
+}
+
+// SetMMRLambda sets the trade-off between relevance and diversity when
+// picking search results by maximal marginal relevance: 1 ranks by
+// relevance alone, lower values increasingly pass over memories similar
+// to ones already picked. Zero disables diversification.
+func (e *Engine) SetMMRLambda(lambda float64) {
+	e.mmrLambda = lambda
+}
+
+// candidateLimit returns how many vector hits a search for limit results
+// starts with: enough to diversify from when diversification is enabled
+func (e *Engine) candidateLimit(limit int) int {
+	if e.mmrLambda <= 0 || e.mmrLambda >= 1 {
+		return limit
+	}
+	return limit * mmrPoolFactor
+}
+
+// diversify reorders results, which are sorted by relevance, so that the
+// first limit of them are picked by maximal marginal relevance: each pick
+// maximizes lambda*relevance - (1-lambda)*(highest cosine similarity to a
+// memory picked before). Near-duplicates then give way to other memories.
+// Candidates without a stored vector are not penalized. If the vectors
+// cannot be fetched, results are returned as they were.
+func (e *Engine) diversify(ctx context.Context, results []*SearchResult, limit int) []*SearchResult {
+	lambda := e.mmrLambda
+	if lambda <= 0 || lambda >= 1 || len(results) <= 1 {
+		return results
+	}
+	fetcher, ok := e.vectorStore.(VectorFetcher)
+	if !ok {
+		return results
+	}
+
+	pool := results[:min(len(results), limit*mmrPoolFactor)]
+	ids := make([]string, len(pool))
+	for i, result := range pool {
+		ids[i] = result.Memory.ID
+	}
+	vectors, err := fetcher.Vectors(ctx, ids)
+	if err != nil {
+		e.logger.Warn("failed to fetch vectors, results are not diversified", "error", err)
+		NoteDegradation(ctx, DegradedDiversity)
+		return results
+	}
+
+	picked := make([]*SearchResult, 0, len(results))
+	remaining := append([]*SearchResult(nil), pool...)
+	redundancy := make([]float64, len(remaining)) // Highest similarity to a picked result
+	for len(picked) < limit && len(remaining) > 0 {
+		best, bestScore := 0, math.Inf(-1)
+		for i, result := range remaining {
+			score := lambda*result.RelevanceScore - (1-lambda)*redundancy[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		chosen := remaining[best]
+		picked = append(picked, chosen)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		redundancy = append(redundancy[:best], redundancy[best+1:]...)
+
+		chosenVector := vectors[chosen.Memory.ID]
+		if chosenVector == nil {
+			continue
+		}
+		for i, result := range remaining {
+			vector := vectors[result.Memory.ID]
+			if len(vector) != len(chosenVector) {
+				continue
+			}
+			if similarity := 1 - storage.CosineDistance(chosenVector, vector); similarity > redundancy[i] {
+				redundancy[i] = similarity
+			}
+		}
+	}
+
+	// Candidates passed over keep their relevance order after the picks
+	picked = append(picked, remaining...)
+	return append(picked, results[len(pool):]...)
+}
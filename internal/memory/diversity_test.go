@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+// fixedVectors returns hand-made vectors for diversify to compare
+type fixedVectors struct {
+	VectorStore
+	vectors map[string][]float32
+	err     error
+}
+
+func (f fixedVectors) Vectors(ctx context.Context, ids []string) (map[string][]float32, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	found := make(map[string][]float32)
+	for _, id := range ids {
+		if v, ok := f.vectors[id]; ok {
+			found[id] = v
+		}
+	}
+	return found, nil
+}
+
+// rankedResults builds results with the given IDs and relevance scores
+func rankedResults(scores map[string]float64, order ...string) []*SearchResult {
+	results := make([]*SearchResult, len(order))
+	for i, id := range order {
+		results[i] = &SearchResult{Memory: &Memory{ID: id}, RelevanceScore: scores[id]}
+	}
+	return results
+}
+
+func resultIDs(results []*SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Memory.ID
+	}
+	return ids
+}
+
+func TestDiversify(t *testing.T) {
+	// dup1-3 point the same way; other1 and other2 elsewhere
+	vectors := map[string][]float32{
+		"dup1":   {1, 0, 0},
+		"dup2":   {0.99, 0.01, 0},
+		"dup3":   {0.98, 0.02, 0},
+		"other1": {0, 1, 0},
+		"other2": {0, 0, 1},
+	}
+	scores := map[string]float64{"dup1": 0.9, "dup2": 0.89, "dup3": 0.88, "other1": 0.7, "other2": 0.6, "novector": 0.5}
+	order := []string{"dup1", "dup2", "dup3", "other1", "other2"}
+
+	tests := []struct {
+		name    string
+		lambda  float64
+		limit   int
+		order   []string
+		vectors map[string][]float32
+		want    []string
+	}{
+		{"disabled", 0, 3, order, vectors, order},
+		{"relevance only", 1, 3, order, vectors, order},
+		{"duplicates give way", 0.5, 3, order, vectors, []string{"dup1", "other1", "other2", "dup2", "dup3"}},
+		{"mostly relevance", 0.95, 3, order, vectors, []string{"dup1", "dup2", "dup3", "other1", "other2"}},
+		{"limit of one", 0.5, 1, order, vectors, order},
+		{"memory without vector is not penalized", 0.5, 2,
+			[]string{"dup1", "dup2", "novector"}, vectors, []string{"dup1", "novector", "dup2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine(nil, fixedVectors{vectors: tt.vectors}, nil)
+			engine.SetMMRLambda(tt.lambda)
+
+			got := resultIDs(engine.diversify(context.Background(), rankedResults(scores, tt.order...), tt.limit))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diversify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiversifyWithoutVectors(t *testing.T) {
+	scores := map[string]float64{"a": 0.9, "b": 0.8, "c": 0.7}
+	order := []string{"a", "b", "c"}
+
+	engine := NewEngine(nil, fixedVectors{err: errors.New("vector store is down")}, nil)
+	engine.SetMMRLambda(0.5)
+	engine.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := WithDegradations(context.Background())
+
+	got := resultIDs(engine.diversify(ctx, rankedResults(scores, order...), 2))
+	if !reflect.DeepEqual(got, order) {
+		t.Errorf("diversify = %v, want the relevance order %v", got, order)
+	}
+	if degradations := Degradations(ctx); !reflect.DeepEqual(degradations, []Degradation{DegradedDiversity}) {
+		t.Errorf("degradations = %v, want %v", degradations, []Degradation{DegradedDiversity})
+	}
+}
+
+func TestSearchDiversifiesNearDuplicates(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+
+	for _, content := range []string{
+		"deploys run the release pipeline when a pull request merges to main",
+		"deploys run the release pipeline when pull requests merge to main",
+		"the release pipeline deploys when a pull request merges to main",
+	} {
+		env.save(t, &Memory{Content: content, Importance: 0.8})
+	}
+	distinct := []*Memory{
+		env.save(t, &Memory{Content: "rolling back a release needs approval from the on-call lead", Importance: 0.8}),
+		env.save(t, &Memory{Content: "pipeline secrets are read from the vault at deploy time", Importance: 0.8}),
+	}
+	query := &SearchQuery{
+		Query:     "how do deploys and the release pipeline work",
+		ProjectID: env.projectID,
+		Mode:      SearchModeSemantic,
+		Limit:     3,
+	}
+
+	countDistinct := func(results []*SearchResult) int {
+		n := 0
+		for _, result := range results {
+			for _, mem := range distinct {
+				if result.Memory.ID == mem.ID {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	// Ranked on relevance alone, the restatements fill the top three
+	results, err := env.engine.SearchMemories(ctx, query)
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+	if n := countDistinct(results); n != 0 {
+		t.Fatalf("without diversification %d distinct memories in the top three, want 0; the fixture no longer ranks the restatements first", n)
+	}
+
+	env.engine.SetMMRLambda(0.5)
+	results, err = env.engine.SearchMemories(ctx, query)
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if n := countDistinct(results); n != 2 {
+		t.Errorf("with diversification %d distinct memories in the top three, want 2", n)
+	}
+}
@@ -1,13 +1,31 @@
 package memory
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/0xGurg/alaala/internal/embeddings"
 	"github.com/0xGurg/alaala/internal/storage"
 	"github.com/google/uuid"
 )
 
+// maxSearchCandidates caps how many vector hits a filtered search fetches
+const maxSearchCandidates = 200
+
+// graphDecay is the factor applied to a related memory's relevance per hop
+// away from the direct search hit it was reached from
+const graphDecay = 0.5
+
+// ErrNoRelevantMemories is returned by SearchMemories when the vector store
+// had candidates but none were similar enough to the query
+var ErrNoRelevantMemories = errors.New("no sufficiently relevant memories found")
+
 // Engine is the core memory management system
 type Engine struct {
 	sqlStore       *storage.SQLiteStore
@@ -15,18 +33,66 @@ type Engine struct {
 	embedder       Embedder
 	graphTraverser *storage.GraphTraverser
 	graphDepth     int
+	minSimilarity  float64
+	dedupThreshold float64
+	dedupAction    DedupAction
+	dedupSaves     bool // CreateMemory deduplicates too
+	contextWeights map[ContextType]float64
+	triggerFuzzy   bool
+	recencyDecay   RecencyDecay
+	weights        ScoringWeights
+	invalidVectors string
+	primerLimit    int
+	mmrLambda      float64 // Relevance vs. diversity of search results; 0 = relevance only
+	expiry         ExpiryPolicy
+	logger         *slog.Logger
+
+	importanceScorer ImportanceScorer
+	importanceBudget float64
+	unusedHalfLife   float64 // Days; 0 = unused memories keep their importance
+
+	recent     recentWrites // Vectors just stored, for searches until the store returns them
+	queryCache *queryCache  // Nil when disabled
 }
 
-// VectorStore is an interface for vector database operations
+// VectorStore is an interface for vector database operations. Store
+// replaces the vector of a memory that already has one.
 type VectorStore interface {
-	Store(id string, content string, embedding []float32, metadata map[string]interface{}) error
-	Search(embedding []float32, limit int, filters map[string]interface{}) ([]storage.VectorSearchResult, error)
-	Delete(id string) error
+	Store(ctx context.Context, id string, content string, embedding []float32, metadata map[string]interface{}) error
+	Search(ctx context.Context, embedding []float32, limit int, filters map[string]interface{}) ([]storage.VectorSearchResult, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// BatchDeleter is implemented by vector stores that can delete many vectors
+// in a single request
+type BatchDeleter interface {
+	DeleteBatch(ctx context.Context, ids []string) (int, error)
 }
 
-// Embedder is an interface for generating embeddings
+// Embedder is an interface for generating embeddings. Implementations must
+// be safe for concurrent use: searches, saves and background work embed
+// from many goroutines at once, and the returned slice must not be shared
+// with another caller, as callers may modify it.
 type Embedder interface {
-	Embed(text string) ([]float32, error)
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// BatchEmbedder is implemented by embedders that can embed many texts in a
+// single call; like Embed, EmbedBatch must be safe for concurrent use
+type BatchEmbedder interface {
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// BatchStorer is implemented by vector stores that can store many vectors
+// in a single request
+type BatchStorer interface {
+	StoreBatch(ctx context.Context, vectors []storage.Vector) error
+}
+
+// EmbeddingInfoProvider is implemented by embedders that can describe the
+// vector space they produce
+type EmbeddingInfoProvider interface {
+	EmbeddingInfo() embeddings.Info
 }
 
 // NewEngine creates a new memory engine
@@ -37,70 +103,498 @@ func NewEngine(sqlStore *storage.SQLiteStore, vectorStore VectorStore, embedder
 		embedder:       embedder,
 		graphTraverser: storage.NewGraphTraverser(sqlStore),
 		graphDepth:     1, // Default depth
+		recencyDecay:   DefaultRecencyDecay(),
+		weights:        DefaultScoringWeights().normalized(),
+		invalidVectors: InvalidVectorsReject,
+		primerLimit:    defaultPrimerLimit,
+		queryCache:     newQueryCache(DefaultQueryCacheSize, DefaultQueryCacheTTL),
+		logger:         slog.Default(),
+	}
+}
+
+// SetLogger sets the logger used for diagnostics
+func (e *Engine) SetLogger(logger *slog.Logger) {
+	e.logger = logger
+}
+
+// ScoringWeights controls how relevance scores are computed. Similarity and
+// Importance are relative: the engine scales them to sum to 1, so the base
+// score stays within 0-1 and only the boosts can push it higher.
+type ScoringWeights struct {
+	Similarity   float64 // Weight of semantic similarity in the base score
+	Importance   float64 // Weight of importance in the base score
+	TriggerBoost float64 // Added when a trigger phrase matches
+	ActionBoost  float64 // Added for action-required memories
+	KeywordBoost float64 // Added when the content contains the query verbatim
+}
+
+// DefaultScoringWeights returns the standard scoring weights
+func DefaultScoringWeights() ScoringWeights {
+	return ScoringWeights{
+		Similarity:   0.6,
+		Importance:   0.3,
+		TriggerBoost: 0.2,
+		ActionBoost:  0.1,
+		KeywordBoost: 0.2,
 	}
 }
 
+// SetScoringWeights sets the relevance scoring weights, normalizing the
+// base weights
+func (e *Engine) SetScoringWeights(weights ScoringWeights) {
+	e.weights = weights.normalized()
+}
+
+// normalized scales Similarity and Importance to sum to 1; weights that sum
+// to 0 or less are returned unchanged
+func (w ScoringWeights) normalized() ScoringWeights {
+	base := w.Similarity + w.Importance
+	if base <= 0 {
+		return w
+	}
+	w.Similarity /= base
+	w.Importance /= base
+	return w
+}
+
 // SetGraphDepth sets the graph traversal depth
 func (e *Engine) SetGraphDepth(depth int) {
 	e.graphDepth = depth
 }
 
-// CreateMemory creates a new memory
-func (e *Engine) CreateMemory(mem *Memory) error {
+// SetPrimerLimit sets how many memories each section of the session primer
+// lists. Zero or less keeps the default of 5.
+func (e *Engine) SetPrimerLimit(limit int) {
+	if limit <= 0 {
+		limit = defaultPrimerLimit
+	}
+	e.primerLimit = limit
+}
+
+// SetMinSimilarity sets the similarity (0-1) below which vector hits are
+// dropped before scoring. Zero keeps every hit.
+func (e *Engine) SetMinSimilarity(similarity float64) {
+	e.minSimilarity = similarity
+}
+
+// SetTriggerFuzzy lets trigger phrase words of five or more letters match
+// with a single typo
+func (e *Engine) SetTriggerFuzzy(fuzzy bool) {
+	e.triggerFuzzy = fuzzy
+}
+
+// SetContextWeights sets per-context-type relevance multipliers. Context
+// types without a weight keep a multiplier of 1.0.
+func (e *Engine) SetContextWeights(weights map[string]float64) error {
+	contextWeights := make(map[ContextType]float64, len(weights))
+	for name, weight := range weights {
+		contextType, err := ParseContextType(strings.ToUpper(name))
+		if err != nil {
+			return err
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("invalid weight %v for context type %s (must be a non-negative number)", weight, contextType)
+		}
+		contextWeights[contextType] = weight
+	}
+
+	e.contextWeights = contextWeights
+	return nil
+}
+
+// CreateMemory creates a new memory. If its vector cannot be stored the
+// SQLite row is removed again, so a failed call leaves no memory that
+// searches could never find. With SetDedupSaves, a memory duplicating an
+// existing one is handled as by CreateMemoriesDeduped and the duplicate is
+// returned; a merged memory is not created and keeps no ID.
+func (e *Engine) CreateMemory(ctx context.Context, mem *Memory) (*Duplicate, error) {
 	// Generate ID if not provided
 	if mem.ID == "" {
 		mem.ID = uuid.New().String()
 	}
 
+	// Assign timestamps up front so SQLite and the vector metadata agree
+	if mem.CreatedAt.IsZero() {
+		mem.CreatedAt = time.Now()
+	}
+	mem.UpdatedAt = mem.CreatedAt
+
 	// Generate embedding
-	embedding, err := e.embedder.Embed(mem.Content)
+	embedding, err := e.embed(ctx, mem.Content)
 	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
+	var dup *Duplicate
+	if e.dedupSaves && e.dedupThreshold > 0 {
+		if dup, err = e.findDuplicate(ctx, mem, embedding, nil, nil); err != nil {
+			return nil, err
+		}
+		if dup != nil && e.dedupAction != DedupSupersede {
+			if err := e.resolveDuplicate(ctx, dup, mem); err != nil {
+				return nil, err
+			}
+			mem.ID = ""
+			return dup, nil
+		}
+	}
+	e.resolveImportance(ctx, mem, embedding)
+
 	// Store in SQLite
-	sqlMemory := &storage.Memory{
-		ID:                mem.ID,
-		ProjectID:         mem.ProjectID,
-		SessionID:         stringPtr(mem.SessionID),
-		Content:           mem.Content,
-		Importance:        mem.Importance,
-		ContextType:       stringPtr(string(mem.ContextType)),
-		TemporalRelevance: stringPtr(string(mem.TemporalRelevance)),
-		ActionRequired:    mem.ActionRequired,
-		Tags:              mem.SemanticTags,
-		TriggerPhrases:    mem.TriggerPhrases,
+	sqlMemory := memoryToSQLMemory(mem)
+	sqlMemory.EmbedderID = e.EmbedderID()
+	if err := e.sqlStore.CreateMemory(ctx, sqlMemory); err != nil {
+		return nil, fmt.Errorf("failed to store memory in SQLite: %w", err)
+	}
+	mem.SemanticTags = sqlMemory.Tags
+	mem.TriggerPhrases = sqlMemory.TriggerPhrases
+
+	// Store in vector database, undoing the SQLite write on failure
+	if err := e.vectorStore.Store(ctx, mem.ID, mem.Content, embedding, e.vectorMetadata(ctx, mem)); err != nil {
+		e.rollbackBatch(ctx, []*Memory{mem}, 0)
+		return nil, fmt.Errorf("failed to store memory in vector database: %w", err)
+	}
+	e.recent.add([]string{mem.ID}, [][]float32{embedding})
+
+	if dup != nil {
+		dup.Superseded = true
+		if err := e.resolveDuplicate(ctx, dup, mem); err != nil {
+			return dup, err
+		}
+	}
+	return dup, nil
+}
+
+// CreateMemories creates several memories at once. It is the bulk variant
+// of CreateMemory: contents are embedded together when the embedder supports
+// batching, the SQLite rows are written in one transaction and the vectors
+// are stored together when the vector store supports it. On success the
+// memories carry their new IDs, in order. A *VectorFailureError means the
+// memories were saved but some of them could not be given a vector; after
+// any other error none of them were saved.
+func (e *Engine) CreateMemories(ctx context.Context, mems []*Memory) error {
+	if len(mems) == 0 {
+		return nil
+	}
+
+	embeddings, err := e.prepareMemories(ctx, mems)
+	if err != nil {
+		return err
+	}
+	return e.insertMemories(ctx, mems, embeddings)
+}
+
+// prepareMemories assigns IDs and timestamps to new memories and embeds
+// their contents
+func (e *Engine) prepareMemories(ctx context.Context, mems []*Memory) ([][]float32, error) {
+	now := time.Now()
+	texts := make([]string, len(mems))
+	for i, mem := range mems {
+		if mem.ID == "" {
+			mem.ID = uuid.New().String()
+		}
+		if mem.CreatedAt.IsZero() {
+			mem.CreatedAt = now
+		}
+		mem.UpdatedAt = mem.CreatedAt
+		texts[i] = mem.Content
+	}
+
+	embeddings, err := e.embedAll(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	return embeddings, nil
+}
+
+// insertMemories stores prepared memories and their embeddings in SQLite
+// and the vector store
+func (e *Engine) insertMemories(ctx context.Context, mems []*Memory, embeddings [][]float32) error {
+	if len(mems) == 0 {
+		return nil
+	}
+
+	embedderID := e.EmbedderID()
+	sqlMemories := make([]*storage.Memory, len(mems))
+	for i, mem := range mems {
+		e.resolveImportance(ctx, mem, embeddings[i])
+		sqlMemories[i] = memoryToSQLMemory(mem)
+		sqlMemories[i].EmbedderID = embedderID
+	}
+	if err := e.sqlStore.CreateMemoryBatch(ctx, sqlMemories, nil, nil); err != nil {
+		return fmt.Errorf("failed to store memories in SQLite: %w", err)
+	}
+	for i, mem := range mems {
+		mem.SemanticTags = sqlMemories[i].Tags
+		mem.TriggerPhrases = sqlMemories[i].TriggerPhrases
 	}
 
-	if err := e.sqlStore.CreateMemory(sqlMemory); err != nil {
-		return fmt.Errorf("failed to store memory in SQLite: %w", err)
+	if stored, err := e.storeVectors(ctx, mems, embeddings); err != nil {
+		var partial *storage.PartialBatchError
+		if errors.As(err, &partial) {
+			return e.retryVectors(ctx, mems, embeddings, partial)
+		}
+		e.rollbackBatch(ctx, mems, stored)
+		return fmt.Errorf("failed to store memories in vector database: %w", err)
 	}
 
-	// Store in vector database
-	metadata := map[string]interface{}{
-		"project_id":         mem.ProjectID,
-		"importance":         mem.Importance,
-		"context_type":       string(mem.ContextType),
-		"temporal_relevance": string(mem.TemporalRelevance),
-		"action_required":    mem.ActionRequired,
-		"tags":               mem.SemanticTags,
-		"trigger_phrases":    mem.TriggerPhrases,
-		"created_at":         mem.CreatedAt.Unix(),
+	return nil
+}
+
+// embedBatchSize bounds how many texts are sent to a batching embedder in
+// one request, so a large batch does not build one huge request body
+const embedBatchSize = 100
+
+// maxPendingVectorDeletes caps how many queued vector deletes one delete
+// retries
+const maxPendingVectorDeletes = 100
+
+// embedAll embeds texts in order, in calls of up to embedBatchSize texts
+// when the embedder supports batching and one at a time otherwise
+func (e *Engine) embedAll(ctx context.Context, texts []string) ([][]float32, error) {
+	if batcher, ok := e.embedder.(BatchEmbedder); ok {
+		embeddings := make([][]float32, 0, len(texts))
+		for start := 0; start < len(texts); start += embedBatchSize {
+			end := start + embedBatchSize
+			if end > len(texts) {
+				end = len(texts)
+			}
+
+			batch, err := batcher.EmbedBatch(ctx, texts[start:end])
+			if err != nil {
+				return nil, err
+			}
+			if len(batch) != end-start {
+				return nil, fmt.Errorf("embedder returned %d embeddings for %d texts", len(batch), end-start)
+			}
+			for i, embedding := range batch {
+				if err := e.checkEmbedding(embedding); err != nil {
+					return nil, fmt.Errorf("text %d: %w", start+i, err)
+				}
+			}
+			embeddings = append(embeddings, batch...)
+		}
+		return embeddings, nil
 	}
 
-	if err := e.vectorStore.Store(mem.ID, mem.Content, embedding, metadata); err != nil {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := e.embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// storeVectors stores the vectors of mems, in a single request when the
+// vector store supports it. It returns how many of mems, from the start,
+// may have been stored before an error. A failed batch stores none, except
+// that a *storage.PartialBatchError leaves every vector not listed in it
+// stored.
+func (e *Engine) storeVectors(ctx context.Context, mems []*Memory, embeddings [][]float32) (stored int, err error) {
+	defer func() {
+		ids := make([]string, stored)
+		for i := range ids {
+			ids[i] = mems[i].ID
+		}
+		e.recent.add(ids, embeddings[:stored])
+	}()
+
+	if batcher, ok := e.vectorStore.(BatchStorer); ok {
+		if err := batcher.StoreBatch(ctx, e.vectors(ctx, mems, embeddings)); err != nil {
+			var partial *storage.PartialBatchError
+			if errors.As(err, &partial) {
+				return len(mems), err
+			}
+			return 0, err
+		}
+		return len(mems), nil
+	}
+
+	for i, mem := range mems {
+		if err := e.vectorStore.Store(ctx, mem.ID, mem.Content, embeddings[i], e.vectorMetadata(ctx, mem)); err != nil {
+			return i, &BatchError{Field: "memories", Index: i, Err: err}
+		}
+	}
+	return len(mems), nil
+}
+
+// vectors pairs memories with their embeddings for BatchStorer
+func (e *Engine) vectors(ctx context.Context, mems []*Memory, embeddings [][]float32) []storage.Vector {
+	vectors := make([]storage.Vector, len(mems))
+	for i, mem := range mems {
+		vectors[i] = storage.Vector{
+			ID:        mem.ID,
+			Content:   mem.Content,
+			Embedding: embeddings[i],
+			Metadata:  e.vectorMetadata(ctx, mem),
+		}
+	}
+	return vectors
+}
+
+// UpdateMemory applies changes to an existing memory, re-embedding it if the
+// content changed. It returns the updated memory and a word-level diff of the
+// content, which is also recorded in the revision history.
+func (e *Engine) UpdateMemory(ctx context.Context, id string, update *MemoryUpdate) (*Memory, string, error) {
+	sqlMemory, err := e.sqlStore.GetMemory(ctx, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get memory: %w", err)
+	}
+	if sqlMemory == nil {
+		return nil, "", fmt.Errorf("memory not found: %s", id)
+	}
+
+	previousContent := sqlMemory.Content
+
+	if update.Content != nil {
+		sqlMemory.Content = *update.Content
+	}
+	if update.Importance != nil {
+		sqlMemory.Importance = *update.Importance
+		sqlMemory.ImportanceMethod = ImportanceMethodManual
+	}
+	if update.SemanticTags != nil {
+		sqlMemory.Tags = update.SemanticTags
+	}
+	if update.ContextType != nil {
+		sqlMemory.ContextType = stringPtr(string(*update.ContextType))
+	}
+	if update.TriggerPhrases != nil {
+		sqlMemory.TriggerPhrases = update.TriggerPhrases
+	}
+	if update.TemporalRelevance != nil {
+		sqlMemory.TemporalRelevance = stringPtr(string(*update.TemporalRelevance))
+	}
+	if update.ActionRequired != nil {
+		sqlMemory.ActionRequired = *update.ActionRequired
+	}
+
+	if err := e.sqlStore.UpdateMemory(ctx, sqlMemory); err != nil {
+		return nil, "", fmt.Errorf("failed to update memory in SQLite: %w", err)
+	}
+
+	mem := e.sqlMemoryToMemory(sqlMemory)
+
+	// Re-embed and replace the vector so search reflects the new content;
+	// other changes only need the metadata a vector store keeps updated.
+	// Archived memories get theirs when they are restored.
+	if !mem.Archived {
+		if mem.Content != previousContent {
+			if err := e.replaceVector(ctx, mem); err != nil {
+				return nil, "", err
+			}
+		} else if updater, ok := e.vectorStore.(PropertyUpdater); ok {
+			if err := updater.UpdateProperties(ctx, mem.ID, e.vectorMetadata(ctx, mem)); err != nil {
+				return nil, "", fmt.Errorf("failed to update memory in vector database: %w", err)
+			}
+		}
+	}
+
+	diff := WordDiff(previousContent, mem.Content)
+	if diff != "" {
+		if err := e.sqlStore.CreateRevision(ctx, &storage.MemoryRevision{
+			MemoryID:        mem.ID,
+			PreviousContent: previousContent,
+			Diff:            diff,
+			Reason:          "update",
+		}); err != nil {
+			return nil, "", fmt.Errorf("failed to record revision: %w", err)
+		}
+	}
+
+	return mem, diff, nil
+}
+
+// replaceVector embeds a memory and replaces its vector. The old vector is
+// overwritten by the store, so a failed store leaves it searchable.
+func (e *Engine) replaceVector(ctx context.Context, mem *Memory) error {
+	embedding, err := e.embed(ctx, mem.Content)
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	if err := e.vectorStore.Store(ctx, mem.ID, mem.Content, embedding, e.vectorMetadata(ctx, mem)); err != nil {
 		return fmt.Errorf("failed to store memory in vector database: %w", err)
 	}
+	if err := e.sqlStore.SetEmbedderID(ctx, mem.ID, e.EmbedderID()); err != nil {
+		return fmt.Errorf("failed to record embedder: %w", err)
+	}
+	return nil
+}
 
-	mem.CreatedAt = time.Now()
-	mem.UpdatedAt = mem.CreatedAt
+// GetMemoryHistory returns the recorded content revisions of a memory
+func (e *Engine) GetMemoryHistory(ctx context.Context, id string) ([]*Revision, error) {
+	revs, err := e.sqlStore.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revisions: %w", err)
+	}
+
+	history := make([]*Revision, 0, len(revs))
+	for _, rev := range revs {
+		history = append(history, &Revision{
+			ID:              rev.ID,
+			MemoryID:        rev.MemoryID,
+			PreviousContent: rev.PreviousContent,
+			Diff:            rev.Diff,
+			Reason:          rev.Reason,
+			CreatedAt:       rev.CreatedAt,
+		})
+	}
+
+	return history, nil
+}
+
+// CreateRelationship links two existing memories. For supersedes relationships
+// the diff between the superseded and the superseding memory is recorded in
+// the new memory's revision history so the replacement can be audited.
+func (e *Engine) CreateRelationship(ctx context.Context, fromID, toID string, relType RelationshipType) error {
+	from, err := e.sqlStore.GetMemory(ctx, fromID)
+	if err != nil {
+		return fmt.Errorf("failed to get memory: %w", err)
+	}
+	if from == nil {
+		return fmt.Errorf("memory not found: %s", fromID)
+	}
+
+	to, err := e.sqlStore.GetMemory(ctx, toID)
+	if err != nil {
+		return fmt.Errorf("failed to get memory: %w", err)
+	}
+	if to == nil {
+		return fmt.Errorf("memory not found: %s", toID)
+	}
+
+	if err := e.sqlStore.CreateRelationship(ctx, &storage.MemoryRelationship{
+		FromMemoryID:     fromID,
+		ToMemoryID:       toID,
+		RelationshipType: string(relType),
+	}); err != nil {
+		return fmt.Errorf("failed to create relationship: %w", err)
+	}
+
+	if relType != RelationshipTypeSupersedes {
+		return nil
+	}
+
+	if diff := WordDiff(to.Content, from.Content); diff != "" {
+		if err := e.sqlStore.CreateRevision(ctx, &storage.MemoryRevision{
+			MemoryID:        fromID,
+			PreviousContent: to.Content,
+			Diff:            diff,
+			Reason:          fmt.Sprintf("supersedes %s", toID),
+		}); err != nil {
+			return fmt.Errorf("failed to record revision: %w", err)
+		}
+	}
 
 	return nil
 }
 
 // GetMemory retrieves a memory by ID
-func (e *Engine) GetMemory(id string) (*Memory, error) {
-	sqlMemory, err := e.sqlStore.GetMemory(id)
+func (e *Engine) GetMemory(ctx context.Context, id string) (*Memory, error) {
+	sqlMemory, err := e.sqlStore.GetMemory(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get memory: %w", err)
 	}
@@ -111,104 +605,618 @@ func (e *Engine) GetMemory(id string) (*Memory, error) {
 	return e.sqlMemoryToMemory(sqlMemory), nil
 }
 
+// GetMemoryWithRelationships retrieves a memory together with up to limit
+// of its outgoing and up to limit of its incoming relationships, oldest
+// first; RelationshipCount tells how many it has in all. A limit of 0 or
+// less loads up to 100 each way. It returns nil if the memory does not
+// exist.
+func (e *Engine) GetMemoryWithRelationships(ctx context.Context, id string, limit int) (*Memory, error) {
+	mem, err := e.GetMemory(ctx, id)
+	if err != nil || mem == nil {
+		return mem, err
+	}
+
+	outgoing, err := e.sqlStore.GetOutgoing(ctx, id, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outgoing relationships: %w", err)
+	}
+	incoming, err := e.sqlStore.GetIncoming(ctx, id, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incoming relationships: %w", err)
+	}
+
+	mem.RelationshipCount = outgoing.Total + incoming.Total
+	mem.Relationships = make([]Relationship, 0, len(outgoing.Edges)+len(incoming.Edges))
+	for _, edge := range append(outgoing.Edges, incoming.Edges...) {
+		mem.Relationships = append(mem.Relationships, Relationship{
+			FromMemoryID: edge.FromMemoryID,
+			ToMemoryID:   edge.ToMemoryID,
+			Type:         RelationshipType(edge.RelationshipType),
+			Direction:    string(edge.Direction),
+			CreatedAt:    edge.CreatedAt,
+		})
+	}
+
+	return mem, nil
+}
+
+// ListMemories returns a page of memories straight from SQLite along with
+// the total number of memories matching the filters
+func (e *Engine) ListMemories(ctx context.Context, query *ListQuery) ([]*Memory, int, error) {
+	sqlMemories, total, err := e.sqlStore.ListMemories(ctx, storage.ListOptions{
+		ProjectID:       query.ProjectID,
+		Limit:           query.Limit,
+		Offset:          query.Offset,
+		OrderBy:         query.OrderBy,
+		ContextType:     string(query.ContextType),
+		Tag:             query.Tag,
+		ActionRequired:  query.ActionRequired,
+		IncludeArchived: query.IncludeArchived,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	memories := make([]*Memory, 0, len(sqlMemories))
+	for _, sqlMem := range sqlMemories {
+		memories = append(memories, e.sqlMemoryToMemory(sqlMem))
+	}
+
+	return memories, total, nil
+}
+
 // SearchMemories searches for relevant memories
-func (e *Engine) SearchMemories(query *SearchQuery) ([]*SearchResult, error) {
-	// Generate embedding for query
-	queryEmbedding, err := e.embedder.Embed(query.Query)
+func (e *Engine) SearchMemories(ctx context.Context, query *SearchQuery) ([]*SearchResult, error) {
+	direction, err := storage.ParseTraversalDirection(query.GraphDirection)
+	if err != nil {
+		return nil, err
+	}
+	switch query.TagMatch {
+	case "", TagMatchAny, TagMatchAll:
+	default:
+		return nil, fmt.Errorf("unknown tag match %q (valid: any, all)", query.TagMatch)
+	}
+	mode := query.Mode
+	switch mode {
+	case "":
+		mode = SearchModeHybrid
+	case SearchModeHybrid, SearchModeSemantic, SearchModeKeyword:
+	default:
+		return nil, fmt.Errorf("unknown search mode %q (valid: hybrid, semantic, keyword)", query.Mode)
+	}
+	for _, ct := range query.ContextTypes {
+		if _, err := ParseContextType(string(ct)); err != nil {
+			return nil, err
+		}
+	}
+	if !query.CreatedAfter.IsZero() && !query.CreatedBefore.IsZero() && !query.CreatedAfter.Before(query.CreatedBefore) {
+		return nil, fmt.Errorf("created after %s must be earlier than created before %s",
+			query.CreatedAfter.Format(time.RFC3339), query.CreatedBefore.Format(time.RFC3339))
+	}
+
+	scopeKey, scopeID, projectIDs, err := e.scopeFilter(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+		return nil, err
+	}
+	minSimilarity := e.minSimilarity
+	if query.MinSimilarity != nil {
+		minSimilarity = *query.MinSimilarity
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = 5
+	}
+
+	if mode == SearchModeKeyword {
+		results := e.keywordResults(ctx, query, projectIDs, limit)
+		return e.finishSearch(ctx, query, results, projectIDs, limit, direction), nil
+	}
+
+	// Generate embedding for query; without one, keyword matches are all
+	// there is to go on
+	queryEmbedding, err := e.embedQuery(ctx, query.Query)
+	if err != nil {
+		return e.textFallback(ctx, query, projectIDs, limit, direction,
+			fmt.Errorf("failed to generate query embedding: %w", err))
 	}
 
 	// Build filters
 	filters := map[string]interface{}{
-		"project_id": query.ProjectID,
+		scopeKey: scopeID,
 	}
 	if query.MinImportance > 0 {
 		filters["importance_gte"] = query.MinImportance
 	}
+	if len(query.ContextTypes) > 0 {
+		contextTypes := make([]string, len(query.ContextTypes))
+		for i, ct := range query.ContextTypes {
+			contextTypes[i] = string(ct)
+		}
+		filters["context_types"] = contextTypes
+	}
+	if len(query.Tags) > 0 {
+		filters["tags"] = query.Tags
+		filters["tags_match"] = query.TagMatch
+	}
+	if !query.CreatedAfter.IsZero() {
+		filters["created_after"] = query.CreatedAfter.Unix()
+	}
+	if !query.CreatedBefore.IsZero() {
+		filters["created_before"] = query.CreatedBefore.Unix()
+	}
 
-	// Search vector database
-	limit := query.Limit
-	if limit == 0 {
-		limit = 5
+	// Memories are also filtered after loading them from SQLite (vectors
+	// can carry stale metadata), so widen the candidate set until enough
+	// survive or the vector store runs out of matches
+	maxFetch := maxSearchCandidates
+	if limit > maxFetch {
+		maxFetch = limit
 	}
 
-	vectorResults, err := e.vectorStore.Search(queryEmbedding, limit*2, filters)
+	var results []*SearchResult
+	var vectorResults []storage.VectorSearchResult
+	var hits, dissimilar int
+	for fetch := e.candidateLimit(limit); ; fetch *= 4 {
+		if fetch > maxFetch {
+			fetch = maxFetch
+		}
+
+		vectorResults, err = e.vectorStore.Search(ctx, queryEmbedding, fetch, filters)
+		if err != nil {
+			return e.textFallback(ctx, query, projectIDs, limit, direction,
+				fmt.Errorf("failed to search vector database: %w", err))
+		}
+
+		var similar []storage.VectorSearchResult
+		similar, dissimilar = e.dropDissimilar(vectorResults, minSimilarity)
+		hits = len(vectorResults)
+
+		results = e.scoreVectorResults(ctx, query, similar, projectIDs)
+		e.logger.Debug("vector search", scopeKey, scopeID, "fetch", fetch,
+			"hits", hits, "dissimilar", dissimilar, "kept", len(results))
+
+		// Hits come nearest first, so once one is too dissimilar fetching
+		// more cannot help
+		if len(results) >= limit || len(vectorResults) < fetch || fetch == maxFetch || dissimilar > 0 {
+			break
+		}
+	}
+
+	// Memories saved moments ago may not be searchable in the vector store
+	// yet; without them a client could save the same memory twice
+	results = e.addRecentHits(ctx, query, queryEmbedding, results, vectorResults, projectIDs, minSimilarity)
+
+	// Exact identifiers and rare words are often missed by embeddings, so
+	// rank full-text matches alongside the vector hits
+	if mode == SearchModeHybrid {
+		results = e.fuseResults(results, e.keywordResults(ctx, query, projectIDs, limit))
+	}
+
+	if len(results) == 0 && dissimilar > 0 && dissimilar == hits {
+		return nil, ErrNoRelevantMemories
+	}
+
+	return e.finishSearch(ctx, query, results, projectIDs, limit, direction), nil
+}
+
+// textFallback answers a search with full-text matches alone when the
+// query could not be embedded or the vector store failed. The results are
+// marked as TextFallback; without any, the search fails with cause.
+func (e *Engine) textFallback(ctx context.Context, query *SearchQuery, projectIDs map[string]bool, limit int, direction storage.TraversalDirection, cause error) ([]*SearchResult, error) {
+	results := e.keywordResults(ctx, query, projectIDs, limit)
+	if len(results) == 0 {
+		return nil, cause
+	}
+	e.logger.Warn("vector search unavailable, returning full-text matches only", "error", cause)
+	NoteDegradation(ctx, DegradedVectorSearch)
+
+	for _, result := range results {
+		result.TextFallback = true
+	}
+	return e.finishSearch(ctx, query, results, projectIDs, limit, direction), nil
+}
+
+// finishSearch ranks scored hits, keeps the best limit of them, expands
+// them through relationships within the query's scope (projectIDs, as for
+// expandResults) and records the access to what is returned
+func (e *Engine) finishSearch(ctx context.Context, query *SearchQuery, results []*SearchResult, projectIDs map[string]bool, limit int, direction storage.TraversalDirection) []*SearchResult {
+	// Sort by relevance score, then let near-duplicates give way
+	sortByRelevance(results)
+	results = e.diversify(ctx, results, limit)
+
+	// Limit results before graph expansion
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	// Expand with graph relationships if configured
+	depth := e.graphDepth
+	if query.IncludeGraphDepth != nil {
+		depth = *query.IncludeGraphDepth
+	}
+	if depth > 0 && len(results) > 0 {
+		results = e.expandResults(ctx, query, results, projectIDs, depth, direction)
+
+		// Related memories compete with direct hits for the final limit
+		sortByRelevance(results)
+		if len(results) > limit {
+			results = results[:limit]
+		}
+	}
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Memory.ID
+	}
+	e.RecordAccess(ctx, ids...)
+
+	return results
+}
+
+// RecordAccess counts an access to each of the memories. Failures are only
+// logged; access counts are not worth failing a read over.
+func (e *Engine) RecordAccess(ctx context.Context, ids ...string) {
+	if err := e.sqlStore.TouchMemories(ctx, ids); err != nil {
+		e.logger.Warn("failed to record memory access", "count", len(ids), "error", err)
+	}
+}
+
+// expandResults appends memories related to the direct hits that match the
+// query's filters and importance threshold, scored by the relevance of the
+// hit they were reached from decayed by graphDecay per hop. Relationships
+// may cross projects, so related memories must also belong to one of
+// projectIDs, or to the query's project if it is nil. Direct hits are never
+// duplicated because traversal starts from them.
+func (e *Engine) expandResults(ctx context.Context, query *SearchQuery, results []*SearchResult, projectIDs map[string]bool, depth int, direction storage.TraversalDirection) []*SearchResult {
+	seedIDs := make([]string, len(results))
+	seedScores := make(map[string]float64, len(results))
+	for i, r := range results {
+		seedIDs[i] = r.Memory.ID
+		seedScores[r.Memory.ID] = r.RelevanceScore
+	}
+
+	// On failure, the memories reached before it are still worth returning
+	expanded, err := e.graphTraverser.Expand(ctx, seedIDs, depth, direction)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search vector database: %w", err)
+		e.logger.Warn("failed to expand search results through relationships", "error", err)
+		NoteDegradation(ctx, DegradedGraph)
+	}
+
+	var related []*SearchResult
+	for _, exp := range expanded {
+		relMem, err := e.GetMemory(ctx, exp.ID)
+		if err != nil {
+			e.logger.Warn("failed to load related memory", "id", exp.ID, "error", err)
+			continue
+		}
+		if relMem == nil || relMem.Importance < query.MinImportance || !matchesFilters(relMem, query) {
+			continue
+		}
+		if !inScope(relMem, query, projectIDs) {
+			continue
+		}
+
+		related = append(related, &SearchResult{
+			Memory:           relMem,
+			RelevanceScore:   seedScores[exp.SeedID] * math.Pow(graphDecay, float64(exp.Depth)),
+			GraphExpanded:    true,
+			RelationshipType: RelationshipType(exp.RelationshipType),
+			Direction:        string(exp.Direction),
+		})
+	}
+
+	return append(results, related...)
+}
+
+// dropDissimilar removes vector hits less similar than minSimilarity,
+// returning the rest and how many were removed
+func (e *Engine) dropDissimilar(vectorResults []storage.VectorSearchResult, minSimilarity float64) ([]storage.VectorSearchResult, int) {
+	if minSimilarity <= 0 {
+		return vectorResults, 0
+	}
+
+	var kept []storage.VectorSearchResult
+	for _, vr := range vectorResults {
+		if e.similarity(vr.Distance) >= minSimilarity {
+			kept = append(kept, vr)
+		}
 	}
+	return kept, len(vectorResults) - len(kept)
+}
 
-	// Convert to search results and score
+// scoreVectorResults loads vector hits from SQLite, drops those that do not
+// match the query's filters or belong to none of projectIDs (if not nil) and
+// scores the rest
+func (e *Engine) scoreVectorResults(ctx context.Context, query *SearchQuery, vectorResults []storage.VectorSearchResult, projectIDs map[string]bool) []*SearchResult {
+	return e.scoreHits(ctx, query, vectorResults, projectIDs, e.distanceMetric())
+}
+
+// scoreHits is scoreVectorResults for distances in the given metric
+func (e *Engine) scoreHits(ctx context.Context, query *SearchQuery, vectorResults []storage.VectorSearchResult, projectIDs map[string]bool, metric storage.DistanceMetric) []*SearchResult {
 	var results []*SearchResult
 	for _, vr := range vectorResults {
 		// Get full memory from SQLite
-		mem, err := e.GetMemory(vr.ID)
+		mem, err := e.GetMemory(ctx, vr.ID)
 		if err != nil {
+			e.logger.Warn("failed to load search hit", "id", vr.ID, "error", err)
+			continue
+		}
+		if mem == nil || !matchesFilters(mem, query) {
 			continue
 		}
-		if mem == nil {
+		if projectIDs != nil && !projectIDs[mem.ProjectID] {
 			continue
 		}
 
-		// Calculate similarity score (1 - normalized distance)
-		similarityScore := 1.0 - vr.Distance
+		similarityScore := e.similarityIn(metric, vr.Distance)
 
-		// Check for trigger phrase matches
-		triggerMatched := e.checkTriggerMatch(query.Query, mem.TriggerPhrases)
+		// Check for trigger phrase and verbatim matches
+		matchedTrigger := e.checkTriggerMatch(query.Query, mem.TriggerPhrases)
+		keywordMatched := containsFold(mem.Content, query.Query)
 
 		// Calculate relevance score
-		relevanceScore := e.calculateRelevanceScore(mem, similarityScore, triggerMatched)
+		relevanceScore := e.calculateRelevanceScore(mem, similarityScore, matchedTrigger != "", keywordMatched)
 
 		results = append(results, &SearchResult{
 			Memory:          mem,
 			SimilarityScore: similarityScore,
 			RelevanceScore:  relevanceScore,
-			TriggerMatched:  triggerMatched,
+			TriggerMatched:  matchedTrigger != "",
+			MatchedTrigger:  matchedTrigger,
+			KeywordMatched:  keywordMatched,
+			Distance:        vr.Distance,
+			DistanceMetric:  metric,
 		})
 	}
+	return results
+}
 
-	// Sort by relevance score
-	sortByRelevance(results)
+// matchesFilters reports whether a memory has one of the query's context
+// types (if any) and at least one of its tags (if any), was created within
+// the query's time range (if any), and is not archived unless the query
+// includes archived memories
+func matchesFilters(mem *Memory, query *SearchQuery) bool {
+	if mem.Archived && !query.IncludeArchived {
+		return false
+	}
 
-	// Limit results before graph expansion
-	if len(results) > limit {
-		results = results[:limit]
+	if len(query.ContextTypes) > 0 {
+		found := false
+		for _, ct := range query.ContextTypes {
+			if mem.ContextType == ct {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
 	}
 
-	// Expand with graph relationships if configured
-	if e.graphDepth > 0 && len(results) > 0 {
-		seedIDs := make([]string, len(results))
-		for i, r := range results {
-			seedIDs[i] = r.Memory.ID
-		}
-
-		relatedIDs, err := e.graphTraverser.ExpandMemories(seedIDs, e.graphDepth)
-		if err == nil && len(relatedIDs) > 0 {
-			// Fetch related memories
-			for _, relID := range relatedIDs {
-				relMem, err := e.GetMemory(relID)
-				if err != nil || relMem == nil {
-					continue
-				}
+	if len(query.Tags) > 0 {
+		has := make(map[string]bool, len(mem.SemanticTags))
+		for _, tag := range mem.SemanticTags {
+			has[tag] = true
+		}
+		matched := 0
+		for _, want := range query.Tags {
+			if has[want] {
+				matched++
+			}
+		}
+		if matched == 0 || (query.TagMatch == TagMatchAll && matched < len(query.Tags)) {
+			return false
+		}
+	}
+
+	// Compared in whole seconds, like the vector stores' createdAt
+	if !query.CreatedAfter.IsZero() && mem.CreatedAt.Unix() < query.CreatedAfter.Unix() {
+		return false
+	}
+	if !query.CreatedBefore.IsZero() && mem.CreatedAt.Unix() >= query.CreatedBefore.Unix() {
+		return false
+	}
+
+	return true
+}
+
+// inScope reports whether a memory belongs to one of projectIDs, or to the
+// query's project if projectIDs is nil
+func inScope(mem *Memory, query *SearchQuery, projectIDs map[string]bool) bool {
+	if projectIDs == nil {
+		return mem.ProjectID == query.ProjectID
+	}
+	return projectIDs[mem.ProjectID]
+}
+
+// DeleteMemories removes memories from SQLite and the vector store, using the
+// vector store's batch delete when available
+func (e *Engine) DeleteMemories(ctx context.Context, ids []string) (*DeleteReport, error) {
+	report := &DeleteReport{Requested: len(ids)}
+	if len(ids) == 0 {
+		return report, nil
+	}
+
+	start := time.Now()
+
+	// Relationships touching the memories go with them by cascade; count
+	// them first so the report can say what was lost
+	if neighborhood, err := e.sqlStore.GetNeighborhood(ctx, ids, 1); err != nil {
+		e.logger.Warn("failed to count relationships of deleted memories", "error", err)
+	} else {
+		report.RelationshipsDeleted = neighborhood.Total
+	}
+
+	deleted, err := e.sqlStore.DeleteMemories(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete memories from SQLite: %w", err)
+	}
+	report.SQLiteDeleted = deleted
+
+	return e.deleteVectors(ctx, ids, report, start)
+}
+
+// PruneMemories deletes temporary memories created before olderThan and,
+// if minImportance is above zero, memories less important than that, from
+// both SQLite and the vector store
+func (e *Engine) PruneMemories(ctx context.Context, olderThan time.Time, minImportance float64) (*DeleteReport, error) {
+	start := time.Now()
+
+	ids, err := e.sqlStore.PruneMemories(ctx, olderThan, minImportance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune memories from SQLite: %w", err)
+	}
+
+	report := &DeleteReport{Requested: len(ids), SQLiteDeleted: len(ids)}
+	if len(ids) == 0 {
+		return report, nil
+	}
+
+	return e.deleteVectors(ctx, ids, report, start)
+}
+
+// deleteVectors removes vectors for memories already deleted from SQLite,
+// recording the outcome in report. Vectors that cannot be deleted are
+// queued and retried by later deletes; until then searches skip them since
+// they have no memory.
+func (e *Engine) deleteVectors(ctx context.Context, ids []string, report *DeleteReport, start time.Time) (*DeleteReport, error) {
+	e.retryVectorDeletes(ctx)
+
+	deleted, failed, err := e.removeVectors(ctx, ids)
+	report.VectorDeleted = deleted
+	report.VectorFailed = len(failed)
+	e.queueVectorDeletes(ctx, failed)
+
+	report.Duration = time.Since(start)
+	if err != nil {
+		return report, fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	return report, nil
+}
+
+// removeVectors deletes vectors, using the vector store's batch delete when
+// available. It returns how many were deleted and why each failed one
+// failed; err is only set when the whole batch failed.
+func (e *Engine) removeVectors(ctx context.Context, ids []string) (int, map[string]string, error) {
+	failed := make(map[string]string)
 
-				// Add with lower relevance score
-				results = append(results, &SearchResult{
-					Memory:          relMem,
-					SimilarityScore: 0.5,
-					RelevanceScore:  0.5,
-					TriggerMatched:  false,
-				})
+	batcher, ok := e.vectorStore.(BatchDeleter)
+	if !ok {
+		deleted := 0
+		for _, id := range ids {
+			if err := e.vectorStore.Delete(ctx, id); err != nil {
+				e.logger.Warn("failed to delete vector", "id", id, "error", err)
+				failed[id] = err.Error()
+				continue
 			}
+			deleted++
+		}
+		return deleted, failed, nil
+	}
+
+	deleted, err := batcher.DeleteBatch(ctx, ids)
+	var partial *storage.PartialBatchError
+	if errors.As(err, &partial) {
+		for _, failure := range partial.Failed {
+			e.logger.Warn("failed to delete vector", "id", failure.ID, "error", failure.Reason)
+			failed[failure.ID] = failure.Reason
+		}
+		return deleted, failed, nil
+	}
+	if err != nil {
+		for _, id := range ids {
+			failed[id] = err.Error()
 		}
+		return deleted, failed, err
+	}
+	return deleted, failed, nil
+}
+
+// queueVectorDeletes records vector deletes to retry later
+func (e *Engine) queueVectorDeletes(ctx context.Context, failed map[string]string) {
+	for id, reason := range failed {
+		if err := e.sqlStore.QueueVectorDelete(ctx, id, reason); err != nil {
+			e.logger.Warn("failed to queue vector delete", "id", id, "error", err)
+		}
+	}
+}
+
+// retryVectorDeletes deletes vectors queued by earlier deletes. Those that
+// fail again stay queued; those whose memory has since been restored are
+// dropped from the queue with their vector kept.
+func (e *Engine) retryVectorDeletes(ctx context.Context) {
+	ids, err := e.sqlStore.PendingVectorDeletes(ctx, maxPendingVectorDeletes)
+	if err != nil {
+		e.logger.Warn("failed to list queued vector deletes", "error", err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	existing, err := e.sqlStore.ExistingMemoryIDs(ctx, ids)
+	if err != nil {
+		e.logger.Warn("failed to check queued vector deletes", "error", err)
+		return
+	}
+	var orphans []string
+	for _, id := range ids {
+		if !existing[id] {
+			orphans = append(orphans, id)
+		}
+	}
+
+	_, failed, err := e.removeVectors(ctx, orphans)
+	if err != nil {
+		e.logger.Warn("failed to retry queued vector deletes", "count", len(orphans), "error", err)
+	}
+	e.queueVectorDeletes(ctx, failed)
+
+	var done []string
+	for _, id := range ids {
+		if _, ok := failed[id]; !ok {
+			done = append(done, id)
+		}
+	}
+	if err := e.sqlStore.RemovePendingVectorDeletes(ctx, done); err != nil {
+		e.logger.Warn("failed to clear queued vector deletes", "error", err)
 	}
+}
+
+// EmbeddingInfo describes the embedder's vector space, if it can
+func (e *Engine) EmbeddingInfo() (embeddings.Info, bool) {
+	provider, ok := e.embedder.(EmbeddingInfoProvider)
+	if !ok {
+		return embeddings.Info{}, false
+	}
+	return provider.EmbeddingInfo(), true
+}
 
-	return results, nil
+// EmbedderID identifies the current embedder, or is empty if the embedder
+// cannot describe itself
+func (e *Engine) EmbedderID() string {
+	info, ok := e.EmbeddingInfo()
+	if !ok {
+		return ""
+	}
+	return info.ID()
+}
+
+// CountMemories counts a project's memories with at least the given importance
+func (e *Engine) CountMemories(ctx context.Context, projectID string, minImportance float64) (int, error) {
+	return e.sqlStore.CountMemories(ctx, projectID, minImportance)
+}
+
+// UsageReport returns the AI usage ledger aggregated by day and model
+func (e *Engine) UsageReport(ctx context.Context, since time.Time) ([]storage.UsageSummary, error) {
+	return e.sqlStore.UsageReport(ctx, since)
 }
 
 // GetOrCreateProject gets or creates a project based on path
-func (e *Engine) GetOrCreateProject(name string, path string) (*storage.Project, error) {
+func (e *Engine) GetOrCreateProject(ctx context.Context, name string, path string) (*storage.Project, error) {
 	// Try to get existing project
-	project, err := e.sqlStore.GetProjectByPath(path)
+	project, err := e.sqlStore.GetProjectByPath(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -220,7 +1228,7 @@ func (e *Engine) GetOrCreateProject(name string, path string) (*storage.Project,
 			Name: name,
 			Path: path,
 		}
-		if err := e.sqlStore.CreateProject(project); err != nil {
+		if err := e.sqlStore.CreateProject(ctx, project); err != nil {
 			return nil, err
 		}
 	}
@@ -229,28 +1237,32 @@ func (e *Engine) GetOrCreateProject(name string, path string) (*storage.Project,
 }
 
 // CreateSession creates a new session
-func (e *Engine) CreateSession(projectID string) (*storage.Session, error) {
+func (e *Engine) CreateSession(ctx context.Context, projectID string) (*storage.Session, error) {
 	session := &storage.Session{
 		ID:        uuid.New().String(),
 		ProjectID: projectID,
 		StartedAt: time.Now(),
 	}
 
-	if err := e.sqlStore.CreateSession(session); err != nil {
+	if err := e.sqlStore.CreateSession(ctx, session); err != nil {
 		return nil, err
 	}
 
 	return session, nil
 }
 
-// EndSession ends a session
-func (e *Engine) EndSession(sessionID string) error {
-	session, err := e.sqlStore.GetSession(sessionID)
+// EndSession ends a session, recording its end time and duration, and
+// returns the ended session
+func (e *Engine) EndSession(ctx context.Context, sessionID string) (*storage.Session, error) {
+	session, err := e.sqlStore.GetSession(ctx, sessionID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if session == nil {
-		return fmt.Errorf("session not found: %s", sessionID)
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	if session.EndedAt != nil {
+		return nil, fmt.Errorf("session %s already ended at %s", sessionID, session.EndedAt.Format(time.RFC3339))
 	}
 
 	now := time.Now()
@@ -258,12 +1270,66 @@ func (e *Engine) EndSession(sessionID string) error {
 	duration := int(now.Sub(session.StartedAt).Seconds())
 	session.DurationSeconds = &duration
 
-	return e.sqlStore.UpdateSession(session)
+	if err := e.sqlStore.UpdateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ResolveSession validates an explicit session ID against a project, or
+// falls back to the project's active (not yet ended) session. It returns ""
+// when no session ID was given and no session is active.
+func (e *Engine) ResolveSession(ctx context.Context, projectID, sessionID string) (string, error) {
+	if sessionID != "" {
+		session, err := e.sqlStore.GetSession(ctx, sessionID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get session: %w", err)
+		}
+		if session == nil {
+			return "", fmt.Errorf("session not found: %s", sessionID)
+		}
+		if session.ProjectID != projectID {
+			return "", fmt.Errorf("session %s belongs to a different project", sessionID)
+		}
+		return session.ID, nil
+	}
+
+	session, err := e.sqlStore.GetLastSession(ctx, projectID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get last session: %w", err)
+	}
+	if session == nil || session.EndedAt != nil {
+		return "", nil
+	}
+	return session.ID, nil
+}
+
+// SetSessionSummary records what a session was about, shown in the next
+// session's primer
+func (e *Engine) SetSessionSummary(ctx context.Context, sessionID, summary string) error {
+	return e.sqlStore.SetSessionSummary(ctx, sessionID, summary)
+}
+
+// AppendTranscript adds text to a session's transcript and returns the
+// whole transcript
+func (e *Engine) AppendTranscript(ctx context.Context, sessionID, text string) (*storage.Transcript, error) {
+	return e.sqlStore.AppendTranscript(ctx, sessionID, text)
+}
+
+// GetTranscript returns a session's transcript, or nil if none was recorded
+func (e *Engine) GetTranscript(ctx context.Context, sessionID string) (*storage.Transcript, error) {
+	return e.sqlStore.GetTranscript(ctx, sessionID)
+}
+
+// SetTranscriptCurated records how much of a session's transcript has been
+// curated
+func (e *Engine) SetTranscriptCurated(ctx context.Context, sessionID string, offset int) error {
+	return e.sqlStore.SetTranscriptCurated(ctx, sessionID, offset)
 }
 
 // GetSessionPrimer generates a session primer for context injection
-func (e *Engine) GetSessionPrimer(projectID string) (*SessionPrimer, error) {
-	project, err := e.sqlStore.GetProject(projectID)
+func (e *Engine) GetSessionPrimer(ctx context.Context, projectID string) (*SessionPrimer, error) {
+	project, err := e.sqlStore.GetProject(ctx, projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -275,29 +1341,24 @@ func (e *Engine) GetSessionPrimer(projectID string) (*SessionPrimer, error) {
 		ProjectName: project.Name,
 	}
 
-	// Get last session
-	lastSession, err := e.sqlStore.GetLastSession(projectID)
+	// Get the last finished session; the current one is still running
+	lastSession, err := e.sqlStore.GetLastEndedSession(ctx, projectID)
 	if err != nil {
 		return nil, err
 	}
 
 	if lastSession != nil && lastSession.EndedAt != nil {
 		primer.LastSessionDate = lastSession.EndedAt
-		timeSince := time.Since(*lastSession.EndedAt)
-		primer.TimeSinceLastSession = formatDuration(timeSince)
+		primer.LastSessionSummary = lastSession.Summary
 	}
 
-	// Get top memories (high importance, recent)
-	topMemories, err := e.SearchMemories(&SearchQuery{
-		Query:         project.Name, // Use project name as general query
-		ProjectID:     projectID,
-		Limit:         3,
-		MinImportance: 0.7,
-	})
-	if err == nil && len(topMemories) > 0 {
-		for _, result := range topMemories {
-			primer.TopMemories = append(primer.TopMemories, result.Memory)
-		}
+	if err := e.addPrimerMemories(ctx, primer, projectID, lastSession); err != nil {
+		return nil, err
+	}
+
+	if err := e.addWorkspacePrimer(ctx, primer, project); err != nil {
+		e.logger.Warn("failed to load workspace memories", "project_id", projectID, "error", err)
+		NoteDegradation(ctx, DegradedWorkspace)
 	}
 
 	return primer, nil
@@ -305,17 +1366,60 @@ func (e *Engine) GetSessionPrimer(projectID string) (*SessionPrimer, error) {
 
 // Helper functions
 
+// vectorMetadata builds the metadata stored alongside a memory's vector
+func (e *Engine) vectorMetadata(ctx context.Context, mem *Memory) map[string]interface{} {
+	// Keys must match the Weaviate schema properties so they can be filtered on
+	return map[string]interface{}{
+		"projectId":         mem.ProjectID,
+		"workspaceId":       e.workspaceOf(ctx, mem.ProjectID),
+		"sessionId":         mem.SessionID,
+		"importance":        mem.Importance,
+		"contextType":       string(mem.ContextType),
+		"temporalRelevance": string(mem.TemporalRelevance),
+		"actionRequired":    mem.ActionRequired,
+		"tags":              mem.SemanticTags,
+		"triggerPhrases":    mem.TriggerPhrases,
+		"createdAt":         mem.CreatedAt.Unix(),
+	}
+}
+
+// memoryToSQLMemory converts a memory to its SQLite representation
+func memoryToSQLMemory(mem *Memory) *storage.Memory {
+	return &storage.Memory{
+		ID:                mem.ID,
+		ProjectID:         mem.ProjectID,
+		SessionID:         stringPtr(mem.SessionID),
+		Content:           mem.Content,
+		Importance:        mem.Importance,
+		ContextType:       stringPtr(string(mem.ContextType)),
+		TemporalRelevance: stringPtr(string(mem.TemporalRelevance)),
+		ActionRequired:    mem.ActionRequired,
+		Tags:              mem.SemanticTags,
+		TriggerPhrases:    mem.TriggerPhrases,
+		CreatedAt:         mem.CreatedAt,
+		UpdatedAt:         mem.UpdatedAt,
+		Reasoning:         mem.Reasoning,
+		ImportanceMethod:  mem.ImportanceMethod,
+	}
+}
+
 func (e *Engine) sqlMemoryToMemory(sqlMem *storage.Memory) *Memory {
 	mem := &Memory{
-		ID:             sqlMem.ID,
-		ProjectID:      sqlMem.ProjectID,
-		Content:        sqlMem.Content,
-		Importance:     sqlMem.Importance,
-		SemanticTags:   sqlMem.Tags,
-		TriggerPhrases: sqlMem.TriggerPhrases,
-		ActionRequired: sqlMem.ActionRequired,
-		CreatedAt:      sqlMem.CreatedAt,
-		UpdatedAt:      sqlMem.UpdatedAt,
+		ID:               sqlMem.ID,
+		ProjectID:        sqlMem.ProjectID,
+		Content:          sqlMem.Content,
+		Importance:       sqlMem.Importance,
+		SemanticTags:     sqlMem.Tags,
+		TriggerPhrases:   sqlMem.TriggerPhrases,
+		ActionRequired:   sqlMem.ActionRequired,
+		Reasoning:        sqlMem.Reasoning,
+		ImportanceMethod: sqlMem.ImportanceMethod,
+		CreatedAt:        sqlMem.CreatedAt,
+		UpdatedAt:        sqlMem.UpdatedAt,
+		AccessCount:      sqlMem.AccessCount,
+		LastAccessedAt:   sqlMem.LastAccessedAt,
+		Archived:         sqlMem.Archived,
+		ArchivedAt:       sqlMem.ArchivedAt,
 	}
 
 	if sqlMem.SessionID != nil {
@@ -331,31 +1435,37 @@ func (e *Engine) sqlMemoryToMemory(sqlMem *storage.Memory) *Memory {
 	return mem
 }
 
-func (e *Engine) checkTriggerMatch(query string, triggers []string) bool {
-	// TODO: Implement sophisticated trigger matching
-	// For now, simple substring match
-	queryLower := toLower(query)
-	for _, trigger := range triggers {
-		if contains(queryLower, toLower(trigger)) {
-			return true
-		}
-	}
-	return false
+// checkTriggerMatch returns the trigger phrase the query matches, or "" if
+// none does
+func (e *Engine) checkTriggerMatch(query string, triggers []string) string {
+	// Match whole words so that "ai" does not fire on "maintain"
+	return matchTrigger(matchTokens(query), triggers, e.triggerFuzzy)
 }
 
-func (e *Engine) calculateRelevanceScore(mem *Memory, similarity float64, triggerMatched bool) float64 {
-	score := similarity * 0.6     // Base semantic similarity (60%)
-	score += mem.Importance * 0.3 // Importance weight (30%)
+func (e *Engine) calculateRelevanceScore(mem *Memory, similarity float64, triggerMatched, keywordMatched bool) float64 {
+	score := similarity * e.weights.Similarity     // Base semantic similarity
+	score += mem.Importance * e.weights.Importance // Importance weight
 
 	if triggerMatched {
-		score += 0.2 // Trigger match boost (20%)
+		score += e.weights.TriggerBoost
+	}
+	if keywordMatched {
+		score += e.weights.KeywordBoost
 	}
 
 	// Boost for action required
 	if mem.ActionRequired {
-		score += 0.1
+		score += e.weights.ActionBoost
 	}
 
+	// Bias toward or away from context types
+	if weight, ok := e.contextWeights[mem.ContextType]; ok {
+		score *= weight
+	}
+
+	// Older temporary and session memories count for less
+	score *= e.recencyDecay.factor(mem.TemporalRelevance, memoryAge(mem, time.Now()))
+
 	// Normalize to 0-1
 	if score > 1.0 {
 		score = 1.0
@@ -364,15 +1474,19 @@ func (e *Engine) calculateRelevanceScore(mem *Memory, similarity float64, trigge
 	return score
 }
 
+// sortByRelevance orders results by relevance, newest first among equal
+// scores and then by ID, so that ties come back in the same order every run
 func sortByRelevance(results []*SearchResult) {
-	// Simple bubble sort for now
-	for i := 0; i < len(results); i++ {
-		for j := i + 1; j < len(results); j++ {
-			if results[j].RelevanceScore > results[i].RelevanceScore {
-				results[i], results[j] = results[j], results[i]
-			}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.RelevanceScore != b.RelevanceScore {
+			return a.RelevanceScore > b.RelevanceScore
 		}
-	}
+		if !a.Memory.CreatedAt.Equal(b.Memory.CreatedAt) {
+			return a.Memory.CreatedAt.After(b.Memory.CreatedAt)
+		}
+		return a.Memory.ID < b.Memory.ID
+	})
 }
 
 func stringPtr(s string) *string {
@@ -381,67 +1495,3 @@ func stringPtr(s string) *string {
 	}
 	return &s
 }
-
-func toLower(s string) string {
-	// Simple ASCII lowercase
-	result := make([]byte, len(s))
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= 'A' && c <= 'Z' {
-			c += 'a' - 'A'
-		}
-		result[i] = c
-	}
-	return string(result)
-}
-
-func contains(haystack, needle string) bool {
-	if len(needle) > len(haystack) {
-		return false
-	}
-	for i := 0; i <= len(haystack)-len(needle); i++ {
-		if haystack[i:i+len(needle)] == needle {
-			return true
-		}
-	}
-	return false
-}
-
-func formatDuration(d time.Duration) string {
-	if d < time.Minute {
-		return "just now"
-	}
-	if d < time.Hour {
-		mins := int(d.Minutes())
-		if mins == 1 {
-			return "1 minute ago"
-		}
-		return fmt.Sprintf("%d minutes ago", mins)
-	}
-	if d < 24*time.Hour {
-		hours := int(d.Hours())
-		if hours == 1 {
-			return "1 hour ago"
-		}
-		return fmt.Sprintf("%d hours ago", hours)
-	}
-	days := int(d.Hours() / 24)
-	if days == 1 {
-		return "1 day ago"
-	}
-	if days < 7 {
-		return fmt.Sprintf("%d days ago", days)
-	}
-	weeks := days / 7
-	if weeks == 1 {
-		return "1 week ago"
-	}
-	if weeks < 4 {
-		return fmt.Sprintf("%d weeks ago", weeks)
-	}
-	months := days / 30
-	if months == 1 {
-		return "1 month ago"
-	}
-	return fmt.Sprintf("%d months ago", months)
-}
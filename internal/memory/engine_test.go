@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// testEnv is an engine over a fresh SQLite database, with the local vector
+// store and the hash embedder, and a project to save memories in
+type testEnv struct {
+	engine    *Engine
+	store     *storage.SQLiteStore
+	vectors   *storage.LocalVectorStore
+	projectID string
+}
+
+func newTestEnv(t testing.TB) *testEnv {
+	t.Helper()
+
+	store, err := storage.NewSQLiteStore(filepath.Join(t.TempDir(), "alaala.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	vectors := storage.NewLocalVectorStore(store)
+	engine := NewEngine(store, vectors, embeddings.NewHashClient())
+	engine.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	project, err := engine.GetOrCreateProject(context.Background(), "test", t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+
+	return &testEnv{engine: engine, store: store, vectors: vectors, projectID: project.ID}
+}
+
+// save creates a memory in the test project
+func (env *testEnv) save(t testing.TB, mem *Memory) *Memory {
+	t.Helper()
+	if mem.ProjectID == "" {
+		mem.ProjectID = env.projectID
+	}
+	if _, err := env.engine.CreateMemory(context.Background(), mem); err != nil {
+		t.Fatalf("failed to save memory %q: %v", mem.Content, err)
+	}
+	return mem
+}
+
+// countMemories counts the test project's memories in SQLite
+func (env *testEnv) countMemories(t testing.TB) int {
+	t.Helper()
+	count, err := env.engine.CountMemories(context.Background(), env.projectID, 0)
+	if err != nil {
+		t.Fatalf("failed to count memories: %v", err)
+	}
+	return count
+}
+
+func TestCreateMemoryStoresCreatedAtInVector(t *testing.T) {
+	env := newTestEnv(t)
+	before := time.Now()
+	mem := env.save(t, &Memory{Content: "timestamps agree", Importance: 0.5})
+
+	vectors, err := env.vectors.All(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read vectors: %v", err)
+	}
+	if len(vectors) != 1 {
+		t.Fatalf("got %d vectors, want 1", len(vectors))
+	}
+
+	// Metadata comes back from JSON, so numbers are float64
+	createdAt, ok := vectors[0].Metadata["createdAt"].(float64)
+	if !ok {
+		t.Fatalf("createdAt metadata = %#v, want a Unix time", vectors[0].Metadata["createdAt"])
+	}
+	if diff := int64(createdAt) - before.Unix(); diff < -1 || diff > 1 {
+		t.Errorf("createdAt metadata = %d, want within a second of %d", int64(createdAt), before.Unix())
+	}
+	if int64(createdAt) != mem.CreatedAt.Unix() {
+		t.Errorf("createdAt metadata = %d, SQLite has %d", int64(createdAt), mem.CreatedAt.Unix())
+	}
+}
+
+func TestSearchExpandsOnlyWithinScope(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	other, err := env.engine.GetOrCreateProject(ctx, "other", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hit := env.save(t, &Memory{Content: "Deploys go through the release pipeline", Importance: 0.5})
+	related := env.save(t, &Memory{Content: "The cache is warmed on startup", Importance: 0.5})
+	unimportant := env.save(t, &Memory{Content: "The cache was slow once", Importance: 0.1})
+	elsewhere := env.save(t, &Memory{ProjectID: other.ID, Content: "Staging shares the production queue", Importance: 0.9})
+	for _, mem := range []*Memory{related, unimportant, elsewhere} {
+		if err := env.engine.CreateRelationship(ctx, hit.ID, mem.ID, RelationshipTypeRelatedTo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	depth := 1
+	results, err := env.engine.SearchMemories(ctx, &SearchQuery{
+		Query:             "pipeline",
+		ProjectID:         env.projectID,
+		Mode:              SearchModeKeyword,
+		MinImportance:     0.3,
+		IncludeGraphDepth: &depth,
+		Limit:             5,
+	})
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+
+	got := resultIDs(results)
+	sort.Strings(got)
+	want := []string{hit.ID, related.ID}
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		for _, result := range results {
+			t.Logf("found %q", result.Memory.Content)
+		}
+		t.Errorf("found %v, want the hit and its related memory of the same project %v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ExpiryPolicy says how long short-lived memories are kept. A zero age
+// keeps that kind forever.
+type ExpiryPolicy struct {
+	TemporaryAge time.Duration // Since the memory was created
+	SessionAge   time.Duration // Since the memory's session ended
+}
+
+// ExpiryReport describes what PruneExpiredMemories deleted
+type ExpiryReport struct {
+	Temporary int // Temporary memories past their age
+	Session   int // Session memories whose session ended long enough ago
+	DeleteReport
+}
+
+// SetExpiryPolicy sets when PruneExpiredMemories deletes temporary and
+// session memories. Without a policy nothing expires.
+func (e *Engine) SetExpiryPolicy(policy ExpiryPolicy) {
+	e.expiry = policy
+}
+
+// ExpiryPolicy returns the policy set with SetExpiryPolicy
+func (e *Engine) ExpiryPolicy() ExpiryPolicy {
+	return e.expiry
+}
+
+// PruneExpiredMemories deletes a project's temporary and session memories
+// that are past the expiry policy, from both SQLite and the vector store
+func (e *Engine) PruneExpiredMemories(ctx context.Context, projectID string) (*ExpiryReport, error) {
+	now := time.Now()
+	var temporaryBefore, sessionEndedBefore time.Time
+	if e.expiry.TemporaryAge > 0 {
+		temporaryBefore = now.Add(-e.expiry.TemporaryAge)
+	}
+	if e.expiry.SessionAge > 0 {
+		sessionEndedBefore = now.Add(-e.expiry.SessionAge)
+	}
+
+	report := &ExpiryReport{}
+	if temporaryBefore.IsZero() && sessionEndedBefore.IsZero() {
+		return report, nil
+	}
+
+	temporary, session, err := e.sqlStore.ExpiredMemories(ctx, projectID, temporaryBefore, sessionEndedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired memories: %w", err)
+	}
+	report.Temporary = len(temporary)
+	report.Session = len(session)
+
+	deleted, err := e.DeleteMemories(ctx, append(temporary, session...))
+	if err != nil {
+		return nil, err
+	}
+	report.DeleteReport = *deleted
+
+	if report.SQLiteDeleted > 0 {
+		e.logger.Info("pruned expired memories", "project_id", projectID,
+			"temporary", report.Temporary, "session", report.Session, "vectors", report.VectorDeleted)
+	}
+	return report, nil
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+)
+
+// ImportanceAuto asks CreateMemory to estimate a memory's importance. It is
+// replaced by the method that produced the estimate.
+const ImportanceAuto = "auto"
+
+// Methods recorded for how a memory's importance was chosen
+const (
+	ImportanceMethodManual    = "manual"    // Given by the caller
+	ImportanceMethodDefault   = "default"   // Nobody chose; the 0.5 default
+	ImportanceMethodCurated   = "curated"   // Rated by the AI during session curation
+	ImportanceMethodHeuristic = "heuristic" // Estimated from content and existing memories
+	ImportanceMethodAI        = "ai"        // Estimated by a scoring call to the AI provider
+)
+
+// ImportanceScorer is implemented by AI clients that can rate a memory's
+// importance with a short call
+type ImportanceScorer interface {
+	ScoreImportance(content, contextType string) (float64, error)
+}
+
+// contextImportancePriors is the starting importance estimate per context type
+var contextImportancePriors = map[ContextType]float64{
+	ContextTypeDecision:                0.7,
+	ContextTypeArchitecture:            0.65,
+	ContextTypeBreakthrough:            0.65,
+	ContextTypeUnresolved:              0.6,
+	ContextTypePreference:              0.55,
+	ContextTypeMilestone:               0.5,
+	ContextTypeTechnicalImplementation: 0.5,
+	ContextTypeRelationship:            0.45,
+}
+
+// defaultImportancePrior is the starting estimate without a context type
+const defaultImportancePrior = 0.45
+
+// contextImportancePrior returns the starting importance estimate for a
+// built-in or custom context type
+func contextImportancePrior(t ContextType) float64 {
+	if prior, ok := contextImportancePriors[t]; ok {
+		return prior
+	}
+	if custom, ok := customContextType(t); ok && custom.Importance > 0 {
+		return custom.Importance
+	}
+	return defaultImportancePrior
+}
+
+// importanceKeywords mark decisions, constraints and gotchas
+var importanceKeywords = []string{
+	"decided", "decision", "must", "never", "always", "gotcha", "careful",
+	"important", "breaking", "workaround", "avoid", "don't", "do not",
+	"required", "security", "root cause", "instead of",
+}
+
+// Neighbors at least this similar to a memory of at least
+// importantNeighborImportance raise the estimate
+const (
+	importantNeighborSimilarity = 0.8
+	importantNeighborImportance = 0.7
+)
+
+// SetImportanceScorer lets importance estimates use an AI scoring call.
+// While the AI spend recorded today is below dailyBudget (USD), estimates
+// come from the scorer; otherwise, or if the call fails, the heuristic is
+// used. A dailyBudget of 0 means no limit.
+func (e *Engine) SetImportanceScorer(scorer ImportanceScorer, dailyBudget float64) {
+	e.importanceScorer = scorer
+	e.importanceBudget = dailyBudget
+}
+
+// resolveImportance replaces an "auto" importance with an estimate and
+// records how it was chosen. embedding is the memory's own embedding.
+func (e *Engine) resolveImportance(ctx context.Context, mem *Memory, embedding []float32) {
+	if mem.ImportanceMethod != ImportanceAuto {
+		return
+	}
+
+	if e.importanceScorer != nil && e.withinImportanceBudget(ctx) {
+		score, err := e.importanceScorer.ScoreImportance(mem.Content, string(mem.ContextType))
+		if err == nil {
+			mem.Importance = roundImportance(score)
+			mem.ImportanceMethod = ImportanceMethodAI
+			return
+		}
+		e.logger.Warn("AI importance scoring failed, using heuristic", "error", err)
+		NoteDegradation(ctx, DegradedImportanceAI)
+	}
+
+	mem.Importance = e.heuristicImportance(ctx, mem, embedding)
+	mem.ImportanceMethod = ImportanceMethodHeuristic
+}
+
+// withinImportanceBudget reports whether today's AI spend leaves room for
+// another scoring call
+func (e *Engine) withinImportanceBudget(ctx context.Context) bool {
+	if e.importanceBudget <= 0 {
+		return true
+	}
+
+	spent, err := e.AISpendToday(ctx)
+	if err != nil {
+		e.logger.Warn("failed to read AI usage for importance budget", "error", err)
+		return false
+	}
+	return spent < e.importanceBudget
+}
+
+// AISpendToday returns the AI spend (USD) recorded in the usage ledger since
+// local midnight
+func (e *Engine) AISpendToday(ctx context.Context) (float64, error) {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	summaries, err := e.sqlStore.UsageReport(ctx, midnight)
+	if err != nil {
+		return 0, err
+	}
+
+	var spent float64
+	for _, s := range summaries {
+		spent += s.Cost
+	}
+	return spent, nil
+}
+
+// heuristicImportance estimates importance from the context type, decision
+// and gotcha keywords, content length and similarity to existing important
+// memories. It makes no AI calls, so the same content, embedding and stored
+// memories always produce the same estimate.
+func (e *Engine) heuristicImportance(ctx context.Context, mem *Memory, embedding []float32) float64 {
+	score := contextImportancePrior(mem.ContextType)
+
+	content := strings.ToLower(mem.Content)
+	matches := 0
+	for _, keyword := range importanceKeywords {
+		if strings.Contains(content, keyword) {
+			matches++
+		}
+	}
+	if matches > 3 {
+		matches = 3
+	}
+	score += 0.05 * float64(matches)
+
+	switch length := len([]rune(strings.TrimSpace(mem.Content))); {
+	case length < 40:
+		score -= 0.1
+	case length > 200:
+		score += 0.05
+	}
+
+	if mem.ActionRequired {
+		score += 0.05
+	}
+
+	if e.nearImportantMemory(ctx, mem.ProjectID, embedding) {
+		score += 0.1
+	}
+
+	return roundImportance(math.Max(0.1, math.Min(0.95, score)))
+}
+
+// nearImportantMemory reports whether the project already has an important
+// memory very similar to embedding
+func (e *Engine) nearImportantMemory(ctx context.Context, projectID string, embedding []float32) bool {
+	if embedding == nil || projectID == "" {
+		return false
+	}
+
+	results, err := e.vectorStore.Search(ctx, embedding, 1, map[string]interface{}{
+		"project_id":     projectID,
+		"importance_gte": importantNeighborImportance,
+	})
+	if err != nil {
+		e.logger.Debug("importance neighbor search failed", "error", err)
+		return false
+	}
+	return len(results) > 0 && e.similarity(results[0].Distance) >= importantNeighborSimilarity
+}
+
+// roundImportance rounds to two decimals so estimates read cleanly
+func roundImportance(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"strings"
+)
+
+// keywordResults scores the memories containing every word of the query,
+// in full-text order: verbatim matches first, then more important ones.
+// They have no similarity, so they rank on importance and the boosts; a
+// verbatim match earns the keyword boost.
+func (e *Engine) keywordResults(ctx context.Context, query *SearchQuery, projectIDs map[string]bool, limit int) []*SearchResult {
+	// A workspace search looks everywhere and keeps the workspace's projects
+	projectID, fetch := query.ProjectID, limit
+	if projectIDs != nil {
+		projectID, fetch = "", maxSearchCandidates
+	}
+
+	sqlMemories, err := e.sqlStore.SearchContentLike(ctx, projectID, query.Query, fetch, query.IncludeArchived)
+	if err != nil {
+		e.logger.Warn("keyword search failed", "error", err)
+		NoteDegradation(ctx, DegradedKeywordSearch)
+		return nil
+	}
+
+	var results []*SearchResult
+	for _, sqlMem := range sqlMemories {
+		mem := e.sqlMemoryToMemory(sqlMem)
+		if mem.Importance < query.MinImportance || !matchesFilters(mem, query) {
+			continue
+		}
+		if projectIDs != nil && !projectIDs[mem.ProjectID] {
+			continue
+		}
+
+		matchedTrigger := e.checkTriggerMatch(query.Query, mem.TriggerPhrases)
+		keywordMatched := containsFold(mem.Content, query.Query)
+		results = append(results, &SearchResult{
+			Memory:         mem,
+			RelevanceScore: e.calculateRelevanceScore(mem, 0, matchedTrigger != "", keywordMatched),
+			TriggerMatched: matchedTrigger != "",
+			MatchedTrigger: matchedTrigger,
+			KeywordMatched: keywordMatched,
+		})
+	}
+	return results
+}
+
+// rrfK dampens the weight of the top ranks in reciprocal rank fusion; 60 is
+// the value from the original paper and common in search engines
+const rrfK = 60
+
+// fuseResults merges vector hits and full-text matches, each in rank order,
+// by reciprocal rank fusion: a memory scores 1/(rrfK + rank) for each
+// ranking it appears in. A memory's relevance is scored on the better of
+// its similarity and the fused score, so a memory both rankings agree on
+// gains on one that only embeds close to the query, while a near-exact
+// vector match keeps the similarity it earned.
+func (e *Engine) fuseResults(vectorResults, keywordResults []*SearchResult) []*SearchResult {
+	fused := make(map[string]float64, len(vectorResults)+len(keywordResults))
+	var results []*SearchResult
+	for _, ranking := range [][]*SearchResult{vectorResults, keywordResults} {
+		for rank, result := range ranking {
+			id := result.Memory.ID
+			if _, ok := fused[id]; !ok {
+				results = append(results, result)
+			}
+			fused[id] += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	for _, result := range results {
+		result.FusionScore = fused[result.Memory.ID] * (rrfK + 1) / 2
+		similarity := math.Max(result.SimilarityScore, result.FusionScore)
+		result.RelevanceScore = e.calculateRelevanceScore(result.Memory, similarity, result.TriggerMatched, result.KeywordMatched)
+	}
+	return results
+}
+
+// containsFold reports whether s contains substr, ignoring case and the
+// whitespace around substr
+func containsFold(s, substr string) bool {
+	substr = strings.TrimSpace(substr)
+	return substr != "" && strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHybridSearchKeepsCloseMatchAboveImportantOne(t *testing.T) {
+	env := newTestEnv(t)
+	near := env.save(t, &Memory{
+		Content:    "the connection pool size is set in database yaml",
+		Importance: 0.3,
+	})
+	weak := env.save(t, &Memory{
+		Content:    "connection retries back off exponentially after timeouts in the client",
+		Importance: 1,
+	})
+
+	results, err := env.engine.SearchMemories(context.Background(), &SearchQuery{
+		Query:     "the connection pool size is set in database config",
+		ProjectID: env.projectID,
+		Mode:      SearchModeHybrid,
+		Limit:     2,
+	})
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	first, second := results[0], results[1]
+	if first.Memory.ID != near.ID || second.Memory.ID != weak.ID {
+		t.Fatalf("ranked %q (%.3f) above %q (%.3f), want the close match first",
+			first.Memory.Content, first.RelevanceScore, second.Memory.Content, second.RelevanceScore)
+	}
+	if first.SimilarityScore <= second.SimilarityScore {
+		t.Errorf("close match similarity = %.3f, want above the weak match's %.3f",
+			first.SimilarityScore, second.SimilarityScore)
+	}
+
+	// The close match's relevance comes from its similarity, which is
+	// well above its rank-only fusion score
+	if first.FusionScore >= first.SimilarityScore {
+		t.Errorf("close match fusion score = %.3f, want below its similarity %.3f",
+			first.FusionScore, first.SimilarityScore)
+	}
+	want := env.engine.calculateRelevanceScore(first.Memory, first.SimilarityScore, first.TriggerMatched, first.KeywordMatched)
+	if first.RelevanceScore != want {
+		t.Errorf("close match relevance = %.3f, want %.3f from its similarity", first.RelevanceScore, want)
+	}
+}
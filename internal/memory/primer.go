@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// defaultPrimerLimit is how many memories each primer section lists unless
+// SetPrimerLimit says otherwise
+const defaultPrimerLimit = 5
+
+// primerPoolFactor sets how many of the most important persistent memories,
+// as a multiple of the primer limit, are weighed against each other when
+// custom context types have primer weights
+const primerPoolFactor = 4
+
+// addPrimerMemories fills the primer's key memories and unresolved items
+// straight from SQLite. Key memories alternate between the project's most
+// important persistent memories and the newest memories of the last session,
+// so neither crowds the other out; action-required memories are listed as
+// unresolved items instead. Custom context types with a primer weight rank
+// their memories' importance up or down.
+func (e *Engine) addPrimerMemories(ctx context.Context, primer *SessionPrimer, projectID string, lastSession *storage.Session) error {
+	limit := e.primerLimit
+	actionRequired := true
+
+	unresolved, _, err := e.sqlStore.ListMemories(ctx, storage.ListOptions{
+		ProjectID:      projectID,
+		ActionRequired: &actionRequired,
+		Limit:          limit,
+		OrderBy:        "importance",
+	})
+	if err != nil {
+		return err
+	}
+
+	weighted := hasPrimerWeights()
+	pool := limit + len(unresolved)
+	if weighted {
+		pool *= primerPoolFactor
+	}
+	important, _, err := e.sqlStore.ListMemories(ctx, storage.ListOptions{
+		ProjectID:         projectID,
+		TemporalRelevance: string(TemporalRelevancePersistent),
+		Limit:             pool,
+		OrderBy:           "importance",
+	})
+	if err != nil {
+		return err
+	}
+	if weighted {
+		sort.SliceStable(important, func(i, j int) bool {
+			return weightedImportance(important[i]) > weightedImportance(important[j])
+		})
+	}
+
+	var recent []*storage.Memory
+	if lastSession != nil {
+		recent, _, err = e.sqlStore.ListMemories(ctx, storage.ListOptions{
+			ProjectID: projectID,
+			SessionID: lastSession.ID,
+			Limit:     limit + len(unresolved),
+			OrderBy:   "created_at",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]bool, len(unresolved))
+	for _, sqlMem := range unresolved {
+		seen[sqlMem.ID] = true
+		primer.UnresolvedItems = append(primer.UnresolvedItems, e.sqlMemoryToMemory(sqlMem))
+	}
+
+	add := func(sqlMem *storage.Memory) {
+		if len(primer.TopMemories) < limit && !seen[sqlMem.ID] {
+			seen[sqlMem.ID] = true
+			primer.TopMemories = append(primer.TopMemories, e.sqlMemoryToMemory(sqlMem))
+		}
+	}
+	for i := 0; i < len(important) || i < len(recent); i++ {
+		if i < len(important) {
+			add(important[i])
+		}
+		if i < len(recent) {
+			add(recent[i])
+		}
+	}
+
+	return nil
+}
+
+// hasPrimerWeights reports whether a custom context type changes how
+// memories are picked for the primer
+func hasPrimerWeights() bool {
+	for _, custom := range CustomContextTypes() {
+		if custom.PrimerWeight > 0 && custom.PrimerWeight != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// weightedImportance is a memory's importance times the primer weight of
+// its context type
+func weightedImportance(mem *storage.Memory) float64 {
+	if mem.ContextType == nil {
+		return mem.Importance
+	}
+	if custom, ok := customContextType(ContextType(*mem.ContextType)); ok && custom.PrimerWeight > 0 {
+		return mem.Importance * custom.PrimerWeight
+	}
+	return mem.Importance
+}
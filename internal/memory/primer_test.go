@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// searchlessVectors is a vector store that fails every search, to show
+// that the primer is built without one
+type searchlessVectors struct {
+	*storage.LocalVectorStore
+	searches int
+}
+
+func (s *searchlessVectors) Search(ctx context.Context, embedding []float32, limit int, filters map[string]interface{}) ([]storage.VectorSearchResult, error) {
+	s.searches++
+	return nil, errors.New("the primer must not search vectors")
+}
+
+func primerIDs(mems []*Memory) []string {
+	ids := make([]string, len(mems))
+	for i, mem := range mems {
+		ids[i] = mem.ID
+	}
+	return ids
+}
+
+func TestSessionPrimerComesFromSQLite(t *testing.T) {
+	env := newTestEnv(t)
+	vectors := &searchlessVectors{LocalVectorStore: env.vectors}
+	env.engine.vectorStore = vectors
+	env.engine.SetPrimerLimit(4)
+	ctx := context.Background()
+
+	session, err := env.engine.CreateSession(ctx, env.projectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-time.Hour)
+	persistent := func(id string, importance float64) *Memory {
+		return &Memory{ID: id, Content: id, Importance: importance, TemporalRelevance: TemporalRelevancePersistent, CreatedAt: start}
+	}
+	inSession := func(id string, minute int) *Memory {
+		return &Memory{ID: id, SessionID: session.ID, Content: id, Importance: 0.2,
+			TemporalRelevance: TemporalRelevanceSession, CreatedAt: start.Add(time.Duration(minute) * time.Minute)}
+	}
+
+	for _, mem := range []*Memory{
+		persistent("important-1", 0.95),
+		persistent("important-2", 0.9),
+		persistent("important-3", 0.85),
+		persistent("minor", 0.1),
+		inSession("session-old", 1),
+		inSession("session-new", 2),
+		{ID: "todo", Content: "todo", Importance: 0.6, ActionRequired: true, TemporalRelevance: TemporalRelevancePersistent, CreatedAt: start},
+	} {
+		env.save(t, mem)
+	}
+
+	if err := env.engine.sqlStore.SetSessionSummary(ctx, session.ID, "Worked on pagination"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.engine.EndSession(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another project's memories never appear
+	other, err := env.engine.GetOrCreateProject(ctx, "other", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.save(t, &Memory{ID: "other-project", ProjectID: other.ID, Content: "elsewhere", Importance: 1, TemporalRelevance: TemporalRelevancePersistent})
+
+	primer, err := env.engine.GetSessionPrimer(ctx, env.projectID)
+	if err != nil {
+		t.Fatalf("GetSessionPrimer: %v", err)
+	}
+	if vectors.searches != 0 {
+		t.Errorf("primer made %d vector searches, want none", vectors.searches)
+	}
+
+	if got := primerIDs(primer.UnresolvedItems); len(got) != 1 || got[0] != "todo" {
+		t.Errorf("unresolved items = %v, want [todo]", got)
+	}
+
+	// Important and last-session memories alternate, up to the limit
+	want := []string{"important-1", "session-new", "important-2", "session-old"}
+	got := primerIDs(primer.TopMemories)
+	if len(got) != len(want) {
+		t.Fatalf("top memories = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("top memories = %v, want %v", got, want)
+		}
+	}
+
+	if primer.LastSessionSummary != "Worked on pagination" || primer.LastSessionDate == nil {
+		t.Errorf("last session = %q at %v, want the ended session's summary", primer.LastSessionSummary, primer.LastSessionDate)
+	}
+}
+
+func TestSessionPrimerEmptyProject(t *testing.T) {
+	env := newTestEnv(t)
+
+	primer, err := env.engine.GetSessionPrimer(context.Background(), env.projectID)
+	if err != nil {
+		t.Fatalf("GetSessionPrimer: %v", err)
+	}
+	if len(primer.TopMemories) != 0 || len(primer.UnresolvedItems) != 0 || primer.LastSessionDate != nil {
+		t.Errorf("empty project got a primer with content: %+v", primer)
+	}
+	if primer.ProjectName != "test" {
+		t.Errorf("project name = %q, want test", primer.ProjectName)
+	}
+}
+
+func TestSessionPrimerUnknownProject(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.engine.GetSessionPrimer(context.Background(), "no-such-project"); err == nil {
+		t.Error("got a primer for a project that does not exist")
+	}
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// Query embedding cache defaults; clients tend to repeat a search within
+// seconds, so a small cache goes a long way
+const (
+	DefaultQueryCacheSize = 256
+	DefaultQueryCacheTTL  = 10 * time.Minute
+)
+
+// QueryCacheStats counts lookups in the query embedding cache
+type QueryCacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+	Size    int    `json:"size"` // Capacity; 0 when the cache is disabled
+}
+
+// queryCacheKey identifies a query by the embedder that embedded it and a
+// hash of its text, so that switching models never returns a vector from
+// another vector space
+type queryCacheKey struct {
+	embedderID string
+	text       [sha256.Size]byte
+}
+
+type queryCacheEntry struct {
+	key       queryCacheKey
+	embedding []float32
+	at        time.Time
+}
+
+// queryCache is a least-recently-used cache of query embeddings with a
+// time to live, safe for concurrent use
+type queryCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // Most recently used first
+	entries map[queryCacheKey]*list.Element
+	hits    uint64
+	misses  uint64
+}
+
+func newQueryCache(size int, ttl time.Duration) *queryCache {
+	return &queryCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[queryCacheKey]*list.Element),
+	}
+}
+
+// get returns a copy of the cached embedding for key, if it has not expired
+func (c *queryCache) get(key queryCacheKey) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && c.ttl > 0 && time.Since(elem.Value.(*queryCacheEntry).at) > c.ttl {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return append([]float32(nil), elem.Value.(*queryCacheEntry).embedding...), true
+}
+
+// put caches a copy of embedding, evicting the least recently used entry
+// when full
+func (c *queryCache) put(key queryCacheKey, embedding []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &queryCacheEntry{key: key, embedding: append([]float32(nil), embedding...), at: time.Now()}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+func (c *queryCache) stats() QueryCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return QueryCacheStats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len(), Size: c.size}
+}
+
+// SetQueryCache sets how many query embeddings are cached and for how long;
+// a zero TTL keeps them until evicted. A size of 0 or less disables the
+// cache. Counters start over.
+func (e *Engine) SetQueryCache(size int, ttl time.Duration) {
+	if size <= 0 {
+		e.queryCache = nil
+		return
+	}
+	e.queryCache = newQueryCache(size, ttl)
+}
+
+// QueryCacheStats returns the query embedding cache's counters
+func (e *Engine) QueryCacheStats() QueryCacheStats {
+	if e.queryCache == nil {
+		return QueryCacheStats{}
+	}
+	return e.queryCache.stats()
+}
+
+// embedQuery embeds a search query, reusing the embedding of an identical
+// query from the cache. Embedders that cannot describe themselves bypass
+// the cache, as a model change could not be told apart.
+func (e *Engine) embedQuery(ctx context.Context, text string) ([]float32, error) {
+	cache := e.queryCache
+	embedderID := e.EmbedderID()
+	if cache == nil || embedderID == "" {
+		return e.embed(ctx, text)
+	}
+
+	key := queryCacheKey{embedderID: embedderID, text: sha256.Sum256([]byte(text))}
+	if embedding, ok := cache.get(key); ok {
+		return embedding, nil
+	}
+
+	embedding, err := e.embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	// An embedder that fell back to another model meanwhile embedded the
+	// query in a different space than the key says
+	if e.EmbedderID() == embedderID {
+		cache.put(key, embedding)
+	}
+	return embedding, nil
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueryCacheConcurrentUse(t *testing.T) {
+	cache := newQueryCache(8, time.Minute)
+	key := func(i int) queryCacheKey {
+		return queryCacheKey{embedderID: "hash/test/3", text: sha256.Sum256([]byte(fmt.Sprintf("query %d", i%20)))}
+	}
+
+	const goroutines, rounds = 50, 100
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				k := key(g + i)
+				if vector, ok := cache.get(k); ok {
+					// A cached vector is the caller's own copy
+					vector[0] = -1
+					continue
+				}
+				cache.put(k, []float32{float32((g + i) % 20), 1, 2})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	stats := cache.stats()
+	if stats.Hits+stats.Misses != goroutines*rounds {
+		t.Errorf("%d hits and %d misses, want %d lookups", stats.Hits, stats.Misses, goroutines*rounds)
+	}
+	if stats.Entries > 8 {
+		t.Errorf("%d entries, want at most 8", stats.Entries)
+	}
+	for i := 0; i < 20; i++ {
+		if vector, ok := cache.get(key(i)); ok && vector[0] != float32(i) {
+			t.Errorf("cached vector for query %d = %v, changed by a caller", i, vector)
+		}
+	}
+}
+
+func TestSearchConcurrentUse(t *testing.T) {
+	env := newTestEnv(t)
+	for _, content := range []string{
+		"Deploys go through the release pipeline",
+		"The cache is warmed on startup",
+		"Use cursor pagination for list endpoints",
+	} {
+		env.save(t, &Memory{Content: content, Importance: 0.5})
+	}
+
+	queries := []string{"how do deploys work", "cache warming", "pagination"}
+	errs := make(chan error, 50)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results, err := env.engine.SearchMemories(context.Background(), &SearchQuery{
+				Query:     queries[i%len(queries)],
+				ProjectID: env.projectID,
+				Limit:     3,
+			})
+			if err != nil || len(results) == 0 {
+				errs <- fmt.Errorf("search %q: %d results, %v", queries[i%len(queries)], len(results), err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if stats := env.engine.QueryCacheStats(); stats.Hits+stats.Misses != 50 {
+		t.Errorf("%d hits and %d misses, want 50 lookups", stats.Hits, stats.Misses)
+	}
+}
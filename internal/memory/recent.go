@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+const (
+	// recentWriteLimit caps how many new memories are kept for searches
+	// until the vector store returns them
+	recentWriteLimit = 200
+
+	// recentWriteTTL is how long a new memory is kept at most; by then the
+	// vector store has long indexed it
+	recentWriteTTL = time.Minute
+)
+
+// recentWrite is a memory whose vector was just stored, with its embedding
+type recentWrite struct {
+	id        string
+	embedding []float32
+	at        time.Time
+}
+
+// recentWrites remembers the vectors this engine stored last, so that a
+// search right after a save finds the new memory even if the vector store
+// has not made it searchable yet
+type recentWrites struct {
+	mu     sync.Mutex
+	writes []recentWrite
+}
+
+// add records newly stored vectors, dropping the oldest beyond the limit
+func (r *recentWrites) add(ids []string, embeddings [][]float32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, id := range ids {
+		r.writes = append(r.writes, recentWrite{id: id, embedding: embeddings[i], at: now})
+	}
+	if excess := len(r.writes) - recentWriteLimit; excess > 0 {
+		r.writes = append(r.writes[:0], r.writes[excess:]...)
+	}
+}
+
+// pending returns the writes the vector store did not return among hits,
+// forgetting those it did, as they are searchable now, and expired ones
+func (r *recentWrites) pending(hits []storage.VectorSearchResult) []recentWrite {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.writes) == 0 {
+		return nil
+	}
+	found := make(map[string]bool, len(hits))
+	for _, hit := range hits {
+		found[hit.ID] = true
+	}
+
+	cutoff := time.Now().Add(-recentWriteTTL)
+	kept := r.writes[:0]
+	var pending []recentWrite
+	for _, write := range r.writes {
+		if found[write.id] || write.at.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, write)
+		pending = append(pending, write)
+	}
+	r.writes = kept
+	return pending
+}
+
+// addRecentHits scores memories saved moments ago that the vector store
+// did not return and adds those matching the query to results. Their
+// similarity is computed from the cached embedding; stores with another
+// metric than cosine get cosine similarity for them.
+func (e *Engine) addRecentHits(ctx context.Context, query *SearchQuery, queryEmbedding []float32, results []*SearchResult, hits []storage.VectorSearchResult, projectIDs map[string]bool, minSimilarity float64) []*SearchResult {
+	pending := e.recent.pending(hits)
+	if len(pending) == 0 {
+		return results
+	}
+
+	var recent []storage.VectorSearchResult
+	for _, write := range pending {
+		if len(write.embedding) != len(queryEmbedding) {
+			continue
+		}
+		distance := storage.CosineDistance(queryEmbedding, write.embedding)
+		if minSimilarity > 0 && 1-distance < minSimilarity {
+			continue
+		}
+		recent = append(recent, storage.VectorSearchResult{ID: write.id, Distance: distance})
+	}
+
+	added := 0
+	for _, result := range e.scoreHits(ctx, query, recent, projectIDs, storage.DistanceCosine) {
+		mem := result.Memory
+		if mem.Importance < query.MinImportance {
+			continue
+		}
+		// The vector store filters by project; recent writes are not
+		if projectIDs == nil && mem.ProjectID != query.ProjectID {
+			continue
+		}
+		results = append(results, result)
+		added++
+	}
+	if added > 0 {
+		e.logger.Debug("added memories not yet searchable in the vector store", "count", added)
+	}
+	return results
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// laggingVectors is a local vector store whose searches do not see new
+// vectors until index is called, like Weaviate before it has indexed them
+type laggingVectors struct {
+	*storage.LocalVectorStore
+
+	mu        sync.Mutex
+	unindexed map[string]bool
+}
+
+func newLaggingVectors(env *testEnv) *laggingVectors {
+	lagging := &laggingVectors{LocalVectorStore: env.vectors, unindexed: make(map[string]bool)}
+	env.engine.vectorStore = lagging
+	return lagging
+}
+
+func (l *laggingVectors) Store(ctx context.Context, id, content string, embedding []float32, metadata map[string]interface{}) error {
+	if err := l.LocalVectorStore.Store(ctx, id, content, embedding, metadata); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.unindexed[id] = true
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *laggingVectors) StoreBatch(ctx context.Context, vectors []storage.Vector) error {
+	if err := l.LocalVectorStore.StoreBatch(ctx, vectors); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	for _, v := range vectors {
+		l.unindexed[v.ID] = true
+	}
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *laggingVectors) Search(ctx context.Context, embedding []float32, limit int, filterMap map[string]interface{}) ([]storage.VectorSearchResult, error) {
+	hits, err := l.LocalVectorStore.Search(ctx, embedding, limit, filterMap)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	visible := hits[:0]
+	for _, hit := range hits {
+		if !l.unindexed[hit.ID] {
+			visible = append(visible, hit)
+		}
+	}
+	return visible, nil
+}
+
+// index makes every stored vector searchable
+func (l *laggingVectors) index() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.unindexed = make(map[string]bool)
+}
+
+// countResults counts the results for a memory
+func countResults(results []*SearchResult, id string) int {
+	n := 0
+	for _, result := range results {
+		if result.Memory.ID == id {
+			n++
+		}
+	}
+	return n
+}
+
+// semanticSearch searches the test project by embedding alone, so that
+// full-text matches cannot stand in for vector hits
+func (env *testEnv) semanticSearch(t testing.TB, query string) []*SearchResult {
+	t.Helper()
+	results, err := env.engine.SearchMemories(context.Background(), &SearchQuery{
+		Query:     query,
+		ProjectID: env.projectID,
+		Mode:      SearchModeSemantic,
+		Limit:     5,
+	})
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+	return results
+}
+
+func TestSearchFindsMemorySavedMomentsAgo(t *testing.T) {
+	env := newTestEnv(t)
+	lagging := newLaggingVectors(env)
+
+	mem := env.save(t, &Memory{Content: "Deploys go through the release pipeline", Importance: 0.5})
+
+	results := env.semanticSearch(t, "how do deploys work")
+	if n := countResults(results, mem.ID); n != 1 {
+		t.Fatalf("memory saved before the search returned %d times, want once", n)
+	}
+	if results[0].SimilarityScore <= 0 {
+		t.Errorf("similarity = %v, want it computed from the cached embedding", results[0].SimilarityScore)
+	}
+
+	// Once the vector store returns it, it must not be added a second time
+	lagging.index()
+	if n := countResults(env.semanticSearch(t, "how do deploys work"), mem.ID); n != 1 {
+		t.Errorf("indexed memory returned %d times, want once", n)
+	}
+}
+
+func TestSearchFindsBatchSavedMomentsAgo(t *testing.T) {
+	env := newTestEnv(t)
+	newLaggingVectors(env)
+
+	mems := []*Memory{
+		{ProjectID: env.projectID, Content: "Deploys go through the release pipeline", Importance: 0.5},
+		{ProjectID: env.projectID, Content: "The cache is warmed on startup", Importance: 0.5},
+	}
+	if err := env.engine.CreateMemories(context.Background(), mems); err != nil {
+		t.Fatalf("CreateMemories: %v", err)
+	}
+
+	if n := countResults(env.semanticSearch(t, "how do deploys work"), mems[0].ID); n != 1 {
+		t.Errorf("memory saved in a batch before the search returned %d times, want once", n)
+	}
+}
+
+func TestSearchSkipsRecentWritesItShouldNotSee(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	newLaggingVectors(env)
+
+	other, err := env.engine.GetOrCreateProject(ctx, "other", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	elsewhere := env.save(t, &Memory{ProjectID: other.ID, Content: "Deploys go through the release pipeline", Importance: 0.5})
+	deleted := env.save(t, &Memory{Content: "Deploys need a green build", Importance: 0.5})
+	unimportant := env.save(t, &Memory{Content: "Deploys happen on weekdays", Importance: 0.1})
+	if _, err := env.engine.DeleteMemories(ctx, []string{deleted.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := env.engine.SearchMemories(ctx, &SearchQuery{
+		Query:         "how do deploys work",
+		ProjectID:     env.projectID,
+		Mode:          SearchModeSemantic,
+		MinImportance: 0.3,
+		Limit:         5,
+	})
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+	for _, mem := range []*Memory{elsewhere, deleted, unimportant} {
+		if countResults(results, mem.ID) != 0 {
+			t.Errorf("search returned %q, which it should not see", mem.Content)
+		}
+	}
+}
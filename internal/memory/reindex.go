@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// reindexBatchSize bounds how many memories are embedded and stored together
+// when the vector store supports batches
+const reindexBatchSize = 100
+
+// ListStaleEmbeddings returns up to limit memories whose vectors were made
+// by an embedder other than the current one, plus the total number of such
+// memories. Memories saved before embedders were recorded count as stale.
+func (e *Engine) ListStaleEmbeddings(ctx context.Context, projectID string, limit int) ([]StaleEmbedding, int, error) {
+	current := e.EmbedderID()
+	if current == "" {
+		return nil, 0, fmt.Errorf("the embedder does not report its identity")
+	}
+
+	memories, total, err := e.sqlStore.ListStaleEmbeddings(ctx, projectID, current, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list stale embeddings: %w", err)
+	}
+
+	stale := make([]StaleEmbedding, len(memories))
+	for i, m := range memories {
+		stale[i] = StaleEmbedding{
+			ID:         m.ID,
+			ProjectID:  m.ProjectID,
+			Content:    m.Content,
+			EmbedderID: m.EmbedderID,
+		}
+	}
+	return stale, total, nil
+}
+
+// ReindexMemories re-embeds memories with the current embedder and replaces
+// their vectors, in batches when the vector store supports it. A failure on
+// one memory does not stop the others.
+func (e *Engine) ReindexMemories(ctx context.Context, ids []string) *ReindexReport {
+	start := time.Now()
+	report := &ReindexReport{Requested: len(ids), Failed: make(map[string]error)}
+	embedderID := e.EmbedderID()
+
+	if batcher, ok := e.vectorStore.(BatchStorer); ok {
+		for first := 0; first < len(ids); first += reindexBatchSize {
+			last := first + reindexBatchSize
+			if last > len(ids) {
+				last = len(ids)
+			}
+			e.reindexBatch(ctx, batcher, ids[first:last], embedderID, report)
+		}
+		report.Duration = time.Since(start)
+		return report
+	}
+
+	for _, id := range ids {
+		if err := e.reindexMemory(ctx, id, embedderID); err != nil {
+			e.logger.Warn("failed to reindex memory", "id", id, "error", err)
+			report.Failed[id] = err
+			continue
+		}
+		report.Reindexed++
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+func (e *Engine) reindexMemory(ctx context.Context, id, embedderID string) error {
+	mem, err := e.GetMemory(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get memory: %w", err)
+	}
+	if mem == nil {
+		return fmt.Errorf("memory not found: %s", id)
+	}
+
+	embedding, err := e.embed(ctx, mem.Content)
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	// The old vector may be missing entirely; if the store is unreachable
+	// the Store below fails too
+	if err := e.vectorStore.Delete(ctx, mem.ID); err != nil {
+		e.logger.Debug("no old vector removed", "id", mem.ID, "error", err)
+	}
+	if err := e.vectorStore.Store(ctx, mem.ID, mem.Content, embedding, e.vectorMetadata(ctx, mem)); err != nil {
+		return fmt.Errorf("failed to store vector: %w", err)
+	}
+
+	return e.sqlStore.SetEmbedderID(ctx, mem.ID, embedderID)
+}
+
+// reindexBatch re-embeds memories together and replaces their vectors in a
+// single StoreBatch, which overwrites existing vectors. Vectors the store
+// rejects are recorded as failures; the others count as reindexed.
+func (e *Engine) reindexBatch(ctx context.Context, batcher BatchStorer, ids []string, embedderID string, report *ReindexReport) {
+	fail := func(id string, err error) {
+		e.logger.Warn("failed to reindex memory", "id", id, "error", err)
+		report.Failed[id] = err
+	}
+
+	mems := make([]*Memory, 0, len(ids))
+	texts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		mem, err := e.GetMemory(ctx, id)
+		if err != nil {
+			fail(id, fmt.Errorf("failed to get memory: %w", err))
+			continue
+		}
+		if mem == nil {
+			fail(id, fmt.Errorf("memory not found: %s", id))
+			continue
+		}
+		mems = append(mems, mem)
+		texts = append(texts, mem.Content)
+	}
+	if len(mems) == 0 {
+		return
+	}
+
+	embeddings, err := e.embedAll(ctx, texts)
+	if err != nil {
+		for _, mem := range mems {
+			fail(mem.ID, fmt.Errorf("failed to generate embedding: %w", err))
+		}
+		return
+	}
+
+	rejected := make(map[string]string)
+	if err := batcher.StoreBatch(ctx, e.vectors(ctx, mems, embeddings)); err != nil {
+		var partial *storage.PartialBatchError
+		if !errors.As(err, &partial) {
+			for _, mem := range mems {
+				fail(mem.ID, fmt.Errorf("failed to store vector: %w", err))
+			}
+			return
+		}
+		for _, failure := range partial.Failed {
+			rejected[failure.ID] = failure.Reason
+		}
+	}
+
+	for _, mem := range mems {
+		if reason, ok := rejected[mem.ID]; ok {
+			fail(mem.ID, fmt.Errorf("failed to store vector: %s", reason))
+			continue
+		}
+		if err := e.sqlStore.SetEmbedderID(ctx, mem.ID, embedderID); err != nil {
+			fail(mem.ID, err)
+			continue
+		}
+		report.Reindexed++
+	}
+}
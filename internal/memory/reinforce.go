@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// MaxReinforceDelta bounds how much a single ReinforceMemory call changes a
+// memory's importance, so that one enthusiastic confirmation cannot make a
+// memory outrank everything else
+const MaxReinforceDelta = 0.2
+
+// ReinforceMemory nudges a memory's importance by delta, up when it proved
+// useful and down when it did not, clamped to 0-1. The memory also counts
+// as accessed, which exempts it from the decay of unused memories. Delta
+// must be within ±MaxReinforceDelta.
+func (e *Engine) ReinforceMemory(ctx context.Context, id string, delta float64) (*Memory, error) {
+	if math.IsNaN(delta) || math.Abs(delta) > MaxReinforceDelta {
+		return nil, fmt.Errorf("delta must be between %v and %v, got %v", -MaxReinforceDelta, MaxReinforceDelta, delta)
+	}
+
+	mem, err := e.GetMemory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if mem == nil {
+		return nil, fmt.Errorf("memory not found: %s", id)
+	}
+
+	importance := clamp01(mem.Importance + delta)
+	if importance != mem.Importance {
+		if err := e.sqlStore.SetImportance(ctx, id, importance); err != nil {
+			return nil, fmt.Errorf("failed to update importance: %w", err)
+		}
+		// Archived memories have no vector to update
+		if updater, ok := e.vectorStore.(PropertyUpdater); ok && !mem.Archived {
+			if err := updater.UpdateProperties(ctx, id, map[string]interface{}{
+				"importance": importance,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to update importance in vector store: %w", err)
+			}
+		}
+	}
+	if err := e.sqlStore.TouchMemories(ctx, []string{id}); err != nil {
+		e.logger.Warn("failed to record memory access", "id", id, "error", err)
+	}
+
+	return e.GetMemory(ctx, id)
+}
+
+// SetUnusedDecay makes DecayUnusedImportance halve the importance of
+// memories never returned by a search or read every halfLifeDays; 0
+// disables it
+func (e *Engine) SetUnusedDecay(halfLifeDays float64) {
+	e.unusedHalfLife = halfLifeDays
+}
+
+// DecayUnusedImportance lowers the importance of a project's memories that
+// were never returned by a search or read, as set with SetUnusedDecay. It
+// is cheap to run often: each run only decays the time since the last.
+func (e *Engine) DecayUnusedImportance(ctx context.Context, projectID string) (*ImportanceDecayReport, error) {
+	if e.unusedHalfLife <= 0 {
+		return &ImportanceDecayReport{}, nil
+	}
+
+	memories, err := e.sqlStore.UnusedMemories(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unused memories: %w", err)
+	}
+	return e.decayImportance(ctx, projectID, memories, e.unusedHalfLife)
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// VectorRestoreReport counts the vectors written by RestoreVectors
+type VectorRestoreReport struct {
+	Stored  int
+	Removed int
+	Failed  int // Vectors the store rejected; their memories are left stale
+}
+
+// RestoreVectors stores the vectors exported with memories that were just
+// restored to SQLite, instead of embedding the memories again;
+// embeddings[i] belongs to mems[i]. A memory with a nil embedding loses
+// any vector it had, which belonged to the memory it replaced. Memories
+// whose vector cannot be stored are marked stale for `alaala reindex`.
+func (e *Engine) RestoreVectors(ctx context.Context, mems []*storage.Memory, embeddings [][]float32) (*VectorRestoreReport, error) {
+	report := &VectorRestoreReport{}
+
+	var stored []*Memory
+	var vectors [][]float32
+	var removed []string
+	for i, mem := range mems {
+		if embeddings[i] == nil {
+			removed = append(removed, mem.ID)
+			continue
+		}
+		stored = append(stored, e.sqlMemoryToMemory(mem))
+		vectors = append(vectors, embeddings[i])
+	}
+
+	if len(stored) > 0 {
+		n, err := e.storeVectors(ctx, stored, vectors)
+		var failed []*Memory
+		var partial *storage.PartialBatchError
+		if errors.As(err, &partial) {
+			rejected := make(map[string]bool, len(partial.Failed))
+			for _, failure := range partial.Failed {
+				e.logger.Warn("failed to restore vector", "id", failure.ID, "error", failure.Reason)
+				rejected[failure.ID] = true
+			}
+			for _, mem := range stored {
+				if rejected[mem.ID] {
+					failed = append(failed, mem)
+				}
+			}
+			err = nil
+		} else if err != nil {
+			failed = stored[n:]
+		}
+		report.Stored = len(stored) - len(failed)
+		report.Failed = len(failed)
+
+		// Without their vector the memories are stale, so reindex embeds them
+		for _, mem := range failed {
+			if clearErr := e.sqlStore.SetEmbedderID(ctx, mem.ID, ""); clearErr != nil && err == nil {
+				err = clearErr
+			}
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to restore vectors: %w", err)
+		}
+	}
+
+	if len(removed) > 0 {
+		deleted, err := e.deleteVectors(ctx, removed, &DeleteReport{}, time.Now())
+		if err != nil {
+			return report, err
+		}
+		report.Removed = deleted.VectorDeleted
+	}
+	return report, nil
+}
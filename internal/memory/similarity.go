@@ -0,0 +1,67 @@
+package memory
+
+import "github.com/0xGurg/alaala/internal/storage"
+
+// DistanceMetricProvider is implemented by vector stores that can tell
+// which metric the distances they return are in. Stores that cannot are
+// assumed to use cosine distance.
+type DistanceMetricProvider interface {
+	DistanceMetric() storage.DistanceMetric
+}
+
+// distanceMetric returns the vector store's distance metric
+func (e *Engine) distanceMetric() storage.DistanceMetric {
+	if provider, ok := e.vectorStore.(DistanceMetricProvider); ok {
+		if metric := provider.DistanceMetric(); metric != "" {
+			return metric
+		}
+	}
+	return storage.DistanceCosine
+}
+
+// similarity converts a distance from the vector store to a similarity
+// from 0 to 1, so thresholds and scoring weights mean the same whatever
+// metric the store uses
+func (e *Engine) similarity(distance float64) float64 {
+	return e.similarityIn(e.distanceMetric(), distance)
+}
+
+// similarityIn converts a distance in the given metric to a similarity
+// from 0 to 1
+func (e *Engine) similarityIn(metric storage.DistanceMetric, distance float64) float64 {
+	switch metric {
+	case storage.DistanceCosine:
+		return clamp01(1 - distance)
+	case storage.DistanceDot:
+		// The negated dot product of unit vectors is their cosine similarity
+		return clamp01(-distance)
+	case storage.DistanceL2Squared:
+		// Unit vectors are 2 - 2*cosine apart; others can only be squashed
+		if info, ok := e.EmbeddingInfo(); ok && info.Normalized {
+			return clamp01(1 - distance/2)
+		}
+		return squash(distance)
+	default:
+		return squash(distance)
+	}
+}
+
+// squash maps a distance from 0 upwards to a similarity from 1 down
+// towards 0, halving at a distance of 1
+func squash(distance float64) float64 {
+	if distance <= 0 {
+		return 1
+	}
+	return 1 / (1 + distance)
+}
+
+// clamp01 limits x to the range 0 to 1
+func clamp01(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/0xGurg/alaala/internal/embeddings"
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// describedEmbedder is the hash embedder reporting the given normalization
+type describedEmbedder struct {
+	*embeddings.Client
+	normalized bool
+}
+
+func (d describedEmbedder) EmbeddingInfo() embeddings.Info {
+	info := d.Client.EmbeddingInfo()
+	info.Normalized = d.normalized
+	return info
+}
+
+func TestSimilarityPerMetric(t *testing.T) {
+	tests := []struct {
+		metric     storage.DistanceMetric
+		normalized bool
+		distance   float64
+		want       float64
+	}{
+		{storage.DistanceCosine, true, 0, 1},
+		{storage.DistanceCosine, true, 0.25, 0.75},
+		{storage.DistanceCosine, true, 1, 0},
+		{storage.DistanceCosine, true, 1.8, 0}, // Opposite vectors
+		{storage.DistanceCosine, true, -0.0001, 1},
+
+		{storage.DistanceDot, true, -1, 1},
+		{storage.DistanceDot, true, -0.6, 0.6},
+		{storage.DistanceDot, true, 0.3, 0},
+		{storage.DistanceDot, false, -12, 1}, // Unnormalized dot products are clamped
+
+		{storage.DistanceL2Squared, true, 0, 1},
+		{storage.DistanceL2Squared, true, 0.5, 0.75},
+		{storage.DistanceL2Squared, true, 2, 0},
+		{storage.DistanceL2Squared, true, 4, 0},
+		{storage.DistanceL2Squared, false, 0, 1},
+		{storage.DistanceL2Squared, false, 1, 0.5},
+		{storage.DistanceL2Squared, false, 3, 0.25},
+
+		{storage.DistanceManhattan, true, 1, 0.5},
+		{storage.DistanceManhattan, true, 9, 0.1},
+		{storage.DistanceHamming, true, 0, 1},
+		{storage.DistanceHamming, true, 4, 0.2},
+		{"unknown-metric", true, 1, 0.5},
+	}
+	for _, tt := range tests {
+		engine := NewEngine(nil, nil, describedEmbedder{embeddings.NewHashClient(), tt.normalized})
+		got := engine.similarityIn(tt.metric, tt.distance)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s (normalized %v): similarity of distance %v = %v, want %v",
+				tt.metric, tt.normalized, tt.distance, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarityWithoutEmbeddingInfo(t *testing.T) {
+	// Without knowing the vectors are normalized, L2 distances are squashed
+	engine := NewEngine(nil, nil, slowEmbedder{})
+	if got := engine.similarityIn(storage.DistanceL2Squared, 1); got != 0.5 {
+		t.Errorf("similarity = %v, want 0.5", got)
+	}
+}
+
+// metricVectors is the local vector store reporting its cosine distances
+// in another metric, as a Weaviate class configured with that metric would
+// for the same normalized vectors
+type metricVectors struct {
+	*storage.LocalVectorStore
+	metric storage.DistanceMetric
+}
+
+func (m *metricVectors) DistanceMetric() storage.DistanceMetric {
+	return m.metric
+}
+
+func (m *metricVectors) Search(ctx context.Context, embedding []float32, limit int, filters map[string]interface{}) ([]storage.VectorSearchResult, error) {
+	results, err := m.LocalVectorStore.Search(ctx, embedding, limit, filters)
+	for i := range results {
+		cosine := 1 - results[i].Distance
+		switch m.metric {
+		case storage.DistanceDot:
+			results[i].Distance = -cosine
+		case storage.DistanceL2Squared:
+			results[i].Distance = 2 - 2*cosine
+		}
+	}
+	return results, err
+}
+
+func TestSearchSimilarityIsTheSameInEveryMetric(t *testing.T) {
+	env := newTestEnv(t)
+	env.save(t, &Memory{Content: "Deploys go through the CI pipeline", Importance: 0.5})
+	env.save(t, &Memory{Content: "The CI pipeline caches Go modules", Importance: 0.5})
+	keepAll := 0.0
+	query := &SearchQuery{Query: "CI pipeline deploys", ProjectID: env.projectID, Limit: 5, Mode: SearchModeSemantic, MinSimilarity: &keepAll}
+
+	similarities := make(map[storage.DistanceMetric]map[string]float64)
+	for _, metric := range []storage.DistanceMetric{storage.DistanceCosine, storage.DistanceDot, storage.DistanceL2Squared} {
+		env.engine.vectorStore = &metricVectors{LocalVectorStore: env.vectors, metric: metric}
+		results, err := env.engine.SearchMemories(context.Background(), query)
+		if err != nil {
+			t.Fatalf("%s: SearchMemories: %v", metric, err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("%s: got %d results, want 2", metric, len(results))
+		}
+
+		similarities[metric] = make(map[string]float64)
+		for _, r := range results {
+			if r.DistanceMetric != metric {
+				t.Errorf("%s: result reports metric %q", metric, r.DistanceMetric)
+			}
+			similarities[metric][r.Memory.ID] = r.SimilarityScore
+		}
+	}
+
+	for metric, byID := range similarities {
+		for id, similarity := range byID {
+			if want := similarities[storage.DistanceCosine][id]; math.Abs(similarity-want) > 1e-6 {
+				t.Errorf("%s: similarity of %s = %v, cosine gives %v", metric, id, similarity, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSortByRelevanceBreaksTies(t *testing.T) {
+	now := time.Now()
+	result := func(id string, relevance float64, age time.Duration) *SearchResult {
+		return &SearchResult{Memory: &Memory{ID: id, CreatedAt: now.Add(-age)}, RelevanceScore: relevance}
+	}
+	want := []string{"top", "newer", "older", "a", "b", "low"}
+
+	// Every input order must give the same result
+	for seed := int64(0); seed < 20; seed++ {
+		results := []*SearchResult{
+			result("b", 0.5, 2*time.Hour),
+			result("older", 0.5, time.Hour),
+			result("low", 0.1, 0),
+			result("a", 0.5, 2*time.Hour),
+			result("top", 0.9, 48*time.Hour),
+			result("newer", 0.5, time.Minute),
+		}
+		rand.New(rand.NewSource(seed)).Shuffle(len(results), func(i, j int) {
+			results[i], results[j] = results[j], results[i]
+		})
+
+		sortByRelevance(results)
+		for i, r := range results {
+			if r.Memory.ID != want[i] {
+				t.Fatalf("seed %d: position %d is %s, want order %v", seed, i, r.Memory.ID, want)
+			}
+		}
+	}
+}
+
+// benchmarkResults returns n results with a few distinct relevances, so
+// that many compare equal and fall through to the tie-breaks
+func benchmarkResults(n int) []*SearchResult {
+	rng := rand.New(rand.NewSource(1))
+	now := time.Now()
+	results := make([]*SearchResult, n)
+	for i := range results {
+		results[i] = &SearchResult{
+			Memory:         &Memory{ID: fmt.Sprintf("mem-%05d", i), CreatedAt: now.Add(-time.Duration(rng.Intn(1000)) * time.Minute)},
+			RelevanceScore: float64(rng.Intn(100)) / 100,
+		}
+	}
+	return results
+}
+
+// exchangeSort is the quadratic sort sortByRelevance replaced, kept to
+// compare against
+func exchangeSort(results []*SearchResult) {
+	for i := 0; i < len(results); i++ {
+		for j := i + 1; j < len(results); j++ {
+			if results[j].RelevanceScore > results[i].RelevanceScore {
+				results[i], results[j] = results[j], results[i]
+			}
+		}
+	}
+}
+
+func BenchmarkSortByRelevance(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		sort func([]*SearchResult)
+	}{
+		{"sort.Slice", sortByRelevance},
+		{"exchange", exchangeSort},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			input := benchmarkResults(10000)
+			results := make([]*SearchResult, len(input))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				copy(results, input)
+				bench.sort(results)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// statusProbe is embedded to check the embedder responds
+const statusProbe = "alaala status check"
+
+// Pinger is implemented by vector stores that can check they are reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthCheck is the outcome of probing one dependency
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	OK        bool    `json:"ok"`
+	LatencyMS float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"` // What was probed, or why it failed
+}
+
+// Budget is a daily AI spending limit
+type Budget struct {
+	Name    string  `json:"name"`  // What the budget limits
+	Limit   float64 `json:"limit"` // USD per day
+	Reached bool    `json:"reached"`
+}
+
+// Status is a snapshot of the engine's dependencies, data and spending
+type Status struct {
+	Storage             storage.StoreStats `json:"storage"`
+	LatestSchemaVersion int                `json:"latest_schema_version"`
+	VectorStore         string             `json:"vector_store"` // "weaviate" or "sqlite"
+	Embedder            string             `json:"embedder"`
+	Checks              []HealthCheck      `json:"checks"`
+	StaleEmbeddings     int                `json:"stale_embeddings"`
+	AISpendToday        float64            `json:"ai_spend_today"` // USD, from the usage ledger
+	Budgets             []Budget           `json:"budgets,omitempty"`
+	QueryCache          QueryCacheStats    `json:"query_cache"`
+	Problems            []string           `json:"problems"` // Empty when everything is fine
+}
+
+// OK reports whether no problem was found
+func (st *Status) OK() bool {
+	return len(st.Problems) == 0
+}
+
+// Status probes the database, the vector store, the embedder and, given a
+// curator with an AI client, the AI provider, and collects counts and
+// spending. It does not fail: whatever cannot be checked is reported as a
+// failed check and a problem.
+func (e *Engine) Status(ctx context.Context, curator *Curator) *Status {
+	st := &Status{
+		LatestSchemaVersion: storage.LatestSchemaVersion(),
+		VectorStore:         vectorStoreName(e.vectorStore),
+		Embedder:            e.EmbedderID(),
+		QueryCache:          e.QueryCacheStats(),
+		Problems:            []string{},
+	}
+
+	st.check("sqlite", func() (string, error) {
+		stats, err := e.sqlStore.Stats(ctx)
+		if err != nil {
+			return "", err
+		}
+		st.Storage = *stats
+		return fmt.Sprintf("schema version %d", stats.SchemaVersion), nil
+	})
+	if pinger, ok := e.vectorStore.(Pinger); ok {
+		st.check("vector_store", func() (string, error) {
+			return st.VectorStore, pinger.Ping(ctx)
+		})
+	}
+	st.check("embeddings", func() (string, error) {
+		_, err := e.embedder.Embed(ctx, statusProbe)
+		return st.Embedder, err
+	})
+	if curator != nil && curator.aiClient != nil {
+		if connection, err := curator.TestConnection(); err == nil {
+			check := HealthCheck{
+				Name:      "ai",
+				OK:        connection.Err == nil,
+				LatencyMS: float64(connection.Latency.Microseconds()) / 1000,
+				Detail:    fmt.Sprintf("%s (%s)", connection.Provider, connection.Model),
+			}
+			if connection.Err != nil {
+				check.Detail = connection.Err.Error()
+				st.Problems = append(st.Problems, "ai: "+check.Detail)
+			}
+			st.Checks = append(st.Checks, check)
+		}
+	}
+
+	if st.Embedder != "" {
+		if _, total, err := e.ListStaleEmbeddings(ctx, "", 1); err == nil && total > 0 {
+			st.StaleEmbeddings = total
+			st.Problems = append(st.Problems, fmt.Sprintf(
+				"%d memories have no vector from %s; run `alaala reindex`", total, st.Embedder))
+		}
+	}
+
+	spent, err := e.AISpendToday(ctx)
+	if err != nil {
+		st.Problems = append(st.Problems, fmt.Sprintf("AI usage could not be read: %v", err))
+	}
+	st.AISpendToday = spent
+	limits := []Budget{{Name: "importance", Limit: e.importanceBudget}}
+	if curator != nil {
+		limits = append(limits,
+			Budget{Name: "auto_curation", Limit: curator.autoBudget},
+			Budget{Name: "digest", Limit: curator.digestBudget})
+	}
+	for _, budget := range limits {
+		if budget.Limit <= 0 {
+			continue
+		}
+		budget.Reached = err == nil && spent >= budget.Limit
+		if budget.Reached {
+			st.Problems = append(st.Problems, fmt.Sprintf("daily %s budget of $%.2f reached", budget.Name, budget.Limit))
+		}
+		st.Budgets = append(st.Budgets, budget)
+	}
+
+	return st
+}
+
+// check times probe and records it, adding a problem if it failed
+func (st *Status) check(name string, probe func() (string, error)) {
+	start := time.Now()
+	detail, err := probe()
+	check := HealthCheck{
+		Name:      name,
+		OK:        err == nil,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Detail:    detail,
+	}
+	if err != nil {
+		check.Detail = err.Error()
+		st.Problems = append(st.Problems, name+": "+check.Detail)
+	}
+	st.Checks = append(st.Checks, check)
+}
+
+// vectorStoreName names the backend a vector store keeps vectors in
+func vectorStoreName(store VectorStore) string {
+	switch store.(type) {
+	case *storage.WeaviateStore:
+		return "weaviate"
+	case *storage.LocalVectorStore:
+		return "sqlite"
+	default:
+		return fmt.Sprintf("%T", store)
+	}
+}
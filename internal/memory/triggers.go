@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minFuzzyWordLen is the shortest word that may match with a typo; shorter
+// words are too easily one edit away from an unrelated word
+const minFuzzyWordLen = 5
+
+// matchTokens splits text into lowercase words for trigger matching.
+// Anything other than a letter or digit separates words, so punctuation,
+// hyphens and runs of whitespace are all equivalent.
+func matchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchTrigger returns the first of triggers that occurs in the query
+// words, or "" if none does. With fuzzy set, words of minFuzzyWordLen or
+// more letters also match with one typo.
+func matchTrigger(words []string, triggers []string, fuzzy bool) string {
+	for _, trigger := range triggers {
+		if containsPhrase(words, matchTokens(trigger), fuzzy) {
+			return trigger
+		}
+	}
+	return ""
+}
+
+// containsPhrase reports whether phrase occurs in words as a run of whole
+// words. The run may also be split or joined differently, so "o auth"
+// matches "oauth" and the other way round.
+func containsPhrase(words, phrase []string, fuzzy bool) bool {
+	if len(phrase) == 0 {
+		return false
+	}
+	joined := strings.Join(phrase, "")
+
+	for i := range words {
+		if i+len(phrase) <= len(words) && wordsMatch(words[i:i+len(phrase)], phrase, fuzzy) {
+			return true
+		}
+
+		// Join runs of query words until they are as long as the phrase
+		run := ""
+		for j := i; j < len(words) && len(run) < len(joined); j++ {
+			run += words[j]
+			if wordMatches(run, joined, fuzzy) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wordsMatch reports whether words and phrase match word for word
+func wordsMatch(words, phrase []string, fuzzy bool) bool {
+	for i, word := range phrase {
+		if !wordMatches(words[i], word, fuzzy) {
+			return false
+		}
+	}
+	return true
+}
+
+// wordMatches reports whether word is want, or with fuzzy set, a long
+// enough word within one edit of it
+func wordMatches(word, want string, fuzzy bool) bool {
+	if word == want {
+		return true
+	}
+	if !fuzzy {
+		return false
+	}
+
+	a, b := []rune(word), []rune(want)
+	if len(a) < minFuzzyWordLen || len(b) < minFuzzyWordLen {
+		return false
+	}
+	return withinOneEdit(a, b)
+}
+
+// withinOneEdit reports whether a and b are at most one insertion,
+// deletion or substitution apart, i.e. a Levenshtein distance of 1 or less
+func withinOneEdit(a, b []rune) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+
+	// Skip the common prefix; what is left must differ by one edit
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		return i == len(a) || string(a[i+1:]) == string(b[i+1:])
+	}
+	return string(a[i:]) == string(b[i+1:])
+}
@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMatchTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"   ", []string{}},
+		{"Maintain the code", []string{"maintain", "the", "code"}},
+		{"rate-limit", []string{"rate", "limit"}},
+		{"Rate  Limit!", []string{"rate", "limit"}},
+		{"what's the API_KEY?", []string{"what", "s", "the", "api", "key"}},
+		{"HTTP/2 v1.22", []string{"http", "2", "v1", "22"}},
+		{"ÉCOLE Straße", []string{"école", "straße"}},
+		{"ПРИВЕТ мир", []string{"привет", "мир"}},
+		{"日本語のテスト", []string{"日本語のテスト"}},
+		{"tabs\tand\nnewlines", []string{"tabs", "and", "newlines"}},
+	}
+	for _, tt := range tests {
+		if got := matchTokens(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchTokens(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTrigger(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		triggers []string
+		fuzzy    bool
+		want     string
+	}{
+		{"substring of a word", "maintain the code", []string{"ai"}, false, ""},
+		{"whole word", "ask the AI about it", []string{"ai"}, false, "ai"},
+		{"hyphenated trigger", "how do we rate limit requests", []string{"rate-limit"}, false, "rate-limit"},
+		{"hyphenated query", "check the rate-limit", []string{"rate limit"}, false, "rate limit"},
+		{"extra whitespace and punctuation", "Rate   Limit!!", []string{"rate limit"}, false, "rate limit"},
+		{"split differently", "set up oauth", []string{"o auth"}, false, "o auth"},
+		{"joined differently", "the o auth flow", []string{"oauth"}, false, "oauth"},
+		{"words out of order", "limit the rate", []string{"rate limit"}, false, ""},
+		{"phrase across a gap", "rate of the limit", []string{"rate limit"}, false, ""},
+		{"unicode case", "ÜBER die Straße", []string{"über"}, false, "über"},
+		{"unicode word boundary", "überall", []string{"über"}, false, ""},
+		{"cyrillic", "Настройка БАЗЫ данных", []string{"базы данных"}, false, "базы данных"},
+		{"first trigger wins", "deploy to staging", []string{"staging", "deploy"}, false, "staging"},
+		{"empty trigger", "anything", []string{"", " - "}, false, ""},
+		{"empty query", "", []string{"deploy"}, false, ""},
+		{"typo without fuzzy", "the databse schema", []string{"database"}, false, ""},
+		{"typo with fuzzy", "the databse schema", []string{"database"}, true, "database"},
+		{"short word stays exact", "the cahe", []string{"cache"}, true, ""},
+		{"two typos", "the dtabse schema", []string{"database"}, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchTrigger(matchTokens(tt.query), tt.triggers, tt.fuzzy); got != tt.want {
+				t.Errorf("matchTrigger(%q, %q) = %q, want %q", tt.query, tt.triggers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithinOneEdit(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"schema", "schema", true},
+		{"schema", "schemas", true},
+		{"schema", "shema", true},
+		{"schema", "scheme", true},
+		{"schema", "sceme", false},
+		{"schema", "schemata", false},
+		{"", "a", true},
+		{"naïve", "naive", true},
+	}
+	for _, tt := range tests {
+		if got := withinOneEdit([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("withinOneEdit(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTriggerEdgeCases(t *testing.T) {
+	tests := []struct {
+		query    string
+		triggers []string
+		fuzzy    bool
+		want     string
+	}{
+		// A word is not matched by its prefix, even with typos allowed
+		{"how does authorization work", []string{"auth"}, true, ""},
+		{"who is the author", []string{"auth"}, true, ""},
+		{"fix the auth bug", []string{"auth"}, true, "auth"},
+
+		// Spacing, case and punctuation do not matter
+		{"explain the oauth flow", []string{"OAuth flow"}, false, "OAuth flow"},
+		{"explain the OAuth-flow", []string{"oauth flow"}, false, "oauth flow"},
+		{"explain the o-auth flow", []string{"OAuth flow"}, false, "OAuth flow"},
+		{"explain the oauthflow", []string{"OAuth flow"}, false, "OAuth flow"},
+
+		// One typo per word in a phrase
+		{"the authentcation middlware", []string{"authentication middleware"}, true, "authentication middleware"},
+		{"the authentcation middlware", []string{"authentication middleware"}, false, ""},
+		{"deploy", []string{"deploys"}, true, "deploys"},
+		{"redeploy", []string{"deploy"}, true, ""},
+
+		// The phrase that matched is returned, not just whether one did
+		{"rotate the signing keys", []string{"api key", "signing keys", "keys"}, false, "signing keys"},
+	}
+	for _, tt := range tests {
+		if got := matchTrigger(matchTokens(tt.query), tt.triggers, tt.fuzzy); got != tt.want {
+			t.Errorf("matchTrigger(%q, %q, fuzzy %v) = %q, want %q", tt.query, tt.triggers, tt.fuzzy, got, tt.want)
+		}
+	}
+}
+
+func TestSearchReportsMatchedTrigger(t *testing.T) {
+	env := newTestEnv(t)
+	env.engine.SetTriggerFuzzy(true)
+	mem := env.save(t, &Memory{
+		Content:        "Tokens are refreshed by the gateway, never by clients",
+		Importance:     0.5,
+		TriggerPhrases: []string{"token refresh", "oauth flow"},
+	})
+
+	results, err := env.engine.SearchMemories(context.Background(), &SearchQuery{
+		Query:     "how does the OAuth-flow work",
+		ProjectID: env.projectID,
+		Limit:     5,
+	})
+	if err != nil {
+		t.Fatalf("SearchMemories: %v", err)
+	}
+	for _, r := range results {
+		if r.Memory.ID != mem.ID {
+			continue
+		}
+		if !r.TriggerMatched || r.MatchedTrigger != "oauth flow" {
+			t.Errorf("trigger matched %v with %q, want the oauth flow phrase", r.TriggerMatched, r.MatchedTrigger)
+		}
+		return
+	}
+	t.Fatalf("memory with a matching trigger not returned: %d results", len(results))
+}
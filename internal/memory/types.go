@@ -1,6 +1,15 @@
 package memory
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
 
 // ContextType represents the type of context for a memory
 type ContextType string
@@ -16,6 +25,147 @@ const (
 	ContextTypePreference              ContextType = "PREFERENCE"
 )
 
+// ContextTypes lists the built-in context types; see AllContextTypes for
+// those including custom ones
+var ContextTypes = []ContextType{
+	ContextTypeTechnicalImplementation,
+	ContextTypeArchitecture,
+	ContextTypeDecision,
+	ContextTypeBreakthrough,
+	ContextTypeRelationship,
+	ContextTypeUnresolved,
+	ContextTypeMilestone,
+	ContextTypePreference,
+}
+
+// ContextTypeUnknown groups memories whose context type is neither built in
+// nor configured, e.g. one since removed from the config, in digests and
+// statistics
+const ContextTypeUnknown ContextType = "custom/unknown"
+
+// CustomContextType is a context type defined in the config in addition to
+// the built-in ones
+type CustomContextType struct {
+	Name         ContextType
+	Description  string  // When to use it; shown to the AI curator
+	Importance   float64 // Starting importance estimate; 0 uses the default
+	PrimerWeight float64 // Multiplies importance when picking the primer's key memories; 0 means 1
+}
+
+// contextTypeName is the form of custom context type names, like the
+// built-in ones
+var contextTypeName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+var (
+	customContextTypesMu sync.RWMutex
+	customContextTypes   []CustomContextType
+)
+
+// RegisterContextTypes replaces the custom context types, which are then
+// accepted wherever a built-in context type is. Call it at startup, before
+// memories are saved or searched.
+func RegisterContextTypes(types []CustomContextType) error {
+	seen := make(map[ContextType]bool, len(ContextTypes)+len(types))
+	for _, t := range ContextTypes {
+		seen[t] = true
+	}
+	for _, t := range types {
+		switch {
+		case !contextTypeName.MatchString(string(t.Name)):
+			return fmt.Errorf("invalid context type name %q (use upper case letters, digits and underscores, e.g. LEGAL_CONSTRAINT)", t.Name)
+		case seen[t.Name]:
+			return fmt.Errorf("context type %s is defined twice or is built in", t.Name)
+		case t.Importance < 0 || t.Importance > 1:
+			return fmt.Errorf("importance %v of context type %s is out of range (0-1)", t.Importance, t.Name)
+		case t.PrimerWeight < 0 || math.IsNaN(t.PrimerWeight) || math.IsInf(t.PrimerWeight, 0):
+			return fmt.Errorf("primer weight %v of context type %s must be a non-negative number", t.PrimerWeight, t.Name)
+		}
+		seen[t.Name] = true
+	}
+
+	customContextTypesMu.Lock()
+	defer customContextTypesMu.Unlock()
+	customContextTypes = append([]CustomContextType(nil), types...)
+	return nil
+}
+
+// CustomContextTypes returns the context types registered with
+// RegisterContextTypes
+func CustomContextTypes() []CustomContextType {
+	customContextTypesMu.RLock()
+	defer customContextTypesMu.RUnlock()
+	return append([]CustomContextType(nil), customContextTypes...)
+}
+
+// AllContextTypes returns the built-in context types followed by the custom
+// ones
+func AllContextTypes() []ContextType {
+	all := append([]ContextType(nil), ContextTypes...)
+	for _, t := range CustomContextTypes() {
+		all = append(all, t.Name)
+	}
+	return all
+}
+
+// customContextType returns the registered custom context type named t
+func customContextType(t ContextType) (CustomContextType, bool) {
+	for _, custom := range CustomContextTypes() {
+		if custom.Name == t {
+			return custom, true
+		}
+	}
+	return CustomContextType{}, false
+}
+
+// ParseContextType validates a context type string against the built-in and
+// custom context types
+func ParseContextType(s string) (ContextType, error) {
+	all := AllContextTypes()
+	for _, t := range all {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+
+	valid := make([]string, len(all))
+	for i, t := range all {
+		valid[i] = string(t)
+	}
+	return "", fmt.Errorf("unknown context type %q (valid: %s)", s, strings.Join(valid, ", "))
+}
+
+// ContextTypeBucket returns the group a memory of context type t is counted
+// in: t itself if it is known or empty, and ContextTypeUnknown otherwise
+func ContextTypeBucket(t ContextType) ContextType {
+	if t == "" {
+		return t
+	}
+	if _, err := ParseContextType(string(t)); err != nil {
+		return ContextTypeUnknown
+	}
+	return t
+}
+
+// GroupContextTypeStats merges the statistics of context types that are
+// neither built in nor configured into one ContextTypeUnknown entry, keeping
+// the order of the others
+func GroupContextTypeStats(stats []storage.ContextTypeStat) []storage.ContextTypeStat {
+	grouped := make([]storage.ContextTypeStat, 0, len(stats))
+	unknown := storage.ContextTypeStat{ContextType: string(ContextTypeUnknown)}
+	for _, stat := range stats {
+		if ContextTypeBucket(ContextType(stat.ContextType)) != ContextTypeUnknown {
+			grouped = append(grouped, stat)
+			continue
+		}
+		unknown.Average = (unknown.Average*float64(unknown.Count) + stat.Average*float64(stat.Count)) / float64(unknown.Count+stat.Count)
+		unknown.Count += stat.Count
+	}
+	if unknown.Count > 0 {
+		grouped = append(grouped, unknown)
+	}
+	return grouped
+}
+
 // TemporalRelevance represents how long a memory stays relevant
 type TemporalRelevance string
 
@@ -25,6 +175,25 @@ const (
 	TemporalRelevanceTemporary  TemporalRelevance = "temporary"
 )
 
+// TemporalRelevances lists the valid temporal relevance values
+var TemporalRelevances = []TemporalRelevance{
+	TemporalRelevancePersistent,
+	TemporalRelevanceSession,
+	TemporalRelevanceTemporary,
+}
+
+// ParseTemporalRelevance validates a temporal relevance string
+func ParseTemporalRelevance(s string) (TemporalRelevance, error) {
+	valid := make([]string, len(TemporalRelevances))
+	for i, t := range TemporalRelevances {
+		if string(t) == s {
+			return t, nil
+		}
+		valid[i] = string(t)
+	}
+	return "", fmt.Errorf("unknown temporal relevance %q (valid: %s)", s, strings.Join(valid, ", "))
+}
+
 // RelationshipType represents the type of relationship between memories
 type RelationshipType string
 
@@ -36,6 +205,30 @@ const (
 	RelationshipTypeExpands    RelationshipType = "expands"
 )
 
+// RelationshipTypes lists all valid relationship types
+var RelationshipTypes = []RelationshipType{
+	RelationshipTypeReferences,
+	RelationshipTypeSupersedes,
+	RelationshipTypeRelatedTo,
+	RelationshipTypeConflicts,
+	RelationshipTypeExpands,
+}
+
+// ParseRelationshipType validates a relationship type string
+func ParseRelationshipType(s string) (RelationshipType, error) {
+	for _, t := range RelationshipTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+
+	valid := make([]string, len(RelationshipTypes))
+	for i, t := range RelationshipTypes {
+		valid[i] = string(t)
+	}
+	return "", fmt.Errorf("unknown relationship type %q (valid: %s)", s, strings.Join(valid, ", "))
+}
+
 // Memory represents a complete memory with all its metadata
 type Memory struct {
 	ID                string
@@ -50,16 +243,79 @@ type Memory struct {
 	TemporalRelevance TemporalRelevance
 	ActionRequired    bool
 	Reasoning         string
+	ImportanceMethod  string // How Importance was chosen; ImportanceAuto asks for an estimate
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
+	AccessCount       int        // Times returned by a search or read with get_memory
+	LastAccessedAt    *time.Time // Nil if never accessed
+	Archived          bool       // Out of search and listings by default; has no vector
+	ArchivedAt        *time.Time // Nil unless archived
 	Relationships     []Relationship
+	RelationshipCount int // All relationships, also those not loaded into Relationships
 }
 
 // Relationship represents a connection between memories
 type Relationship struct {
-	ToMemoryID string
-	Type       RelationshipType
-	CreatedAt  time.Time
+	FromMemoryID string
+	ToMemoryID   string
+	Type         RelationshipType
+	Direction    string // "outgoing" from or "incoming" to the memory it was loaded with
+	CreatedAt    time.Time
+}
+
+// MemoryUpdate describes changes to an existing memory; nil fields are left unchanged
+type MemoryUpdate struct {
+	Content           *string
+	Importance        *float64
+	SemanticTags      []string
+	ContextType       *ContextType
+	TriggerPhrases    []string
+	TemporalRelevance *TemporalRelevance
+	ActionRequired    *bool
+}
+
+// Revision represents a recorded change to a memory's content
+type Revision struct {
+	ID              int64
+	MemoryID        string
+	PreviousContent string
+	Diff            string
+	Reason          string
+	CreatedAt       time.Time
+}
+
+// DeleteReport summarizes a bulk delete across both stores
+type DeleteReport struct {
+	Requested            int
+	SQLiteDeleted        int
+	RelationshipsDeleted int // Relationships removed along with the memories
+	VectorDeleted        int
+	VectorFailed         int
+	Duration             time.Duration
+}
+
+// StaleEmbedding is a memory whose vector came from a different embedder
+type StaleEmbedding struct {
+	ID         string
+	ProjectID  string
+	Content    string
+	EmbedderID string // Empty if the embedder was never recorded
+}
+
+// ReindexReport summarizes a reindex run
+type ReindexReport struct {
+	Requested int
+	Reindexed int
+	Failed    map[string]error
+	Duration  time.Duration
+}
+
+// Throughput returns deleted memories per second
+func (r *DeleteReport) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.SQLiteDeleted) / r.Duration.Seconds()
 }
 
 // SearchQuery represents a memory search request
@@ -68,8 +324,48 @@ type SearchQuery struct {
 	ProjectID         string
 	Limit             int
 	MinImportance     float64
-	ContextTypes      []ContextType
-	IncludeGraphDepth int
+	MinSimilarity     *float64      // Drop vector hits less similar than this (0-1); nil uses the engine default, 0 or less keeps all
+	ContextTypes      []ContextType // Match any of these context types (optional)
+	Tags              []string      // Match memories with any of these tags (optional)
+	TagMatch          string        // "any" (default) or "all" of Tags
+	IncludeGraphDepth *int          // Relationship hops to expand; nil uses the engine default, 0 or less disables
+	GraphDirection    string        // "outgoing", "incoming" or "both" (default)
+	Scope             string        // "project" (default) or "workspace"
+	IncludeArchived   bool          // Also return archived memories; without vectors they only match by keyword
+	Mode              string        // "hybrid" (default), "semantic" or "keyword"
+	CreatedAfter      time.Time     // Only memories created at or after this time, if set
+	CreatedBefore     time.Time     // Only memories created before this time, if set
+}
+
+// Search scopes
+const (
+	ScopeProject   = "project"   // Only ProjectID's memories
+	ScopeWorkspace = "workspace" // Memories of every project in ProjectID's workspace
+)
+
+// Search modes
+const (
+	SearchModeHybrid   = "hybrid"   // Vector and full-text matches, fused by rank
+	SearchModeSemantic = "semantic" // Vector matches only
+	SearchModeKeyword  = "keyword"  // Full-text matches only
+)
+
+// How SearchQuery.Tags are matched
+const (
+	TagMatchAny = "any" // Memories with at least one of the tags
+	TagMatchAll = "all" // Memories with every tag
+)
+
+// ListQuery represents a paginated listing of memories without a search query
+type ListQuery struct {
+	ProjectID       string
+	Limit           int
+	Offset          int
+	OrderBy         string // "created_at", "importance", "updated_at", "access_count", or "last_accessed_at"
+	ContextType     ContextType
+	Tag             string
+	ActionRequired  *bool
+	IncludeArchived bool // List archived memories too
 }
 
 // SearchResult represents a memory search result with scoring
@@ -78,16 +374,38 @@ type SearchResult struct {
 	SimilarityScore float64
 	RelevanceScore  float64
 	TriggerMatched  bool
+	MatchedTrigger  string // The trigger phrase the query matched, if any
+	KeywordMatched  bool   // The content contains the query verbatim
+	GraphExpanded   bool   // Reached through relationships rather than matched directly
+	TextFallback    bool   // Found by full-text search because the embedder or vector store failed
+
+	// For vector hits, the distance the vector store returned and its
+	// metric, from which SimilarityScore was derived
+	Distance       float64
+	DistanceMetric storage.DistanceMetric
+
+	// For hybrid searches, the reciprocal rank fusion of the vector and
+	// full-text rankings, scaled so that 1 is first in both. RelevanceScore
+	// uses it in place of SimilarityScore when it is the higher of the two.
+	FusionScore float64
+
+	// For graph-expanded results, the relationship that reached the memory
+	// and whether it points away from (outgoing) or to (incoming) the
+	// memory it was reached from
+	RelationshipType RelationshipType
+	Direction        string
 }
 
 // SessionPrimer represents contextual information injected at session start
 type SessionPrimer struct {
-	ProjectName          string
-	LastSessionDate      *time.Time
-	TimeSinceLastSession string
-	LastSessionSummary   string
-	TopMemories          []*Memory
-	UnresolvedItems      []*Memory
+	ProjectName        string
+	LastSessionDate    *time.Time
+	LastSessionSummary string
+	TopMemories        []*Memory
+	UnresolvedItems    []*Memory
+	WorkspaceName      string            // Empty if the project is not in a workspace
+	WorkspaceMemories  []*Memory         // Top memories of the workspace's other projects
+	ProjectNames       map[string]string // Names of the projects WorkspaceMemories come from, by ID
 }
 
 // CurationRequest represents a request to curate memories from a transcript
@@ -99,11 +417,27 @@ type CurationRequest struct {
 
 // CurationResponse represents the result of memory curation
 type CurationResponse struct {
-	Memories      []*Memory
-	Relationships []struct {
+	Memories          []*Memory // Memories created; duplicates are not included
+	DuplicatesSkipped int       // Memories merged into existing ones instead of being created
+	Superseding       int       // Memories created superseding an existing one they duplicate
+	Relationships     []struct {
 		FromID string
 		ToID   string
 		Type   RelationshipType
 	}
-	Summary string
+	RelationshipsStored  int
+	RelationshipsSkipped int
+	Summary              string
+	VectorsFailed        []string // Memories saved without a vector; reindex stores them
+	TruncationRecovered  bool
+	AutoTriggered        bool // Started by AppendTranscript rather than curate_session
+}
+
+// TranscriptAppend is the result of appending to a session's transcript
+type TranscriptAppend struct {
+	SessionID    string
+	Length       int               // Bytes accumulated so far
+	Pending      int               // Bytes not curated yet
+	Curation     *CurationResponse // Set if the append triggered a curation
+	CurationSkip string            // Why a due curation did not run, if it didn't
 }
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// Policies for embeddings that contain NaN or Inf components
+const (
+	InvalidVectorsReject = "reject" // Fail the operation
+	InvalidVectorsZero   = "zero"   // Replace the bad components with 0
+)
+
+// InvalidEmbeddingError reports an embedding with a NaN or Inf component
+type InvalidEmbeddingError struct {
+	Component int
+	Value     float32
+	Count     int // Number of invalid components
+}
+
+func (e *InvalidEmbeddingError) Error() string {
+	return fmt.Sprintf("embedding provider returned an invalid vector: component %d is %v (%d invalid components); "+
+		"set embeddings.invalid_vectors to \"zero\" to replace them with 0 instead", e.Component, e.Value, e.Count)
+}
+
+// SetInvalidVectorPolicy sets how embeddings containing NaN or Inf are
+// handled: "reject" (the default) fails the operation, "zero" replaces the
+// offending components with 0 and logs a warning
+func (e *Engine) SetInvalidVectorPolicy(policy string) error {
+	switch policy {
+	case "":
+		e.invalidVectors = InvalidVectorsReject
+	case InvalidVectorsReject, InvalidVectorsZero:
+		e.invalidVectors = policy
+	default:
+		return fmt.Errorf("unknown invalid vector policy %q (valid: %s, %s)", policy, InvalidVectorsReject, InvalidVectorsZero)
+	}
+	return nil
+}
+
+// embed generates an embedding for text and checks it
+func (e *Engine) embed(ctx context.Context, text string) ([]float32, error) {
+	embedding, err := e.embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkEmbedding(embedding); err != nil {
+		return nil, err
+	}
+	return embedding, nil
+}
+
+// checkEmbedding applies the invalid vector policy to an embedding, zeroing
+// its NaN and Inf components in place or rejecting it
+func (e *Engine) checkEmbedding(embedding []float32) error {
+	var invalid *InvalidEmbeddingError
+	for i, v := range embedding {
+		f := float64(v)
+		if !math.IsNaN(f) && !math.IsInf(f, 0) {
+			continue
+		}
+		if invalid == nil {
+			invalid = &InvalidEmbeddingError{Component: i, Value: v}
+		}
+		invalid.Count++
+	}
+	if invalid == nil {
+		return nil
+	}
+
+	if e.invalidVectors != InvalidVectorsZero {
+		return invalid
+	}
+
+	for i, v := range embedding {
+		if f := float64(v); math.IsNaN(f) || math.IsInf(f, 0) {
+			embedding[i] = 0
+		}
+	}
+	e.logger.Warn("zeroed invalid embedding components", "count", invalid.Count, "dimension", len(embedding))
+	return nil
+}
+
+// VectorFailureError is returned when memories were saved but some of their
+// vectors could not be stored, even when retried one at a time. Those
+// memories are listed as stale embeddings until `alaala reindex` stores
+// their vectors.
+type VectorFailureError struct {
+	Failed []storage.BatchFailure
+}
+
+func (e *VectorFailureError) Error() string {
+	return fmt.Sprintf("%d memories were saved without a vector (first: %s: %s); run `alaala reindex` to store them",
+		len(e.Failed), e.Failed[0].ID, e.Failed[0].Reason)
+}
+
+// IDs returns the IDs of the memories saved without a vector
+func (e *VectorFailureError) IDs() []string {
+	ids := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		ids[i] = f.ID
+	}
+	return ids
+}
+
+// retryVectors stores the vectors a batch rejected one at a time. Memories
+// whose vector still fails lose their embedder ID, which makes them stale
+// for ListStaleEmbeddings and reindex.
+func (e *Engine) retryVectors(ctx context.Context, mems []*Memory, embeddings [][]float32, partial *storage.PartialBatchError) error {
+	index := make(map[string]int, len(mems))
+	for i, mem := range mems {
+		index[mem.ID] = i
+	}
+
+	failure := &VectorFailureError{}
+	for _, rejected := range partial.Failed {
+		i, ok := index[rejected.ID]
+		if !ok {
+			continue
+		}
+		mem := mems[i]
+
+		err := e.vectorStore.Store(ctx, mem.ID, mem.Content, embeddings[i], e.vectorMetadata(ctx, mem))
+		if err == nil {
+			continue
+		}
+		e.logger.Warn("failed to store vector", "id", mem.ID, "batch_error", rejected.Reason, "error", err)
+		if err := e.sqlStore.SetEmbedderID(ctx, mem.ID, ""); err != nil {
+			e.logger.Warn("failed to mark embedding as stale", "id", mem.ID, "error", err)
+		}
+		failure.Failed = append(failure.Failed, storage.BatchFailure{ID: mem.ID, Reason: err.Error()})
+	}
+
+	if len(failure.Failed) > 0 {
+		NoteDegradation(ctx, DegradedVectorStore)
+		return failure
+	}
+	return nil
+}
@@ -0,0 +1,419 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+// flakyVectors is a local vector store that fails chosen writes the way
+// Weaviate can: batch requests reject some objects inside a successful
+// response, and single requests fail outright
+type flakyVectors struct {
+	*storage.LocalVectorStore
+
+	mu          sync.Mutex
+	rejectBatch map[string]bool // IDs StoreBatch rejects
+	failStore   map[string]bool // IDs Store fails for
+	failDelete  map[string]bool // IDs Delete fails for and DeleteBatch rejects
+	down        bool            // Every batch delete fails outright
+	deleted     []string        // IDs deleted, in order
+}
+
+func newFlakyVectors(env *testEnv) *flakyVectors {
+	flaky := &flakyVectors{
+		LocalVectorStore: env.vectors,
+		rejectBatch:      make(map[string]bool),
+		failStore:        make(map[string]bool),
+		failDelete:       make(map[string]bool),
+	}
+	env.engine.vectorStore = flaky
+	return flaky
+}
+
+var errVectorStoreDown = errors.New("vector store is down")
+
+func (f *flakyVectors) Store(ctx context.Context, id, content string, embedding []float32, metadata map[string]interface{}) error {
+	f.mu.Lock()
+	fail := f.failStore[id]
+	f.mu.Unlock()
+	if fail {
+		return errVectorStoreDown
+	}
+	return f.LocalVectorStore.Store(ctx, id, content, embedding, metadata)
+}
+
+func (f *flakyVectors) StoreBatch(ctx context.Context, vectors []storage.Vector) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	partial := &storage.PartialBatchError{Op: "store", Total: len(vectors)}
+	accepted := make([]storage.Vector, 0, len(vectors))
+	for _, v := range vectors {
+		if f.rejectBatch[v.ID] {
+			partial.Failed = append(partial.Failed, storage.BatchFailure{ID: v.ID, Reason: "rejected"})
+			continue
+		}
+		accepted = append(accepted, v)
+	}
+	if err := f.LocalVectorStore.StoreBatch(ctx, accepted); err != nil {
+		return err
+	}
+	if len(partial.Failed) > 0 {
+		return partial
+	}
+	return nil
+}
+
+func (f *flakyVectors) Delete(ctx context.Context, id string) error {
+	f.mu.Lock()
+	fail := f.failDelete[id]
+	f.mu.Unlock()
+	if fail {
+		return errVectorStoreDown
+	}
+	if err := f.LocalVectorStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.deleted = append(f.deleted, id)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *flakyVectors) DeleteBatch(ctx context.Context, ids []string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return 0, errVectorStoreDown
+	}
+
+	partial := &storage.PartialBatchError{Op: "delete", Total: len(ids)}
+	accepted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if f.failDelete[id] {
+			partial.Failed = append(partial.Failed, storage.BatchFailure{ID: id, Reason: "rejected"})
+			continue
+		}
+		accepted = append(accepted, id)
+	}
+	// Like Weaviate, count every accepted ID; local vectors may already
+	// have gone with their memory by cascade
+	if _, err := f.LocalVectorStore.DeleteBatch(ctx, accepted); err != nil {
+		return 0, err
+	}
+	f.deleted = append(f.deleted, accepted...)
+	if len(partial.Failed) > 0 {
+		return len(accepted), partial
+	}
+	return len(accepted), nil
+}
+
+// deletedIDs returns the IDs deleted so far, sorted
+func (f *flakyVectors) deletedIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := append([]string(nil), f.deleted...)
+	sort.Strings(ids)
+	return ids
+}
+
+// recover makes every write succeed again
+func (f *flakyVectors) recover() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejectBatch = make(map[string]bool)
+	f.failStore = make(map[string]bool)
+	f.failDelete = make(map[string]bool)
+	f.down = false
+}
+
+// unbatchedVectors hides a store's batch methods, so the engine writes and
+// deletes vectors one at a time
+type unbatchedVectors struct {
+	VectorStore
+}
+
+// vectorIDs returns the IDs of the stored vectors, sorted
+func (env *testEnv) vectorIDs(t testing.TB) []string {
+	t.Helper()
+	vectors, err := env.vectors.All(context.Background())
+	if err != nil {
+		t.Fatalf("failed to read vectors: %v", err)
+	}
+	ids := make([]string, len(vectors))
+	for i, v := range vectors {
+		ids[i] = v.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// pendingDeletes returns the IDs of the queued vector deletes, sorted
+func (env *testEnv) pendingDeletes(t testing.TB) []string {
+	t.Helper()
+	ids, err := env.store.PendingVectorDeletes(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("failed to read queued vector deletes: %v", err)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestCreateMemoriesRetriesRejectedVectors(t *testing.T) {
+	env := newTestEnv(t)
+	flaky := newFlakyVectors(env)
+
+	mems := []*Memory{
+		{ID: "accepted", ProjectID: env.projectID, Content: "Deploys go through CI", Importance: 0.5},
+		{ID: "retried", ProjectID: env.projectID, Content: "The cache is warmed on startup", Importance: 0.5},
+		{ID: "failed", ProjectID: env.projectID, Content: "Use cursor pagination", Importance: 0.5},
+	}
+	flaky.rejectBatch["retried"] = true
+	flaky.rejectBatch["failed"] = true
+	flaky.failStore["failed"] = true
+
+	err := env.engine.CreateMemories(context.Background(), mems)
+	var failure *VectorFailureError
+	if !errors.As(err, &failure) {
+		t.Fatalf("CreateMemories error = %v, want a *VectorFailureError", err)
+	}
+	if ids := failure.IDs(); !reflect.DeepEqual(ids, []string{"failed"}) {
+		t.Errorf("failed IDs = %v, want [failed]", ids)
+	}
+
+	// Every memory is saved; the one without a vector is left for reindex
+	if n := env.countMemories(t); n != 3 {
+		t.Errorf("%d memories in SQLite, want 3", n)
+	}
+	if ids := env.vectorIDs(t); !reflect.DeepEqual(ids, []string{"accepted", "retried"}) {
+		t.Errorf("vectors stored for %v, want accepted and retried", ids)
+	}
+	stale, _, err := env.engine.ListStaleEmbeddings(context.Background(), env.projectID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].ID != "failed" {
+		t.Errorf("stale embeddings = %+v, want only the failed memory", stale)
+	}
+}
+
+func TestCreateMemoryRollsBackWhenVectorStoreFails(t *testing.T) {
+	env := newTestEnv(t)
+	flaky := newFlakyVectors(env)
+	flaky.failStore["lost"] = true
+
+	_, err := env.engine.CreateMemory(context.Background(), &Memory{
+		ID: "lost", ProjectID: env.projectID, Content: "Deploys go through CI", Importance: 0.5,
+	})
+	if !errors.Is(err, errVectorStoreDown) {
+		t.Fatalf("CreateMemory error = %v, want %v", err, errVectorStoreDown)
+	}
+	if n := env.countMemories(t); n != 0 {
+		t.Errorf("%d memories in SQLite after rollback, want 0", n)
+	}
+	if ids := env.vectorIDs(t); len(ids) != 0 {
+		t.Errorf("vectors stored for %v after rollback, want none", ids)
+	}
+}
+
+func TestUpdateMemoryKeepsVectorWhenVectorStoreFails(t *testing.T) {
+	env := newTestEnv(t)
+	flaky := newFlakyVectors(env)
+	mem := env.save(t, &Memory{ID: "kept", Content: "Deploys go through CI", Importance: 0.5})
+	flaky.failStore[mem.ID] = true
+
+	content := "Deploys go through the release pipeline"
+	if _, _, err := env.engine.UpdateMemory(context.Background(), mem.ID, &MemoryUpdate{Content: &content}); !errors.Is(err, errVectorStoreDown) {
+		t.Fatalf("UpdateMemory error = %v, want %v", err, errVectorStoreDown)
+	}
+	if ids := env.vectorIDs(t); len(ids) != 1 || ids[0] != mem.ID {
+		t.Errorf("vectors stored for %v after a failed update, want the old one kept", ids)
+	}
+}
+
+func TestUpdateMemoryReembedsOnlyChangedContent(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	flaky := newFlakyVectors(env)
+	mem := env.save(t, &Memory{Content: "Deploys go through CI", Importance: 0.5})
+	flaky.failStore[mem.ID] = true
+
+	importance := 0.9
+	if _, _, err := env.engine.UpdateMemory(ctx, mem.ID, &MemoryUpdate{Importance: &importance, SemanticTags: []string{"ci"}}); err != nil {
+		t.Errorf("UpdateMemory of importance and tags stored a vector: %v", err)
+	}
+	same := mem.Content
+	if _, _, err := env.engine.UpdateMemory(ctx, mem.ID, &MemoryUpdate{Content: &same}); err != nil {
+		t.Errorf("UpdateMemory with unchanged content stored a vector: %v", err)
+	}
+
+	content := "Deploys go through the release pipeline"
+	if _, _, err := env.engine.UpdateMemory(ctx, mem.ID, &MemoryUpdate{Content: &content}); !errors.Is(err, errVectorStoreDown) {
+		t.Errorf("UpdateMemory of content error = %v, want it to store a vector and get %v", err, errVectorStoreDown)
+	}
+}
+
+func TestCreateMemoriesRollbackQueuesUndeletedVectors(t *testing.T) {
+	env := newTestEnv(t)
+	flaky := newFlakyVectors(env)
+	env.engine.vectorStore = unbatchedVectors{flaky}
+
+	mems := []*Memory{
+		{ID: "stuck", ProjectID: env.projectID, Content: "Deploys go through CI", Importance: 0.5},
+		{ID: "undone", ProjectID: env.projectID, Content: "The cache is warmed on startup", Importance: 0.5},
+		{ID: "failed", ProjectID: env.projectID, Content: "Use cursor pagination", Importance: 0.5},
+	}
+	flaky.failStore["failed"] = true
+	flaky.failDelete["stuck"] = true
+
+	if err := env.engine.CreateMemories(context.Background(), mems); !errors.Is(err, errVectorStoreDown) {
+		t.Fatalf("CreateMemories error = %v, want %v", err, errVectorStoreDown)
+	}
+	if n := env.countMemories(t); n != 0 {
+		t.Errorf("%d memories in SQLite after rollback, want 0", n)
+	}
+	if ids := flaky.deletedIDs(); !reflect.DeepEqual(ids, []string{"undone"}) {
+		t.Errorf("rollback deleted vectors %v, want [undone]", ids)
+	}
+	if ids := env.pendingDeletes(t); !reflect.DeepEqual(ids, []string{"stuck"}) {
+		t.Errorf("queued vector deletes = %v, want [stuck]", ids)
+	}
+
+	// The next delete removes the leftover vector once the store recovers
+	flaky.recover()
+	other := env.save(t, &Memory{Content: "Logs are kept for a week", Importance: 0.5})
+	if _, err := env.engine.DeleteMemories(context.Background(), []string{other.ID}); err != nil {
+		t.Fatalf("DeleteMemories: %v", err)
+	}
+	want := []string{other.ID, "stuck", "undone"}
+	sort.Strings(want)
+	if ids := flaky.deletedIDs(); !reflect.DeepEqual(ids, want) {
+		t.Errorf("deleted vectors = %v, want %v", ids, want)
+	}
+	if ids := env.pendingDeletes(t); len(ids) != 0 {
+		t.Errorf("queued vector deletes = %v, want none", ids)
+	}
+}
+
+func TestDeleteMemoriesQueuesFailedVectors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		batched bool
+	}{
+		{"batch delete", true},
+		{"single deletes", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			flaky := newFlakyVectors(env)
+			if !tt.batched {
+				env.engine.vectorStore = unbatchedVectors{flaky}
+			}
+			kept := env.save(t, &Memory{Content: "Deploys go through CI", Importance: 0.5})
+			stuck := env.save(t, &Memory{Content: "The cache is warmed on startup", Importance: 0.5})
+			flaky.failDelete[stuck.ID] = true
+
+			report, err := env.engine.DeleteMemories(ctx, []string{kept.ID, stuck.ID})
+			if err != nil {
+				t.Fatalf("DeleteMemories: %v", err)
+			}
+			if report.SQLiteDeleted != 2 || report.VectorDeleted != 1 || report.VectorFailed != 1 {
+				t.Errorf("report = %+v, want 2 SQLite rows, 1 vector deleted and 1 failed", report)
+			}
+			if ids := env.pendingDeletes(t); !reflect.DeepEqual(ids, []string{stuck.ID}) {
+				t.Errorf("queued vector deletes = %v, want [%s]", ids, stuck.ID)
+			}
+
+			// Still failing: the delete stays queued
+			next := env.save(t, &Memory{Content: "Use cursor pagination", Importance: 0.5})
+			if _, err := env.engine.DeleteMemories(ctx, []string{next.ID}); err != nil {
+				t.Fatalf("DeleteMemories: %v", err)
+			}
+			if ids := env.pendingDeletes(t); !reflect.DeepEqual(ids, []string{stuck.ID}) {
+				t.Errorf("queued vector deletes = %v, want [%s]", ids, stuck.ID)
+			}
+
+			flaky.recover()
+			last := env.save(t, &Memory{Content: "Logs are kept for a week", Importance: 0.5})
+			if _, err := env.engine.DeleteMemories(ctx, []string{last.ID}); err != nil {
+				t.Fatalf("DeleteMemories: %v", err)
+			}
+			want := []string{kept.ID, stuck.ID, next.ID, last.ID}
+			sort.Strings(want)
+			if ids := flaky.deletedIDs(); !reflect.DeepEqual(ids, want) {
+				t.Errorf("deleted vectors = %v, want %v", ids, want)
+			}
+			if ids := env.pendingDeletes(t); len(ids) != 0 {
+				t.Errorf("queued vector deletes = %v, want none", ids)
+			}
+		})
+	}
+}
+
+func TestDeleteMemoriesQueuesVectorsWhenStoreIsDown(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	flaky := newFlakyVectors(env)
+	a := env.save(t, &Memory{Content: "Deploys go through CI", Importance: 0.5})
+	b := env.save(t, &Memory{Content: "The cache is warmed on startup", Importance: 0.5})
+	flaky.down = true
+
+	report, err := env.engine.DeleteMemories(ctx, []string{a.ID, b.ID})
+	if !errors.Is(err, errVectorStoreDown) {
+		t.Fatalf("DeleteMemories error = %v, want %v", err, errVectorStoreDown)
+	}
+	if report.SQLiteDeleted != 2 || report.VectorFailed != 2 {
+		t.Errorf("report = %+v, want 2 SQLite rows deleted and 2 vectors failed", report)
+	}
+	want := []string{a.ID, b.ID}
+	sort.Strings(want)
+	if ids := env.pendingDeletes(t); !reflect.DeepEqual(ids, want) {
+		t.Errorf("queued vector deletes = %v, want %v", ids, want)
+	}
+
+	// Pruning retries them too
+	flaky.recover()
+	c := env.save(t, &Memory{Content: "Use cursor pagination", Importance: 0.1})
+	if _, err := env.engine.PruneMemories(ctx, time.Now().Add(time.Hour), 0.5); err != nil {
+		t.Fatalf("PruneMemories: %v", err)
+	}
+	want = append(want, c.ID)
+	sort.Strings(want)
+	if ids := flaky.deletedIDs(); !reflect.DeepEqual(ids, want) {
+		t.Errorf("deleted vectors = %v, want %v", ids, want)
+	}
+	if ids := env.pendingDeletes(t); len(ids) != 0 {
+		t.Errorf("queued vector deletes = %v, want none", ids)
+	}
+}
+
+func TestRetryKeepsVectorOfRestoredMemory(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t)
+	mem := env.save(t, &Memory{Content: "Deploys go through CI", Importance: 0.5})
+
+	// A queued delete for a memory that exists again must not remove its vector
+	if err := env.store.QueueVectorDelete(ctx, mem.ID, "rejected"); err != nil {
+		t.Fatal(err)
+	}
+	other := env.save(t, &Memory{Content: "Use cursor pagination", Importance: 0.5})
+	if _, err := env.engine.DeleteMemories(ctx, []string{other.ID}); err != nil {
+		t.Fatalf("DeleteMemories: %v", err)
+	}
+	if ids := env.vectorIDs(t); !reflect.DeepEqual(ids, []string{mem.ID}) {
+		t.Errorf("vectors left for %v, want [%s]", ids, mem.ID)
+	}
+	if ids := env.pendingDeletes(t); len(ids) != 0 {
+		t.Errorf("queued vector deletes = %v, want none", ids)
+	}
+}
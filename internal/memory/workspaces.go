@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xGurg/alaala/internal/storage"
+)
+
+const (
+	// workspacePrimerLimit is how many memories of sibling projects the
+	// session primer shows
+	workspacePrimerLimit = 3
+
+	// workspacePrimerMinImportance keeps the workspace section of the primer
+	// to memories worth knowing across repositories
+	workspacePrimerMinImportance = 0.7
+)
+
+// GetProject retrieves a project by ID, returning nil if it does not exist
+func (e *Engine) GetProject(ctx context.Context, id string) (*storage.Project, error) {
+	return e.sqlStore.GetProject(ctx, id)
+}
+
+// workspaceOf returns the ID of the workspace a project belongs to, or an
+// empty string if it has none or cannot be loaded
+func (e *Engine) workspaceOf(ctx context.Context, projectID string) string {
+	project, err := e.sqlStore.GetProject(ctx, projectID)
+	if err != nil {
+		e.logger.Warn("failed to load project", "project_id", projectID, "error", err)
+		return ""
+	}
+	if project == nil || project.WorkspaceID == nil {
+		return ""
+	}
+	return *project.WorkspaceID
+}
+
+// scopeFilter returns the vector store filter that limits a search to its
+// scope and, for workspace searches, the projects the workspace holds now.
+// Vectors can carry a stale workspace, so hits are checked against them.
+func (e *Engine) scopeFilter(ctx context.Context, query *SearchQuery) (string, string, map[string]bool, error) {
+	switch query.Scope {
+	case "", ScopeProject:
+		return "project_id", query.ProjectID, nil, nil
+	case ScopeWorkspace:
+	default:
+		return "", "", nil, fmt.Errorf("unknown scope %q (valid: project, workspace)", query.Scope)
+	}
+
+	project, err := e.sqlStore.GetProject(ctx, query.ProjectID)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project == nil {
+		return "", "", nil, fmt.Errorf("project not found: %s", query.ProjectID)
+	}
+	if project.WorkspaceID == nil {
+		return "", "", nil, fmt.Errorf("project %s is not in a workspace; "+
+			"add it with `alaala workspaces assign %s <workspace>`", project.Name, project.ID)
+	}
+
+	projects, err := e.sqlStore.WorkspaceProjects(ctx, *project.WorkspaceID)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to list workspace projects: %w", err)
+	}
+	projectIDs := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		projectIDs[p.ID] = true
+	}
+
+	return "workspace_id", *project.WorkspaceID, projectIDs, nil
+}
+
+// addWorkspacePrimer fills the workspace section of a session primer with
+// the most important memories of the project's sibling projects
+func (e *Engine) addWorkspacePrimer(ctx context.Context, primer *SessionPrimer, project *storage.Project) error {
+	if project.WorkspaceID == nil {
+		return nil
+	}
+
+	workspace, err := e.sqlStore.GetWorkspace(ctx, *project.WorkspaceID)
+	if err != nil || workspace == nil {
+		return err
+	}
+	primer.WorkspaceName = workspace.Name
+
+	sqlMemories, _, err := e.sqlStore.ListMemories(ctx, storage.ListOptions{
+		WorkspaceID:    workspace.ID,
+		ExcludeProject: project.ID,
+		MinImportance:  workspacePrimerMinImportance,
+		Limit:          workspacePrimerLimit,
+		OrderBy:        "importance",
+	})
+	if err != nil {
+		return err
+	}
+	if len(sqlMemories) == 0 {
+		return nil
+	}
+
+	projects, err := e.sqlStore.WorkspaceProjects(ctx, workspace.ID)
+	if err != nil {
+		return err
+	}
+	primer.ProjectNames = make(map[string]string, len(projects))
+	for _, p := range projects {
+		primer.ProjectNames[p.ID] = p.Name
+	}
+
+	for _, sqlMem := range sqlMemories {
+		primer.WorkspaceMemories = append(primer.WorkspaceMemories, e.sqlMemoryToMemory(sqlMem))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BatchFailure is an object a batch request could not write or delete
+type BatchFailure struct {
+	ID     string
+	Reason string
+}
+
+// PartialBatchError is returned by batch operations that failed for some
+// objects. Weaviate reports such failures per object inside a successful
+// response; every object not listed in Failed was processed.
+type PartialBatchError struct {
+	Op     string // "store" or "delete"
+	Total  int
+	Failed []BatchFailure
+}
+
+func (e *PartialBatchError) Error() string {
+	const shown = 3
+	reasons := make([]string, 0, shown)
+	for i, f := range e.Failed {
+		if i == shown {
+			reasons = append(reasons, fmt.Sprintf("and %d more", len(e.Failed)-shown))
+			break
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %s", f.ID, f.Reason))
+	}
+	return fmt.Sprintf("batch %s failed for %d of %d objects (%s)",
+		e.Op, len(e.Failed), e.Total, strings.Join(reasons, "; "))
+}
+
+// FailedIDs returns the IDs of the objects that failed, in batch order
+func (e *PartialBatchError) FailedIDs() []string {
+	ids := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		ids[i] = f.ID
+	}
+	return ids
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CompactReport describes what Compact removed and how much space it
+// reclaimed
+type CompactReport struct {
+	Revisions     int64 // Revisions older than the retention window
+	Tags          int64 // Orphaned rows whose memory no longer exists
+	Triggers      int64
+	Relationships int64
+	Vectors       int64
+	SizeBefore    int64 // Database size in bytes
+	SizeAfter     int64
+}
+
+// Reclaimed returns the number of bytes the database shrank by
+func (r *CompactReport) Reclaimed() int64 {
+	return r.SizeBefore - r.SizeAfter
+}
+
+// orphanQueries delete rows left behind by memories deleted while foreign
+// keys were not enforced (e.g. by older versions or the sqlite3 shell)
+var orphanQueries = []struct {
+	query string
+	count func(*CompactReport) *int64
+}{
+	{`DELETE FROM memory_tags WHERE memory_id NOT IN (SELECT id FROM memories)`,
+		func(r *CompactReport) *int64 { return &r.Tags }},
+	{`DELETE FROM memory_triggers WHERE memory_id NOT IN (SELECT id FROM memories)`,
+		func(r *CompactReport) *int64 { return &r.Triggers }},
+	{`DELETE FROM memory_relationships WHERE from_memory_id NOT IN (SELECT id FROM memories)
+		OR to_memory_id NOT IN (SELECT id FROM memories)`,
+		func(r *CompactReport) *int64 { return &r.Relationships }},
+	{`DELETE FROM memory_vectors WHERE memory_id NOT IN (SELECT id FROM memories)`,
+		func(r *CompactReport) *int64 { return &r.Vectors }},
+}
+
+// Compact permanently deletes revisions created before revisionsBefore (none
+// if it is zero) and orphaned rows, then vacuums the database file. Memories
+// themselves are never deleted; use PruneMemories for that.
+func (s *SQLiteStore) Compact(ctx context.Context, revisionsBefore time.Time) (*CompactReport, error) {
+	report := &CompactReport{}
+
+	var err error
+	if report.SizeBefore, err = s.size(ctx); err != nil {
+		return nil, fmt.Errorf("failed to measure database: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if !revisionsBefore.IsZero() {
+		result, err := tx.ExecContext(ctx, `DELETE FROM memory_revisions WHERE created_at < ?`, revisionsBefore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete revisions: %w", err)
+		}
+		if report.Revisions, err = result.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, orphans := range orphanQueries {
+		result, err := tx.ExecContext(ctx, orphans.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete orphaned rows: %w", err)
+		}
+		if *orphans.count(report), err = result.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := s.vacuum(ctx); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+
+	if report.SizeAfter, err = s.size(ctx); err != nil {
+		return nil, fmt.Errorf("failed to measure database: %w", err)
+	}
+
+	return report, nil
+}
+
+// vacuum returns free pages to the file system: incrementally when the
+// database was created with auto_vacuum = INCREMENTAL, by rebuilding the
+// file otherwise. Either fails with "database is locked" while another
+// connection is writing.
+func (s *SQLiteStore) vacuum(ctx context.Context) error {
+	var autoVacuum int
+	if err := s.queryRow(ctx, `PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+		return err
+	}
+
+	if autoVacuum == 2 {
+		_, err := s.exec(ctx, `PRAGMA incremental_vacuum`)
+		return err
+	}
+	if _, err := s.exec(ctx, `VACUUM`); err != nil {
+		return err
+	}
+
+	// VACUUM may renumber the rowids the full-text index refers to
+	_, err := s.exec(ctx, `INSERT INTO memories_fts (memories_fts) VALUES ('rebuild')`)
+	return err
+}
+
+// size returns the size of the database in bytes
+func (s *SQLiteStore) size(ctx context.Context) (int64, error) {
+	var pageCount, pageSize int64
+	if err := s.queryRow(ctx, `PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := s.queryRow(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
@@ -0,0 +1,26 @@
+package storage
+
+import "fmt"
+
+// DimensionMismatchError is returned by vector stores when a vector does not
+// have the dimension of the deployment's embedding model
+type DimensionMismatchError struct {
+	Op       string // "store" or "search"
+	Expected int
+	Actual   int
+}
+
+func (e *DimensionMismatchError) Error() string {
+	return fmt.Sprintf("vector dimension mismatch on %s: got %d, expected %d "+
+		"(did the embeddings model change? existing memories must be re-embedded)",
+		e.Op, e.Actual, e.Expected)
+}
+
+// checkDimension validates a vector against the expected dimension; an
+// expected dimension of 0 disables the check
+func checkDimension(op string, expected int, vector []float32) error {
+	if expected > 0 && len(vector) != expected {
+		return &DimensionMismatchError{Op: op, Expected: expected, Actual: len(vector)}
+	}
+	return nil
+}
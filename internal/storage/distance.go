@@ -0,0 +1,36 @@
+package storage
+
+import "github.com/weaviate/weaviate/entities/models"
+
+// DistanceMetric is how a vector store measures the distance between
+// vectors, named as in Weaviate's vectorIndexConfig
+type DistanceMetric string
+
+const (
+	// DistanceCosine is 1 - cosine similarity, from 0 to 2
+	DistanceCosine DistanceMetric = "cosine"
+	// DistanceDot is the negated dot product
+	DistanceDot DistanceMetric = "dot"
+	// DistanceL2Squared is the squared Euclidean distance
+	DistanceL2Squared DistanceMetric = "l2-squared"
+	// DistanceManhattan is the sum of absolute differences
+	DistanceManhattan DistanceMetric = "manhattan"
+	// DistanceHamming counts the dimensions that differ
+	DistanceHamming DistanceMetric = "hamming"
+)
+
+// classDistanceMetric reads the distance metric from a class's vector index
+// configuration; Weaviate uses cosine unless configured otherwise
+func classDistanceMetric(class *models.Class) DistanceMetric {
+	if class == nil {
+		return DistanceCosine
+	}
+	config, ok := class.VectorIndexConfig.(map[string]interface{})
+	if !ok {
+		return DistanceCosine
+	}
+	if distance, ok := config["distance"].(string); ok && distance != "" {
+		return DistanceMetric(distance)
+	}
+	return DistanceCosine
+}
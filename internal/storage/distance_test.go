@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/weaviate/weaviate/entities/models"
+)
+
+func TestClassDistanceMetric(t *testing.T) {
+	tests := []struct {
+		name  string
+		class *models.Class
+		want  DistanceMetric
+	}{
+		{"no class", nil, DistanceCosine},
+		{"no index config", &models.Class{}, DistanceCosine},
+		{"no distance", &models.Class{VectorIndexConfig: map[string]interface{}{"ef": 64}}, DistanceCosine},
+		{"empty distance", &models.Class{VectorIndexConfig: map[string]interface{}{"distance": ""}}, DistanceCosine},
+		{"cosine", &models.Class{VectorIndexConfig: map[string]interface{}{"distance": "cosine"}}, DistanceCosine},
+		{"dot", &models.Class{VectorIndexConfig: map[string]interface{}{"distance": "dot"}}, DistanceDot},
+		{"l2-squared", &models.Class{VectorIndexConfig: map[string]interface{}{"distance": "l2-squared"}}, DistanceL2Squared},
+		{"manhattan", &models.Class{VectorIndexConfig: map[string]interface{}{"distance": "manhattan"}}, DistanceManhattan},
+		{"unexpected config type", &models.Class{VectorIndexConfig: "hnsw"}, DistanceCosine},
+	}
+	for _, tt := range tests {
+		if got := classDistanceMetric(tt.class); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SearchContentLike returns memories whose content contains every word of
+// term, using the full-text index. Memories containing term verbatim
+// (ignoring case) come first, then more important ones. An empty projectID
+// searches every project. Archived memories are skipped unless
+// includeArchived is set.
+func (s *SQLiteStore) SearchContentLike(ctx context.Context, projectID, term string, limit int, includeArchived bool) ([]*Memory, error) {
+	match := fullTextQuery(term)
+	if match == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := `SELECT ` + memoryColumns + ` FROM memories
+		WHERE rowid IN (SELECT rowid FROM memories_fts WHERE memories_fts MATCH ?)`
+	args := []interface{}{match}
+	if projectID != "" {
+		query += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+	if !includeArchived {
+		query += ` AND archived = 0`
+	}
+	query += ` ORDER BY instr(lower(content), lower(?)) > 0 DESC, importance DESC, created_at DESC, id LIMIT ?`
+	args = append(args, strings.TrimSpace(term), limit)
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memories []*Memory
+	for rows.Next() {
+		memory, err := scanMemory(rows)
+		if err != nil {
+			return nil, err
+		}
+		memories = append(memories, memory)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadMemoryLists(ctx, memories); err != nil {
+		return nil, err
+	}
+	return memories, nil
+}
+
+// fullTextQuery turns free text into an FTS query requiring every word.
+// Each word is quoted so that operators and punctuation in it are taken
+// literally; "parse_config" becomes the phrase "parse config", matching the
+// tokens the index stores.
+func fullTextQuery(term string) string {
+	var words []string
+	for _, word := range strings.Fields(term) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if strings.IndexFunc(word, isWordRune) < 0 {
+			continue
+		}
+		words = append(words, `"`+word+`"`)
+	}
+	return strings.Join(words, " ")
+}
+
+func isWordRune(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127
+}
+
+// contentIndexSchema creates the full-text index over memories.content for
+// each module. The index stores no copy of the content, only tokens keyed by
+// the memory's rowid; triggers keep it in sync however memories are written
+// or deleted.
+var contentIndexSchema = map[string]string{
+	"fts5": `
+	CREATE VIRTUAL TABLE memories_fts USING fts5(content, content='memories', content_rowid='rowid');
+
+	CREATE TRIGGER memories_fts_ai AFTER INSERT ON memories BEGIN
+		INSERT INTO memories_fts (rowid, content) VALUES (new.rowid, new.content);
+	END;
+	CREATE TRIGGER memories_fts_ad AFTER DELETE ON memories BEGIN
+		INSERT INTO memories_fts (memories_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+	END;
+	CREATE TRIGGER memories_fts_au AFTER UPDATE OF content ON memories BEGIN
+		INSERT INTO memories_fts (memories_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+		INSERT INTO memories_fts (rowid, content) VALUES (new.rowid, new.content);
+	END;
+	`,
+	"fts4": `
+	CREATE VIRTUAL TABLE memories_fts USING fts4(content, content="memories");
+
+	CREATE TRIGGER memories_fts_bu BEFORE UPDATE OF content ON memories BEGIN
+		DELETE FROM memories_fts WHERE docid = old.rowid;
+	END;
+	CREATE TRIGGER memories_fts_bd BEFORE DELETE ON memories BEGIN
+		DELETE FROM memories_fts WHERE docid = old.rowid;
+	END;
+	CREATE TRIGGER memories_fts_au AFTER UPDATE OF content ON memories BEGIN
+		INSERT INTO memories_fts (docid, content) VALUES (new.rowid, new.content);
+	END;
+	CREATE TRIGGER memories_fts_ai AFTER INSERT ON memories BEGIN
+		INSERT INTO memories_fts (docid, content) VALUES (new.rowid, new.content);
+	END;
+	`,
+}
+
+// createContentIndex creates the full-text index with the given module and
+// fills it from the memories already stored
+func createContentIndex(tx *sql.Tx, module string) error {
+	if _, err := tx.Exec(contentIndexSchema[module]); err != nil {
+		return fmt.Errorf("failed to create %s index: %w", module, err)
+	}
+	_, err := tx.Exec(`INSERT INTO memories_fts (memories_fts) VALUES ('rebuild')`)
+	return err
+}
+
+// contentIndexModule returns the module of the existing full-text index,
+// or "" if there is none
+func contentIndexModule(db *sql.DB) (string, error) {
+	var schema string
+	err := db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'memories_fts'`).Scan(&schema)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	schema = strings.ToLower(schema)
+	for module := range contentIndexSchema {
+		if strings.Contains(schema, "using "+module) {
+			return module, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized full-text index: %s", schema)
+}
+
+// syncFullTextIndex rebuilds the full-text index with this binary's module
+// when a binary built with the other one created it, e.g. an FTS4 index
+// from a build without the sqlite_fts5 tag
+func (s *SQLiteStore) syncFullTextIndex() error {
+	current, err := contentIndexModule(s.db)
+	if err != nil || current == "" || current == fullTextModule {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+	DROP TRIGGER IF EXISTS memories_fts_ai;
+	DROP TRIGGER IF EXISTS memories_fts_ad;
+	DROP TRIGGER IF EXISTS memories_fts_au;
+	DROP TRIGGER IF EXISTS memories_fts_bu;
+	DROP TRIGGER IF EXISTS memories_fts_bd;
+	DROP TABLE memories_fts;
+	`); err != nil {
+		return err
+	}
+	if err := createContentIndex(tx, fullTextModule); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.logger.Info("converted full-text index", "from", current, "to", fullTextModule)
+	return nil
+}
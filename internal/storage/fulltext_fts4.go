@@ -0,0 +1,8 @@
+//go:build !sqlite_fts5 && !fts5
+
+package storage
+
+// fullTextModule is the SQLite module behind the full-text index. Without
+// the sqlite_fts5 build tag go-sqlite3 has no FTS5, so the index falls back
+// to FTS4, which it always includes.
+const fullTextModule = "fts4"
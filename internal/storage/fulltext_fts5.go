@@ -0,0 +1,7 @@
+//go:build sqlite_fts5 || fts5
+
+package storage
+
+// fullTextModule is the SQLite module behind the full-text index. The
+// sqlite_fts5 build tag compiles FTS5 into go-sqlite3.
+const fullTextModule = "fts5"
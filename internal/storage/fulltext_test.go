@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// searchIDs runs SearchContentLike in the test project and returns the IDs
+func searchIDs(t *testing.T, store *SQLiteStore, projectID, term string) []string {
+	t.Helper()
+	memories, err := store.SearchContentLike(context.Background(), projectID, term, 10, false)
+	if err != nil {
+		t.Fatalf("SearchContentLike(%q): %v", term, err)
+	}
+	ids := make([]string, len(memories))
+	for i, mem := range memories {
+		ids[i] = mem.ID
+	}
+	return ids
+}
+
+func TestFullTextIndexFollowsWrites(t *testing.T) {
+	store, projectID := newTestStore(t)
+	ctx := context.Background()
+
+	for _, mem := range []*Memory{
+		{ID: "init", ProjectID: projectID, Content: "initWeaviateStore retries the schema check", Importance: 0.5},
+		{ID: "cache", ProjectID: projectID, Content: "The cache is warmed on startup", Importance: 0.9},
+	} {
+		if err := store.CreateMemory(ctx, mem); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if ids := searchIDs(t, store, projectID, "initWeaviateStore"); len(ids) != 1 || ids[0] != "init" {
+		t.Errorf("identifier search = %v, want [init]", ids)
+	}
+
+	// Updated content is indexed and the old content is gone
+	cache, err := store.GetMemory(ctx, "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Content = "The cache is cold after deploys"
+	cache.UpdatedAt = time.Now()
+	if err := store.UpdateMemory(ctx, cache); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, store, projectID, "warmed"); len(ids) != 0 {
+		t.Errorf("old content still matches: %v", ids)
+	}
+	if ids := searchIDs(t, store, projectID, "deploys"); len(ids) != 1 || ids[0] != "cache" {
+		t.Errorf("new content search = %v, want [cache]", ids)
+	}
+
+	if _, err := store.DeleteMemories(ctx, []string{"init"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, store, projectID, "initWeaviateStore"); len(ids) != 0 {
+		t.Errorf("deleted memory still matches: %v", ids)
+	}
+}
+
+func TestFullTextQueryIsLiteral(t *testing.T) {
+	store, projectID := newTestStore(t)
+	ctx := context.Background()
+	if err := store.CreateMemory(ctx, &Memory{ID: "m", ProjectID: projectID, Content: "parse_config reads NOT-NULL columns OR rows", Importance: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Operators and punctuation must not reach the query parser as syntax
+	for _, term := range []string{`parse_config`, `NOT-NULL`, `"parse_config"`, `columns OR`, `reads*`, `(columns`} {
+		if ids := searchIDs(t, store, projectID, term); len(ids) != 1 {
+			t.Errorf("search %q = %v, want [m]", term, ids)
+		}
+	}
+	if ids := searchIDs(t, store, projectID, `" * "`); len(ids) != 0 {
+		t.Errorf("punctuation-only search = %v, want nothing", ids)
+	}
+}
+
+func TestFullTextIndexUsesBuildModule(t *testing.T) {
+	store, _ := newTestStore(t)
+	module, err := contentIndexModule(store.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if module != fullTextModule {
+		t.Errorf("index module = %q, want %q", module, fullTextModule)
+	}
+}
+
+func TestFullTextIndexConvertedFromFTS4(t *testing.T) {
+	if fullTextModule == "fts4" {
+		t.Skip("converting to FTS5 needs the sqlite_fts5 build tag")
+	}
+
+	path := filepath.Join(t.TempDir(), "alaala.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	project := &Project{ID: "project", Name: "test", Path: t.TempDir()}
+	if err := store.CreateProject(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateMemory(ctx, &Memory{ID: "m", ProjectID: project.ID, Content: "Rotate the signing key monthly", Importance: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the index with the one a build without the tag creates
+	tx, err := store.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`
+	DROP TRIGGER memories_fts_ai;
+	DROP TRIGGER memories_fts_ad;
+	DROP TRIGGER memories_fts_au;
+	DROP TABLE memories_fts;
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if err := createContentIndex(tx, "fts4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer store.Close()
+
+	if module, err := contentIndexModule(store.db); err != nil || module != "fts5" {
+		t.Fatalf("index module = %q (%v), want fts5", module, err)
+	}
+	if ids := searchIDs(t, store, project.ID, "signing key"); len(ids) != 1 {
+		t.Errorf("existing memory not found after conversion: %v", ids)
+	}
+	if err := store.CreateMemory(ctx, &Memory{ID: "n", ProjectID: project.ID, Content: "Signing happens in CI", Importance: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, store, project.ID, "signing"); len(ids) != 2 {
+		t.Errorf("search after conversion = %v, want both memories", ids)
+	}
+}
@@ -1,5 +1,41 @@
 package storage
 
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// TraversalDirection selects which relationships graph traversal follows,
+// relative to the memory it is currently at
+type TraversalDirection string
+
+const (
+	DirectionOutgoing TraversalDirection = "outgoing" // Follow from_memory_id -> to_memory_id
+	DirectionIncoming TraversalDirection = "incoming" // Follow to_memory_id -> from_memory_id
+	DirectionBoth     TraversalDirection = "both"     // Follow both, except forward-only types backwards
+)
+
+// ParseTraversalDirection validates a direction name; an empty name means both
+func ParseTraversalDirection(s string) (TraversalDirection, error) {
+	switch d := TraversalDirection(s); d {
+	case "":
+		return DirectionBoth, nil
+	case DirectionOutgoing, DirectionIncoming, DirectionBoth:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown traversal direction %q (valid: outgoing, incoming, both)", s)
+	}
+}
+
+// forwardOnlyTypes are relationships whose reverse is not meaningful context:
+// an old memory superseded by a new one, or a memory merely referenced by
+// another. Traversing both directions follows them outgoing only.
+var forwardOnlyTypes = map[string]bool{
+	"supersedes": true,
+	"references": true,
+}
+
 // GraphTraverser handles memory relationship traversal
 type GraphTraverser struct {
 	sqlStore *SQLiteStore
@@ -12,50 +48,96 @@ func NewGraphTraverser(sqlStore *SQLiteStore) *GraphTraverser {
 	}
 }
 
+// ExpandedMemory is a memory reached by graph traversal
+type ExpandedMemory struct {
+	ID               string
+	Depth            int                // Number of hops from the seed
+	SeedID           string             // Seed memory the traversal started from
+	ViaID            string             // Memory it was reached from, one hop closer to the seed
+	RelationshipType string             // Type of the relationship that reached it
+	Direction        TraversalDirection // Outgoing if ViaID is the relationship's source, else incoming
+}
+
 // ExpandMemories performs BFS traversal of memory relationships
 // Returns additional memory IDs to include, up to the specified depth
-func (g *GraphTraverser) ExpandMemories(seedIDs []string, depth int) ([]string, error) {
-	if depth == 0 || len(seedIDs) == 0 {
-		return []string{}, nil
+func (g *GraphTraverser) ExpandMemories(ctx context.Context, seedIDs []string, depth int, direction TraversalDirection) ([]string, error) {
+	expanded, err := g.Expand(ctx, seedIDs, depth, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(expanded))
+	for i, e := range expanded {
+		result[i] = e.ID
+	}
+	return result, nil
+}
+
+// Expand performs BFS traversal of memory relationships like ExpandMemories,
+// reporting how each memory was reached from the seed that reached it first.
+// Self-referencing relationships are ignored. If a level cannot be read, the
+// memories reached before it are returned with the error.
+func (g *GraphTraverser) Expand(ctx context.Context, seedIDs []string, depth int, direction TraversalDirection) ([]ExpandedMemory, error) {
+	if depth <= 0 || len(seedIDs) == 0 {
+		return []ExpandedMemory{}, nil
+	}
+	if direction == "" {
+		direction = DirectionBoth
 	}
 
 	visited := make(map[string]bool)
-	var result []string
+	seedOf := make(map[string]string)
+	var result []ExpandedMemory
 
 	// Mark seed IDs as visited
 	for _, id := range seedIDs {
 		visited[id] = true
+		seedOf[id] = id
 	}
 
-	// BFS traversal
+	// BFS traversal, one query per level
 	currentLevel := seedIDs
-	for currentDepth := 0; currentDepth < depth; currentDepth++ {
+	for currentDepth := 1; currentDepth <= depth; currentDepth++ {
 		if len(currentLevel) == 0 {
 			break
 		}
 
-		var nextLevel []string
+		// A level touching more than maxRelationshipLimit relationships is
+		// expanded through the oldest of them only
+		page, err := g.sqlStore.GetNeighborhood(ctx, currentLevel, maxRelationshipLimit)
+		if err != nil {
+			return result, fmt.Errorf("failed to expand level %d: %w", currentDepth, err)
+		}
 
-		// Get relationships for all IDs in current level
-		for _, memID := range currentLevel {
-			rels, err := g.sqlStore.GetRelationships(memID)
-			if err != nil {
-				continue // Skip on error, don't fail entire traversal
+		// Visit the level's memories in order, so that a memory reachable
+		// from two of them is credited to the earlier one
+		position := make(map[string]int, len(currentLevel))
+		for i, id := range currentLevel {
+			position[id] = i
+		}
+		edges := page.Edges
+		sort.SliceStable(edges, func(i, j int) bool {
+			return position[edges[i].MemoryID] < position[edges[j].MemoryID]
+		})
+
+		var nextLevel []string
+		for _, edge := range edges {
+			if edge.OtherID == edge.MemoryID || !follows(direction, edge.Direction, edge.RelationshipType) {
+				continue
 			}
 
-			for _, rel := range rels {
-				// Add related memory if not visited
-				relatedID := rel.ToMemoryID
-				if relatedID == memID {
-					// This is an incoming relationship, use FromMemoryID
-					relatedID = rel.FromMemoryID
-				}
-
-				if !visited[relatedID] {
-					visited[relatedID] = true
-					result = append(result, relatedID)
-					nextLevel = append(nextLevel, relatedID)
-				}
+			if !visited[edge.OtherID] {
+				visited[edge.OtherID] = true
+				seedOf[edge.OtherID] = seedOf[edge.MemoryID]
+				result = append(result, ExpandedMemory{
+					ID:               edge.OtherID,
+					Depth:            currentDepth,
+					SeedID:           seedOf[edge.MemoryID],
+					ViaID:            edge.MemoryID,
+					RelationshipType: edge.RelationshipType,
+					Direction:        edge.Direction,
+				})
+				nextLevel = append(nextLevel, edge.OtherID)
 			}
 		}
 
@@ -64,3 +146,14 @@ func (g *GraphTraverser) ExpandMemories(seedIDs []string, depth int) ([]string,
 
 	return result, nil
 }
+
+// follows reports whether a traversal in the given direction follows a
+// relationship lying in relDirection
+func follows(direction, relDirection TraversalDirection, relType string) bool {
+	switch direction {
+	case DirectionOutgoing, DirectionIncoming:
+		return relDirection == direction
+	default:
+		return relDirection == DirectionOutgoing || !forwardOnlyTypes[relType]
+	}
+}
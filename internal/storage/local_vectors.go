@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocalVectorStore keeps vectors in the SQLite database and searches them by
+// brute force. It needs no external services, which makes it the fallback
+// when Weaviate is unavailable; it is practical up to tens of thousands of
+// memories. Filters are evaluated against the memories table, so they always
+// reflect the current metadata.
+type LocalVectorStore struct {
+	db        *sql.DB
+	store     *SQLiteStore // Times statements for the slow query log
+	dimension int
+}
+
+// Vector is a memory's embedding with the content and metadata stored
+// alongside it
+type Vector struct {
+	ID        string
+	Content   string
+	Embedding []float32
+	Metadata  map[string]interface{}
+}
+
+// NewLocalVectorStore creates a vector store backed by the given SQLite store
+func NewLocalVectorStore(sqlStore *SQLiteStore) *LocalVectorStore {
+	return &LocalVectorStore{db: sqlStore.db, store: sqlStore}
+}
+
+// SetDimension sets the vector dimension that Store and Search accept.
+// Vectors of any other size are rejected with a DimensionMismatchError.
+func (l *LocalVectorStore) SetDimension(dimension int) {
+	l.dimension = dimension
+}
+
+// DistanceMetric returns DistanceCosine, the metric Search ranks by
+func (l *LocalVectorStore) DistanceMetric() DistanceMetric {
+	return DistanceCosine
+}
+
+// Store stores or replaces a memory's vector
+func (l *LocalVectorStore) Store(ctx context.Context, id string, content string, embedding []float32, metadata map[string]interface{}) error {
+	if err := checkDimension("store", l.dimension, embedding); err != nil {
+		return err
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	_, err = l.store.exec(ctx, `
+		INSERT OR REPLACE INTO memory_vectors (memory_id, content, metadata, embedding)
+		VALUES (?, ?, ?, ?)
+	`, id, content, string(metadataJSON), encodeVector(embedding))
+	if err != nil {
+		return fmt.Errorf("failed to store vector: %w", err)
+	}
+
+	return nil
+}
+
+// StoreBatch stores or replaces many vectors in a single transaction
+func (l *LocalVectorStore) StoreBatch(ctx context.Context, vectors []Vector) error {
+	for _, v := range vectors {
+		if err := checkDimension("store", l.dimension, v.Embedding); err != nil {
+			return err
+		}
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO memory_vectors (memory_id, content, metadata, embedding)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, v := range vectors {
+		metadataJSON, err := json.Marshal(v.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of %s: %w", v.ID, err)
+		}
+		if _, err := stmt.ExecContext(ctx, v.ID, v.Content, string(metadataJSON), encodeVector(v.Embedding)); err != nil {
+			return fmt.Errorf("failed to store vector %s: %w", v.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit vectors: %w", err)
+	}
+	return nil
+}
+
+// Search returns the vectors closest to embedding by cosine distance. It
+// supports the same filters as WeaviateStore.Search. Stored vectors of a
+// different dimension (from an earlier embedding model) are skipped.
+func (l *LocalVectorStore) Search(ctx context.Context, embedding []float32, limit int, filterMap map[string]interface{}) ([]VectorSearchResult, error) {
+	if err := checkDimension("search", l.dimension, embedding); err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if projectID, ok := filterMap["project_id"].(string); ok && projectID != "" {
+		conditions = append(conditions, "m.project_id = ?")
+		args = append(args, projectID)
+	}
+	if workspaceID, ok := filterMap["workspace_id"].(string); ok && workspaceID != "" {
+		conditions = append(conditions, "m.project_id IN (SELECT id FROM projects WHERE workspace_id = ?)")
+		args = append(args, workspaceID)
+	}
+	if minImp, ok := filterMap["importance_gte"].(float64); ok {
+		conditions = append(conditions, "m.importance >= ?")
+		args = append(args, minImp)
+	}
+	if contextTypes, ok := filterMap["context_types"].([]string); ok && len(contextTypes) > 0 {
+		conditions = append(conditions, "m.context_type IN (?"+strings.Repeat(", ?", len(contextTypes)-1)+")")
+		for _, ct := range contextTypes {
+			args = append(args, ct)
+		}
+	}
+	if tags, ok := filterMap["tags"].([]string); ok && len(tags) > 0 {
+		in := "t.tag IN (?" + strings.Repeat(", ?", len(tags)-1) + ")"
+		if filterMap["tags_match"] == "all" {
+			conditions = append(conditions, "(SELECT COUNT(DISTINCT t.tag) FROM memory_tags t WHERE t.memory_id = m.id AND "+
+				in+") = ?")
+		} else {
+			conditions = append(conditions, "EXISTS (SELECT 1 FROM memory_tags t WHERE t.memory_id = m.id AND "+in+")")
+		}
+		distinct := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			args = append(args, tag)
+			distinct[tag] = true
+		}
+		if filterMap["tags_match"] == "all" {
+			args = append(args, len(distinct))
+		}
+	}
+	if after, ok := filterMap["created_after"].(int64); ok {
+		conditions = append(conditions, "m.created_at >= ?")
+		args = append(args, time.Unix(after, 0))
+	}
+	if before, ok := filterMap["created_before"].(int64); ok {
+		conditions = append(conditions, "m.created_at < ?")
+		args = append(args, time.Unix(before, 0))
+	}
+
+	query := `SELECT v.memory_id, v.embedding FROM memory_vectors v JOIN memories m ON m.id = v.memory_id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := l.store.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("local vector query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []VectorSearchResult
+	for rows.Next() {
+		var id string
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, err
+		}
+
+		stored := decodeVector(blob)
+		if len(stored) != len(embedding) {
+			continue
+		}
+
+		results = append(results, VectorSearchResult{
+			ID:       id,
+			Distance: CosineDistance(embedding, stored),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Distance < results[j].Distance
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// Vectors returns the stored vectors of the given memories by ID; memories
+// without a vector are left out
+func (l *LocalVectorStore) Vectors(ctx context.Context, ids []string) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(ids))
+	if len(ids) == 0 {
+		return vectors, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := l.store.query(ctx, `SELECT memory_id, embedding FROM memory_vectors WHERE memory_id IN (?`+
+		strings.Repeat(", ?", len(ids)-1)+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("local vector query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, err
+		}
+		vectors[id] = decodeVector(blob)
+	}
+	return vectors, rows.Err()
+}
+
+// Delete deletes a memory's vector
+func (l *LocalVectorStore) Delete(ctx context.Context, id string) error {
+	if _, err := l.store.exec(ctx, `DELETE FROM memory_vectors WHERE memory_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete vector: %w", err)
+	}
+	return nil
+}
+
+// DeleteBatch deletes many vectors, returning how many existed
+func (l *LocalVectorStore) DeleteBatch(ctx context.Context, ids []string) (int, error) {
+	deleted := 0
+	for _, id := range ids {
+		result, err := l.store.exec(ctx, `DELETE FROM memory_vectors WHERE memory_id = ?`, id)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete vector: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += int(n)
+	}
+	return deleted, nil
+}
+
+// Count returns the number of stored vectors
+func (l *LocalVectorStore) Count(ctx context.Context) (int, error) {
+	var count int
+	err := l.store.queryRow(ctx, `SELECT COUNT(*) FROM memory_vectors`).Scan(&count)
+	return count, err
+}
+
+// All returns every stored vector, e.g. to move them into another store
+func (l *LocalVectorStore) All(ctx context.Context) ([]Vector, error) {
+	rows, err := l.store.query(ctx, `SELECT memory_id, content, metadata, embedding FROM memory_vectors`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vectors []Vector
+	for rows.Next() {
+		var v Vector
+		var metadataJSON string
+		var blob []byte
+		if err := rows.Scan(&v.ID, &v.Content, &metadataJSON, &blob); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(metadataJSON), &v.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of %s: %w", v.ID, err)
+		}
+		v.Embedding = decodeVector(blob)
+		vectors = append(vectors, v)
+	}
+
+	return vectors, rows.Err()
+}
+
+// encodeVector packs a vector as little-endian float32s
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector
+}
+
+// CosineDistance returns 1 - cosine similarity, matching Weaviate's default
+// distance metric. Zero vectors are treated as maximally distant.
+func CosineDistance(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration upgrades the schema by one version. Each runs in its own
+// transaction together with the bump of the recorded version.
+type migration struct {
+	version int
+	name    string
+	apply   func(tx *sql.Tx) error
+}
+
+// migrations lists every schema change in order. Append new ones with the
+// next version; never edit one that has shipped.
+var migrations = []migration{
+	{1, "initial schema", migrateInitialSchema},
+	{2, "session transcripts", migrateSessionTranscripts},
+	{3, "memory access tracking", migrateMemoryAccess},
+	{4, "memory content full-text index", migrateContentIndex},
+	{5, "memory archive", migrateMemoryArchive},
+	{6, "relationship target index", migrateRelationshipTargetIndex},
+	{7, "importance decay tracking", migrateImportanceDecay},
+	{8, "pending vector deletes", migratePendingVectorDeletes},
+}
+
+// migrate applies the migrations newer than the database's version
+func (s *SQLiteStore) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at DATETIME NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	if latest := migrations[len(migrations)-1].version; current > latest {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d)", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		s.logger.Info("applied schema migration", "version", m.version, "name", m.name)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) applyMigration(m migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.apply(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+		m.version, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrateInitialSchema creates the schema as it was when migrations were
+// introduced. Databases created before then already have some of it, so
+// tables are created only if missing and later columns are added if absent.
+func migrateInitialSchema(tx *sql.Tx) error {
+	schema := `
+	-- Workspaces group related projects (e.g. one customer's repositories)
+	CREATE TABLE IF NOT EXISTS workspaces (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL
+	);
+
+	-- Projects table
+	CREATE TABLE IF NOT EXISTS projects (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		path TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		workspace_id TEXT REFERENCES workspaces(id) ON DELETE SET NULL
+	);
+
+	-- Sessions table
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		ended_at DATETIME,
+		duration_seconds INTEGER,
+		summary TEXT,
+		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+	);
+
+	-- Memories table (metadata only, vectors in Weaviate)
+	CREATE TABLE IF NOT EXISTS memories (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		session_id TEXT,
+		content TEXT NOT NULL,
+		importance REAL NOT NULL DEFAULT 0.5,
+		context_type TEXT,
+		temporal_relevance TEXT,
+		action_required BOOLEAN DEFAULT FALSE,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		embedder_id TEXT,
+		reasoning TEXT,
+		importance_method TEXT,
+		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE SET NULL
+	);
+
+	-- Memory tags (many-to-many)
+	CREATE TABLE IF NOT EXISTS memory_tags (
+		memory_id TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (memory_id, tag),
+		FOREIGN KEY (memory_id) REFERENCES memories(id) ON DELETE CASCADE
+	);
+
+	-- Memory trigger phrases
+	CREATE TABLE IF NOT EXISTS memory_triggers (
+		memory_id TEXT NOT NULL,
+		phrase TEXT NOT NULL,
+		PRIMARY KEY (memory_id, phrase),
+		FOREIGN KEY (memory_id) REFERENCES memories(id) ON DELETE CASCADE
+	);
+
+	-- Memory relationships (graph)
+	CREATE TABLE IF NOT EXISTS memory_relationships (
+		from_memory_id TEXT NOT NULL,
+		to_memory_id TEXT NOT NULL,
+		relationship_type TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (from_memory_id, to_memory_id, relationship_type),
+		FOREIGN KEY (from_memory_id) REFERENCES memories(id) ON DELETE CASCADE,
+		FOREIGN KEY (to_memory_id) REFERENCES memories(id) ON DELETE CASCADE
+	);
+
+	-- Memory revisions (content history)
+	CREATE TABLE IF NOT EXISTS memory_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		memory_id TEXT NOT NULL,
+		previous_content TEXT NOT NULL,
+		diff TEXT NOT NULL,
+		reason TEXT,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (memory_id) REFERENCES memories(id) ON DELETE CASCADE
+	);
+
+	-- Vectors for the SQLite-backed LocalVectorStore (used when Weaviate is unavailable)
+	CREATE TABLE IF NOT EXISTS memory_vectors (
+		memory_id TEXT PRIMARY KEY,
+		content TEXT NOT NULL,
+		metadata TEXT NOT NULL,
+		embedding BLOB NOT NULL,
+		FOREIGN KEY (memory_id) REFERENCES memories(id) ON DELETE CASCADE
+	);
+
+	-- AI usage ledger (only written when ai.track_usage is enabled)
+	CREATE TABLE IF NOT EXISTS ai_usage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider TEXT NOT NULL,
+		model TEXT NOT NULL,
+		prompt_tokens INTEGER NOT NULL,
+		completion_tokens INTEGER NOT NULL,
+		cost REAL NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+
+	-- Indexes for performance
+	CREATE INDEX IF NOT EXISTS idx_memories_project ON memories(project_id);
+	CREATE INDEX IF NOT EXISTS idx_memories_session ON memories(session_id);
+	CREATE INDEX IF NOT EXISTS idx_memories_importance ON memories(importance);
+	CREATE INDEX IF NOT EXISTS idx_memories_created ON memories(created_at);
+	CREATE INDEX IF NOT EXISTS idx_sessions_project ON sessions(project_id);
+	CREATE INDEX IF NOT EXISTS idx_sessions_started ON sessions(started_at);
+	CREATE INDEX IF NOT EXISTS idx_revisions_memory ON memory_revisions(memory_id);
+	CREATE INDEX IF NOT EXISTS idx_ai_usage_created ON ai_usage(created_at);
+	`
+
+	if _, err := tx.Exec(schema); err != nil {
+		return err
+	}
+
+	// Columns added after the first release
+	for _, col := range []struct{ table, column, definition string }{
+		{"projects", "workspace_id", "TEXT REFERENCES workspaces(id) ON DELETE SET NULL"},
+		{"memories", "embedder_id", "TEXT"},
+		{"memories", "reasoning", "TEXT"},
+		{"memories", "importance_method", "TEXT"},
+		{"sessions", "summary", "TEXT"},
+	} {
+		if err := ensureColumn(tx, col.table, col.column, col.definition); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_projects_workspace ON projects(workspace_id)`)
+	return err
+}
+
+// ensureColumn adds a column to a table created by an older version
+func ensureColumn(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
+// migrateSessionTranscripts adds the table append_transcript accumulates
+// session transcripts in
+func migrateSessionTranscripts(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE session_transcripts (
+		session_id TEXT PRIMARY KEY,
+		transcript TEXT NOT NULL,
+		curated_offset INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	)`)
+	return err
+}
+
+// migrateMemoryAccess adds the counters TouchMemories maintains
+func migrateMemoryAccess(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	ALTER TABLE memories ADD COLUMN access_count INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE memories ADD COLUMN last_accessed_at DATETIME;
+	`)
+	return err
+}
+
+// migrateContentIndex adds the full-text index SearchContentLike queries,
+// using the module this binary was built with. syncFullTextIndex converts an
+// index a binary with the other module created.
+func migrateContentIndex(tx *sql.Tx) error {
+	return createContentIndex(tx, fullTextModule)
+}
+
+// migrateMemoryArchive adds the archive state. Archived memories keep their
+// row but lose their vector, and are left out of listings and searches
+// unless asked for.
+func migrateMemoryArchive(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	ALTER TABLE memories ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE memories ADD COLUMN archived_at DATETIME;
+	CREATE INDEX idx_memories_archived ON memories(project_id, archived);
+	`)
+	return err
+}
+
+// migrateRelationshipTargetIndex indexes relationships by target so that
+// GetIncoming and GetNeighborhood do not scan the table; the primary key
+// already covers lookups by source
+func migrateRelationshipTargetIndex(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE INDEX idx_relationships_to ON memory_relationships(to_memory_id, relationship_type)`)
+	return err
+}
+
+// migrateImportanceDecay records when DecayImportance last lowered a
+// memory's importance, so that running it again only decays the time since
+func migrateImportanceDecay(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE memories ADD COLUMN importance_decayed_at DATETIME`)
+	return err
+}
+
+// migratePendingVectorDeletes adds the queue of vectors whose delete failed
+// after their memory was already gone from SQLite
+func migratePendingVectorDeletes(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+	CREATE TABLE pending_vector_deletes (
+		memory_id TEXT PRIMARY KEY,
+		attempts INTEGER NOT NULL DEFAULT 1,
+		last_error TEXT,
+		queued_at DATETIME NOT NULL
+	)`)
+	return err
+}
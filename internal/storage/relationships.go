@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Relationship lookups return at most maxRelationshipLimit relationships,
+// defaultRelationshipLimit unless asked for fewer or more
+const (
+	defaultRelationshipLimit = 100
+	maxRelationshipLimit     = 1000
+)
+
+// Edge is a relationship as seen from one of its memories
+type Edge struct {
+	MemoryRelationship
+	MemoryID  string             // Memory the relationship was looked up from
+	OtherID   string             // Memory at the other end
+	Direction TraversalDirection // Outgoing if MemoryID is the source, else incoming
+}
+
+// EdgePage is a page of relationships and how many match in total
+type EdgePage struct {
+	Edges []Edge
+	Total int // Matching relationships, including those past the page
+}
+
+// edgeColumns selects a relationship and the memory it is seen from
+const edgeColumns = `from_memory_id, to_memory_id, relationship_type, created_at`
+
+// GetOutgoing returns a page of the relationships pointing away from a
+// memory, oldest first, optionally only those of the given types. A limit
+// of 0 or less returns up to 100; no more than 1000 are returned at once.
+func (s *SQLiteStore) GetOutgoing(ctx context.Context, id string, limit, offset int, types ...string) (*EdgePage, error) {
+	typeFilter, typeArgs := relationshipTypeFilter(types)
+	args := append([]interface{}{id}, typeArgs...)
+
+	return s.edgePage(ctx, `SELECT `+edgeColumns+`, from_memory_id AS memory_id
+		FROM memory_relationships WHERE from_memory_id = ?`+typeFilter, args, limit, offset)
+}
+
+// GetIncoming returns a page of the relationships pointing to a memory,
+// like GetOutgoing. A relationship of a memory to itself counts as
+// outgoing only.
+func (s *SQLiteStore) GetIncoming(ctx context.Context, id string, limit, offset int, types ...string) (*EdgePage, error) {
+	typeFilter, typeArgs := relationshipTypeFilter(types)
+	args := append([]interface{}{id, id}, typeArgs...)
+
+	return s.edgePage(ctx, `SELECT `+edgeColumns+`, to_memory_id AS memory_id
+		FROM memory_relationships WHERE to_memory_id = ? AND from_memory_id != ?`+typeFilter, args, limit, offset)
+}
+
+// GetNeighborhood returns the relationships touching any of the memories,
+// each once, seen from its source if that is one of them and from its
+// target otherwise. Limits work as for GetOutgoing.
+func (s *SQLiteStore) GetNeighborhood(ctx context.Context, ids []string, limit int, types ...string) (*EdgePage, error) {
+	if len(ids) == 0 {
+		return &EdgePage{}, nil
+	}
+
+	in := "(?" + strings.Repeat(", ?", len(ids)-1) + ")"
+	idArgs := make([]interface{}, len(ids))
+	for i, id := range ids {
+		idArgs[i] = id
+	}
+	typeFilter, typeArgs := relationshipTypeFilter(types)
+
+	var args []interface{}
+	args = append(args, idArgs...)
+	args = append(args, typeArgs...)
+	args = append(args, idArgs...)
+	args = append(args, idArgs...)
+	args = append(args, typeArgs...)
+
+	// Each arm can use an index: the primary key for sources, the target
+	// index for targets
+	return s.edgePage(ctx, `SELECT `+edgeColumns+`, from_memory_id AS memory_id
+		FROM memory_relationships WHERE from_memory_id IN `+in+typeFilter+`
+		UNION ALL
+		SELECT `+edgeColumns+`, to_memory_id AS memory_id
+		FROM memory_relationships WHERE to_memory_id IN `+in+` AND from_memory_id NOT IN `+in+typeFilter,
+		args, limit, 0)
+}
+
+// edgePage counts the relationships selected by edges and returns a page of
+// them. edges must select edgeColumns followed by memory_id.
+func (s *SQLiteStore) edgePage(ctx context.Context, edges string, args []interface{}, limit, offset int) (*EdgePage, error) {
+	if limit <= 0 {
+		limit = defaultRelationshipLimit
+	}
+	if limit > maxRelationshipLimit {
+		limit = maxRelationshipLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	page := &EdgePage{}
+	if err := s.queryRow(ctx, `SELECT COUNT(*) FROM (`+edges+`)`, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+	if page.Total <= offset {
+		return page, nil
+	}
+
+	rows, err := s.query(ctx, `SELECT * FROM (`+edges+`)
+		ORDER BY created_at, from_memory_id, to_memory_id, relationship_type
+		LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var edge Edge
+		if err := rows.Scan(&edge.FromMemoryID, &edge.ToMemoryID, &edge.RelationshipType,
+			&edge.CreatedAt, &edge.MemoryID); err != nil {
+			return nil, err
+		}
+		if edge.MemoryID == edge.FromMemoryID {
+			edge.OtherID, edge.Direction = edge.ToMemoryID, DirectionOutgoing
+		} else {
+			edge.OtherID, edge.Direction = edge.FromMemoryID, DirectionIncoming
+		}
+		page.Edges = append(page.Edges, edge)
+	}
+
+	return page, rows.Err()
+}
+
+// GetRelationshipsSince returns a page of the relationships created at or
+// after since, seen from their source, oldest first, optionally only those
+// of the given types
+func (s *SQLiteStore) GetRelationshipsSince(ctx context.Context, since time.Time, limit int, types ...string) (*EdgePage, error) {
+	typeFilter, typeArgs := relationshipTypeFilter(types)
+	args := append([]interface{}{since}, typeArgs...)
+	return s.edgePage(ctx, `SELECT `+edgeColumns+`, from_memory_id AS memory_id
+		FROM memory_relationships WHERE created_at >= ?`+typeFilter, args, limit, 0)
+}
+
+// relationshipTypeFilter restricts a relationship query to the given types,
+// if any
+func relationshipTypeFilter(types []string) (string, []interface{}) {
+	if len(types) == 0 {
+		return "", nil
+	}
+
+	args := make([]interface{}, len(types))
+	for i, t := range types {
+		args[i] = t
+	}
+	return " AND relationship_type IN (?" + strings.Repeat(", ?", len(types)-1) + ")", args
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// The Restore methods store records from a backup as they were exported,
+// keeping their IDs and timestamps. A record whose ID already exists is
+// overwritten; callers that want to keep existing records check first.
+
+// RestoreWorkspace stores a workspace from a backup
+func (s *SQLiteStore) RestoreWorkspace(ctx context.Context, workspace *Workspace) error {
+	_, err := s.exec(ctx, `
+		INSERT INTO workspaces (id, name, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, created_at = excluded.created_at
+	`, workspace.ID, workspace.Name, workspace.CreatedAt)
+
+	return err
+}
+
+// RestoreProject stores a project from a backup
+func (s *SQLiteStore) RestoreProject(ctx context.Context, project *Project) error {
+	_, err := s.exec(ctx, `
+		INSERT INTO projects (id, name, path, workspace_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, path = excluded.path,
+			workspace_id = excluded.workspace_id, created_at = excluded.created_at,
+			updated_at = excluded.updated_at
+	`, project.ID, project.Name, project.Path, project.WorkspaceID, project.CreatedAt, project.UpdatedAt)
+
+	return err
+}
+
+// RestoreSession stores a session from a backup, with its summary
+func (s *SQLiteStore) RestoreSession(ctx context.Context, session *Session) error {
+	_, err := s.exec(ctx, `
+		INSERT INTO sessions (id, project_id, started_at, ended_at, duration_seconds, summary)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT (id) DO UPDATE SET project_id = excluded.project_id,
+			started_at = excluded.started_at, ended_at = excluded.ended_at,
+			duration_seconds = excluded.duration_seconds, summary = excluded.summary
+	`, session.ID, session.ProjectID, session.StartedAt, session.EndedAt, session.DurationSeconds, session.Summary)
+
+	return err
+}
+
+// RestoreMemories stores memories from a backup in a single transaction,
+// including their access counts and archived state. The tags and trigger
+// phrases of a memory that is overwritten are replaced by the backup's.
+func (s *SQLiteStore) RestoreMemories(ctx context.Context, memories []*Memory) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, memory := range memories {
+		for _, table := range []string{"memory_tags", "memory_triggers"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE memory_id = ?`, memory.ID); err != nil {
+				return fmt.Errorf("failed to restore memory %s: %w", memory.ID, err)
+			}
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO memories (id, project_id, session_id, content, importance,
+				context_type, temporal_relevance, action_required, created_at, updated_at,
+				embedder_id, reasoning, importance_method, access_count, last_accessed_at,
+				archived, archived_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET project_id = excluded.project_id,
+				session_id = excluded.session_id, content = excluded.content,
+				importance = excluded.importance, context_type = excluded.context_type,
+				temporal_relevance = excluded.temporal_relevance,
+				action_required = excluded.action_required, created_at = excluded.created_at,
+				updated_at = excluded.updated_at, embedder_id = excluded.embedder_id,
+				reasoning = excluded.reasoning, importance_method = excluded.importance_method,
+				access_count = excluded.access_count, last_accessed_at = excluded.last_accessed_at,
+				archived = excluded.archived, archived_at = excluded.archived_at
+		`, memory.ID, memory.ProjectID, memory.SessionID, memory.Content, memory.Importance,
+			memory.ContextType, memory.TemporalRelevance, memory.ActionRequired,
+			memory.CreatedAt, memory.UpdatedAt, memory.EmbedderID, memory.Reasoning,
+			memory.ImportanceMethod, memory.AccessCount, memory.LastAccessedAt,
+			memory.Archived, memory.ArchivedAt)
+		if err != nil {
+			return fmt.Errorf("failed to restore memory %s: %w", memory.ID, err)
+		}
+
+		memory.Tags = dedupeFold(memory.Tags)
+		memory.TriggerPhrases = dedupeFold(memory.TriggerPhrases)
+		for _, tag := range memory.Tags {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, memory.ID, tag); err != nil {
+				return err
+			}
+		}
+		for _, phrase := range memory.TriggerPhrases {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO memory_triggers (memory_id, phrase) VALUES (?, ?)`, memory.ID, phrase); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RestoreRelationship stores a relationship from a backup, reporting false
+// if it already existed
+func (s *SQLiteStore) RestoreRelationship(ctx context.Context, rel *MemoryRelationship) (bool, error) {
+	result, err := s.exec(ctx, `
+		INSERT OR IGNORE INTO memory_relationships (from_memory_id, to_memory_id, relationship_type, created_at)
+		VALUES (?, ?, ?, ?)
+	`, rel.FromMemoryID, rel.ToMemoryID, rel.RelationshipType, rel.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ExistingMemoryIDs returns which of ids belong to stored memories
+func (s *SQLiteStore) ExistingMemoryIDs(ctx context.Context, ids []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	found, err := s.memoryIDs(ctx, `SELECT id FROM memories WHERE id IN (?`+
+		strings.Repeat(", ?", len(ids)-1)+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
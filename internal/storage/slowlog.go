@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLoggedArgLength is the longest string argument logged verbatim. Longer
+// strings are memory contents and are redacted to their length.
+const maxLoggedArgLength = 64
+
+// SlowQuery is a statement that took longer than the slow query threshold,
+// as written to the slow query log (one JSON object per line)
+type SlowQuery struct {
+	Time       time.Time `json:"time"`
+	Statement  string    `json:"statement"`
+	Args       []string  `json:"args,omitempty"` // Redacted
+	DurationMS float64   `json:"duration_ms"`
+	Plan       []string  `json:"plan,omitempty"` // EXPLAIN QUERY PLAN, captured at debug level
+}
+
+// slowQueryLog holds the slow query settings of a store
+type slowQueryLog struct {
+	threshold time.Duration
+	mu        sync.Mutex
+	w         io.Writer
+}
+
+// SetLogger sets the logger used for slow query warnings
+func (s *SQLiteStore) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetSlowQueryLog logs statements slower than threshold, appending them to
+// w (which may be nil) as JSON lines. When the logger is at debug level the
+// statement's query plan is captured too. A threshold of 0 disables it.
+func (s *SQLiteStore) SetSlowQueryLog(threshold time.Duration, w io.Writer) {
+	s.slow = &slowQueryLog{threshold: threshold, w: w}
+}
+
+// query runs db.Query, recording it if it is slow
+func (s *SQLiteStore) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	s.observe(query, args, start)
+	return rows, err
+}
+
+// queryRow runs db.QueryRow, recording it if it is slow
+func (s *SQLiteStore) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := s.db.QueryRowContext(ctx, query, args...)
+	s.observe(query, args, start)
+	return row
+}
+
+// exec runs db.Exec, recording it if it is slow
+func (s *SQLiteStore) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := s.db.ExecContext(ctx, query, args...)
+	s.observe(query, args, start)
+	return result, err
+}
+
+// observe logs a statement that started at start if it exceeded the slow
+// query threshold
+func (s *SQLiteStore) observe(query string, args []interface{}, start time.Time) {
+	if s.slow == nil || s.slow.threshold <= 0 {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed < s.slow.threshold {
+		return
+	}
+
+	entry := SlowQuery{
+		Time:       start,
+		Statement:  strings.Join(strings.Fields(query), " "),
+		Args:       redactArgs(args),
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+	}
+	if s.logger.Enabled(context.Background(), slog.LevelDebug) {
+		entry.Plan = s.explain(query, args)
+	}
+
+	s.logger.Warn("slow query", "duration", elapsed, "statement", entry.Statement, "args", entry.Args)
+
+	if s.slow.w == nil {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	s.slow.mu.Lock()
+	defer s.slow.mu.Unlock()
+	if _, err := s.slow.w.Write(append(line, '\n')); err != nil {
+		s.logger.Debug("failed to write slow query log", "error", err)
+	}
+}
+
+// explain returns the query plan of a statement, one step per line
+func (s *SQLiteStore) explain(query string, args []interface{}) []string {
+	keyword := strings.ToUpper(strings.SplitN(strings.TrimSpace(query), " ", 2)[0])
+	switch keyword {
+	case "SELECT", "WITH", "INSERT", "UPDATE", "DELETE":
+	default:
+		return nil
+	}
+
+	rows, err := s.db.Query(`EXPLAIN QUERY PLAN `+query, args...)
+	if err != nil {
+		return []string{fmt.Sprintf("explain failed: %v", err)}
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return append(plan, fmt.Sprintf("explain failed: %v", err))
+		}
+		plan = append(plan, detail)
+	}
+	return plan
+}
+
+// redactArgs renders statement arguments for logging, hiding long strings
+// (memory contents) and blobs (vectors)
+func redactArgs(args []interface{}) []string {
+	if len(args) == 0 {
+		return nil
+	}
+
+	rendered := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			if n := utf8.RuneCountInString(v); n > maxLoggedArgLength {
+				rendered[i] = fmt.Sprintf("<%d chars>", n)
+			} else {
+				rendered[i] = fmt.Sprintf("%q", v)
+			}
+		case *string:
+			if v == nil {
+				rendered[i] = "NULL"
+			} else {
+				rendered[i] = redactArgs([]interface{}{*v})[0]
+			}
+		case []byte:
+			rendered[i] = fmt.Sprintf("<%d bytes>", len(v))
+		case time.Time:
+			rendered[i] = v.Format(time.RFC3339)
+		default:
+			rendered[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return rendered
+}
+
+// placeholderList matches lists of placeholders such as "?, ?, ?"
+var placeholderList = regexp.MustCompile(`\?(\s*,\s*\?)+`)
+
+// StatementShape normalizes a logged statement so that statements differing
+// only in the number of placeholders in an IN list group together
+func StatementShape(statement string) string {
+	return placeholderList.ReplaceAllString(statement, "?...")
+}
@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -10,7 +13,9 @@ import (
 
 // SQLiteStore handles SQLite operations for metadata storage
 type SQLiteStore struct {
-	db *sql.DB
+	db     *sql.DB
+	logger *slog.Logger
+	slow   *slowQueryLog
 }
 
 // NewSQLiteStore creates a new SQLite store
@@ -25,11 +30,14 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	store := &SQLiteStore{db: db}
+	store := &SQLiteStore{db: db, logger: slog.Default()}
 
-	// Initialize schema
-	if err := store.initSchema(); err != nil {
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	// Create or upgrade the schema
+	if err := store.migrate(); err != nil {
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+	if err := store.syncFullTextIndex(); err != nil {
+		return nil, fmt.Errorf("failed to convert full-text index: %w", err)
 	}
 
 	return store, nil
@@ -40,91 +48,14 @@ func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
 
-// initSchema creates the database schema
-func (s *SQLiteStore) initSchema() error {
-	schema := `
-	-- Projects table
-	CREATE TABLE IF NOT EXISTS projects (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		path TEXT NOT NULL UNIQUE,
-		created_at DATETIME NOT NULL,
-		updated_at DATETIME NOT NULL
-	);
-
-	-- Sessions table
-	CREATE TABLE IF NOT EXISTS sessions (
-		id TEXT PRIMARY KEY,
-		project_id TEXT NOT NULL,
-		started_at DATETIME NOT NULL,
-		ended_at DATETIME,
-		duration_seconds INTEGER,
-		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
-	);
-
-	-- Memories table (metadata only, vectors in Weaviate)
-	CREATE TABLE IF NOT EXISTS memories (
-		id TEXT PRIMARY KEY,
-		project_id TEXT NOT NULL,
-		session_id TEXT,
-		content TEXT NOT NULL,
-		importance REAL NOT NULL DEFAULT 0.5,
-		context_type TEXT,
-		temporal_relevance TEXT,
-		action_required BOOLEAN DEFAULT FALSE,
-		created_at DATETIME NOT NULL,
-		updated_at DATETIME NOT NULL,
-		FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
-		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE SET NULL
-	);
-
-	-- Memory tags (many-to-many)
-	CREATE TABLE IF NOT EXISTS memory_tags (
-		memory_id TEXT NOT NULL,
-		tag TEXT NOT NULL,
-		PRIMARY KEY (memory_id, tag),
-		FOREIGN KEY (memory_id) REFERENCES memories(id) ON DELETE CASCADE
-	);
-
-	-- Memory trigger phrases
-	CREATE TABLE IF NOT EXISTS memory_triggers (
-		memory_id TEXT NOT NULL,
-		phrase TEXT NOT NULL,
-		PRIMARY KEY (memory_id, phrase),
-		FOREIGN KEY (memory_id) REFERENCES memories(id) ON DELETE CASCADE
-	);
-
-	-- Memory relationships (graph)
-	CREATE TABLE IF NOT EXISTS memory_relationships (
-		from_memory_id TEXT NOT NULL,
-		to_memory_id TEXT NOT NULL,
-		relationship_type TEXT NOT NULL,
-		created_at DATETIME NOT NULL,
-		PRIMARY KEY (from_memory_id, to_memory_id, relationship_type),
-		FOREIGN KEY (from_memory_id) REFERENCES memories(id) ON DELETE CASCADE,
-		FOREIGN KEY (to_memory_id) REFERENCES memories(id) ON DELETE CASCADE
-	);
-
-	-- Indexes for performance
-	CREATE INDEX IF NOT EXISTS idx_memories_project ON memories(project_id);
-	CREATE INDEX IF NOT EXISTS idx_memories_session ON memories(session_id);
-	CREATE INDEX IF NOT EXISTS idx_memories_importance ON memories(importance);
-	CREATE INDEX IF NOT EXISTS idx_memories_created ON memories(created_at);
-	CREATE INDEX IF NOT EXISTS idx_sessions_project ON sessions(project_id);
-	CREATE INDEX IF NOT EXISTS idx_sessions_started ON sessions(started_at);
-	`
-
-	_, err := s.db.Exec(schema)
-	return err
-}
-
 // Project represents a project in the database
 type Project struct {
-	ID        string
-	Name      string
-	Path      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          string
+	Name        string
+	Path        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	WorkspaceID *string // Workspace the project belongs to, nil if none
 }
 
 // Session represents a session in the database
@@ -134,6 +65,7 @@ type Session struct {
 	StartedAt       time.Time
 	EndedAt         *time.Time
 	DurationSeconds *int
+	Summary         string // What the session was about, from curation
 }
 
 // Memory represents memory metadata in the database
@@ -150,6 +82,13 @@ type Memory struct {
 	TriggerPhrases    []string
 	CreatedAt         time.Time
 	UpdatedAt         time.Time
+	EmbedderID        string     // Embedder that produced the memory's vector, empty if unknown
+	Reasoning         string     // Why the memory was kept, empty if not recorded
+	ImportanceMethod  string     // How the importance was chosen, empty if not recorded
+	AccessCount       int        // Times the memory was returned by a search or read
+	LastAccessedAt    *time.Time // Nil if it never was
+	Archived          bool       // Kept but out of search; the memory has no vector
+	ArchivedAt        *time.Time // Nil unless archived
 }
 
 // MemoryRelationship represents a relationship between memories
@@ -160,13 +99,53 @@ type MemoryRelationship struct {
 	CreatedAt        time.Time
 }
 
+// MemoryRevision represents a recorded change to a memory's content
+type MemoryRevision struct {
+	ID              int64
+	MemoryID        string
+	PreviousContent string
+	Diff            string
+	Reason          string
+	CreatedAt       time.Time
+}
+
+// UsageRecord is a single AI call in the usage ledger
+type UsageRecord struct {
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	CreatedAt        time.Time
+}
+
+// UsageSummary aggregates the usage ledger for one day and model
+type UsageSummary struct {
+	Day              string
+	Provider         string
+	Model            string
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// ImportanceStat describes the importance distribution of the memories
+// whose importance was chosen by one method
+type ImportanceStat struct {
+	Method  string // Empty for memories saved before methods were recorded
+	Count   int
+	Average float64
+	Buckets [5]int // Counts for importance 0-0.2, 0.2-0.4, 0.4-0.6, 0.6-0.8 and 0.8-1
+}
+
 // CreateProject creates a new project
-func (s *SQLiteStore) CreateProject(project *Project) error {
+func (s *SQLiteStore) CreateProject(ctx context.Context, project *Project) error {
 	now := time.Now()
 	project.CreatedAt = now
 	project.UpdatedAt = now
 
-	_, err := s.db.Exec(`
+	_, err := s.exec(ctx, `
 		INSERT INTO projects (id, name, path, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?)
 	`, project.ID, project.Name, project.Path, project.CreatedAt, project.UpdatedAt)
@@ -175,44 +154,134 @@ func (s *SQLiteStore) CreateProject(project *Project) error {
 }
 
 // GetProject retrieves a project by ID
-func (s *SQLiteStore) GetProject(id string) (*Project, error) {
-	var project Project
-	err := s.db.QueryRow(`
-		SELECT id, name, path, created_at, updated_at
-		FROM projects WHERE id = ?
-	`, id).Scan(&project.ID, &project.Name, &project.Path, &project.CreatedAt, &project.UpdatedAt)
+func (s *SQLiteStore) GetProject(ctx context.Context, id string) (*Project, error) {
+	project, err := scanProject(s.queryRow(ctx, `SELECT `+projectColumns+` FROM projects WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return project, err
+}
 
+// GetProjectByPath retrieves a project by path
+func (s *SQLiteStore) GetProjectByPath(ctx context.Context, path string) (*Project, error) {
+	project, err := scanProject(s.queryRow(ctx, `SELECT `+projectColumns+` FROM projects WHERE path = ?`, path))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	return project, err
+}
+
+// RenameProject changes a project's name
+func (s *SQLiteStore) RenameProject(ctx context.Context, id, name string) error {
+	result, err := s.exec(ctx, `
+		UPDATE projects SET name = ?, updated_at = ? WHERE id = ?
+	`, name, time.Now(), id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return &project, nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("project not found: %s", id)
+	}
+	return nil
 }
 
-// GetProjectByPath retrieves a project by path
-func (s *SQLiteStore) GetProjectByPath(path string) (*Project, error) {
-	var project Project
-	err := s.db.QueryRow(`
-		SELECT id, name, path, created_at, updated_at
-		FROM projects WHERE path = ?
-	`, path).Scan(&project.ID, &project.Name, &project.Path, &project.CreatedAt, &project.UpdatedAt)
+// MoveProject changes a project's path. Opening the new directory before
+// the move creates a second project there; if that project has no
+// memories it is deleted along with its sessions, otherwise the move is
+// refused so the two are not mixed. It returns the ID of the deleted
+// project, if any.
+func (s *SQLiteStore) MoveProject(ctx context.Context, id, path string) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+	var replaced string
+	var memories int
+	err = tx.QueryRowContext(ctx, `
+		SELECT p.id, (SELECT COUNT(*) FROM memories WHERE project_id = p.id)
+		FROM projects p WHERE p.path = ? AND p.id != ?
+	`, path, id).Scan(&replaced, &memories)
+	switch {
+	case err == sql.ErrNoRows:
+		replaced = ""
+	case err != nil:
+		return "", err
+	case memories > 0:
+		return "", fmt.Errorf("project %s at %s already has %d memories", replaced, path, memories)
+	default:
+		if _, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = ?`, replaced); err != nil {
+			return "", err
+		}
 	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE projects SET path = ?, updated_at = ? WHERE id = ?
+	`, path, time.Now(), id)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return "", err
 	}
+	if affected == 0 {
+		return "", fmt.Errorf("project not found: %s", id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return replaced, nil
+}
 
+// ListProjects retrieves all projects ordered by name
+func (s *SQLiteStore) ListProjects(ctx context.Context) ([]Project, error) {
+	return s.listProjects(ctx, `SELECT `+projectColumns+` FROM projects ORDER BY name`)
+}
+
+// projectColumns lists the columns scanProject expects, in order
+const projectColumns = `id, name, path, created_at, updated_at, workspace_id`
+
+// scanProject scans a row selected with projectColumns
+func scanProject(row rowScanner) (*Project, error) {
+	var project Project
+	if err := row.Scan(&project.ID, &project.Name, &project.Path, &project.CreatedAt, &project.UpdatedAt,
+		&project.WorkspaceID); err != nil {
+		return nil, err
+	}
 	return &project, nil
 }
 
+// listProjects runs a query selecting projectColumns and collects the projects
+func (s *SQLiteStore) listProjects(ctx context.Context, query string, args ...interface{}) ([]Project, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+
+	return projects, rows.Err()
+}
+
 // CreateSession creates a new session
-func (s *SQLiteStore) CreateSession(session *Session) error {
-	_, err := s.db.Exec(`
+func (s *SQLiteStore) CreateSession(ctx context.Context, session *Session) error {
+	_, err := s.exec(ctx, `
 		INSERT INTO sessions (id, project_id, started_at, ended_at, duration_seconds)
 		VALUES (?, ?, ?, ?, ?)
 	`, session.ID, session.ProjectID, session.StartedAt, session.EndedAt, session.DurationSeconds)
@@ -221,8 +290,8 @@ func (s *SQLiteStore) CreateSession(session *Session) error {
 }
 
 // UpdateSession updates a session
-func (s *SQLiteStore) UpdateSession(session *Session) error {
-	_, err := s.db.Exec(`
+func (s *SQLiteStore) UpdateSession(ctx context.Context, session *Session) error {
+	_, err := s.exec(ctx, `
 		UPDATE sessions 
 		SET ended_at = ?, duration_seconds = ?
 		WHERE id = ?
@@ -231,13 +300,55 @@ func (s *SQLiteStore) UpdateSession(session *Session) error {
 	return err
 }
 
+// SetSessionSummary records what a session was about
+func (s *SQLiteStore) SetSessionSummary(ctx context.Context, id, summary string) error {
+	result, err := s.exec(ctx, `UPDATE sessions SET summary = ? WHERE id = ?`, summary, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("session not found: %s", id)
+	}
+	return nil
+}
+
 // GetSession retrieves a session by ID
-func (s *SQLiteStore) GetSession(id string) (*Session, error) {
+func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	return s.getSession(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id)
+}
+
+// GetLastSession retrieves the most recent session for a project
+func (s *SQLiteStore) GetLastSession(ctx context.Context, projectID string) (*Session, error) {
+	return s.getSession(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE project_id = ?
+		ORDER BY started_at DESC
+		LIMIT 1
+	`, projectID)
+}
+
+// GetLastEndedSession retrieves the most recently ended session of a project
+func (s *SQLiteStore) GetLastEndedSession(ctx context.Context, projectID string) (*Session, error) {
+	return s.getSession(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE project_id = ? AND ended_at IS NOT NULL
+		ORDER BY ended_at DESC
+		LIMIT 1
+	`, projectID)
+}
+
+// sessionColumns lists the columns getSession expects, in order
+const sessionColumns = `id, project_id, started_at, ended_at, duration_seconds, summary`
+
+// getSession runs a query selecting sessionColumns and scans the first
+// session, or returns nil if there is none
+func (s *SQLiteStore) getSession(ctx context.Context, query string, args ...interface{}) (*Session, error) {
 	var session Session
-	err := s.db.QueryRow(`
-		SELECT id, project_id, started_at, ended_at, duration_seconds
-		FROM sessions WHERE id = ?
-	`, id).Scan(&session.ID, &session.ProjectID, &session.StartedAt, &session.EndedAt, &session.DurationSeconds)
+	var summary sql.NullString
+	err := s.queryRow(ctx, query, args...).Scan(&session.ID, &session.ProjectID, &session.StartedAt,
+		&session.EndedAt, &session.DurationSeconds, &summary)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -246,57 +357,201 @@ func (s *SQLiteStore) GetSession(id string) (*Session, error) {
 		return nil, err
 	}
 
+	session.Summary = summary.String
 	return &session, nil
 }
 
-// GetLastSession retrieves the most recent session for a project
-func (s *SQLiteStore) GetLastSession(projectID string) (*Session, error) {
-	var session Session
-	err := s.db.QueryRow(`
-		SELECT id, project_id, started_at, ended_at, duration_seconds
-		FROM sessions 
-		WHERE project_id = ? 
-		ORDER BY started_at DESC 
-		LIMIT 1
-	`, projectID).Scan(&session.ID, &session.ProjectID, &session.StartedAt, &session.EndedAt, &session.DurationSeconds)
+// BackfillSessions links sessionless memories to the session of the same
+// project whose time window contains their created_at. A session without an
+// end time is treated as running until the next session starts. An empty
+// projectID backfills every project. It returns the number of memories linked.
+func (s *SQLiteStore) BackfillSessions(ctx context.Context, projectID string) (int, error) {
+	query := `SELECT id, project_id, started_at, ended_at FROM sessions`
+	var args []interface{}
+	if projectID != "" {
+		query += ` WHERE project_id = ?`
+		args = append(args, projectID)
+	}
+	query += ` ORDER BY project_id, started_at`
 
-	if err == sql.ErrNoRows {
-		return nil, nil
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	sessionsByProject := make(map[string][]Session)
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.ProjectID, &session.StartedAt, &session.EndedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		sessionsByProject[session.ProjectID] = append(sessionsByProject[session.ProjectID], session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	query = `SELECT id, project_id, created_at FROM memories WHERE session_id IS NULL`
+	if projectID != "" {
+		query += ` AND project_id = ?`
 	}
+
+	rows, err = s.query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	return &session, nil
+	links := make(map[string]string)
+	for rows.Next() {
+		var id, memProjectID string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &memProjectID, &createdAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if sessionID := sessionAt(sessionsByProject[memProjectID], createdAt); sessionID != "" {
+			links[id] = sessionID
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(links) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for memoryID, sessionID := range links {
+		if _, err := tx.ExecContext(ctx, `UPDATE memories SET session_id = ? WHERE id = ? AND session_id IS NULL`,
+			sessionID, memoryID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(links), nil
+}
+
+// sessionAt returns the ID of the session (sorted by start time) whose
+// window contains t, or "" if none does
+func sessionAt(sessions []Session, t time.Time) string {
+	for i := len(sessions) - 1; i >= 0; i-- {
+		session := sessions[i]
+		if session.StartedAt.After(t) {
+			continue
+		}
+
+		end := session.EndedAt
+		if end == nil && i+1 < len(sessions) {
+			end = &sessions[i+1].StartedAt
+		}
+		if end == nil || !end.Before(t) {
+			return session.ID
+		}
+		return ""
+	}
+	return ""
 }
 
 // CreateMemory creates a new memory with tags and trigger phrases
-func (s *SQLiteStore) CreateMemory(memory *Memory) error {
-	tx, err := s.db.Begin()
+func (s *SQLiteStore) CreateMemory(ctx context.Context, memory *Memory) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := insertMemory(ctx, tx, memory); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateMemoryBatch stores memories together with relationships and
+// revisions between them in a single transaction: either everything is
+// stored or nothing is
+func (s *SQLiteStore) CreateMemoryBatch(ctx context.Context, memories []*Memory, relationships []*MemoryRelationship, revisions []*MemoryRevision) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	for _, memory := range memories {
+		if err := insertMemory(ctx, tx, memory); err != nil {
+			return fmt.Errorf("failed to store memory %s: %w", memory.ID, err)
+		}
+	}
+
 	now := time.Now()
-	memory.CreatedAt = now
-	memory.UpdatedAt = now
+	for _, rel := range relationships {
+		rel.CreatedAt = now
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO memory_relationships (from_memory_id, to_memory_id, relationship_type, created_at)
+			VALUES (?, ?, ?, ?)
+		`, rel.FromMemoryID, rel.ToMemoryID, rel.RelationshipType, rel.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to store relationship %s -> %s: %w", rel.FromMemoryID, rel.ToMemoryID, err)
+		}
+	}
+
+	for _, rev := range revisions {
+		rev.CreatedAt = now
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO memory_revisions (memory_id, previous_content, diff, reason, created_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, rev.MemoryID, rev.PreviousContent, rev.Diff, rev.Reason, rev.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to store revision of %s: %w", rev.MemoryID, err)
+		}
+		if rev.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertMemory inserts a memory with its tags and trigger phrases
+func insertMemory(ctx context.Context, tx *sql.Tx, memory *Memory) error {
+	if memory.CreatedAt.IsZero() {
+		memory.CreatedAt = time.Now()
+	}
+	if memory.UpdatedAt.IsZero() {
+		memory.UpdatedAt = memory.CreatedAt
+	}
 
 	// Insert memory
-	_, err = tx.Exec(`
+	_, err := tx.ExecContext(ctx, `
 		INSERT INTO memories (id, project_id, session_id, content, importance, 
-			context_type, temporal_relevance, action_required, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			context_type, temporal_relevance, action_required, created_at, updated_at, embedder_id, reasoning, importance_method)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))
 	`, memory.ID, memory.ProjectID, memory.SessionID, memory.Content, memory.Importance,
 		memory.ContextType, memory.TemporalRelevance, memory.ActionRequired,
-		memory.CreatedAt, memory.UpdatedAt)
+		memory.CreatedAt, memory.UpdatedAt, memory.EmbedderID, memory.Reasoning, memory.ImportanceMethod)
 	if err != nil {
 		return err
 	}
 
+	memory.Tags = dedupeFold(memory.Tags)
+	memory.TriggerPhrases = dedupeFold(memory.TriggerPhrases)
+
 	// Insert tags
 	for _, tag := range memory.Tags {
-		_, err = tx.Exec(`INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, memory.ID, tag)
+		_, err = tx.ExecContext(ctx, `INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, memory.ID, tag)
 		if err != nil {
 			return err
 		}
@@ -304,26 +559,61 @@ func (s *SQLiteStore) CreateMemory(memory *Memory) error {
 
 	// Insert trigger phrases
 	for _, phrase := range memory.TriggerPhrases {
-		_, err = tx.Exec(`INSERT INTO memory_triggers (memory_id, phrase) VALUES (?, ?)`, memory.ID, phrase)
+		_, err = tx.ExecContext(ctx, `INSERT INTO memory_triggers (memory_id, phrase) VALUES (?, ?)`, memory.ID, phrase)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
-// GetMemory retrieves a memory by ID with its tags and trigger phrases
-func (s *SQLiteStore) GetMemory(id string) (*Memory, error) {
+// memoryColumns is the column list scanned by scanMemory
+const memoryColumns = `id, project_id, session_id, content, importance,
+	context_type, temporal_relevance, action_required, created_at, updated_at,
+	COALESCE(embedder_id, ''), COALESCE(reasoning, ''),
+	COALESCE(importance_method, ''), access_count, last_accessed_at,
+	archived, archived_at`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMemory scans a row selected with memoryColumns
+func scanMemory(row rowScanner) (*Memory, error) {
 	var memory Memory
-	err := s.db.QueryRow(`
-		SELECT id, project_id, session_id, content, importance,
-			context_type, temporal_relevance, action_required, created_at, updated_at
-		FROM memories WHERE id = ?
-	`, id).Scan(&memory.ID, &memory.ProjectID, &memory.SessionID, &memory.Content,
+	err := row.Scan(&memory.ID, &memory.ProjectID, &memory.SessionID, &memory.Content,
 		&memory.Importance, &memory.ContextType, &memory.TemporalRelevance,
-		&memory.ActionRequired, &memory.CreatedAt, &memory.UpdatedAt)
+		&memory.ActionRequired, &memory.CreatedAt, &memory.UpdatedAt, &memory.EmbedderID, &memory.Reasoning,
+		&memory.ImportanceMethod, &memory.AccessCount, &memory.LastAccessedAt,
+		&memory.Archived, &memory.ArchivedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
 
+// dedupeFold trims values and drops empty ones and case-insensitive
+// duplicates, keeping the first spelling of each
+func dedupeFold(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		key := strings.ToLower(v)
+		if v == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, v)
+	}
+	return result
+}
+
+// GetMemory retrieves a memory by ID with its tags and trigger phrases
+func (s *SQLiteStore) GetMemory(ctx context.Context, id string) (*Memory, error) {
+	memory, err := scanMemory(s.queryRow(ctx, `SELECT `+memoryColumns+` FROM memories WHERE id = ?`, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -331,71 +621,901 @@ func (s *SQLiteStore) GetMemory(id string) (*Memory, error) {
 		return nil, err
 	}
 
-	// Load tags
-	rows, err := s.db.Query(`SELECT tag FROM memory_tags WHERE memory_id = ?`, id)
-	if err != nil {
+	if err := s.loadMemoryLists(ctx, []*Memory{memory}); err != nil {
 		return nil, err
 	}
+
+	return memory, nil
+}
+
+// loadMemoryLists fills in tags and trigger phrases for a set of memories
+func (s *SQLiteStore) loadMemoryLists(ctx context.Context, memories []*Memory) error {
+	if len(memories) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*Memory, len(memories))
+	args := make([]interface{}, 0, len(memories))
+	for _, m := range memories {
+		byID[m.ID] = m
+		args = append(args, m.ID)
+	}
+	placeholders := "?" + strings.Repeat(", ?", len(args)-1)
+
+	rows, err := s.query(ctx, `SELECT memory_id, tag FROM memory_tags WHERE memory_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var tag string
-		if err := rows.Scan(&tag); err != nil {
-			return nil, err
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
 		}
-		memory.Tags = append(memory.Tags, tag)
+		byID[id].Tags = append(byID[id].Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	// Load trigger phrases
-	rows, err = s.db.Query(`SELECT phrase FROM memory_triggers WHERE memory_id = ?`, id)
+	triggerRows, err := s.query(ctx, `SELECT memory_id, phrase FROM memory_triggers WHERE memory_id IN (`+placeholders+`)`, args...)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer triggerRows.Close()
+
+	for triggerRows.Next() {
+		var id, phrase string
+		if err := triggerRows.Scan(&id, &phrase); err != nil {
+			return err
+		}
+		byID[id].TriggerPhrases = append(byID[id].TriggerPhrases, phrase)
+	}
+
+	return triggerRows.Err()
+}
+
+// ListOptions controls filtering, ordering and pagination for ListMemories
+type ListOptions struct {
+	ProjectID      string
+	SessionID      string
+	WorkspaceID    string    // Memories of every project in the workspace
+	ExcludeProject string    // Skip this project's memories
+	MinImportance  float64   // Only memories at least this important
+	CreatedAfter   time.Time // Only memories created at or after this time, if set
+	Limit          int
+	Offset         int
+	OrderBy        string // "created_at", "importance", "updated_at", "access_count", or "last_accessed_at"
+	ContextType    string
+	Tag            string
+	ActionRequired *bool
+
+	// TemporalRelevance filters on temporal relevance; memories without
+	// one count as "persistent"
+	TemporalRelevance string
+
+	IncludeArchived bool // List archived memories too
+}
+
+// listOrderings maps allowed order_by values to ORDER BY clauses
+var listOrderings = map[string]string{
+	"":           "m.created_at DESC, m.id",
+	"created_at": "m.created_at DESC, m.id",
+	"updated_at": "m.updated_at DESC, m.id",
+	"importance": "m.importance DESC, m.created_at DESC, m.id",
+
+	"access_count":     "m.access_count DESC, m.last_accessed_at DESC, m.id",
+	"last_accessed_at": "m.last_accessed_at IS NULL, m.last_accessed_at DESC, m.id",
+}
+
+// ListMemories returns a page of memories matching the options along with
+// the total number of matching memories
+func (s *SQLiteStore) ListMemories(ctx context.Context, opts ListOptions) ([]*Memory, int, error) {
+	orderBy, ok := listOrderings[opts.OrderBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid order_by %q (valid: created_at, importance, updated_at, access_count, last_accessed_at)", opts.OrderBy)
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	if opts.ProjectID != "" {
+		conditions = append(conditions, "m.project_id = ?")
+		args = append(args, opts.ProjectID)
+	}
+	if opts.SessionID != "" {
+		conditions = append(conditions, "m.session_id = ?")
+		args = append(args, opts.SessionID)
+	}
+	if opts.WorkspaceID != "" {
+		conditions = append(conditions, "m.project_id IN (SELECT id FROM projects WHERE workspace_id = ?)")
+		args = append(args, opts.WorkspaceID)
+	}
+	if opts.ExcludeProject != "" {
+		conditions = append(conditions, "m.project_id != ?")
+		args = append(args, opts.ExcludeProject)
+	}
+	if opts.MinImportance > 0 {
+		conditions = append(conditions, "m.importance >= ?")
+		args = append(args, opts.MinImportance)
+	}
+	if !opts.CreatedAfter.IsZero() {
+		// Timestamps carry the offset they were written in, so they are
+		// compared as instants rather than as text
+		conditions = append(conditions, "julianday(m.created_at) >= julianday(?)")
+		args = append(args, opts.CreatedAfter)
+	}
+	if opts.ContextType != "" {
+		conditions = append(conditions, "m.context_type = ?")
+		args = append(args, opts.ContextType)
+	}
+	if opts.Tag != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM memory_tags t WHERE t.memory_id = m.id AND t.tag = ?)")
+		args = append(args, opts.Tag)
+	}
+	if opts.ActionRequired != nil {
+		conditions = append(conditions, "m.action_required = ?")
+		args = append(args, *opts.ActionRequired)
+	}
+	if opts.TemporalRelevance != "" {
+		conditions = append(conditions, "COALESCE(NULLIF(m.temporal_relevance, ''), 'persistent') = ?")
+		args = append(args, opts.TemporalRelevance)
+	}
+	if !opts.IncludeArchived {
+		conditions = append(conditions, "m.archived = 0")
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.queryRow(ctx, `SELECT COUNT(*) FROM memories m`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	offset := opts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := s.query(ctx, `SELECT `+memoryColumns+` FROM memories m`+where+
+		` ORDER BY `+orderBy+` LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
 	}
 	defer rows.Close()
 
+	var memories []*Memory
 	for rows.Next() {
-		var phrase string
-		if err := rows.Scan(&phrase); err != nil {
-			return nil, err
+		memory, err := scanMemory(rows)
+		if err != nil {
+			return nil, 0, err
 		}
-		memory.TriggerPhrases = append(memory.TriggerPhrases, phrase)
+		memories = append(memories, memory)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
 	}
 
-	return &memory, nil
+	if err := s.loadMemoryLists(ctx, memories); err != nil {
+		return nil, 0, err
+	}
+
+	return memories, total, nil
 }
 
-// CreateRelationship creates a relationship between two memories
-func (s *SQLiteStore) CreateRelationship(rel *MemoryRelationship) error {
-	rel.CreatedAt = time.Now()
+// EachMemory calls fn for every memory of a project in ID order. Memories
+// are fetched pageSize at a time using keyset pagination, so only one page is
+// held in memory no matter how large the project is. Iteration stops at the
+// first error returned by fn.
+func (s *SQLiteStore) EachMemory(ctx context.Context, projectID string, pageSize int, fn func(*Memory) error) error {
+	if pageSize <= 0 {
+		pageSize = 500
+	}
 
-	_, err := s.db.Exec(`
-		INSERT INTO memory_relationships (from_memory_id, to_memory_id, relationship_type, created_at)
-		VALUES (?, ?, ?, ?)
-	`, rel.FromMemoryID, rel.ToMemoryID, rel.RelationshipType, rel.CreatedAt)
+	lastID := ""
+	for {
+		rows, err := s.query(ctx, `SELECT `+memoryColumns+` FROM memories
+			WHERE project_id = ? AND id > ? ORDER BY id LIMIT ?`, projectID, lastID, pageSize)
+		if err != nil {
+			return err
+		}
 
-	return err
+		var page []*Memory
+		for rows.Next() {
+			memory, err := scanMemory(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, memory)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(page) == 0 {
+			return nil
+		}
+		if err := s.loadMemoryLists(ctx, page); err != nil {
+			return err
+		}
+
+		for _, memory := range page {
+			if err := fn(memory); err != nil {
+				return err
+			}
+		}
+
+		if len(page) < pageSize {
+			return nil
+		}
+		lastID = page[len(page)-1].ID
+	}
 }
 
-// GetRelationships retrieves all relationships for a memory
-func (s *SQLiteStore) GetRelationships(memoryID string) ([]MemoryRelationship, error) {
-	rows, err := s.db.Query(`
-		SELECT from_memory_id, to_memory_id, relationship_type, created_at
-		FROM memory_relationships
-		WHERE from_memory_id = ? OR to_memory_id = ?
-	`, memoryID, memoryID)
+// EachSession calls fn for every session of every project, oldest first,
+// reading them one row at a time. Iteration stops at the first error
+// returned by fn.
+func (s *SQLiteStore) EachSession(ctx context.Context, fn func(*Session) error) error {
+	rows, err := s.query(ctx, `SELECT `+sessionColumns+` FROM sessions ORDER BY started_at, id`)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var relationships []MemoryRelationship
 	for rows.Next() {
-		var rel MemoryRelationship
-		if err := rows.Scan(&rel.FromMemoryID, &rel.ToMemoryID, &rel.RelationshipType, &rel.CreatedAt); err != nil {
+		var session Session
+		var summary sql.NullString
+		if err := rows.Scan(&session.ID, &session.ProjectID, &session.StartedAt,
+			&session.EndedAt, &session.DurationSeconds, &summary); err != nil {
+			return err
+		}
+		session.Summary = summary.String
+		if err := fn(&session); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachRelationship calls fn for every relationship between memories, oldest
+// first, reading them one row at a time. Iteration stops at the first error
+// returned by fn.
+func (s *SQLiteStore) EachRelationship(ctx context.Context, fn func(*MemoryRelationship) error) error {
+	rows, err := s.query(ctx, `SELECT from_memory_id, to_memory_id, relationship_type, created_at
+		FROM memory_relationships ORDER BY created_at, from_memory_id, to_memory_id, relationship_type`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rel MemoryRelationship
+		if err := rows.Scan(&rel.FromMemoryID, &rel.ToMemoryID, &rel.RelationshipType, &rel.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(&rel); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// TouchMemories counts an access to each of the memories and sets their
+// last access time, in a single statement
+func (s *SQLiteStore) TouchMemories(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, time.Now())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	_, err := s.exec(ctx, `UPDATE memories SET access_count = access_count + 1, last_accessed_at = ?
+		WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)`, args...)
+	return err
+}
+
+// SetMemoryArchived archives a memory or restores it, recording when it was
+// archived
+func (s *SQLiteStore) SetMemoryArchived(ctx context.Context, id string, archived bool) error {
+	var archivedAt *time.Time
+	if archived {
+		now := time.Now()
+		archivedAt = &now
+	}
+
+	result, err := s.exec(ctx, `UPDATE memories SET archived = ?, archived_at = ? WHERE id = ?`, archived, archivedAt, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("memory not found: %s", id)
+	}
+	return nil
+}
+
+// SetEmbedderID records which embedder produced a memory's vector
+func (s *SQLiteStore) SetEmbedderID(ctx context.Context, memoryID, embedderID string) error {
+	_, err := s.exec(ctx, `UPDATE memories SET embedder_id = NULLIF(?, '') WHERE id = ?`, embedderID, memoryID)
+	return err
+}
+
+// ListStaleEmbeddings returns up to limit memories whose vector was produced
+// by a different embedder than embedderID, or by an unknown one, along with
+// the total number of such memories. Archived memories have no vector and
+// are skipped. An empty projectID covers all projects.
+func (s *SQLiteStore) ListStaleEmbeddings(ctx context.Context, projectID, embedderID string, limit int) ([]*Memory, int, error) {
+	where := `archived = 0 AND (embedder_id IS NULL OR embedder_id != ?)`
+	args := []interface{}{embedderID}
+	if projectID != "" {
+		where += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+
+	var total int
+	if err := s.queryRow(ctx, `SELECT COUNT(*) FROM memories WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT ` + memoryColumns + ` FROM memories WHERE ` + where + ` ORDER BY created_at, id`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var memories []*Memory
+	for rows.Next() {
+		memory, err := scanMemory(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		memories = append(memories, memory)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return memories, total, nil
+}
+
+// UpdateMemory updates a memory's fields and replaces its tags and trigger phrases
+func (s *SQLiteStore) UpdateMemory(ctx context.Context, memory *Memory) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	memory.UpdatedAt = time.Now()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE memories
+		SET content = ?, importance = ?, context_type = ?, temporal_relevance = ?,
+			action_required = ?, updated_at = ?, importance_method = NULLIF(?, '')
+		WHERE id = ?
+	`, memory.Content, memory.Importance, memory.ContextType, memory.TemporalRelevance,
+		memory.ActionRequired, memory.UpdatedAt, memory.ImportanceMethod, memory.ID)
+	if err != nil {
+		return err
+	}
+
+	memory.Tags = dedupeFold(memory.Tags)
+	memory.TriggerPhrases = dedupeFold(memory.TriggerPhrases)
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM memory_tags WHERE memory_id = ?`, memory.ID); err != nil {
+		return err
+	}
+	for _, tag := range memory.Tags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)`, memory.ID, tag); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM memory_triggers WHERE memory_id = ?`, memory.ID); err != nil {
+		return err
+	}
+	for _, phrase := range memory.TriggerPhrases {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO memory_triggers (memory_id, phrase) VALUES (?, ?)`, memory.ID, phrase); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateRevision records a content revision for a memory
+func (s *SQLiteStore) CreateRevision(ctx context.Context, rev *MemoryRevision) error {
+	rev.CreatedAt = time.Now()
+
+	result, err := s.exec(ctx, `
+		INSERT INTO memory_revisions (memory_id, previous_content, diff, reason, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, rev.MemoryID, rev.PreviousContent, rev.Diff, rev.Reason, rev.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	rev.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetRevisions retrieves the revision history of a memory, oldest first
+func (s *SQLiteStore) GetRevisions(ctx context.Context, memoryID string) ([]MemoryRevision, error) {
+	rows, err := s.query(ctx, `
+		SELECT id, memory_id, previous_content, diff, COALESCE(reason, ''), created_at
+		FROM memory_revisions
+		WHERE memory_id = ?
+		ORDER BY created_at ASC, id ASC
+	`, memoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []MemoryRevision
+	for rows.Next() {
+		var rev MemoryRevision
+		if err := rows.Scan(&rev.ID, &rev.MemoryID, &rev.PreviousContent, &rev.Diff, &rev.Reason, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// PruneMemories deletes temporary memories created before olderThan and,
+// if minImportance is above zero, memories with importance below it. It
+// returns the IDs of the deleted memories so their vectors can be removed.
+func (s *SQLiteStore) PruneMemories(ctx context.Context, olderThan time.Time, minImportance float64) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM memories
+		WHERE (temporal_relevance = 'temporary' AND created_at < ?)
+			OR (? > 0 AND importance < ?)
+	`, olderThan, minImportance, minImportance)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM memories WHERE id = ?`, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ExpiredMemories returns the IDs of a project's temporary memories created
+// before temporaryBefore and of its session memories whose session ended
+// before sessionEndedBefore. Session memories without a session count from
+// their creation. A zero time skips that kind.
+func (s *SQLiteStore) ExpiredMemories(ctx context.Context, projectID string, temporaryBefore, sessionEndedBefore time.Time) (temporary, session []string, err error) {
+	if !temporaryBefore.IsZero() {
+		temporary, err = s.memoryIDs(ctx, `
+			SELECT id FROM memories
+			WHERE project_id = ? AND temporal_relevance = 'temporary' AND created_at < ?
+		`, projectID, temporaryBefore)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !sessionEndedBefore.IsZero() {
+		session, err = s.memoryIDs(ctx, `
+			SELECT m.id FROM memories m
+			LEFT JOIN sessions s ON s.id = m.session_id
+			WHERE m.project_id = ? AND m.temporal_relevance = 'session'
+				AND COALESCE(s.ended_at, CASE WHEN m.session_id IS NULL THEN m.created_at END) < ?
+		`, projectID, sessionEndedBefore)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return temporary, session, nil
+}
+
+// DecayingMemory is a memory whose importance decays with age
+type DecayingMemory struct {
+	ID         string
+	Importance float64
+	Archived   bool
+	Since      time.Time // When the importance was last decayed, or else the creation time
+}
+
+// DecayingMemories returns the session and temporary memories of a project,
+// or of all projects if projectID is empty. Persistent memories, including
+// those without a temporal relevance, keep their importance.
+func (s *SQLiteStore) DecayingMemories(ctx context.Context, projectID string) ([]DecayingMemory, error) {
+	return s.decayingMemories(ctx, `temporal_relevance IN ('session', 'temporary')`, projectID)
+}
+
+// UnusedMemories returns the memories of a project, or of all projects if
+// projectID is empty, that were never returned by a search or read.
+// Archived memories are left out.
+func (s *SQLiteStore) UnusedMemories(ctx context.Context, projectID string) ([]DecayingMemory, error) {
+	return s.decayingMemories(ctx, `access_count = 0 AND archived = 0`, projectID)
+}
+
+// decayingMemories selects the memories matching where for importance decay
+func (s *SQLiteStore) decayingMemories(ctx context.Context, where, projectID string) ([]DecayingMemory, error) {
+	query := `SELECT id, importance, archived, created_at, importance_decayed_at FROM memories WHERE ` + where
+	var args []interface{}
+	if projectID != "" {
+		query += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memories []DecayingMemory
+	for rows.Next() {
+		var mem DecayingMemory
+		var decayedAt *time.Time
+		if err := rows.Scan(&mem.ID, &mem.Importance, &mem.Archived, &mem.Since, &decayedAt); err != nil {
+			return nil, err
+		}
+		if decayedAt != nil {
+			mem.Since = *decayedAt
+		}
+		memories = append(memories, mem)
+	}
+	return memories, rows.Err()
+}
+
+// SetDecayedImportance sets the importance of memories by ID in a single
+// transaction and records at as when they were decayed. updated_at is left
+// alone: decay is not an edit.
+func (s *SQLiteStore) SetDecayedImportance(ctx context.Context, importance map[string]float64, at time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE memories SET importance = ?, importance_decayed_at = ? WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for id, value := range importance {
+		if _, err := stmt.ExecContext(ctx, value, at, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetImportance sets a memory's importance without marking it as updated
+func (s *SQLiteStore) SetImportance(ctx context.Context, id string, importance float64) error {
+	result, err := s.exec(ctx, `UPDATE memories SET importance = ? WHERE id = ?`, importance, id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("memory not found: %s", id)
+	}
+	return nil
+}
+
+// memoryIDs runs a query selecting a single ID column
+func (s *SQLiteStore) memoryIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteMemories deletes memories by ID in a single transaction, returning
+// how many rows were removed. Tags, triggers, revisions and relationships are
+// removed by cascade.
+func (s *SQLiteStore) DeleteMemories(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM memories WHERE id = ?`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	deleted := 0
+	for _, id := range ids {
+		result, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		deleted += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// CountMemories counts a project's memories with importance >= minImportance
+func (s *SQLiteStore) CountMemories(ctx context.Context, projectID string, minImportance float64) (int, error) {
+	var count int
+	err := s.queryRow(ctx, `
+		SELECT COUNT(*) FROM memories WHERE project_id = ? AND importance >= ?
+	`, projectID, minImportance).Scan(&count)
+	return count, err
+}
+
+// GraphFilter restricts which memories are included in a project graph
+type GraphFilter struct {
+	MinImportance float64
+	ContextTypes  []string
+	CreatedAfter  *time.Time
+}
+
+// ProjectGraph holds the memories of a project and the relationships between them
+type ProjectGraph struct {
+	Nodes []Memory
+	Edges []MemoryRelationship
+}
+
+// GetProjectGraph loads a project's memories and relationships in two queries.
+// Only edges whose endpoints both pass the filter are returned.
+func (s *SQLiteStore) GetProjectGraph(ctx context.Context, projectID string, filter GraphFilter) (*ProjectGraph, error) {
+	where := "project_id = ? AND importance >= ? AND archived = 0"
+	args := []interface{}{projectID, filter.MinImportance}
+
+	if len(filter.ContextTypes) > 0 {
+		where += " AND context_type IN (?" + strings.Repeat(", ?", len(filter.ContextTypes)-1) + ")"
+		for _, ct := range filter.ContextTypes {
+			args = append(args, ct)
+		}
+	}
+	if filter.CreatedAfter != nil {
+		where += " AND julianday(created_at) >= julianday(?)" // As instants, whatever their offsets
+		args = append(args, *filter.CreatedAfter)
+	}
+
+	rows, err := s.query(ctx, `SELECT `+memoryColumns+` FROM memories WHERE `+where+` ORDER BY created_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	graph := &ProjectGraph{}
+	included := make(map[string]bool)
+	for rows.Next() {
+		m, err := scanMemory(rows)
+		if err != nil {
+			return nil, err
+		}
+		graph.Nodes = append(graph.Nodes, *m)
+		included[m.ID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	relRows, err := s.query(ctx, `
+		SELECT r.from_memory_id, r.to_memory_id, r.relationship_type, r.created_at
+		FROM memory_relationships r
+		JOIN memories m ON m.id = r.from_memory_id
+		WHERE m.project_id = ?
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer relRows.Close()
+
+	for relRows.Next() {
+		var rel MemoryRelationship
+		if err := relRows.Scan(&rel.FromMemoryID, &rel.ToMemoryID, &rel.RelationshipType, &rel.CreatedAt); err != nil {
+			return nil, err
+		}
+		if included[rel.FromMemoryID] && included[rel.ToMemoryID] {
+			graph.Edges = append(graph.Edges, rel)
+		}
+	}
+
+	return graph, relRows.Err()
+}
+
+// CreateRelationship creates a relationship between two memories
+func (s *SQLiteStore) CreateRelationship(ctx context.Context, rel *MemoryRelationship) error {
+	rel.CreatedAt = time.Now()
+
+	_, err := s.exec(ctx, `
+		INSERT INTO memory_relationships (from_memory_id, to_memory_id, relationship_type, created_at)
+		VALUES (?, ?, ?, ?)
+	`, rel.FromMemoryID, rel.ToMemoryID, rel.RelationshipType, rel.CreatedAt)
+
+	return err
+}
+
+// ImportanceStats summarizes the importance of a project's memories (or of
+// all memories if projectID is empty) per method that chose it
+func (s *SQLiteStore) ImportanceStats(ctx context.Context, projectID string) ([]ImportanceStat, error) {
+	where, args := "", []interface{}{}
+	if projectID != "" {
+		where, args = " WHERE project_id = ?", append(args, projectID)
+	}
+
+	rows, err := s.query(ctx, `
+		SELECT COALESCE(importance_method, ''), COUNT(*), AVG(importance),
+			SUM(importance < 0.2),
+			SUM(importance >= 0.2 AND importance < 0.4),
+			SUM(importance >= 0.4 AND importance < 0.6),
+			SUM(importance >= 0.6 AND importance < 0.8),
+			SUM(importance >= 0.8)
+		FROM memories`+where+`
+		GROUP BY 1
+		ORDER BY 2 DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []ImportanceStat
+	for rows.Next() {
+		var stat ImportanceStat
+		b := &stat.Buckets
+		if err := rows.Scan(&stat.Method, &stat.Count, &stat.Average, &b[0], &b[1], &b[2], &b[3], &b[4]); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// ContextTypeStat counts the memories of one context type
+type ContextTypeStat struct {
+	ContextType string // Empty for memories without one
+	Count       int
+	Average     float64 // Average importance
+}
+
+// ContextTypeStats counts a project's memories (or all memories if
+// projectID is empty) per context type, most common first
+func (s *SQLiteStore) ContextTypeStats(ctx context.Context, projectID string) ([]ContextTypeStat, error) {
+	where, args := "", []interface{}{}
+	if projectID != "" {
+		where, args = " WHERE project_id = ?", append(args, projectID)
+	}
+
+	rows, err := s.query(ctx, `
+		SELECT COALESCE(context_type, ''), COUNT(*), AVG(importance)
+		FROM memories`+where+`
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []ContextTypeStat
+	for rows.Next() {
+		var stat ContextTypeStat
+		if err := rows.Scan(&stat.ContextType, &stat.Count, &stat.Average); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// RecordUsage appends an AI call to the usage ledger
+func (s *SQLiteStore) RecordUsage(ctx context.Context, record *UsageRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	_, err := s.exec(ctx, `
+		INSERT INTO ai_usage (provider, model, prompt_tokens, completion_tokens, cost, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, record.Provider, record.Model, record.PromptTokens, record.CompletionTokens, record.Cost,
+		record.CreatedAt.UTC())
+
+	return err
+}
+
+// UsageReport aggregates the usage ledger by UTC day and model for calls
+// made at or after since, newest day first
+func (s *SQLiteStore) UsageReport(ctx context.Context, since time.Time) ([]UsageSummary, error) {
+	rows, err := s.query(ctx, `
+		SELECT substr(created_at, 1, 10) AS day, provider, model, COUNT(*),
+			SUM(prompt_tokens), SUM(completion_tokens), SUM(cost)
+		FROM ai_usage
+		WHERE created_at >= ?
+		GROUP BY day, provider, model
+		ORDER BY day DESC, SUM(cost) DESC, model
+	`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []UsageSummary
+	for rows.Next() {
+		var summary UsageSummary
+		if err := rows.Scan(&summary.Day, &summary.Provider, &summary.Model, &summary.Calls,
+			&summary.PromptTokens, &summary.CompletionTokens, &summary.Cost); err != nil {
 			return nil, err
 		}
-		relationships = append(relationships, rel)
+		summaries = append(summaries, summary)
 	}
 
-	return relationships, nil
+	return summaries, rows.Err()
 }
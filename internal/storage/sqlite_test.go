@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestStore opens a fresh database with one project, whose ID it returns
+func newTestStore(t testing.TB) (*SQLiteStore, string) {
+	t.Helper()
+
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "alaala.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	project := &Project{ID: "project", Name: "test", Path: t.TempDir()}
+	if err := store.CreateProject(context.Background(), project); err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+	return store, project.ID
+}
+
+func TestCreateMemoryAssignsCreatedAt(t *testing.T) {
+	store, projectID := newTestStore(t)
+	ctx := context.Background()
+
+	before := time.Now()
+	mem := &Memory{ID: "m1", ProjectID: projectID, Content: "no timestamps", Importance: 0.5}
+	if err := store.CreateMemory(ctx, mem); err != nil {
+		t.Fatalf("CreateMemory: %v", err)
+	}
+
+	stored, err := store.GetMemory(ctx, mem.ID)
+	if err != nil {
+		t.Fatalf("GetMemory: %v", err)
+	}
+	if age := stored.CreatedAt.Sub(before); age < -time.Second || age > time.Second {
+		t.Errorf("created_at = %v, want within a second of %v", stored.CreatedAt, before)
+	}
+	if !stored.UpdatedAt.Equal(stored.CreatedAt) {
+		t.Errorf("updated_at = %v, want created_at %v", stored.UpdatedAt, stored.CreatedAt)
+	}
+}
+
+func TestCreateMemoryKeepsCreatedAt(t *testing.T) {
+	store, projectID := newTestStore(t)
+	ctx := context.Background()
+
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	mem := &Memory{ID: "m1", ProjectID: projectID, Content: "imported", Importance: 0.5, CreatedAt: created, UpdatedAt: created}
+	if err := store.CreateMemory(ctx, mem); err != nil {
+		t.Fatalf("CreateMemory: %v", err)
+	}
+
+	stored, err := store.GetMemory(ctx, mem.ID)
+	if err != nil {
+		t.Fatalf("GetMemory: %v", err)
+	}
+	if !stored.CreatedAt.Equal(created) {
+		t.Errorf("created_at = %v, want %v", stored.CreatedAt, created)
+	}
+}
+
+// saveAcrossOffsets saves an earlier and a later memory written in different
+// UTC offsets, and returns a time between them. As text, the earlier memory
+// sorts after that time and the later one before it.
+func saveAcrossOffsets(t *testing.T, store *SQLiteStore, projectID string) time.Time {
+	t.Helper()
+	east := time.FixedZone("UTC+5", 5*60*60)
+	west := time.FixedZone("UTC-5", -5*60*60)
+	for _, mem := range []*Memory{
+		{ID: "earlier", CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, east)}, // 05:00 UTC
+		{ID: "later", CreatedAt: time.Date(2024, 3, 1, 4, 0, 0, 0, west)},    // 09:00 UTC
+	} {
+		mem.ProjectID, mem.Content, mem.Importance, mem.UpdatedAt = projectID, mem.ID, 0.5, mem.CreatedAt
+		if err := store.CreateMemory(context.Background(), mem); err != nil {
+			t.Fatalf("CreateMemory: %v", err)
+		}
+	}
+	return time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+}
+
+func TestGetProjectGraphCreatedAfterComparesInstants(t *testing.T) {
+	store, projectID := newTestStore(t)
+	after := saveAcrossOffsets(t, store, projectID)
+
+	graph, err := store.GetProjectGraph(context.Background(), projectID, GraphFilter{CreatedAfter: &after})
+	if err != nil {
+		t.Fatalf("GetProjectGraph: %v", err)
+	}
+	var ids []string
+	for _, mem := range graph.Nodes {
+		ids = append(ids, mem.ID)
+	}
+	if len(ids) != 1 || ids[0] != "later" {
+		t.Errorf("GetProjectGraph returned %v, want only the later memory", ids)
+	}
+}
+
+func TestListMemoriesCreatedAfterComparesInstants(t *testing.T) {
+	store, projectID := newTestStore(t)
+	after := saveAcrossOffsets(t, store, projectID)
+
+	listed, _, err := store.ListMemories(context.Background(), ListOptions{ProjectID: projectID, CreatedAfter: after})
+	if err != nil {
+		t.Fatalf("ListMemories: %v", err)
+	}
+	var ids []string
+	for _, mem := range listed {
+		ids = append(ids, mem.ID)
+	}
+	if len(ids) != 1 || ids[0] != "later" {
+		t.Errorf("ListMemories returned %v, want only the later memory", ids)
+	}
+}
@@ -0,0 +1,40 @@
+package storage
+
+import "context"
+
+// StoreStats summarizes what the database holds
+type StoreStats struct {
+	SizeBytes     int64 `json:"size_bytes"`
+	SchemaVersion int   `json:"schema_version"`
+	Projects      int   `json:"projects"`
+	Memories      int   `json:"memories"`
+	Archived      int   `json:"archived"`      // Of Memories, those without a vector
+	OpenSessions  int   `json:"open_sessions"` // Sessions that were started but not ended
+}
+
+// LatestSchemaVersion returns the schema version this build migrates to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// Stats counts the database's projects, memories and open sessions
+func (s *SQLiteStore) Stats(ctx context.Context) (*StoreStats, error) {
+	stats := &StoreStats{}
+	var err error
+	if stats.SizeBytes, err = s.size(ctx); err != nil {
+		return nil, err
+	}
+
+	err = s.queryRow(ctx, `
+		SELECT
+			(SELECT COALESCE(MAX(version), 0) FROM schema_migrations),
+			(SELECT COUNT(*) FROM projects),
+			(SELECT COUNT(*) FROM memories),
+			(SELECT COUNT(*) FROM memories WHERE archived = 1),
+			(SELECT COUNT(*) FROM sessions WHERE ended_at IS NULL)
+	`).Scan(&stats.SchemaVersion, &stats.Projects, &stats.Memories, &stats.Archived, &stats.OpenSessions)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Transcript is the conversation accumulated for a session
+type Transcript struct {
+	SessionID     string
+	Text          string
+	CuratedOffset int // Bytes of Text already curated; the rest is pending
+	UpdatedAt     time.Time
+}
+
+// Pending returns the part of the transcript that has not been curated yet
+func (t *Transcript) Pending() string {
+	return t.Text[t.CuratedOffset:]
+}
+
+// AppendTranscript adds text to a session's transcript and returns the
+// whole transcript
+func (s *SQLiteStore) AppendTranscript(ctx context.Context, sessionID, text string) (*Transcript, error) {
+	_, err := s.exec(ctx, `
+		INSERT INTO session_transcripts (session_id, transcript, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (session_id) DO UPDATE
+		SET transcript = transcript || excluded.transcript, updated_at = excluded.updated_at
+	`, sessionID, text, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTranscript(ctx, sessionID)
+}
+
+// GetTranscript retrieves a session's transcript, or nil if nothing was
+// appended to it
+func (s *SQLiteStore) GetTranscript(ctx context.Context, sessionID string) (*Transcript, error) {
+	var t Transcript
+	err := s.queryRow(ctx, `
+		SELECT session_id, transcript, curated_offset, updated_at
+		FROM session_transcripts
+		WHERE session_id = ?
+	`, sessionID).Scan(&t.SessionID, &t.Text, &t.CuratedOffset, &t.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.CuratedOffset > len(t.Text) {
+		t.CuratedOffset = len(t.Text)
+	}
+	return &t, nil
+}
+
+// SetTranscriptCurated records that the first offset bytes of a session's
+// transcript have been curated
+func (s *SQLiteStore) SetTranscriptCurated(ctx context.Context, sessionID string, offset int) error {
+	result, err := s.exec(ctx, `UPDATE session_transcripts SET curated_offset = ? WHERE session_id = ?`, offset, sessionID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("no transcript for session: %s", sessionID)
+	}
+	return nil
+}
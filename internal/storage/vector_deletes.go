@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// QueueVectorDelete records that the vector of a deleted memory could not be
+// removed, so that a later delete can try again
+func (s *SQLiteStore) QueueVectorDelete(ctx context.Context, memoryID, reason string) error {
+	_, err := s.exec(ctx, `
+		INSERT INTO pending_vector_deletes (memory_id, last_error, queued_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (memory_id) DO UPDATE
+		SET attempts = attempts + 1, last_error = excluded.last_error
+	`, memoryID, reason, time.Now())
+	return err
+}
+
+// PendingVectorDeletes lists up to limit queued vector deletes, oldest first
+func (s *SQLiteStore) PendingVectorDeletes(ctx context.Context, limit int) ([]string, error) {
+	return s.memoryIDs(ctx, `
+		SELECT memory_id FROM pending_vector_deletes
+		ORDER BY queued_at, memory_id
+		LIMIT ?
+	`, limit)
+}
+
+// RemovePendingVectorDeletes drops vector deletes that have now succeeded
+// from the queue
+func (s *SQLiteStore) RemovePendingVectorDeletes(ctx context.Context, memoryIDs []string) error {
+	if len(memoryIDs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM pending_vector_deletes WHERE memory_id = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range memoryIDs {
+		if _, err := stmt.ExecContext(ctx, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
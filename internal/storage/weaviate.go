@@ -2,16 +2,30 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 
+	"github.com/go-openapi/strfmt"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate/auth"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/fault"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
 	"github.com/weaviate/weaviate/entities/models"
 )
 
 const (
 	// MemoryClassName is the Weaviate class name for memories
 	MemoryClassName = "Memory"
+
+	// deleteBatchSize bounds how many IDs go into a single batch delete,
+	// staying well below Weaviate's default QUERY_MAXIMUM_RESULTS
+	deleteBatchSize = 1000
+
+	// storeBatchSize bounds how many objects go into a single batch import
+	storeBatchSize = 100
 )
 
 // VectorSearchResult represents a result from vector search
@@ -23,43 +37,48 @@ type VectorSearchResult struct {
 
 // WeaviateStore handles vector storage operations
 type WeaviateStore struct {
-	client *weaviate.Client
-	ctx    context.Context
+	client    *weaviate.Client
+	dimension int
+	metric    DistanceMetric // Read from the class when the store is created
 }
 
 // NewWeaviateStore creates a new Weaviate store
 func NewWeaviateStore(host string, scheme string) (*WeaviateStore, error) {
-	cfg := weaviate.Config{
+	return newWeaviateStore(weaviate.Config{
 		Host:   host,
 		Scheme: scheme,
-	}
-
-	client, err := weaviate.NewClient(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Weaviate client: %w", err)
-	}
-
-	store := &WeaviateStore{
-		client: client,
-		ctx:    context.Background(),
-	}
-
-	// Initialize schema
-	if err := store.initSchema(); err != nil {
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
-	}
-
-	return store, nil
+	})
 }
 
-// NewWeaviateStoreWithAuth creates a new Weaviate store with authentication
+// NewWeaviateStoreWithAuth creates a new Weaviate store that authenticates
+// with an API key, sent as a bearer token as Weaviate Cloud expects
 func NewWeaviateStoreWithAuth(host string, scheme string, apiKey string) (*WeaviateStore, error) {
-	cfg := weaviate.Config{
+	return newWeaviateStore(weaviate.Config{
 		Host:       host,
 		Scheme:     scheme,
 		AuthConfig: auth.ApiKey{Value: apiKey},
+	})
+}
+
+// WeaviateAuthError is returned when Weaviate refuses the connection for
+// lack of a valid API key
+type WeaviateAuthError struct {
+	Host    string
+	Status  int
+	KeySent bool // Whether an API key was sent at all
+}
+
+func (e *WeaviateAuthError) Error() string {
+	if !e.KeySent {
+		return fmt.Sprintf("Weaviate at %s requires an API key (status %d); set storage.weaviate_api_key or WEAVIATE_API_KEY",
+			e.Host, e.Status)
 	}
+	return fmt.Sprintf("Weaviate at %s rejected the API key (status %d); check storage.weaviate_api_key or WEAVIATE_API_KEY",
+		e.Host, e.Status)
+}
 
+// newWeaviateStore connects to Weaviate and makes sure the schema exists
+func newWeaviateStore(cfg weaviate.Config) (*WeaviateStore, error) {
 	client, err := weaviate.NewClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Weaviate client: %w", err)
@@ -67,29 +86,67 @@ func NewWeaviateStoreWithAuth(host string, scheme string, apiKey string) (*Weavi
 
 	store := &WeaviateStore{
 		client: client,
-		ctx:    context.Background(),
+		metric: DistanceCosine,
+	}
+
+	// Fail early, with the address, rather than on the first schema call
+	if err := store.Ping(context.Background()); err != nil {
+		return nil, fmt.Errorf("Weaviate not reachable at %s://%s: %w", cfg.Scheme, cfg.Host, err)
 	}
 
 	// Initialize schema
-	if err := store.initSchema(); err != nil {
+	if err := store.initSchema(context.Background()); err != nil {
+		var clientErr *fault.WeaviateClientError
+		if errors.As(err, &clientErr) &&
+			(clientErr.StatusCode == http.StatusUnauthorized || clientErr.StatusCode == http.StatusForbidden) {
+			return nil, &WeaviateAuthError{Host: cfg.Host, Status: clientErr.StatusCode, KeySent: cfg.AuthConfig != nil}
+		}
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
 	return store, nil
 }
 
+// SetDimension sets the vector dimension that Store and Search accept.
+// Vectors of any other size are rejected with a DimensionMismatchError.
+func (w *WeaviateStore) SetDimension(dimension int) {
+	w.dimension = dimension
+}
+
+// DistanceMetric returns the distance metric of the Memory class, which
+// determines what the distances returned by Search mean
+func (w *WeaviateStore) DistanceMetric() DistanceMetric {
+	return w.metric
+}
+
+// workspaceIDProperty is filtered on by workspace-scoped searches
+var workspaceIDProperty = &models.Property{
+	Name:        "workspaceId",
+	DataType:    []string{"text"},
+	Description: "Workspace ID of the memory's project, empty if none",
+}
+
 // initSchema creates the Weaviate schema for memories
-func (w *WeaviateStore) initSchema() error {
+func (w *WeaviateStore) initSchema(ctx context.Context) error {
 	// Check if schema already exists
 	exists, err := w.client.Schema().ClassExistenceChecker().
 		WithClassName(MemoryClassName).
-		Do(w.ctx)
+		Do(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to check schema existence: %w", err)
 	}
 
 	if exists {
-		return nil // Schema already exists
+		class, err := w.client.Schema().ClassGetter().
+			WithClassName(MemoryClassName).
+			Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get schema: %w", err)
+		}
+		w.metric = classDistanceMetric(class)
+
+		// Classes created before workspaces existed lack workspaceId
+		return w.ensureProperty(ctx, class, workspaceIDProperty)
 	}
 
 	// Create schema
@@ -107,6 +164,7 @@ func (w *WeaviateStore) initSchema() error {
 				DataType:    []string{"text"},
 				Description: "Project ID",
 			},
+			workspaceIDProperty,
 			{
 				Name:        "sessionId",
 				DataType:    []string{"text"},
@@ -153,7 +211,7 @@ func (w *WeaviateStore) initSchema() error {
 
 	err = w.client.Schema().ClassCreator().
 		WithClass(classObj).
-		Do(w.ctx)
+		Do(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
@@ -161,33 +219,140 @@ func (w *WeaviateStore) initSchema() error {
 	return nil
 }
 
-// Store stores a memory with its embedding
-func (w *WeaviateStore) Store(id string, content string, embedding []float32, metadata map[string]interface{}) error {
-	properties := map[string]interface{}{
-		"content": content,
+// ensureProperty adds a property to the existing Memory class unless it
+// already has it
+func (w *WeaviateStore) ensureProperty(ctx context.Context, class *models.Class, property *models.Property) error {
+	for _, p := range class.Properties {
+		if p.Name == property.Name {
+			return nil
+		}
 	}
 
-	// Add all metadata as properties
-	for k, v := range metadata {
-		properties[k] = v
+	err := w.client.Schema().PropertyCreator().
+		WithClassName(MemoryClassName).
+		WithProperty(property).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add property %s: %w", property.Name, err)
 	}
 
-	_, err := w.client.Data().Creator().
-		WithClassName(MemoryClassName).
-		WithID(id).
-		WithProperties(properties).
-		WithVector(embedding).
-		Do(w.ctx)
+	return nil
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to store memory: %w", err)
+// Store stores or replaces a memory with its embedding. It goes through the
+// batch endpoint because, unlike creating an object, a batch import
+// overwrites an object that already exists.
+func (w *WeaviateStore) Store(ctx context.Context, id string, content string, embedding []float32, metadata map[string]interface{}) error {
+	err := w.StoreBatch(ctx, []Vector{{ID: id, Content: content, Embedding: embedding, Metadata: metadata}})
+	var partial *PartialBatchError
+	if errors.As(err, &partial) && len(partial.Failed) == 1 {
+		return fmt.Errorf("failed to store memory: %s", partial.Failed[0].Reason)
+	}
+	return err
+}
+
+// StoreBatch stores or replaces many memories with batch import requests.
+// Weaviate accepts a batch as a whole and reports failures per object, so
+// objects it rejected are returned in a *PartialBatchError; the others are
+// stored. An error of another type means nothing was stored.
+func (w *WeaviateStore) StoreBatch(ctx context.Context, vectors []Vector) error {
+	for _, v := range vectors {
+		if err := checkDimension("store", w.dimension, v.Embedding); err != nil {
+			return err
+		}
+	}
+
+	partial := &PartialBatchError{Op: "store", Total: len(vectors)}
+	for start := 0; start < len(vectors); start += storeBatchSize {
+		end := start + storeBatchSize
+		if end > len(vectors) {
+			end = len(vectors)
+		}
+
+		objects := make([]*models.Object, 0, end-start)
+		for _, v := range vectors[start:end] {
+			properties := map[string]interface{}{
+				"content": v.Content,
+			}
+			for k, value := range v.Metadata {
+				properties[k] = value
+			}
+			objects = append(objects, &models.Object{
+				Class:      MemoryClassName,
+				ID:         strfmt.UUID(v.ID),
+				Properties: properties,
+				Vector:     v.Embedding,
+			})
+		}
+
+		responses, err := w.client.Batch().ObjectsBatcher().
+			WithObjects(objects...).
+			Do(ctx)
+		if err != nil {
+			if start == 0 {
+				return fmt.Errorf("failed to batch store memories: %w", err)
+			}
+			// Earlier requests went through; report the rest as failed
+			for _, v := range vectors[start:] {
+				partial.Failed = append(partial.Failed, BatchFailure{ID: v.ID, Reason: err.Error()})
+			}
+			return partial
+		}
+		partial.Failed = append(partial.Failed, objectFailures(responses)...)
 	}
 
+	if len(partial.Failed) > 0 {
+		return partial
+	}
 	return nil
 }
 
-// Search performs vector similarity search
-func (w *WeaviateStore) Search(embedding []float32, limit int, filterMap map[string]interface{}) ([]VectorSearchResult, error) {
+// objectFailures collects the objects a batch import response rejected
+func objectFailures(responses []models.ObjectsGetResponse) []BatchFailure {
+	var failures []BatchFailure
+	for _, resp := range responses {
+		if resp.Result == nil {
+			continue
+		}
+		failed := resp.Result.Status != nil && *resp.Result.Status == "FAILED"
+		reason := errorMessages(resp.Result.Errors)
+		if !failed && reason == "" {
+			continue
+		}
+		if reason == "" {
+			reason = "rejected without a reason"
+		}
+		failures = append(failures, BatchFailure{ID: resp.ID.String(), Reason: reason})
+	}
+	return failures
+}
+
+// errorMessages joins the messages of a Weaviate error response
+func errorMessages(resp *models.ErrorResponse) string {
+	if resp == nil {
+		return ""
+	}
+	messages := make([]string, 0, len(resp.Error))
+	for _, item := range resp.Error {
+		if item != nil && item.Message != "" {
+			messages = append(messages, item.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Search performs vector similarity search. Supported filters are
+// "project_id" and "workspace_id" (string equality), "importance_gte"
+// (minimum importance), "context_types" and "tags" (both []string, matching
+// any value), "tags_match" ("all" to require every tag instead of any) and
+// "created_after" and "created_before" (int64 Unix seconds, the former
+// inclusive and the latter exclusive); all are applied by Weaviate so that
+// limit counts only matching memories.
+func (w *WeaviateStore) Search(ctx context.Context, embedding []float32, limit int, filterMap map[string]interface{}) ([]VectorSearchResult, error) {
+	if err := checkDimension("search", w.dimension, embedding); err != nil {
+		return nil, err
+	}
+
 	// Build near vector argument
 	nearVector := w.client.GraphQL().NearVectorArgBuilder().
 		WithVector(embedding)
@@ -195,21 +360,28 @@ func (w *WeaviateStore) Search(embedding []float32, limit int, filterMap map[str
 	// Build the query
 	query := w.client.GraphQL().Get().
 		WithClassName(MemoryClassName).
+		WithFields(
+			graphql.Field{Name: "projectId"},
+			graphql.Field{Name: "importance"},
+			graphql.Field{Name: "_additional", Fields: []graphql.Field{
+				{Name: "id"},
+				{Name: "distance"},
+			}},
+		).
 		WithNearVector(nearVector).
 		WithLimit(limit)
 
-	// Add filters if provided
-	if projectID, ok := filterMap["project_id"].(string); ok && projectID != "" {
-		// Simple project filter - just query and parse results manually
-		// More complex filters can be added later
-		_ = projectID // Will use in manual filtering below
+	if where := searchFilter(filterMap); where != nil {
+		query = query.WithWhere(where)
 	}
 
-	// Execute the query - we need to get the raw response
-	result, err := query.Do(w.ctx)
+	result, err := query.Do(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("weaviate query failed: %w", err)
 	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("weaviate query failed: %s", result.Errors[0].Message)
+	}
 
 	// Parse results
 	var searchResults []VectorSearchResult
@@ -256,20 +428,6 @@ func (w *WeaviateStore) Search(embedding []float32, limit int, filterMap map[str
 			continue
 		}
 
-		// Apply project filter if specified (manual filtering)
-		if projectID, ok := filterMap["project_id"].(string); ok && projectID != "" {
-			if projID, ok := memData["projectId"].(string); ok && projID != projectID {
-				continue // Skip if project doesn't match
-			}
-		}
-
-		// Apply importance filter if specified
-		if minImp, ok := filterMap["importance_gte"].(float64); ok {
-			if imp, ok := memData["importance"].(float64); ok && imp < minImp {
-				continue // Skip if importance too low
-			}
-		}
-
 		searchResults = append(searchResults, VectorSearchResult{
 			ID:       id,
 			Distance: distance,
@@ -280,12 +438,153 @@ func (w *WeaviateStore) Search(embedding []float32, limit int, filterMap map[str
 	return searchResults, nil
 }
 
+// Vectors returns the stored vectors of the given memories by ID; memories
+// without a vector are left out
+func (w *WeaviateStore) Vectors(ctx context.Context, ids []string) (map[string][]float32, error) {
+	vectors := make(map[string][]float32, len(ids))
+	if len(ids) == 0 {
+		return vectors, nil
+	}
+
+	result, err := w.client.GraphQL().Get().
+		WithClassName(MemoryClassName).
+		WithFields(graphql.Field{Name: "_additional", Fields: []graphql.Field{
+			{Name: "id"},
+			{Name: "vector"},
+		}}).
+		WithWhere(filters.Where().
+			WithPath([]string{"id"}).
+			WithOperator(filters.ContainsAny).
+			WithValueText(ids...)).
+		WithLimit(len(ids)).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("weaviate query failed: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("weaviate query failed: %s", result.Errors[0].Message)
+	}
+
+	getData, _ := result.Data["Get"].(map[string]interface{})
+	memories, _ := getData[MemoryClassName].([]interface{})
+	for _, item := range memories {
+		memData, _ := item.(map[string]interface{})
+		additional, _ := memData["_additional"].(map[string]interface{})
+		id, _ := additional["id"].(string)
+		values, _ := additional["vector"].([]interface{})
+		if id == "" || len(values) == 0 {
+			continue
+		}
+
+		vector := make([]float32, 0, len(values))
+		for _, v := range values {
+			f, ok := v.(float64)
+			if !ok {
+				break
+			}
+			vector = append(vector, float32(f))
+		}
+		if len(vector) == len(values) {
+			vectors[id] = vector
+		}
+	}
+
+	return vectors, nil
+}
+
+// searchFilter builds a Weaviate where filter from Search's filter map,
+// returning nil when there is nothing to filter on
+func searchFilter(filterMap map[string]interface{}) *filters.WhereBuilder {
+	var operands []*filters.WhereBuilder
+
+	if projectID, ok := filterMap["project_id"].(string); ok && projectID != "" {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"projectId"}).
+			WithOperator(filters.Equal).
+			WithValueText(projectID))
+	}
+
+	if workspaceID, ok := filterMap["workspace_id"].(string); ok && workspaceID != "" {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"workspaceId"}).
+			WithOperator(filters.Equal).
+			WithValueText(workspaceID))
+	}
+
+	if minImp, ok := filterMap["importance_gte"].(float64); ok {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"importance"}).
+			WithOperator(filters.GreaterThanEqual).
+			WithValueNumber(minImp))
+	}
+
+	if contextTypes, ok := filterMap["context_types"].([]string); ok && len(contextTypes) > 0 {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"contextType"}).
+			WithOperator(filters.ContainsAny).
+			WithValueText(contextTypes...))
+	}
+
+	if tags, ok := filterMap["tags"].([]string); ok && len(tags) > 0 {
+		operator := filters.ContainsAny
+		if filterMap["tags_match"] == "all" {
+			operator = filters.ContainsAll
+		}
+		operands = append(operands, filters.Where().
+			WithPath([]string{"tags"}).
+			WithOperator(operator).
+			WithValueText(tags...))
+	}
+
+	if after, ok := filterMap["created_after"].(int64); ok {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"createdAt"}).
+			WithOperator(filters.GreaterThanEqual).
+			WithValueNumber(float64(after)))
+	}
+
+	if before, ok := filterMap["created_before"].(int64); ok {
+		operands = append(operands, filters.Where().
+			WithPath([]string{"createdAt"}).
+			WithOperator(filters.LessThan).
+			WithValueNumber(float64(before)))
+	}
+
+	switch len(operands) {
+	case 0:
+		return nil
+	case 1:
+		return operands[0]
+	default:
+		return filters.Where().
+			WithOperator(filters.And).
+			WithOperands(operands)
+	}
+}
+
+// UpdateProperties changes some of a stored memory's properties, leaving its
+// vector and the other properties as they are
+func (w *WeaviateStore) UpdateProperties(ctx context.Context, id string, properties map[string]interface{}) error {
+	err := w.client.Data().Updater().
+		WithMerge().
+		WithClassName(MemoryClassName).
+		WithID(id).
+		WithProperties(properties).
+		Do(ctx)
+
+	if err != nil {
+		return fmt.Errorf("failed to update memory: %w", err)
+	}
+
+	return nil
+}
+
 // Delete deletes a memory by ID
-func (w *WeaviateStore) Delete(id string) error {
+func (w *WeaviateStore) Delete(ctx context.Context, id string) error {
 	err := w.client.Data().Deleter().
 		WithClassName(MemoryClassName).
 		WithID(id).
-		Do(w.ctx)
+		Do(ctx)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete memory: %w", err)
@@ -294,6 +593,80 @@ func (w *WeaviateStore) Delete(id string) error {
 	return nil
 }
 
+// DeleteBatch deletes many memories with batch-delete-by-filter requests,
+// returning the number of objects Weaviate reported as deleted. Objects
+// Weaviate matched but failed to delete are returned in a
+// *PartialBatchError; IDs it has no object for are not failures.
+func (w *WeaviateStore) DeleteBatch(ctx context.Context, ids []string) (int, error) {
+	deleted := 0
+	partial := &PartialBatchError{Op: "delete", Total: len(ids)}
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		where := filters.Where().
+			WithPath([]string{"id"}).
+			WithOperator(filters.ContainsAny).
+			WithValueText(ids[start:end]...)
+
+		resp, err := w.client.Batch().ObjectsBatchDeleter().
+			WithClassName(MemoryClassName).
+			WithWhere(where).
+			WithOutput("verbose").
+			Do(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to batch delete memories: %w", err)
+		}
+
+		if resp != nil && resp.Results != nil {
+			deleted += int(resp.Results.Successful)
+			partial.Failed = append(partial.Failed, deleteFailures(resp.Results)...)
+		}
+	}
+
+	if len(partial.Failed) > 0 {
+		return deleted, partial
+	}
+	return deleted, nil
+}
+
+// deleteFailures collects the objects a verbose batch delete response
+// reports as failed
+func deleteFailures(results *models.BatchDeleteResponseResults) []BatchFailure {
+	var failures []BatchFailure
+	for _, obj := range results.Objects {
+		if obj == nil || obj.Status == nil || *obj.Status != "FAILED" {
+			continue
+		}
+		reason := errorMessages(obj.Errors)
+		if reason == "" {
+			reason = "failed without a reason"
+		}
+		failures = append(failures, BatchFailure{ID: obj.ID.String(), Reason: reason})
+	}
+	return failures
+}
+
+// Ping checks that Weaviate is up and ready to serve requests
+func (w *WeaviateStore) Ping(ctx context.Context) error {
+	ready, err := w.client.Misc().ReadyChecker().Do(ctx)
+	if err != nil {
+		// The client's wrapper only adds "status code: -1" to the network
+		// error
+		var clientErr *fault.WeaviateClientError
+		if errors.As(err, &clientErr) && clientErr.DerivedFromError != nil {
+			return clientErr.DerivedFromError
+		}
+		return err
+	}
+	if !ready {
+		return fmt.Errorf("weaviate is not ready")
+	}
+	return nil
+}
+
 // Close closes the Weaviate connection
 func (w *WeaviateStore) Close() error {
 	// Weaviate Go client doesn't have explicit close
@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+)
+
+// fakeWeaviate serves the parts of Weaviate's REST API that the batch
+// operations use, failing the objects whose IDs are in fail. Requests with a
+// fixture are answered with the testdata file instead.
+type fakeWeaviate struct {
+	mu       sync.Mutex
+	objects  map[string]bool
+	fail     map[string]string // Reason by object ID
+	fixtures map[string]string // Testdata file by "METHOD /path"
+	requests int               // Object and batch requests served
+}
+
+func newFakeWeaviate(t testing.TB) (*fakeWeaviate, *WeaviateStore) {
+	t.Helper()
+
+	fake := &fakeWeaviate{objects: make(map[string]bool), fail: make(map[string]string), fixtures: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := weaviate.NewClient(weaviate.Config{Host: u.Host, Scheme: u.Scheme})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return fake, &WeaviateStore{client: client, metric: DistanceCosine}
+}
+
+func (f *fakeWeaviate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if fixture, ok := f.fixtures[r.Method+" "+r.URL.Path]; ok {
+		f.requests++
+		body, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/batch/objects":
+		f.requests++
+		f.batchStore(w, r)
+	case r.Method == http.MethodDelete && r.URL.Path == "/v1/batch/objects":
+		f.requests++
+		f.batchDelete(w, r)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/objects/"):
+		f.requests++
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if !f.objects[id] {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.objects, id)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/meta":
+		writeJSON(w, map[string]string{"version": "1.27.0"})
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusNotImplemented)
+	}
+}
+
+// batchStore answers a batch import, which overwrites existing objects
+func (f *fakeWeaviate) batchStore(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Objects []struct {
+			ID string `json:"id"`
+		} `json:"objects"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	var objects []map[string]interface{}
+	for _, obj := range req.Objects {
+		result := map[string]interface{}{"status": "SUCCESS"}
+		if reason, ok := f.fail[obj.ID]; ok {
+			result = map[string]interface{}{
+				"status": "FAILED",
+				"errors": map[string]interface{}{"error": []map[string]string{{"message": reason}}},
+			}
+		} else {
+			f.objects[obj.ID] = true
+		}
+		objects = append(objects, map[string]interface{}{"class": MemoryClassName, "id": obj.ID, "result": result})
+	}
+	writeJSON(w, objects)
+}
+
+// batchDelete answers a verbose batch delete by ID filter
+func (f *fakeWeaviate) batchDelete(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Match struct {
+			Where struct {
+				Path           []string `json:"path"`
+				ValueTextArray []string `json:"valueTextArray"`
+			} `json:"where"`
+		} `json:"match"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	type object struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Errors *struct {
+			Error []map[string]string `json:"error"`
+		} `json:"errors,omitempty"`
+	}
+	var objects []object
+	successful, failed := 0, 0
+	for _, id := range req.Match.Where.ValueTextArray {
+		if !f.objects[id] {
+			continue
+		}
+		if reason, ok := f.fail[id]; ok {
+			failed++
+			objects = append(objects, object{ID: id, Status: "FAILED", Errors: &struct {
+				Error []map[string]string `json:"error"`
+			}{[]map[string]string{{"message": reason}}}})
+			continue
+		}
+		delete(f.objects, id)
+		successful++
+		objects = append(objects, object{ID: id, Status: "SUCCESS"})
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"output": "verbose",
+		"results": map[string]interface{}{
+			"matches":    successful + failed,
+			"successful": successful,
+			"failed":     failed,
+			"objects":    objects,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// addObjects stores n objects with fresh IDs and returns the IDs
+func (f *fakeWeaviate) addObjects(n int) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = uuid.New().String()
+		f.objects[ids[i]] = true
+	}
+	return ids
+}
+
+func TestWeaviateDeleteBatchChunks(t *testing.T) {
+	fake, store := newFakeWeaviate(t)
+	ids := fake.addObjects(2500)
+	ids = append(ids, uuid.New().String()) // Never stored
+
+	deleted, err := store.DeleteBatch(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("DeleteBatch: %v", err)
+	}
+	if deleted != 2500 {
+		t.Errorf("deleted %d, want 2500", deleted)
+	}
+	if len(fake.objects) != 0 {
+		t.Errorf("%d objects left", len(fake.objects))
+	}
+	if fake.requests != 3 {
+		t.Errorf("made %d requests, want 3 of up to %d IDs", fake.requests, deleteBatchSize)
+	}
+}
+
+func TestWeaviateStoreBatchReportsRejectedObjects(t *testing.T) {
+	fake, store := newFakeWeaviate(t)
+	fake.fixtures["POST /v1/batch/objects"] = "batch_store_mixed.json"
+
+	ids := []string{
+		"11111111-1111-4111-8111-111111111111",
+		"22222222-2222-4222-8222-222222222222",
+		"33333333-3333-4333-8333-333333333333",
+		"44444444-4444-4444-8444-444444444444",
+		"55555555-5555-4555-8555-555555555555",
+	}
+	vectors := make([]Vector, len(ids))
+	for i, id := range ids {
+		vectors[i] = Vector{ID: id, Content: "memory", Embedding: []float32{1, 0, 0}}
+	}
+
+	err := store.StoreBatch(context.Background(), vectors)
+	var partial *PartialBatchError
+	if !errors.As(err, &partial) {
+		t.Fatalf("StoreBatch error = %v, want a *PartialBatchError", err)
+	}
+	if partial.Op != "store" || partial.Total != 5 {
+		t.Errorf("got op %q total %d, want store of 5", partial.Op, partial.Total)
+	}
+
+	want := []BatchFailure{
+		{ID: ids[1], Reason: "vector lengths don't match: 384 vs 768"},
+		{ID: ids[3], Reason: "no such prop with name 'actionRequired' found in class 'Memory'; context deadline exceeded"},
+		{ID: ids[4], Reason: "rejected without a reason"},
+	}
+	if !reflect.DeepEqual(partial.Failed, want) {
+		t.Errorf("failures = %+v\nwant %+v", partial.Failed, want)
+	}
+	if msg := partial.Error(); !strings.Contains(msg, "batch store failed for 3 of 5 objects") {
+		t.Errorf("error message = %q", msg)
+	}
+}
+
+func TestWeaviateStoreReplacesExistingObject(t *testing.T) {
+	ctx := context.Background()
+	fake, store := newFakeWeaviate(t)
+	id := fake.addObjects(1)[0]
+
+	if err := store.Store(ctx, id, "Deploys go through CI", []float32{1, 0, 0}, nil); err != nil {
+		t.Fatalf("Store over an existing object: %v", err)
+	}
+	if len(fake.objects) != 1 {
+		t.Errorf("%d objects stored, want the one replaced", len(fake.objects))
+	}
+
+	rejected := uuid.New().String()
+	fake.fail[rejected] = "shard Memory_abc is read-only"
+	err := store.Store(ctx, rejected, "The cache is warmed on startup", []float32{1, 0, 0}, nil)
+	if err == nil || !strings.Contains(err.Error(), "shard Memory_abc is read-only") {
+		t.Errorf("Store error = %v, want the rejection reason", err)
+	}
+}
+
+func TestWeaviateDeleteBatchReportsFailedObjects(t *testing.T) {
+	fake, store := newFakeWeaviate(t)
+	fake.fixtures["DELETE /v1/batch/objects"] = "batch_delete_mixed.json"
+
+	deleted, err := store.DeleteBatch(context.Background(), []string{
+		"11111111-1111-4111-8111-111111111111",
+		"22222222-2222-4222-8222-222222222222",
+		"33333333-3333-4333-8333-333333333333",
+		"66666666-6666-4666-8666-666666666666", // Never stored, so not matched
+	})
+	if deleted != 2 {
+		t.Errorf("deleted %d, want 2", deleted)
+	}
+
+	var partial *PartialBatchError
+	if !errors.As(err, &partial) {
+		t.Fatalf("DeleteBatch error = %v, want a *PartialBatchError", err)
+	}
+	want := []BatchFailure{{ID: "22222222-2222-4222-8222-222222222222", Reason: "shard Memory_abc is read-only"}}
+	if !reflect.DeepEqual(partial.Failed, want) || partial.Total != 4 {
+		t.Errorf("got %d of %d failed: %+v, want %+v", len(partial.Failed), partial.Total, partial.Failed, want)
+	}
+}
+
+// BenchmarkDeleteBatch deletes 10,000 memories from a fake Weaviate with
+// batch deletes and, for comparison, one request per memory
+func BenchmarkDeleteBatch(b *testing.B) {
+	const count = 10000
+
+	for _, mode := range []string{"batched", "single"} {
+		b.Run(fmt.Sprintf("%s-%d", mode, count), func(b *testing.B) {
+			fake, store := newFakeWeaviate(b)
+			ctx := context.Background()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				ids := fake.addObjects(count)
+				fake.requests = 0
+				b.StartTimer()
+
+				if mode == "batched" {
+					if _, err := store.DeleteBatch(ctx, ids); err != nil {
+						b.Fatal(err)
+					}
+				} else {
+					for _, id := range ids {
+						if err := store.Delete(ctx, id); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+			}
+
+			b.ReportMetric(float64(fake.requests), "requests/op")
+			b.ReportMetric(float64(count)*float64(b.N)/b.Elapsed().Seconds(), "deletes/s")
+		})
+	}
+}
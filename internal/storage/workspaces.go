@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Workspace groups related projects, such as the repositories of one
+// customer, so their memories can be searched together
+type Workspace struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+// WorkspaceStat aggregates the memories of every project in a workspace
+type WorkspaceStat struct {
+	Workspace         Workspace
+	Projects          int
+	Memories          int
+	AverageImportance float64
+	ActionRequired    int
+}
+
+// CreateWorkspace creates a new workspace. Workspace names are unique.
+func (s *SQLiteStore) CreateWorkspace(ctx context.Context, workspace *Workspace) error {
+	workspace.CreatedAt = time.Now()
+
+	_, err := s.exec(ctx, `
+		INSERT INTO workspaces (id, name, created_at)
+		VALUES (?, ?, ?)
+	`, workspace.ID, workspace.Name, workspace.CreatedAt)
+
+	return err
+}
+
+// GetWorkspace retrieves a workspace by ID, returning nil if it does not exist
+func (s *SQLiteStore) GetWorkspace(ctx context.Context, id string) (*Workspace, error) {
+	return s.getWorkspace(ctx, `SELECT id, name, created_at FROM workspaces WHERE id = ?`, id)
+}
+
+// GetWorkspaceByName retrieves a workspace by name, returning nil if it does
+// not exist
+func (s *SQLiteStore) GetWorkspaceByName(ctx context.Context, name string) (*Workspace, error) {
+	return s.getWorkspace(ctx, `SELECT id, name, created_at FROM workspaces WHERE name = ?`, name)
+}
+
+func (s *SQLiteStore) getWorkspace(ctx context.Context, query string, arg string) (*Workspace, error) {
+	var workspace Workspace
+	err := s.queryRow(ctx, query, arg).Scan(&workspace.ID, &workspace.Name, &workspace.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// ListWorkspaces retrieves all workspaces ordered by name
+func (s *SQLiteStore) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
+	rows, err := s.query(ctx, `SELECT id, name, created_at FROM workspaces ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []Workspace
+	for rows.Next() {
+		var workspace Workspace
+		if err := rows.Scan(&workspace.ID, &workspace.Name, &workspace.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// SetProjectWorkspace moves a project into a workspace, or out of any
+// workspace when workspaceID is nil
+func (s *SQLiteStore) SetProjectWorkspace(ctx context.Context, projectID string, workspaceID *string) error {
+	result, err := s.exec(ctx, `
+		UPDATE projects SET workspace_id = ?, updated_at = ? WHERE id = ?
+	`, workspaceID, time.Now(), projectID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("project not found: %s", projectID)
+	}
+	return nil
+}
+
+// WorkspaceProjects retrieves the projects in a workspace ordered by name
+func (s *SQLiteStore) WorkspaceProjects(ctx context.Context, workspaceID string) ([]Project, error) {
+	return s.listProjects(ctx, `SELECT `+projectColumns+` FROM projects WHERE workspace_id = ? ORDER BY name`, workspaceID)
+}
+
+// WorkspaceStats aggregates memories per workspace, including workspaces
+// without projects or memories
+func (s *SQLiteStore) WorkspaceStats(ctx context.Context) ([]WorkspaceStat, error) {
+	rows, err := s.query(ctx, `
+		SELECT w.id, w.name, w.created_at,
+			COUNT(DISTINCT p.id), COUNT(m.id),
+			COALESCE(AVG(m.importance), 0),
+			COALESCE(SUM(CASE WHEN m.action_required THEN 1 ELSE 0 END), 0)
+		FROM workspaces w
+		LEFT JOIN projects p ON p.workspace_id = w.id
+		LEFT JOIN memories m ON m.project_id = p.id
+		GROUP BY w.id, w.name, w.created_at
+		ORDER BY w.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []WorkspaceStat
+	for rows.Next() {
+		var stat WorkspaceStat
+		if err := rows.Scan(&stat.Workspace.ID, &stat.Workspace.Name, &stat.Workspace.CreatedAt,
+			&stat.Projects, &stat.Memories, &stat.AverageImportance, &stat.ActionRequired); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
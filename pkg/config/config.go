@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,14 +17,53 @@ type Config struct {
 	Embeddings EmbeddingsConfig `yaml:"embeddings"`
 	Retrieval  RetrievalConfig  `yaml:"retrieval"`
 	Logging    LoggingConfig    `yaml:"logging"`
+	Importance ImportanceConfig `yaml:"importance"`
+	Curation   CurationConfig   `yaml:"curation"`
+	Expiry     ExpiryConfig     `yaml:"expiry"`
+	MCP        MCPConfig        `yaml:"mcp"`
+	Digest     DigestConfig     `yaml:"digest"`
+
+	// ContextTypes adds context types to the built-in ones, e.g. for a
+	// domain the built-in taxonomy does not cover
+	ContextTypes []ContextTypeConfig `yaml:"context_types"`
+
+	// Locale is the language of text alaala generates, such as durations
+	// in the session primer: "en", "de", "es" or "fr" (others use English)
+	Locale string `yaml:"locale"`
+}
+
+// ContextTypeConfig defines a custom context type
+type ContextTypeConfig struct {
+	Name        string `yaml:"name"`        // Upper case, e.g. LEGAL_CONSTRAINT
+	Description string `yaml:"description"` // When to use it; shown to the AI curator
+
+	// Importance is the starting importance estimate of its memories (0-1,
+	// 0 uses the default); PrimerWeight multiplies their importance when
+	// the session primer picks key memories (0 means 1)
+	Importance   float64 `yaml:"importance"`
+	PrimerWeight float64 `yaml:"primer_weight"`
 }
 
 // StorageConfig holds storage-related configuration
 type StorageConfig struct {
+	// Mode is where vectors are kept: "docker" (default) in the Weaviate
+	// server at WeaviateURL, "embedded" in the SQLite database, searched in
+	// process without any server
+	Mode        string `yaml:"mode"`
 	WeaviateURL string `yaml:"weaviate_url"`
 	SQLitePath  string `yaml:"sqlite_path"`
+
+	// WeaviateAPIKey authenticates with Weaviate, e.g. on Weaviate Cloud;
+	// empty uses WEAVIATE_API_KEY, and no key at all connects anonymously
+	WeaviateAPIKey string `yaml:"weaviate_api_key"`
 }
 
+// Storage modes
+const (
+	StorageModeDocker   = "docker"
+	StorageModeEmbedded = "embedded"
+)
+
 // AIConfig holds AI provider configuration
 type AIConfig struct {
 	Provider      string `yaml:"provider"` // "anthropic", "openrouter", or "ollama"
@@ -30,57 +71,287 @@ type AIConfig struct {
 	Model         string `yaml:"model"`
 	OpenRouterURL string `yaml:"openrouter_url"` // Default: https://openrouter.ai/api/v1
 	OllamaURL     string `yaml:"ollama_url"`     // Default: http://localhost:11434
+	TrackUsage    bool   `yaml:"track_usage"`    // Record tokens and cost of each call in the ai_usage table
+
+	// ClientSampling asks the MCP client's own model, through sampling, to
+	// write session summaries and compress the primer when the client
+	// supports it; the provider above is the fallback
+	ClientSampling bool `yaml:"client_sampling"`
+
+	// MaxOutputTokens caps retries of truncated curation responses; the
+	// model's own output limit applies when it is lower
+	MaxOutputTokens int `yaml:"max_output_tokens"`
+
+	// Timeout bounds a single request to the provider; 0 keeps the
+	// provider's default (120s, or 300s for Ollama). Failed Anthropic and
+	// OpenRouter requests are retried, each attempt with this timeout.
+	Timeout time.Duration `yaml:"timeout"`
 }
 
 // EmbeddingsConfig holds embeddings configuration
 type EmbeddingsConfig struct {
-	Provider  string `yaml:"provider"` // "local", "ollama", or "openai"
+	Provider  string `yaml:"provider"` // "local", "ollama", or "openai" (uses OPENAI_API_KEY)
 	Model     string `yaml:"model"`
+	ModelPath string `yaml:"model_path"` // Local model directory (default: <data dir>/models/<model>)
 	OllamaURL string `yaml:"ollama_url"` // Default: http://localhost:11434
+
+	// InvalidVectors decides what happens to embeddings containing NaN or
+	// Inf: "reject" (default) or "zero" the bad components
+	InvalidVectors string `yaml:"invalid_vectors"`
+
+	// QueryCacheSize caps how many search query embeddings are kept so that
+	// repeated searches skip the embedder; 0 disables the cache.
+	// QueryCacheTTL is how long one is kept; 0 keeps it until evicted.
+	QueryCacheSize int           `yaml:"query_cache_size"`
+	QueryCacheTTL  time.Duration `yaml:"query_cache_ttl"`
 }
 
 // RetrievalConfig holds memory retrieval configuration
 type RetrievalConfig struct {
-	MaxMemories       int     `yaml:"max_memories"`
+	MaxMemories       int     `yaml:"max_memories"` // Memories per section of the session primer
 	MinImportance     float64 `yaml:"min_importance"`
+	MinSimilarity     float64 `yaml:"min_similarity"`      // Vector hits less similar than this are dropped (0 keeps all)
 	IncludeGraphDepth int     `yaml:"include_graph_depth"` // Depth to traverse relationships
+
+	// ResourceMemoryLimit caps the memories returned by the project-memories resource
+	ResourceMemoryLimit int `yaml:"resource_memory_limit"`
+
+	// PrimerMaxChars compresses longer session primers (0 = never)
+	PrimerMaxChars int `yaml:"primer_max_chars"`
+
+	// ContextWeights multiplies relevance by context type (default 1.0),
+	// e.g. {DECISION: 1.2, PREFERENCE: 0.8}
+	ContextWeights map[string]float64 `yaml:"context_weights"`
+
+	RecencyDecay RecencyDecayConfig `yaml:"recency_decay"`
+
+	// Relevance scoring: similarity and importance are blended into a 0-1
+	// base score, then the boosts are added for trigger phrase matches,
+	// action-required memories and content containing the query verbatim.
+	// The two base weights are relative; they are scaled to sum to 1.
+	SimilarityWeight float64 `yaml:"similarity_weight"`
+	ImportanceWeight float64 `yaml:"importance_weight"`
+	TriggerBoost     float64 `yaml:"trigger_boost"`
+	ActionBoost      float64 `yaml:"action_boost"`
+	KeywordBoost     float64 `yaml:"keyword_boost"`
+
+	// MMRLambda diversifies search results by maximal marginal relevance:
+	// 1 ranks by relevance alone, lower values increasingly skip memories
+	// that repeat ones already returned; 0 disables it
+	MMRLambda float64 `yaml:"mmr_lambda"`
+
+	// TriggerFuzzy lets trigger phrase words of five or more letters match
+	// with one typo, e.g. "authentcation" for "authentication"
+	TriggerFuzzy bool `yaml:"trigger_fuzzy"`
+}
+
+// validateWeights checks that the relevance scoring weights and the
+// similarity threshold are usable
+func (r *RetrievalConfig) validateWeights() error {
+	// The base weights are normalized, so only their ratio matters
+	base := []struct {
+		name  string
+		value float64
+	}{
+		{"similarity_weight", r.SimilarityWeight},
+		{"importance_weight", r.ImportanceWeight},
+	}
+	for _, w := range base {
+		if math.IsNaN(w.value) || math.IsInf(w.value, 0) || w.value < 0 {
+			return fmt.Errorf("retrieval.%s must not be negative, got %v", w.name, w.value)
+		}
+	}
+	if r.SimilarityWeight+r.ImportanceWeight <= 0 {
+		return fmt.Errorf("retrieval.similarity_weight and retrieval.importance_weight cannot both be 0")
+	}
+
+	boosts := []struct {
+		name  string
+		value float64
+	}{
+		{"trigger_boost", r.TriggerBoost},
+		{"action_boost", r.ActionBoost},
+		{"keyword_boost", r.KeywordBoost},
+	}
+	for _, w := range boosts {
+		if math.IsNaN(w.value) || w.value < 0 || w.value > 1 {
+			return fmt.Errorf("retrieval.%s must be between 0 and 1, got %v", w.name, w.value)
+		}
+	}
+
+	if math.IsNaN(r.MinSimilarity) || r.MinSimilarity < 0 || r.MinSimilarity > 1 {
+		return fmt.Errorf("retrieval.min_similarity must be between 0 and 1, got %v", r.MinSimilarity)
+	}
+	if math.IsNaN(r.MMRLambda) || r.MMRLambda < 0 || r.MMRLambda > 1 {
+		return fmt.Errorf("retrieval.mmr_lambda must be between 0 and 1, got %v", r.MMRLambda)
+	}
+	return nil
+}
+
+// RecencyDecayConfig controls how relevance decays with a memory's age.
+// Half-lives are durations such as "6h" or "72h"; 0 disables decay.
+type RecencyDecayConfig struct {
+	Curve              string        `yaml:"curve"` // "exponential", "linear", or "none"
+	TemporaryHalfLife  time.Duration `yaml:"temporary_half_life"`
+	SessionHalfLife    time.Duration `yaml:"session_half_life"`
+	PersistentHalfLife time.Duration `yaml:"persistent_half_life"`
 }
 
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level string `yaml:"level"` // "debug", "info", "warn", "error"
 	File  string `yaml:"file"`
+
+	// SQLite statements slower than SlowQueryThreshold are logged and
+	// appended to SlowQueryLog; 0 disables slow query logging
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	SlowQueryLog       string        `yaml:"slow_query_log"`
+}
+
+// ImportanceConfig controls how importance is chosen for manually saved
+// memories that don't specify one
+type ImportanceConfig struct {
+	Default     string  `yaml:"default"`      // "fixed" (0.5) or "auto" (estimate it)
+	AIScoring   bool    `yaml:"ai_scoring"`   // Let "auto" estimates use a short AI call
+	DailyBudget float64 `yaml:"daily_budget"` // USD of AI spend per day after which estimates fall back to heuristics; 0 = no limit
+
+	// UnusedHalfLifeDays halves the importance of memories never returned
+	// by a search or read every this many days, applied whenever a session
+	// ends; 0 disables
+	UnusedHalfLifeDays float64 `yaml:"unused_half_life_days"`
+}
+
+// CurationConfig controls how curated memories are stored
+type CurationConfig struct {
+	// A curated memory at least this similar (0-1) to an existing memory of
+	// the project is a duplicate; 0 disables. DedupAction decides what
+	// happens to it: "merge" (default) folds it into the existing memory,
+	// "supersede" creates it with a supersedes relationship to that memory.
+	// DedupSaves applies the same to memories saved with save_memory.
+	DedupThreshold float64 `yaml:"dedup_threshold"`
+	DedupAction    string  `yaml:"dedup_action"`
+	DedupSaves     bool    `yaml:"dedup_saves"`
+
+	// Once a session transcript accumulated with append_transcript has
+	// AutoCurateChars uncurated characters, that part is curated
+	// automatically; 0 disables. AutoCurateDailyBudget (USD) stops automatic
+	// curation for the day once reached and needs ai.track_usage; 0 = no limit.
+	AutoCurateChars       int     `yaml:"auto_curate_chars"`
+	AutoCurateDailyBudget float64 `yaml:"auto_curate_daily_budget"`
+}
+
+// ExpiryConfig controls when short-lived memories are deleted, by the
+// prune_memories tool and whenever a session ends
+type ExpiryConfig struct {
+	TemporaryDays int `yaml:"temporary_days"` // Delete temporary memories older than this; 0 keeps them
+	SessionDays   int `yaml:"session_days"`   // Delete session memories this long after their session ended; 0 keeps them
+}
+
+// DigestConfig controls `alaala digest` and the generate_digest tool
+type DigestConfig struct {
+	// AISummary opens digests with a narrative written by the AI provider.
+	// DailyBudget (USD) skips it once today's recorded AI spend reaches it
+	// and needs ai.track_usage; 0 = no limit.
+	AISummary   bool    `yaml:"ai_summary"`
+	DailyBudget float64 `yaml:"daily_budget"`
+
+	// WebhookURL receives each digest from `alaala digest` as a JSON POST
+	// of {"text": <markdown>}, e.g. a Slack incoming webhook; empty = none
+	WebhookURL string `yaml:"webhook_url"`
+}
+
+// MCPConfig holds MCP server configuration
+type MCPConfig struct {
+	// RequestTimeout cancels the embedding, vector and database work of a
+	// request that takes longer and fails it; 0 disables the deadline
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+}
+
+// dataDir overrides where alaala keeps its state; see SetDataDir
+var dataDir string
+
+// SetDataDir relocates the config file and the default locations of the
+// database, logs and models to dir, e.g. for the --data-dir flag. It must be
+// called before DefaultConfig, Load or GetConfigPath.
+func SetDataDir(dir string) {
+	dataDir = dir
+}
+
+// DataDir returns the directory alaala keeps its state in: the one given to
+// SetDataDir, or ~/.alaala
+func DataDir() string {
+	if dataDir != "" {
+		return dataDir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".alaala")
 }
 
 // DefaultConfig returns a default configuration
 func DefaultConfig() *Config {
-	homeDir, _ := os.UserHomeDir()
-	alaalaDir := filepath.Join(homeDir, ".alaala")
+	alaalaDir := DataDir()
 
 	return &Config{
+		Locale: "en",
 		Storage: StorageConfig{
+			Mode:        StorageModeDocker,
 			WeaviateURL: "http://localhost:8080",
 			SQLitePath:  filepath.Join(alaalaDir, "alaala.db"),
 		},
 		AI: AIConfig{
-			Provider:      "anthropic",
-			Model:         "claude-3-5-sonnet-20241022",
-			OpenRouterURL: "https://openrouter.ai/api/v1",
-			OllamaURL:     "http://localhost:11434",
+			Provider:        "anthropic",
+			Model:           "claude-3-5-sonnet-20241022",
+			OpenRouterURL:   "https://openrouter.ai/api/v1",
+			OllamaURL:       "http://localhost:11434",
+			MaxOutputTokens: 16384,
 		},
 		Embeddings: EmbeddingsConfig{
-			Provider:  "local",
-			Model:     "all-MiniLM-L6-v2",
-			OllamaURL: "http://localhost:11434",
+			Provider:       "local",
+			Model:          "all-MiniLM-L6-v2",
+			ModelPath:      filepath.Join(alaalaDir, "models", "all-MiniLM-L6-v2"),
+			OllamaURL:      "http://localhost:11434",
+			InvalidVectors: "reject",
+			QueryCacheSize: 256,
+			QueryCacheTTL:  10 * time.Minute,
 		},
 		Retrieval: RetrievalConfig{
-			MaxMemories:       5,
-			MinImportance:     0.3,
-			IncludeGraphDepth: 1,
+			MaxMemories:         5,
+			MinImportance:       0.3,
+			MinSimilarity:       0.2,
+			IncludeGraphDepth:   1,
+			ResourceMemoryLimit: 100,
+			SimilarityWeight:    0.6,
+			ImportanceWeight:    0.3,
+			TriggerBoost:        0.2,
+			ActionBoost:         0.1,
+			KeywordBoost:        0.2,
+			MMRLambda:           0.5,
+			RecencyDecay: RecencyDecayConfig{
+				Curve:             "exponential",
+				TemporaryHalfLife: 6 * time.Hour,
+				SessionHalfLife:   72 * time.Hour,
+			},
 		},
 		Logging: LoggingConfig{
-			Level: "info",
-			File:  filepath.Join(alaalaDir, "alaala.log"),
+			Level:              "info",
+			File:               filepath.Join(alaalaDir, "alaala.log"),
+			SlowQueryThreshold: 200 * time.Millisecond,
+			SlowQueryLog:       filepath.Join(alaalaDir, "slow-queries.jsonl"),
+		},
+		Importance: ImportanceConfig{
+			Default:            "fixed",
+			UnusedHalfLifeDays: 180,
+		},
+		MCP: MCPConfig{
+			RequestTimeout: 5 * time.Minute,
+		},
+		Curation: CurationConfig{
+			DedupThreshold: 0.95,
+			DedupAction:    "merge",
+		},
+		Expiry: ExpiryConfig{
+			TemporaryDays: 30,
+			SessionDays:   7,
 		},
 	}
 }
@@ -109,6 +380,53 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// The embeddings package would fall back to ~/.alaala, outside a
+	// relocated data dir
+	if cfg.Embeddings.ModelPath == "" {
+		cfg.Embeddings.ModelPath = filepath.Join(DataDir(), "models", cfg.Embeddings.Model)
+	}
+
+	if err := cfg.Retrieval.validateWeights(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if m := cfg.Storage.Mode; m != "" && m != StorageModeDocker && m != StorageModeEmbedded {
+		return nil, fmt.Errorf("invalid config file %s: storage.mode must be \"docker\" or \"embedded\", got %q", path, m)
+	}
+	if cfg.Importance.Default != "fixed" && cfg.Importance.Default != "auto" {
+		return nil, fmt.Errorf("invalid config file %s: importance.default must be \"fixed\" or \"auto\", got %q",
+			path, cfg.Importance.Default)
+	}
+	if cfg.Importance.DailyBudget < 0 {
+		return nil, fmt.Errorf("invalid config file %s: importance.daily_budget must not be negative", path)
+	}
+	if d := cfg.Importance.UnusedHalfLifeDays; math.IsNaN(d) || d < 0 {
+		return nil, fmt.Errorf("invalid config file %s: importance.unused_half_life_days must not be negative", path)
+	}
+	if t := cfg.Curation.DedupThreshold; math.IsNaN(t) || t < 0 || t > 1 {
+		return nil, fmt.Errorf("invalid config file %s: curation.dedup_threshold must be between 0 and 1, got %v", path, t)
+	}
+	if a := cfg.Curation.DedupAction; a != "" && a != "merge" && a != "supersede" {
+		return nil, fmt.Errorf("invalid config file %s: curation.dedup_action must be \"merge\" or \"supersede\", got %q", path, a)
+	}
+	if cfg.Curation.AutoCurateChars < 0 {
+		return nil, fmt.Errorf("invalid config file %s: curation.auto_curate_chars must not be negative", path)
+	}
+	if cfg.Curation.AutoCurateDailyBudget < 0 {
+		return nil, fmt.Errorf("invalid config file %s: curation.auto_curate_daily_budget must not be negative", path)
+	}
+	if cfg.Expiry.TemporaryDays < 0 || cfg.Expiry.SessionDays < 0 {
+		return nil, fmt.Errorf("invalid config file %s: expiry.temporary_days and expiry.session_days must not be negative", path)
+	}
+	if cfg.AI.Timeout < 0 {
+		return nil, fmt.Errorf("invalid config file %s: ai.timeout must not be negative", path)
+	}
+	if cfg.Digest.DailyBudget < 0 {
+		return nil, fmt.Errorf("invalid config file %s: digest.daily_budget must not be negative", path)
+	}
+	if cfg.MCP.RequestTimeout < 0 {
+		return nil, fmt.Errorf("invalid config file %s: mcp.request_timeout must not be negative", path)
+	}
+
 	return cfg, nil
 }
 
@@ -132,8 +450,7 @@ func (c *Config) Save(path string) error {
 	return nil
 }
 
-// GetConfigPath returns the default configuration file path
+// GetConfigPath returns the configuration file path
 func GetConfigPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".alaala", "config.yaml")
+	return filepath.Join(DataDir(), "config.yaml")
 }